@@ -1,12 +1,25 @@
 package main
 
 import (
+	"fmt"
+	"os"
+
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
+	handling "github.com/Leda-Editor/Leda-Text-Editor/pkg/handling"
 	ui "github.com/Leda-Editor/Leda-Text-Editor/pkg/ui"
 )
 
 func main() {
+	// Run command line subcommands without starting the GUI.
+	if len(os.Args) > 1 && os.Args[1] == "export" {
+		if err := handling.RunExportCommand(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, "leda export:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Initialize Fyne Application.
 	app := app.NewWithID("leda-text-editor")
 
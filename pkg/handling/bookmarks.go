@@ -0,0 +1,241 @@
+package handling
+
+import (
+	"encoding/json"
+	"slices"
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2"
+)
+
+// bookmarksKey is the preference the bookmarks are stored under.
+const bookmarksKey = "bookmarks"
+
+// Bookmark is a marked line in a file.
+type Bookmark struct {
+	Path string
+	// Line is zero based.
+	Line int
+}
+
+// Bookmarks holds the bookmarked lines of every file, keyed by path.
+// Bookmarks in an unsaved buffer use the empty path and aren't persisted.
+type Bookmarks struct {
+	lines map[string][]int
+	prefs fyne.Preferences
+}
+
+// LoadBookmarks reads the saved bookmarks, prefs may be nil to keep them in memory only.
+func LoadBookmarks(prefs fyne.Preferences) *Bookmarks {
+	b := &Bookmarks{lines: map[string][]int{}, prefs: prefs}
+	if prefs == nil {
+		return b
+	}
+	if saved := prefs.String(bookmarksKey); saved != "" {
+		if err := json.Unmarshal([]byte(saved), &b.lines); err != nil {
+			fyne.LogError("Failed to read bookmarks", err)
+		}
+	}
+	return b
+}
+
+// save writes the bookmarks of saved files to the preferences.
+func (b *Bookmarks) save() {
+	if b.prefs == nil {
+		return
+	}
+	saved := map[string][]int{}
+	for path, lines := range b.lines {
+		if path != "" && len(lines) > 0 {
+			saved[path] = lines
+		}
+	}
+	data, err := json.Marshal(saved)
+	if err != nil {
+		fyne.LogError("Failed to save bookmarks", err)
+		return
+	}
+	b.prefs.SetString(bookmarksKey, string(data))
+}
+
+// Lines returns the bookmarked lines of a file in ascending order.
+func (b *Bookmarks) Lines(path string) []int {
+	return b.lines[path]
+}
+
+// Has reports whether a line is bookmarked.
+func (b *Bookmarks) Has(path string, line int) bool {
+	lines := b.lines[path]
+	i := sort.SearchInts(lines, line)
+	return i < len(lines) && lines[i] == line
+}
+
+// Toggle adds or removes a bookmark and reports whether the line is now bookmarked.
+func (b *Bookmarks) Toggle(path string, line int) bool {
+	lines := b.lines[path]
+	i := sort.SearchInts(lines, line)
+	added := i == len(lines) || lines[i] != line
+	if added {
+		lines = append(lines[:i], append([]int{line}, lines[i:]...)...)
+	} else {
+		lines = append(lines[:i], lines[i+1:]...)
+	}
+	b.set(path, lines)
+	return added
+}
+
+// Clear removes the bookmarks of a file, or of everything in a folder.
+func (b *Bookmarks) Clear(path string) {
+	for p := range b.lines {
+		if p == path || path != "" && isWithin(p, path) {
+			delete(b.lines, p)
+		}
+	}
+	b.save()
+}
+
+// set replaces the lines of a file and saves.
+func (b *Bookmarks) set(path string, lines []int) {
+	if len(lines) == 0 {
+		delete(b.lines, path)
+	} else {
+		b.lines[path] = lines
+	}
+	b.save()
+}
+
+// Next returns the first bookmark after line, wrapping around to the top.
+func (b *Bookmarks) Next(path string, line int) (int, bool) {
+	lines := b.lines[path]
+	if len(lines) == 0 {
+		return 0, false
+	}
+	i := sort.SearchInts(lines, line+1)
+	if i == len(lines) {
+		i = 0
+	}
+	return lines[i], true
+}
+
+// Previous returns the last bookmark before line, wrapping around to the bottom.
+func (b *Bookmarks) Previous(path string, line int) (int, bool) {
+	lines := b.lines[path]
+	if len(lines) == 0 {
+		return 0, false
+	}
+	i := sort.SearchInts(lines, line) - 1
+	if i < 0 {
+		i = len(lines) - 1
+	}
+	return lines[i], true
+}
+
+// All lists every bookmark sorted by path and line.
+func (b *Bookmarks) All() []Bookmark {
+	paths := make([]string, 0, len(b.lines))
+	for path := range b.lines {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var all []Bookmark
+	for _, path := range paths {
+		for _, line := range b.lines[path] {
+			all = append(all, Bookmark{Path: path, Line: line})
+		}
+	}
+	return all
+}
+
+// Rename moves bookmarks along with a renamed file or folder.
+func (b *Bookmarks) Rename(from, to string) {
+	moved := map[string][]int{}
+	for path, lines := range b.lines {
+		if path != "" && isWithin(path, from) {
+			moved[to+strings.TrimPrefix(path, from)] = lines
+			delete(b.lines, path)
+		}
+	}
+	if len(moved) == 0 {
+		return
+	}
+	for path, lines := range moved {
+		b.lines[path] = lines
+	}
+	b.save()
+}
+
+// SavedAs gives a file the bookmarks of the text saved as it. Those of the unsaved buffer are moved,
+// those of a file are copied since it's still there.
+func (b *Bookmarks) SavedAs(from, to string) {
+	lines := b.lines[from]
+	if from == to || len(lines) == 0 {
+		return
+	}
+	if from == "" {
+		delete(b.lines, from)
+	}
+	b.set(to, slices.Clone(lines))
+}
+
+// Adjust keeps the bookmarks of a file on the same text after an edit, moving them
+// down when lines are inserted above them and up when lines are removed.
+// Bookmarks on lines that were deleted are dropped.
+func (b *Bookmarks) Adjust(path, before, after string) {
+	lines := b.lines[path]
+	if len(lines) == 0 || before == after {
+		return
+	}
+
+	// Find the edited region by trimming the common prefix and suffix.
+	prefix := 0
+	for prefix < len(before) && prefix < len(after) && before[prefix] == after[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(before)-prefix && suffix < len(after)-prefix &&
+		before[len(before)-1-suffix] == after[len(after)-1-suffix] {
+		suffix++
+	}
+	// Replacing everything is a reload rather than an edit, keep the bookmarks as they are.
+	if prefix == 0 && suffix == 0 {
+		return
+	}
+
+	removedEnd := len(before) - suffix
+	delta := strings.Count(after[prefix:len(after)-suffix], "\n") - strings.Count(before[prefix:removedEnd], "\n")
+	if delta == 0 {
+		return
+	}
+	// Where each line of before starts, and where the one after the last would.
+	starts := []int{0}
+	for i := 0; i < len(before); i++ {
+		if before[i] == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	starts = append(starts, len(before)+1)
+
+	adjusted := make([]int, 0, len(lines))
+	for _, line := range lines {
+		if line >= len(starts)-1 {
+			adjusted = append(adjusted, line+delta)
+			continue
+		}
+		start, end := starts[line], min(starts[line+1], len(before))
+		switch {
+		case start >= prefix && end <= removedEnd:
+			// The whole line, with its line break or the one before it, was deleted.
+			continue
+		case start < prefix:
+			// The edit is within or after the line, which stays where it is.
+		default:
+			// What's left of the line comes after the edit.
+			line += delta
+		}
+		adjusted = append(adjusted, line)
+	}
+	sort.Ints(adjusted)
+	b.set(path, slices.Compact(adjusted))
+}
@@ -0,0 +1,60 @@
+package handling
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBookmarksAdjust(t *testing.T) {
+	tests := []struct {
+		name          string
+		lines         []int
+		before, after string
+		want          []int
+	}{
+		{"line inserted above", []int{1}, "a\nb\nc\n", "x\na\nb\nc\n", []int{2}},
+		{"line inserted below", []int{1}, "a\nb\nc\n", "a\nb\nx\nc\n", []int{1}},
+		{"line inserted at the start of the line", []int{1}, "a\nb\nc\n", "a\nx\nb\nc\n", []int{2}},
+		{"line break typed within the line", []int{1}, "a\nbb\nc\n", "a\nb\nb\nc\n", []int{1}},
+		{"line removed above", []int{2}, "a\nb\nc\n", "a\nc\n", []int{1}},
+		{"bookmarked line deleted", []int{1}, "a\nb\nc\n", "a\nc\n", nil},
+		{"last line deleted", []int{2}, "a\nb\nc", "a\nb", nil},
+		{"lines joined", []int{0, 1}, "a\nb\n", "ab\n", []int{0}},
+		{"text typed on the line", []int{1}, "a\nb\n", "a\nbx\n", []int{1}},
+		{"reload", []int{1}, "a\nb", "x\ny\nz", []int{1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := LoadBookmarks(nil)
+			b.set("f", tt.lines)
+			b.Adjust("f", tt.before, tt.after)
+			if got := b.Lines("f"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Adjust(%q, %q) moved %v to %v, want %v", tt.before, tt.after, tt.lines, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBookmarksSavedAs(t *testing.T) {
+	b := LoadBookmarks(nil)
+	b.set("", []int{1, 3})
+	b.SavedAs("", "/new.txt")
+	if got := b.Lines("/new.txt"); !reflect.DeepEqual(got, []int{1, 3}) {
+		t.Errorf("the saved buffer's bookmarks = %v, want [1 3]", got)
+	}
+	if got := b.Lines(""); got != nil {
+		t.Errorf("the unsaved buffer kept bookmarks %v", got)
+	}
+
+	b.SavedAs("/new.txt", "/copy.txt")
+	for _, path := range []string{"/new.txt", "/copy.txt"} {
+		if got := b.Lines(path); !reflect.DeepEqual(got, []int{1, 3}) {
+			t.Errorf("%s bookmarks = %v, want [1 3]", path, got)
+		}
+	}
+	// The copy's bookmarks are its own.
+	b.Toggle("/copy.txt", 1)
+	if got := b.Lines("/new.txt"); !reflect.DeepEqual(got, []int{1, 3}) {
+		t.Errorf("changing the copy's bookmarks changed the original's to %v", got)
+	}
+}
@@ -0,0 +1,64 @@
+package handling
+
+import (
+	"container/list"
+	"sync"
+)
+
+// lruCache holds up to size values by key, dropping the least recently used once it's full.
+// It's safe to use from more than one goroutine.
+type lruCache[V any] struct {
+	lock sync.Mutex
+	size int
+	// order holds the entries, the most recently used first.
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+// lruEntry is a value in an lruCache along with its key.
+type lruEntry[V any] struct {
+	key   string
+	value V
+}
+
+// newLRUCache creates a cache holding up to size values.
+func newLRUCache[V any](size int) *lruCache[V] {
+	return &lruCache[V]{size: size, order: list.New(), entries: map[string]*list.Element{}}
+}
+
+// get returns the value kept for key, reporting false if there's none.
+func (c *lruCache[V]) get(key string) (V, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	element, ok := c.entries[key]
+	if !ok {
+		var none V
+		return none, false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*lruEntry[V]).value, true
+}
+
+// put keeps value for key, dropping the least recently used value if there's no room for it.
+func (c *lruCache[V]) put(key string, value V) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if element, ok := c.entries[key]; ok {
+		element.Value.(*lruEntry[V]).value = value
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&lruEntry[V]{key, value})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry[V]).key)
+	}
+}
+
+// len returns how many values are kept.
+func (c *lruCache[V]) len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.order.Len()
+}
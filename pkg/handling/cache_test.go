@@ -0,0 +1,30 @@
+package handling
+
+import "testing"
+
+func TestLRUCache(t *testing.T) {
+	c := newLRUCache[int](2)
+	c.put("a", 1)
+	c.put("b", 2)
+	// Using a makes b the least recently used.
+	if got, ok := c.get("a"); !ok || got != 1 {
+		t.Fatalf("get(a) = %d, %v, want 1, true", got, ok)
+	}
+	c.put("c", 3)
+	if _, ok := c.get("b"); ok {
+		t.Error("b was kept after the cache filled up")
+	}
+	for key, want := range map[string]int{"a": 1, "c": 3} {
+		if got, ok := c.get(key); !ok || got != want {
+			t.Errorf("get(%s) = %d, %v, want %d, true", key, got, ok, want)
+		}
+	}
+
+	c.put("a", 4)
+	if got, _ := c.get("a"); got != 4 {
+		t.Errorf("get(a) after replacing it = %d, want 4", got)
+	}
+	if c.len() != 2 {
+		t.Errorf("len = %d, want 2", c.len())
+	}
+}
@@ -0,0 +1,64 @@
+package handling
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// RunExportCommand implements `leda export`, converting files without opening a window.
+func RunExportCommand(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := flags.String("format", "html", "output format: html or pdf")
+	output := flags.String("o", "", "output file (only valid with a single input)")
+	toc := flags.Bool("toc", false, "include a table of contents (html)")
+	lines := flags.Bool("lines", false, "number the lines of plain text files (pdf)")
+	configPath := flags.String("config", "config.json", "config file to take theme colours and fonts from")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: leda export [-format html|pdf] [-toc] [-lines] [-o output] [-config config.json] file...")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	inputs := flags.Args()
+	if len(inputs) == 0 {
+		flags.Usage()
+		return errors.New("no input files")
+	}
+	if *output != "" && len(inputs) > 1 {
+		return errors.New("-o can only be used with a single input file")
+	}
+	if *format != "html" && *format != "pdf" {
+		return fmt.Errorf("unknown format %q", *format)
+	}
+
+	config, err := LoadConfig(*configPath)
+	if err != nil {
+		config = &Config{}
+	}
+
+	for _, in := range inputs {
+		out := *output
+		if out == "" {
+			out = strings.TrimSuffix(in, filepath.Ext(in)) + "." + *format
+		}
+
+		var err error
+		if *format == "pdf" {
+			opts := PDFExportOptions{LineNumbers: *lines, Fonts: PDFFontsFromConfig(config)}
+			err = ExportPDFFile(in, out, opts)
+		} else {
+			opts := HTMLExportOptions{TableOfContents: *toc, Style: ExportStyleFromConfig(config)}
+			err = ExportHTMLFile(in, out, opts)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", in, err)
+		}
+		fmt.Println("Exported", in, "->", out)
+	}
+	return nil
+}
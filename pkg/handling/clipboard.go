@@ -0,0 +1,112 @@
+package handling
+
+import (
+	"slices"
+	"strings"
+
+	"fyne.io/fyne/v2"
+)
+
+const (
+	clipboard_history = "clipboard_history"
+
+	// maxClipboard is how many unpinned copies are remembered.
+	maxClipboard = 30
+	// maxClipboardText is the longest text remembered, in bytes, bigger copies are left out.
+	maxClipboardText = 1 << 20
+)
+
+// ClipboardHistory is a most recent first list of the text copied or cut in the editor,
+// pinned entries are kept separately and never drop off.
+// It's only saved in the app preferences while persisting is turned on.
+type ClipboardHistory struct {
+	prefs   fyne.Preferences
+	persist bool
+	entries []string
+	pinned  []string
+}
+
+// LoadClipboardHistory creates the clipboard history, reading the saved one if it's persisted.
+func LoadClipboardHistory(prefs fyne.Preferences, persist bool) *ClipboardHistory {
+	h := &ClipboardHistory{prefs: prefs, persist: persist}
+	if persist && prefs != nil {
+		h.entries = prefs.StringList(clipboard_history)
+		h.pinned = prefs.StringList(clipboard_history + pinned_suffix)
+	}
+	return h
+}
+
+// save stores the history if it's persisted.
+func (h *ClipboardHistory) save() {
+	if h.persist && h.prefs != nil {
+		h.prefs.SetStringList(clipboard_history, h.entries)
+		h.prefs.SetStringList(clipboard_history+pinned_suffix, h.pinned)
+	}
+}
+
+// SetPersist turns saving the history between sessions on or off, turning it off forgets the saved history.
+func (h *ClipboardHistory) SetPersist(persist bool) {
+	h.persist = persist
+	if h.prefs == nil {
+		return
+	}
+	if persist {
+		h.save()
+	} else {
+		h.prefs.RemoveValue(clipboard_history)
+		h.prefs.RemoveValue(clipboard_history + pinned_suffix)
+	}
+}
+
+// Add moves text to the front of the history, pinned entries keep their place.
+// Blank text and text too big to keep are left out.
+func (h *ClipboardHistory) Add(text string) {
+	if strings.TrimSpace(text) == "" || len(text) > maxClipboardText || slices.Contains(h.pinned, text) {
+		return
+	}
+	h.entries = append([]string{text}, slices.DeleteFunc(h.entries, func(e string) bool { return e == text })...)
+	if len(h.entries) > maxClipboard {
+		h.entries = h.entries[:maxClipboard]
+	}
+	h.save()
+}
+
+// Entries returns the unpinned entries, most recent first.
+func (h *ClipboardHistory) Entries() []string {
+	return h.entries
+}
+
+// Pinned returns the pinned entries in the order they were pinned.
+func (h *ClipboardHistory) Pinned() []string {
+	return h.pinned
+}
+
+// IsPinned reports whether text is pinned.
+func (h *ClipboardHistory) IsPinned(text string) bool {
+	return slices.Contains(h.pinned, text)
+}
+
+// SetPinned pins or unpins text, unpinned text goes back to the top of the history.
+func (h *ClipboardHistory) SetPinned(text string, pinned bool) {
+	h.pinned = slices.DeleteFunc(h.pinned, func(e string) bool { return e == text })
+	h.entries = slices.DeleteFunc(h.entries, func(e string) bool { return e == text })
+	if pinned {
+		h.pinned = append(h.pinned, text)
+	} else {
+		h.entries = append([]string{text}, h.entries...)
+	}
+	h.save()
+}
+
+// Remove forgets text, pinned or not.
+func (h *ClipboardHistory) Remove(text string) {
+	h.pinned = slices.DeleteFunc(h.pinned, func(e string) bool { return e == text })
+	h.entries = slices.DeleteFunc(h.entries, func(e string) bool { return e == text })
+	h.save()
+}
+
+// Clear forgets every entry that isn't pinned.
+func (h *ClipboardHistory) Clear() {
+	h.entries = nil
+	h.save()
+}
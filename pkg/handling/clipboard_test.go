@@ -0,0 +1,65 @@
+package handling
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"fyne.io/fyne/v2/test"
+)
+
+func TestClipboardHistory(t *testing.T) {
+	h := LoadClipboardHistory(nil, false)
+	for _, text := range []string{"a", "  \n", "b", "a", strings.Repeat("x", maxClipboardText+1)} {
+		h.Add(text)
+	}
+	if want := []string{"a", "b"}; !reflect.DeepEqual(h.Entries(), want) {
+		t.Errorf("Entries = %q, want %q", h.Entries(), want)
+	}
+
+	h.SetPinned("b", true)
+	h.Add("b")
+	if !h.IsPinned("b") || !reflect.DeepEqual(h.Entries(), []string{"a"}) || !reflect.DeepEqual(h.Pinned(), []string{"b"}) {
+		t.Errorf("after pinning b: entries %q, pinned %q", h.Entries(), h.Pinned())
+	}
+	h.Clear()
+	if len(h.Entries()) != 0 || !h.IsPinned("b") {
+		t.Errorf("Clear left entries %q and pinned %q", h.Entries(), h.Pinned())
+	}
+	h.SetPinned("b", false)
+	h.Remove("b")
+	if len(h.Entries()) != 0 || len(h.Pinned()) != 0 {
+		t.Errorf("Remove left entries %q and pinned %q", h.Entries(), h.Pinned())
+	}
+
+	for i := 0; i < maxClipboard+5; i++ {
+		h.Add(strings.Repeat("y", i+1))
+	}
+	if len(h.Entries()) != maxClipboard {
+		t.Errorf("kept %d entries, want %d", len(h.Entries()), maxClipboard)
+	}
+}
+
+func TestClipboardHistoryPersist(t *testing.T) {
+	prefs := test.NewTempApp(t).Preferences()
+
+	// Nothing is saved until persisting is turned on.
+	h := LoadClipboardHistory(prefs, false)
+	h.Add("secret")
+	if saved := LoadClipboardHistory(prefs, true).Entries(); len(saved) != 0 {
+		t.Errorf("saved %q without persisting", saved)
+	}
+
+	h.SetPersist(true)
+	h.SetPinned("kept", true)
+	saved := LoadClipboardHistory(prefs, true)
+	if !reflect.DeepEqual(saved.Entries(), []string{"secret"}) || !saved.IsPinned("kept") {
+		t.Errorf("reloaded entries %q and pinned %q", saved.Entries(), saved.Pinned())
+	}
+
+	// Turning it off forgets what was saved.
+	h.SetPersist(false)
+	if saved := LoadClipboardHistory(prefs, true); len(saved.Entries()) != 0 || len(saved.Pinned()) != 0 {
+		t.Errorf("still saved entries %q and pinned %q", saved.Entries(), saved.Pinned())
+	}
+}
@@ -0,0 +1,118 @@
+package handling
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// CommentSyntax is how a language marks comments.
+type CommentSyntax struct {
+	// Line starts a comment running to the end of the line, empty if the language has none.
+	Line string
+	// BlockStart and BlockEnd surround a comment that can span lines, empty if the language has none.
+	BlockStart, BlockEnd string
+}
+
+var (
+	cComments    = CommentSyntax{Line: "//", BlockStart: "/*", BlockEnd: "*/"}
+	hashComments = CommentSyntax{Line: "#"}
+	htmlComments = CommentSyntax{BlockStart: "<!--", BlockEnd: "-->"}
+)
+
+// commentSyntaxes holds the comment syntax of each language, keyed by extension.
+var commentSyntaxes = map[string]CommentSyntax{
+	".go": cComments, ".c": cComments, ".h": cComments, ".cc": cComments, ".cpp": cComments, ".hpp": cComments,
+	".cs": cComments, ".java": cComments, ".kt": cComments, ".scala": cComments, ".swift": cComments,
+	".rs": cComments, ".dart": cComments, ".php": cComments,
+	".js": cComments, ".jsx": cComments, ".ts": cComments, ".tsx": cComments, ".json": cComments,
+	".py": hashComments, ".rb": hashComments, ".pl": hashComments, ".r": hashComments,
+	".sh": hashComments, ".bash": hashComments, ".zsh": hashComments, ".ps1": {Line: "#", BlockStart: "<#", BlockEnd: "#>"},
+	".yaml": hashComments, ".yml": hashComments, ".toml": hashComments, ".conf": hashComments, ".mk": hashComments,
+	".sql":  {Line: "--", BlockStart: "/*", BlockEnd: "*/"},
+	".lua":  {Line: "--", BlockStart: "--[[", BlockEnd: "]]"},
+	".hs":   {Line: "--", BlockStart: "{-", BlockEnd: "-}"},
+	".ini":  {Line: ";"},
+	".tex":  {Line: "%"},
+	".css":  {BlockStart: "/*", BlockEnd: "*/"},
+	".html": htmlComments, ".xml": htmlComments, ".svg": htmlComments, ".md": htmlComments, ".markdown": htmlComments,
+}
+
+// CommentSyntaxFor returns how comments are written in a file. Files of unknown languages use "#".
+func CommentSyntaxFor(path string) CommentSyntax {
+	ext := strings.ToLower(filepath.Ext(path))
+	if filepath.Base(path) == "Makefile" {
+		ext = ".mk"
+	}
+	if path == "" {
+		ext = ".md"
+	}
+	if syntax, ok := commentSyntaxes[ext]; ok {
+		return syntax
+	}
+	return hashComments
+}
+
+// codeSpan is a comment or string of code, as byte ranges of text.
+type codeSpan struct {
+	// outer includes the comment markers or quotes, inner leaves them out.
+	outer, inner [2]int
+}
+
+// codeSpans finds the comments and strings of code in order, strings being in double, single or back quotes.
+// A single quote that isn't closed on its line, such as an apostrophe or a Rust lifetime, doesn't start a string.
+func codeSpans(text string, syntax CommentSyntax) []codeSpan {
+	var spans []codeSpan
+	add := func(start, innerStart, innerEnd, end int) {
+		spans = append(spans, codeSpan{outer: [2]int{start, end}, inner: [2]int{innerStart, innerEnd}})
+	}
+	for i := 0; i < len(text); {
+		rest := text[i:]
+		switch {
+		case syntax.Line != "" && strings.HasPrefix(rest, syntax.Line):
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+			add(i, i+len(syntax.Line), i+end, i+end)
+			i += end
+		case syntax.BlockStart != "" && strings.HasPrefix(rest, syntax.BlockStart):
+			start := len(syntax.BlockStart)
+			end := strings.Index(rest[start:], syntax.BlockEnd)
+			if end < 0 {
+				end = len(rest) - start
+			}
+			next := min(start+end+len(syntax.BlockEnd), len(rest))
+			add(i, i+start, i+start+end, i+next)
+			i += next
+		case strings.HasPrefix(rest, `"""`), strings.HasPrefix(rest, "'''"):
+			end := strings.Index(rest[3:], rest[:3])
+			if end < 0 {
+				end = len(rest) - 3
+			}
+			next := min(3+end+3, len(rest))
+			add(i, i+3, i+3+end, i+next)
+			i += next
+		case rest[0] == '"', rest[0] == '\'', rest[0] == '`':
+			// Strings end with the line, but for back quoted ones which can span lines.
+			quote := rest[0]
+			end := 1
+			for end < len(rest) && rest[end] != quote && (rest[end] != '\n' || quote == '`') {
+				if rest[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			end = min(end, len(rest))
+			if quote == '\'' && (end == len(rest) || rest[end] != quote) {
+				i++
+				continue
+			}
+			next := min(end+1, len(rest))
+			add(i, i+1, i+end, i+next)
+			i += next
+		default:
+			i++
+		}
+	}
+	return spans
+}
@@ -0,0 +1,472 @@
+package handling
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/Leda-Editor/Leda-Text-Editor/pkg/lsp"
+)
+
+const (
+	// minCompletionWord is the shortest word worth suggesting.
+	minCompletionWord = 3
+	// maxWordFileSize is the largest file whose words are suggested.
+	maxWordFileSize = 1 << 20
+	// maxWordFiles is how many files' words are kept between requests.
+	maxWordFiles = 50
+	// maxPathCompletions is how many entries of a folder are suggested.
+	maxPathCompletions = 500
+)
+
+// CompletionKind is what a suggestion is, which picks its icon.
+type CompletionKind int
+
+const (
+	CompletionWord CompletionKind = iota
+	CompletionFile
+	CompletionFolder
+	CompletionHeading
+	CompletionSnippet
+	CompletionChoice
+	CompletionFunction
+	CompletionField
+	CompletionKeyword
+)
+
+// CompletionItem is a suggestion for the text before the cursor.
+type CompletionItem struct {
+	Label  string
+	Detail string
+	// Insert replaces the text from Start up to the cursor.
+	Insert string
+	// Start is the column the text the suggestion replaces starts at.
+	Start int
+	// Filter is matched against what's typed instead of Label when set.
+	Filter string
+	Sort   string
+	Kind   CompletionKind
+	// Rank orders suggestions that match equally well, lower first. It's the position of their provider.
+	Rank int
+	// Snippet is inserted in place of Insert when set.
+	Snippet *Snippet
+}
+
+// CompletionContext is what providers are told about the cursor.
+type CompletionContext struct {
+	// Path is the current file, "" if it hasn't been saved yet.
+	Path string
+	Text string
+	Row  int
+	// Column is the cursor's column in runes.
+	Column int
+	// Before is the current line up to the cursor.
+	Before string
+	// Manual is set when the suggestions were asked for, rather than offered while typing.
+	Manual bool
+	// Root is the workspace folder, "" if none is open.
+	Root string
+	// Ignores hide files of the workspace.
+	Ignores Ignores
+	// Buffers are the other files recently open in the editor.
+	Buffers []string
+}
+
+// Word returns the part of an identifier that is before the cursor.
+func (c CompletionContext) Word() string {
+	return WordBefore(c.Before, c.Column)
+}
+
+// CompletionProvider makes suggestions for the cursor.
+// Providers run in the background and mustn't touch the UI.
+type CompletionProvider func(ctx context.Context, c CompletionContext) []CompletionItem
+
+// WordBefore returns the part of an identifier that is before the column.
+func WordBefore(line string, column int) string {
+	runes := []rune(line)
+	column = min(column, len(runes))
+	start := column
+	for start > 0 && IsWordRune(runes[start-1]) {
+		start--
+	}
+	return string(runes[start:column])
+}
+
+// IsWordRune reports whether r can be part of an identifier.
+func IsWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// CollectCompletions runs the providers side by side, keeping their suggestions in provider order.
+func CollectCompletions(ctx context.Context, providers []CompletionProvider, c CompletionContext) []CompletionItem {
+	results := make([][]CompletionItem, len(providers))
+	var wg sync.WaitGroup
+	for i, provide := range providers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = provide(ctx, c)
+		}()
+	}
+	wg.Wait()
+
+	var items []CompletionItem
+	for rank, result := range results {
+		for _, item := range result {
+			item.Rank = rank
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// FilterCompletions ranks the suggestions against what's been typed on line up to column, best first.
+// When more than one suggestion inserts the same text only the best is kept, and at most limit are returned.
+func FilterCompletions(items []CompletionItem, line string, column, limit int) []CompletionItem {
+	before := []rune(line)
+	column = min(column, len(before))
+
+	type match struct {
+		item  CompletionItem
+		score int
+	}
+	matches := make([]match, 0, len(items))
+	for _, item := range items {
+		if item.Start < 0 || item.Start > column {
+			continue
+		}
+		typed := string(before[item.Start:column])
+		if item.Snippet == nil && item.Insert == typed {
+			// Nothing left to complete.
+			continue
+		}
+		against := item.Filter
+		if against == "" {
+			against = item.Label
+		}
+		if score, ok := FuzzyScore(typed, against); ok {
+			matches = append(matches, match{item, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.score != b.score {
+			return a.score > b.score
+		}
+		if a.item.Rank != b.item.Rank {
+			return a.item.Rank < b.item.Rank
+		}
+		return a.item.Sort < b.item.Sort
+	})
+
+	// The same text can be suggested by more than one provider, keep the best.
+	type key struct {
+		insert string
+		start  int
+	}
+	seen := map[key]bool{}
+	filtered := make([]CompletionItem, 0, min(len(matches), limit))
+	for _, m := range matches {
+		k := key{m.item.Insert, m.item.Start}
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		filtered = append(filtered, m.item)
+		if len(filtered) == limit {
+			break
+		}
+	}
+	return filtered
+}
+
+// LSPCompletions turns a language server's suggestions for the cursor into completion items.
+func LSPCompletions(results []lsp.CompletionItem, c CompletionContext) []CompletionItem {
+	start := c.Column - len([]rune(c.Word()))
+	items := make([]CompletionItem, 0, len(results))
+	for _, result := range results {
+		item := CompletionItem{
+			Label:  result.Label,
+			Detail: result.Detail,
+			Insert: lspInsertText(result),
+			Start:  start,
+			Filter: result.FilterText,
+			Sort:   result.SortText,
+			Kind:   lspCompletionKind(result.Kind),
+		}
+		// The server may replace more or less than the word before the cursor.
+		if result.TextEdit != nil {
+			if row, column := lsp.ColumnFor(c.Text, result.TextEdit.Range.Start); row == c.Row && column <= c.Column {
+				item.Start = column
+			}
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// snippetSyntax matches the tab stops and placeholders of LSP snippets.
+var snippetSyntax = regexp.MustCompile(`\$\{\d+:([^}]*)\}|\$\{\d+\}|\$\d+`)
+
+// lspInsertText returns the text a server suggestion inserts, with snippet tab stops reduced to their placeholders.
+func lspInsertText(item lsp.CompletionItem) string {
+	text := item.InsertText
+	if item.TextEdit != nil {
+		text = item.TextEdit.NewText
+	}
+	if text == "" {
+		text = item.Label
+	}
+	if item.InsertTextFormat == lsp.InsertTextFormatSnippet {
+		text = snippetSyntax.ReplaceAllString(text, "$1")
+	}
+	return text
+}
+
+// lspCompletionKind picks the kind of suggestion a server's kind is shown as.
+func lspCompletionKind(kind int) CompletionKind {
+	switch kind {
+	case lsp.CompletionMethod, lsp.CompletionFunction:
+		return CompletionFunction
+	case lsp.CompletionField, lsp.CompletionVariable:
+		return CompletionField
+	case lsp.CompletionKeyword:
+		return CompletionKeyword
+	case lsp.CompletionSnippet:
+		return CompletionSnippet
+	}
+	return CompletionWord
+}
+
+// SnippetCompletions suggests the snippets whose prefix is being typed.
+func SnippetCompletions(ctx context.Context, c CompletionContext) []CompletionItem {
+	word := c.Word()
+	if word == "" && !c.Manual {
+		return nil
+	}
+	snippets, _ := LoadSnippets(SnippetLanguage(c.Path))
+	start := c.Column - len([]rune(word))
+	var items []CompletionItem
+	for _, snippet := range snippets {
+		for _, prefix := range snippet.Prefixes {
+			items = append(items, CompletionItem{
+				Label:   prefix,
+				Detail:  snippet.Name,
+				Start:   start,
+				Kind:    CompletionSnippet,
+				Snippet: &snippet,
+			})
+		}
+	}
+	return items
+}
+
+// wordPattern matches the words suggested from open files.
+var wordPattern = regexp.MustCompile(`[\p{L}_][\p{L}\p{N}_]*`)
+
+// fileWords are the words of a file as it was when last modified.
+type fileWords struct {
+	modified time.Time
+	words    []string
+}
+
+// wordCache holds the words of the files last lending theirs to completion, so they're read again only once changed.
+var wordCache = newLRUCache[fileWords](maxWordFiles)
+
+// BufferWordCompletions suggests words from the current file and the other files recently open in the editor.
+func BufferWordCompletions(ctx context.Context, c CompletionContext) []CompletionItem {
+	word := c.Word()
+	if word == "" && !c.Manual {
+		return nil
+	}
+	start := c.Column - len([]rune(word))
+	seen := map[string]bool{}
+	var items []CompletionItem
+	add := func(words []string, detail string) {
+		for _, w := range words {
+			if seen[w] {
+				continue
+			}
+			seen[w] = true
+			items = append(items, CompletionItem{Label: w, Detail: detail, Insert: w, Start: start, Kind: CompletionWord})
+		}
+	}
+
+	add(textWords(c.Text), "")
+	for _, path := range c.Buffers {
+		if ctx.Err() != nil {
+			break
+		}
+		if path != c.Path {
+			add(cachedWords(path), filepath.Base(path))
+		}
+	}
+	return items
+}
+
+// textWords lists the distinct words of text long enough to be worth completing, in the order they first appear.
+func textWords(text string) []string {
+	seen := map[string]bool{}
+	var words []string
+	for _, w := range wordPattern.FindAllString(text, -1) {
+		if len([]rune(w)) >= minCompletionWord && !seen[w] {
+			seen[w] = true
+			words = append(words, w)
+		}
+	}
+	return words
+}
+
+// cachedWords returns the words of a file, reading it only if it changed since last time.
+func cachedWords(path string) []string {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() || info.Size() > maxWordFileSize {
+		return nil
+	}
+	if cached, ok := wordCache.get(path); ok && cached.modified.Equal(info.ModTime()) {
+		return cached.words
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	words := textWords(string(data))
+	wordCache.put(path, fileWords{info.ModTime(), words})
+	return words
+}
+
+// pathBefore matches the path being typed before the cursor.
+var pathBefore = regexp.MustCompile(`[^\s"'` + "`" + `()<>\[\]{}=,;]*$`)
+
+// PathCompletions suggests the entries of the folder whose path is being typed.
+// Relative paths are taken from the current file's folder, or the workspace for new files.
+func PathCompletions(ctx context.Context, c CompletionContext) []CompletionItem {
+	token := pathBefore.FindString(c.Before)
+	slash := strings.LastIndexAny(token, `/\`)
+	// "//" starts a comment or a URL rather than a path.
+	if slash < 0 || strings.Contains(token, "//") {
+		return nil
+	}
+	dir, segment := token[:slash+1], token[slash+1:]
+
+	switch {
+	case strings.HasPrefix(dir, "~/"):
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil
+		}
+		dir = filepath.Join(home, dir[2:])
+	case filepath.IsAbs(dir):
+	case c.Path != "":
+		dir = filepath.Join(filepath.Dir(c.Path), dir)
+	case c.Root != "":
+		dir = filepath.Join(c.Root, dir)
+	default:
+		return nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	start := c.Column - len([]rune(segment))
+	var items []CompletionItem
+	for _, entry := range entries {
+		name := entry.Name()
+		// Hidden files are only suggested once a "." is typed.
+		if strings.HasPrefix(name, ".") && !strings.HasPrefix(segment, ".") {
+			continue
+		}
+		item := CompletionItem{Label: name, Insert: name, Start: start, Kind: CompletionFile}
+		if entry.IsDir() {
+			item.Label += "/"
+			item.Insert += "/"
+			item.Kind = CompletionFolder
+		}
+		items = append(items, item)
+		if len(items) == maxPathCompletions {
+			break
+		}
+	}
+	return items
+}
+
+// linkTarget matches the target of a markdown link being typed, such as "](notes.md".
+var linkTarget = regexp.MustCompile(`\]\(([^()\s]*)$`)
+
+// MarkdownLinkCompletions suggests files of the workspace as link targets in markdown,
+// and the headings of a document after "#".
+func MarkdownLinkCompletions(ctx context.Context, c CompletionContext) []CompletionItem {
+	if c.Path != "" && !IsMarkdownFile(c.Path) {
+		return nil
+	}
+	match := linkTarget.FindStringSubmatch(c.Before)
+	if match == nil {
+		return nil
+	}
+	target := match[1]
+	start := c.Column - len([]rune(target))
+	dir := c.Root
+	if c.Path != "" {
+		dir = filepath.Dir(c.Path)
+	}
+
+	if hash := strings.IndexByte(target, '#'); hash >= 0 {
+		source := []byte(c.Text)
+		if file := target[:hash]; file != "" {
+			data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(file)))
+			if err != nil {
+				return nil
+			}
+			source = data
+		}
+		var items []CompletionItem
+		for _, heading := range DocumentHeadings(source) {
+			if heading.ID == "" {
+				continue
+			}
+			items = append(items, CompletionItem{
+				Label:  heading.ID,
+				Detail: heading.Text,
+				Insert: heading.ID,
+				Start:  start + len([]rune(target[:hash+1])),
+				Kind:   CompletionHeading,
+			})
+		}
+		return items
+	}
+
+	// Once there's a folder in the target the entries of that folder are suggested instead.
+	if c.Root == "" || dir == "" || strings.ContainsAny(target, `/\`) {
+		return nil
+	}
+	var items []CompletionItem
+	for _, file := range IndexWorkspace(c.Root, c.Ignores) {
+		if ctx.Err() != nil {
+			break
+		}
+		path := filepath.Join(c.Root, file)
+		if path == c.Path {
+			continue
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		items = append(items, CompletionItem{
+			Label:  rel,
+			Insert: strings.ReplaceAll(rel, " ", "%20"),
+			Start:  start,
+			Kind:   CompletionFile,
+		})
+	}
+	return items
+}
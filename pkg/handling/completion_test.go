@@ -0,0 +1,305 @@
+package handling
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/Leda-Editor/Leda-Text-Editor/pkg/lsp"
+)
+
+// completionLabels lists the labels of suggestions, in order.
+func completionLabels(items []CompletionItem) []string {
+	labels := make([]string, len(items))
+	for i, item := range items {
+		labels[i] = item.Label
+	}
+	return labels
+}
+
+// completionAt describes the cursor at the end of before, the last line of text.
+func completionAt(path, text, before string) CompletionContext {
+	row := 0
+	for _, r := range text {
+		if r == '\n' {
+			row++
+		}
+	}
+	return CompletionContext{Path: path, Text: text, Row: row, Column: len([]rune(before)), Before: before}
+}
+
+func TestWordBefore(t *testing.T) {
+	tests := []struct {
+		line   string
+		column int
+		want   string
+	}{
+		{"fmt.Prin", 8, "Prin"},
+		{"héllo wörld", 11, "wörld"},
+		{"a_b1 ", 5, ""},
+		{"a_b1", 2, "a_"},
+		{"short", 99, "short"},
+	}
+	for _, tt := range tests {
+		if got := WordBefore(tt.line, tt.column); got != tt.want {
+			t.Errorf("WordBefore(%q, %d) = %q, want %q", tt.line, tt.column, got, tt.want)
+		}
+	}
+}
+
+func TestFilterCompletions(t *testing.T) {
+	items := []CompletionItem{
+		// Ones matching as well are listed by provider, then as the provider sorts them.
+		{Label: "Println", Insert: "Println", Start: 4, Rank: 0, Sort: "c"},
+		{Label: "Printf", Insert: "Printf", Start: 4, Rank: 0, Sort: "b"},
+		{Label: "Print", Insert: "Print", Start: 4, Rank: 0, Sort: "a"},
+		// Another provider suggesting the same text is dropped.
+		{Label: "Println", Detail: "words", Insert: "Println", Start: 4, Rank: 2},
+		// Matched against its filter text rather than its label.
+		{Label: "func Sprint", Filter: "Sprint", Insert: "Sprint", Start: 4, Rank: 1},
+		{Label: "Scan", Insert: "Scan", Start: 4},
+		// What's already typed has nothing left to complete.
+		{Label: "Pr", Insert: "Pr", Start: 4},
+		// Starts after the cursor.
+		{Label: "Later", Insert: "Later", Start: 9},
+	}
+	got := FilterCompletions(items, "fmt.Pr", 6, 10)
+	if want := []string{"Print", "Printf", "Println", "func Sprint"}; !reflect.DeepEqual(completionLabels(got), want) {
+		t.Errorf("FilterCompletions = %q, want %q", completionLabels(got), want)
+	}
+	for _, item := range got {
+		if item.Detail == "words" {
+			t.Error("FilterCompletions kept both suggestions of Println")
+		}
+	}
+	if got := FilterCompletions(items, "fmt.Pr", 6, 2); len(got) != 2 {
+		t.Errorf("FilterCompletions with a limit of 2 returned %d", len(got))
+	}
+
+	// A snippet is still offered once its prefix is typed in full.
+	snippet := CompletionItem{Label: "for", Start: 0, Snippet: &Snippet{Name: "for loop"}}
+	if got := FilterCompletions([]CompletionItem{snippet}, "for", 3, 10); len(got) != 1 {
+		t.Errorf("FilterCompletions dropped a snippet whose prefix was typed")
+	}
+}
+
+func TestCollectCompletions(t *testing.T) {
+	provider := func(labels ...string) CompletionProvider {
+		return func(ctx context.Context, c CompletionContext) []CompletionItem {
+			var items []CompletionItem
+			for _, label := range labels {
+				items = append(items, CompletionItem{Label: label})
+			}
+			return items
+		}
+	}
+	got := CollectCompletions(context.Background(), []CompletionProvider{provider("a", "b"), provider(), provider("c")}, CompletionContext{})
+	want := []CompletionItem{{Label: "a"}, {Label: "b"}, {Label: "c", Rank: 2}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CollectCompletions = %+v, want %+v", got, want)
+	}
+}
+
+func TestTextWords(t *testing.T) {
+	got := textWords("the cat sat on the mat, naïve_word x2 cat 123abc")
+	want := []string{"the", "cat", "sat", "mat", "naïve_word", "abc"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("textWords = %q, want %q", got, want)
+	}
+}
+
+func TestBufferWordCompletions(t *testing.T) {
+	dir := t.TempDir()
+	other := filepath.Join(dir, "other.txt")
+	current := filepath.Join(dir, "current.txt")
+	for path, text := range map[string]string{other: "banana apple cherry", current: "stale words on disk"} {
+		if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := completionAt(current, "apple pie\nap", "ap")
+	c.Buffers = []string{current, other, filepath.Join(dir, "missing.txt")}
+	got := BufferWordCompletions(context.Background(), c)
+	// The current file's words come from the editor and each word is suggested once.
+	if want := []string{"apple", "pie", "banana", "cherry"}; !reflect.DeepEqual(completionLabels(got), want) {
+		t.Errorf("BufferWordCompletions = %q, want %q", completionLabels(got), want)
+	}
+	for _, item := range got {
+		if item.Start != 0 {
+			t.Errorf("%s starts at %d, want 0", item.Label, item.Start)
+		}
+		if want := map[bool]string{true: "other.txt", false: ""}[item.Label == "banana" || item.Label == "cherry"]; item.Detail != want {
+			t.Errorf("%s detail = %q, want %q", item.Label, item.Detail, want)
+		}
+	}
+
+	// Nothing is offered while typing until a word is started.
+	if got := BufferWordCompletions(context.Background(), completionAt("", "apple ", "apple ")); got != nil {
+		t.Errorf("BufferWordCompletions after a space = %q, want none", completionLabels(got))
+	}
+}
+
+func TestCachedWordsIsBounded(t *testing.T) {
+	cache := wordCache
+	wordCache = newLRUCache[fileWords](2)
+	t.Cleanup(func() { wordCache = cache })
+
+	dir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("words in "+name), 0644); err != nil {
+			t.Fatal(err)
+		}
+		cachedWords(path)
+	}
+	if wordCache.len() != 2 {
+		t.Errorf("the word cache holds %d files, want 2", wordCache.len())
+	}
+	if _, ok := wordCache.get(filepath.Join(dir, "a.txt")); ok {
+		t.Error("the least recently read file is still cached")
+	}
+}
+
+func TestPathCompletions(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"docs/guide.md", "main.go", ".env"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	current := filepath.Join(dir, "notes.txt")
+	tests := []struct {
+		name   string
+		c      CompletionContext
+		want   []string
+		start  int
+		insert string
+	}{
+		{"relative to the file", completionAt(current, "see ./ma", "see ./ma"), []string{"docs/", "main.go"}, 6, "docs/"},
+		{"hidden once a dot is typed", completionAt(current, "./.e", "./.e"), []string{".env", "docs/", "main.go"}, 2, ".env"},
+		{"absolute", completionAt("", dir+"/docs/", dir+"/docs/"), []string{"guide.md"}, len([]rune(dir + "/docs/")), "guide.md"},
+		{"new file in the workspace", CompletionContext{Root: dir, Before: "docs/", Column: 5}, []string{"guide.md"}, 5, "guide.md"},
+		{"new file without a workspace", completionAt("", "docs/", "docs/"), nil, 0, ""},
+		{"comment", completionAt(current, "// main", "// main"), nil, 0, ""},
+		{"url", completionAt(current, "https://example.com/", "https://example.com/"), nil, 0, ""},
+		{"missing folder", completionAt(current, "nowhere/", "nowhere/"), nil, 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := PathCompletions(context.Background(), tt.c)
+			labels := completionLabels(got)
+			sort.Strings(labels)
+			if len(labels) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(labels, tt.want) {
+				t.Fatalf("PathCompletions = %q, want %q", labels, tt.want)
+			}
+			for _, item := range got {
+				if item.Start != tt.start {
+					t.Errorf("%s starts at %d, want %d", item.Label, item.Start, tt.start)
+				}
+				if item.Label == tt.insert && item.Insert != tt.insert {
+					t.Errorf("%s inserts %q, want %q", item.Label, item.Insert, tt.insert)
+				}
+			}
+		})
+	}
+}
+
+func TestMarkdownLinkCompletions(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"index.md":          "",
+		"docs/setup.md":     "# Install it\n## Build\n",
+		"docs/my notes.md":  "",
+		"node_modules/x.md": "",
+		"drafts/idea.md":    "",
+		".gitignore":        "drafts/\n",
+	}
+	for name, text := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	current := filepath.Join(root, "docs", "setup.md")
+	at := func(path, text, before string) CompletionContext {
+		c := completionAt(path, text, before)
+		c.Root = root
+		c.Ignores = LoadIgnores(root)
+		return c
+	}
+
+	t.Run("files", func(t *testing.T) {
+		got := MarkdownLinkCompletions(context.Background(), at(current, "[home](", "[home]("))
+		inserts := map[string]string{}
+		for _, item := range got {
+			inserts[item.Label] = item.Insert
+			if item.Start != 7 {
+				t.Errorf("%s starts at %d, want 7", item.Label, item.Start)
+			}
+		}
+		// Targets are relative to the current file, which isn't offered, and ignored folders are skipped.
+		want := map[string]string{"../index.md": "../index.md", "my notes.md": "my%20notes.md", "../.gitignore": "../.gitignore"}
+		if !reflect.DeepEqual(inserts, want) {
+			t.Errorf("MarkdownLinkCompletions = %v, want %v", inserts, want)
+		}
+	})
+	t.Run("headings of another file", func(t *testing.T) {
+		before := "see [build](docs/setup.md#b"
+		got := MarkdownLinkCompletions(context.Background(), at(filepath.Join(root, "index.md"), before, before))
+		if want := []string{"install-it", "build"}; !reflect.DeepEqual(completionLabels(got), want) {
+			t.Fatalf("MarkdownLinkCompletions = %q, want %q", completionLabels(got), want)
+		}
+		if start := len([]rune(before)) - 1; got[0].Start != start {
+			t.Errorf("headings start at %d, want %d", got[0].Start, start)
+		}
+	})
+	t.Run("headings of the same file", func(t *testing.T) {
+		text := "# Top\n\n[up](#"
+		got := MarkdownLinkCompletions(context.Background(), at(current, text, "[up](#"))
+		if want := []string{"top"}; !reflect.DeepEqual(completionLabels(got), want) {
+			t.Errorf("MarkdownLinkCompletions = %q, want %q", completionLabels(got), want)
+		}
+	})
+	t.Run("not markdown", func(t *testing.T) {
+		if got := MarkdownLinkCompletions(context.Background(), at(filepath.Join(root, "main.go"), "[a](", "[a](")); got != nil {
+			t.Errorf("MarkdownLinkCompletions in a Go file = %q, want none", completionLabels(got))
+		}
+	})
+}
+
+func TestLSPCompletions(t *testing.T) {
+	text := "x := fmt.Pri"
+	c := completionAt("/main.go", text, text)
+	results := []lsp.CompletionItem{
+		{Label: "Println", Kind: lsp.CompletionFunction, InsertText: "Println(${1:a})", InsertTextFormat: lsp.InsertTextFormatSnippet},
+		{Label: "Printf", Kind: lsp.CompletionFunction, FilterText: "Printf", SortText: "2"},
+		{Label: "fmt.Print", Kind: lsp.CompletionVariable, TextEdit: &lsp.TextEdit{
+			Range:   lsp.Range{Start: lsp.Position{Line: 0, Character: 5}, End: lsp.Position{Line: 0, Character: 12}},
+			NewText: "fmt.Print",
+		}},
+	}
+	got := LSPCompletions(results, c)
+	want := []CompletionItem{
+		{Label: "Println", Insert: "Println(a)", Start: 9, Kind: CompletionFunction},
+		{Label: "Printf", Insert: "Printf", Start: 9, Filter: "Printf", Sort: "2", Kind: CompletionFunction},
+		// The server replaces the whole selector rather than the word before the cursor.
+		{Label: "fmt.Print", Insert: "fmt.Print", Start: 5, Kind: CompletionField},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LSPCompletions = %+v, want %+v", got, want)
+	}
+}
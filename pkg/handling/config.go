@@ -8,6 +8,7 @@ import (
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
 	"fyne.io/fyne/v2/widget"
 )
 
@@ -27,7 +28,25 @@ type Config struct {
 		Default string `json:"default"`
 		Bold    string `json:"bold"`
 		Italic  string `json:"italic"`
+		// Monospace is optional and used for plain text and code in exports.
+		Monospace string `json:"monospace"`
 	} `json:"fonts"`
+	// Interpreters overrides the command Run File uses, keyed by file extension, e.g. ".py": "python3 -u".
+	Interpreters map[string]string `json:"interpreters"`
+	// LanguageServers overrides the language server started for a file extension, e.g. ".go": ["gopls", "serve"].
+	// An empty list turns the server off.
+	LanguageServers map[string][]string `json:"languageServers"`
+	// Formatters overrides how Format Document formats a file extension, e.g. ".py": ["black", "-q", "-"].
+	// The command reads the source on its input and writes the result, "${file}" is replaced by the file's path.
+	// An empty list turns formatting off.
+	Formatters map[string][]string `json:"formatters"`
+	// Indentation overrides how a file extension is indented, e.g. ".py": {"tabs": false, "width": 4}.
+	Indentation map[string]Indentation `json:"indentation"`
+	// Spelling sets where the Hunspell dictionaries are, e.g. {"dictionaries": "/usr/share/hunspell"}.
+	Spelling struct {
+		// Dictionaries is the folder of .dic and .aff files, the dictionaries folder next to config.json by default.
+		Dictionaries string `json:"dictionaries"`
+	} `json:"spelling"`
 }
 
 // LoadConfig reads the config.json file and parses it into a Config struct
@@ -82,5 +101,8 @@ func OpenConfigFile(window fyne.Window, editor *widget.Entry) {
 	}
 
 	// Set the text in the editor
+	if OnFileLoading != nil {
+		OnFileLoading(storage.NewFileURI(configPath))
+	}
 	editor.SetText(string(data))
 }
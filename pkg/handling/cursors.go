@@ -0,0 +1,118 @@
+package handling
+
+import (
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Cursor is one of several cursors in a text, positions are in runes.
+// The text between Anchor and Position is selected, Position is where the caret is.
+type Cursor struct {
+	Anchor, Position int
+}
+
+// Start returns where the cursor's selection starts.
+func (c Cursor) Start() int {
+	return min(c.Anchor, c.Position)
+}
+
+// End returns where the cursor's selection ends.
+func (c Cursor) End() int {
+	return max(c.Anchor, c.Position)
+}
+
+// Empty reports whether nothing is selected.
+func (c Cursor) Empty() bool {
+	return c.Anchor == c.Position
+}
+
+// MergeCursors orders cursors by position and joins those that overlap or are at the same place.
+func MergeCursors(cursors []Cursor) []Cursor {
+	sorted := slices.Clone(cursors)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Start() < sorted[j].Start() })
+	var merged []Cursor
+	for _, c := range sorted {
+		n := len(merged)
+		if n == 0 || c.Start() > merged[n-1].End() || (c.Start() == merged[n-1].End() && !c.Empty() && !merged[n-1].Empty()) {
+			merged = append(merged, c)
+			continue
+		}
+		last := merged[n-1]
+		start, end := last.Start(), max(last.End(), c.End())
+		if last.Position < last.Anchor {
+			merged[n-1] = Cursor{Anchor: end, Position: start}
+		} else {
+			merged[n-1] = Cursor{Anchor: start, Position: end}
+		}
+	}
+	return merged
+}
+
+// CursorEdit is what an edit does at one cursor: it replaces the text from Start to End with Insert.
+type CursorEdit struct {
+	Start, End int
+	Insert     string
+}
+
+// EditCursors makes an edit at every cursor at once and returns the new text with a cursor after each insertion.
+// Cursors are returned in the order they were given, edit is given each cursor in order of position
+// and the text to replace may not reach back past the previous cursor's edit.
+func EditCursors(text []rune, cursors []Cursor, edit func(Cursor) CursorEdit) ([]rune, []Cursor) {
+	order := make([]int, len(cursors))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return cursors[order[i]].Start() < cursors[order[j]].Start() })
+
+	var result []rune
+	moved := make([]Cursor, len(cursors))
+	done := 0
+	for _, i := range order {
+		e := edit(cursors[i])
+		e.Start = min(max(e.Start, done), len(text))
+		e.End = min(max(e.End, e.Start), len(text))
+		result = append(result, text[done:e.Start]...)
+		result = append(result, []rune(e.Insert)...)
+		moved[i] = Cursor{Anchor: len(result), Position: len(result)}
+		done = e.End
+	}
+	return append(result, text[done:]...), moved
+}
+
+// NextOccurrence finds needle in text after from, starting again from the beginning when it isn't found.
+func NextOccurrence(text, needle []rune, from int) (int, bool) {
+	if len(needle) == 0 {
+		return 0, false
+	}
+	find := func(start, end int) int {
+		for i := start; i+len(needle) <= end; i++ {
+			if slices.Equal(text[i:i+len(needle)], needle) {
+				return i
+			}
+		}
+		return -1
+	}
+	from = min(max(from, 0), len(text))
+	if at := find(from, len(text)); at >= 0 {
+		return at, true
+	}
+	if at := find(0, min(from+len(needle)-1, len(text))); at >= 0 {
+		return at, true
+	}
+	return 0, false
+}
+
+// SplitForCursors divides pasted text between cursors when it has a line for each of them.
+// Otherwise every cursor gets all of it.
+func SplitForCursors(text string, count int) []string {
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	if count > 1 && len(lines) == count {
+		return lines
+	}
+	pieces := make([]string, count)
+	for i := range pieces {
+		pieces[i] = text
+	}
+	return pieces
+}
@@ -0,0 +1,100 @@
+package handling
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeCursors(t *testing.T) {
+	tests := []struct {
+		name    string
+		cursors []Cursor
+		want    []Cursor
+	}{
+		{"sorted", []Cursor{{5, 5}, {1, 1}}, []Cursor{{1, 1}, {5, 5}}},
+		{"same place", []Cursor{{3, 3}, {3, 3}}, []Cursor{{3, 3}}},
+		{"overlapping selections", []Cursor{{0, 4}, {2, 8}}, []Cursor{{0, 8}}},
+		{"backwards selection stays backwards", []Cursor{{4, 0}, {2, 8}}, []Cursor{{8, 0}}},
+		{"caret inside a selection", []Cursor{{0, 4}, {2, 2}}, []Cursor{{0, 4}}},
+		{"touching selections stay apart", []Cursor{{0, 2}, {2, 4}}, []Cursor{{0, 2}, {2, 4}}},
+		{"caret at the end of a selection", []Cursor{{0, 2}, {2, 2}}, []Cursor{{0, 2}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MergeCursors(tt.cursors); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MergeCursors(%v) = %v, want %v", tt.cursors, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEditCursors(t *testing.T) {
+	insert := func(s string) func(Cursor) CursorEdit {
+		return func(c Cursor) CursorEdit { return CursorEdit{c.Start(), c.End(), s} }
+	}
+	backspace := func(c Cursor) CursorEdit { return CursorEdit{c.Position - 1, c.Position, ""} }
+	tests := []struct {
+		name    string
+		text    string
+		cursors []Cursor
+		edit    func(Cursor) CursorEdit
+		want    string
+		moved   []Cursor
+	}{
+		{"type at each cursor", "ab\ncd", []Cursor{{3, 3}, {0, 0}}, insert("x"), "xab\nxcd", []Cursor{{5, 5}, {1, 1}}},
+		{"replace selections", "one two", []Cursor{{0, 3}, {4, 7}}, insert("1"), "1 1", []Cursor{{1, 1}, {3, 3}}},
+		{"backspace", "abc", []Cursor{{1, 1}, {3, 3}}, backspace, "b", []Cursor{{0, 0}, {1, 1}}},
+		{"edits don't reach back past the previous one", "abc", []Cursor{{1, 1}, {2, 2}}, func(c Cursor) CursorEdit {
+			return CursorEdit{c.Position - 2, c.Position, "_"}
+		}, "__c", []Cursor{{1, 1}, {2, 2}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, moved := EditCursors([]rune(tt.text), tt.cursors, tt.edit)
+			if string(text) != tt.want || !reflect.DeepEqual(moved, tt.moved) {
+				t.Errorf("EditCursors(%q) = %q %v, want %q %v", tt.text, string(text), moved, tt.want, tt.moved)
+			}
+		})
+	}
+}
+
+func TestNextOccurrence(t *testing.T) {
+	text := []rune("foo bar foo baz")
+	tests := []struct {
+		needle string
+		from   int
+		want   int
+		ok     bool
+	}{
+		{"foo", 0, 0, true},
+		{"foo", 3, 8, true},
+		{"foo", 11, 0, true},
+		{"baz", 0, 12, true},
+		{"qux", 0, 0, false},
+		{"", 0, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := NextOccurrence(text, []rune(tt.needle), tt.from)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("NextOccurrence(%q, %d) = %d, %v, want %d, %v", tt.needle, tt.from, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestSplitForCursors(t *testing.T) {
+	tests := []struct {
+		text  string
+		count int
+		want  []string
+	}{
+		{"a\nb\nc\n", 3, []string{"a", "b", "c"}},
+		{"a\nb", 2, []string{"a", "b"}},
+		{"a\nb", 3, []string{"a\nb", "a\nb", "a\nb"}},
+		{"x", 1, []string{"x"}},
+	}
+	for _, tt := range tests {
+		if got := SplitForCursors(tt.text, tt.count); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SplitForCursors(%q, %d) = %q, want %q", tt.text, tt.count, got, tt.want)
+		}
+	}
+}
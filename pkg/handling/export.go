@@ -0,0 +1,305 @@
+package handling
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+// ExportStyle holds the CSS colours (as hex strings) used for exported documents.
+type ExportStyle struct {
+	Background string
+	Text       string
+	Primary    string
+	Code       string
+}
+
+// HTMLExportOptions controls how markdown is turned into a standalone HTML page.
+type HTMLExportOptions struct {
+	// Title is used for the <title> element, defaults to the first heading.
+	Title string
+	// BaseDir is the directory relative image paths are resolved against.
+	BaseDir string
+	// TableOfContents adds a list of links to every heading at the top of the page.
+	TableOfContents bool
+	Style           ExportStyle
+}
+
+// DefaultExportStyle is used when no theme colours are available.
+var DefaultExportStyle = ExportStyle{
+	Background: "#FFFFFF",
+	Text:       "#000000",
+	Primary:    "#6200EE",
+	Code:       "#F5F5F5",
+}
+
+// ExportStyleFromConfig builds an export style from the theme section of config.json.
+func ExportStyleFromConfig(config *Config) ExportStyle {
+	style := DefaultExportStyle
+	if config.Theme.BackgroundColour != "" {
+		style.Background = config.Theme.BackgroundColour
+	}
+	if config.Theme.TextColour != "" {
+		style.Text = config.Theme.TextColour
+	}
+	if config.Theme.PrimaryColour != "" {
+		style.Primary = config.Theme.PrimaryColour
+	}
+	if config.Theme.EditorColour != "" {
+		style.Code = config.Theme.EditorColour
+	}
+	return style
+}
+
+// NewMarkdown creates the goldmark converter shared by the exporters and the preview.
+func NewMarkdown() goldmark.Markdown {
+	return goldmark.New(
+		goldmark.WithExtensions(extension.GFM, extension.Footnote),
+		goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+	)
+}
+
+// RenderHTML converts markdown source into a self-contained HTML document.
+func RenderHTML(source []byte, opts HTMLExportOptions) ([]byte, error) {
+	md := NewMarkdown()
+	doc := md.Parser().Parse(text.NewReader(source))
+
+	inlineImages(doc, opts.BaseDir)
+	headings := collectHeadings(doc, source)
+
+	var body bytes.Buffer
+	if err := md.Renderer().Render(&body, source, doc); err != nil {
+		return nil, err
+	}
+
+	title := opts.Title
+	if title == "" && len(headings) > 0 {
+		title = headings[0].Text
+	}
+	if title == "" {
+		title = "Untitled"
+	}
+
+	var out bytes.Buffer
+	out.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(&out, "<title>%s</title>\n", html.EscapeString(title))
+	fmt.Fprintf(&out, "<style>\n%s</style>\n", exportCSS(opts.Style))
+	out.WriteString("</head>\n<body>\n")
+	if opts.TableOfContents && len(headings) > 0 {
+		writeTableOfContents(&out, headings)
+	}
+	out.Write(body.Bytes())
+	out.WriteString("</body>\n</html>\n")
+	return out.Bytes(), nil
+}
+
+// Heading is a single entry in a document's table of contents.
+type Heading struct {
+	Level int
+	Text  string
+	ID    string
+}
+
+// collectHeadings lists every heading in the document in order.
+func collectHeadings(doc ast.Node, source []byte) []Heading {
+	var headings []Heading
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		heading, ok := n.(*ast.Heading)
+		if !entering || !ok {
+			return ast.WalkContinue, nil
+		}
+		h := Heading{Level: heading.Level, Text: NodeText(heading, source)}
+		if id, ok := heading.AttributeString("id"); ok {
+			if b, ok := id.([]byte); ok {
+				h.ID = string(b)
+			}
+		}
+		headings = append(headings, h)
+		return ast.WalkSkipChildren, nil
+	})
+	return headings
+}
+
+// DocumentHeadings lists the headings of markdown source with the IDs links use to jump to them.
+func DocumentHeadings(source []byte) []Heading {
+	doc := NewMarkdown().Parser().Parse(text.NewReader(source))
+	return collectHeadings(doc, source)
+}
+
+// NodeText returns the plain text content of an inline node tree.
+func NodeText(n ast.Node, source []byte) string {
+	var sb strings.Builder
+	ast.Walk(n, func(child ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch t := child.(type) {
+		case *ast.Text:
+			sb.Write(t.Segment.Value(source))
+			if t.SoftLineBreak() {
+				sb.WriteByte(' ')
+			}
+		case *ast.String:
+			sb.Write(t.Value)
+		}
+		return ast.WalkContinue, nil
+	})
+	return sb.String()
+}
+
+// inlineImages replaces local image references with data URIs so the page has no external files.
+func inlineImages(doc ast.Node, baseDir string) {
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		img, ok := n.(*ast.Image)
+		if !entering || !ok {
+			return ast.WalkContinue, nil
+		}
+		if uri, err := imageDataURI(string(img.Destination), baseDir); err == nil {
+			img.Destination = []byte(uri)
+		}
+		return ast.WalkContinue, nil
+	})
+}
+
+// ResolveImagePath turns a markdown image destination into a local file path.
+func ResolveImagePath(dest, baseDir string) (string, error) {
+	if u, err := url.Parse(dest); err != nil || u.Scheme != "" {
+		return "", fmt.Errorf("not a local image: %s", dest)
+	}
+	path, err := url.PathUnescape(dest)
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(baseDir, path)
+	}
+	return path, nil
+}
+
+// imageDataURI reads a local image and encodes it as a data URI.
+func imageDataURI(dest, baseDir string) (string, error) {
+	path, err := ResolveImagePath(dest, baseDir)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	mimeType := mime.TypeByExtension(strings.ToLower(filepath.Ext(path)))
+	if mimeType == "" {
+		mimeType = http.DetectContentType(data)
+	}
+	// Drop parameters such as "; charset=utf-8".
+	mimeType = strings.TrimSpace(strings.Split(mimeType, ";")[0])
+	return "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data), nil
+}
+
+func writeTableOfContents(out *bytes.Buffer, headings []Heading) {
+	out.WriteString("<nav class=\"toc\">\n<ul>\n")
+	for _, h := range headings {
+		fmt.Fprintf(out, "<li class=\"toc-h%d\"><a href=\"#%s\">%s</a></li>\n",
+			h.Level, html.EscapeString(h.ID), html.EscapeString(h.Text))
+	}
+	out.WriteString("</ul>\n</nav>\n")
+}
+
+// exportCSS builds the embedded stylesheet from the given colours.
+func exportCSS(style ExportStyle) string {
+	if style == (ExportStyle{}) {
+		style = DefaultExportStyle
+	}
+	return fmt.Sprintf(`body { background: %[1]s; color: %[2]s; font-family: "Open Sans", sans-serif; line-height: 1.6; max-width: 50em; margin: 2em auto; padding: 0 1em; }
+a { color: %[3]s; }
+h1, h2 { border-bottom: 1px solid %[3]s; padding-bottom: 0.2em; }
+code, pre { background: %[4]s; font-family: monospace; }
+code { padding: 0.1em 0.3em; }
+pre { padding: 0.8em; overflow-x: auto; }
+pre code { padding: 0; }
+blockquote { border-left: 4px solid %[3]s; margin-left: 0; padding-left: 1em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid %[3]s; padding: 0.3em 0.6em; }
+img { max-width: 100%%; }
+.toc { border: 1px solid %[3]s; padding: 0.5em 1em; margin-bottom: 2em; }
+.toc ul { list-style: none; padding-left: 0; }
+.toc-h2 { padding-left: 1em; }
+.toc-h3 { padding-left: 2em; }
+.toc-h4 { padding-left: 3em; }
+.toc-h5 { padding-left: 4em; }
+.toc-h6 { padding-left: 5em; }
+`, style.Background, style.Text, style.Primary, style.Code)
+}
+
+// ExportHTMLFile converts a markdown file on disk into an HTML file.
+func ExportHTMLFile(inPath, outPath string, opts HTMLExportOptions) error {
+	source, err := os.ReadFile(inPath)
+	if err != nil {
+		return err
+	}
+	if opts.BaseDir == "" {
+		opts.BaseDir = filepath.Dir(inPath)
+	}
+	data, err := RenderHTML(source, opts)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(outPath, data, 0644)
+}
+
+// opens a save dialog and exports the editor's markdown as HTML.
+func ExportHTML(window fyne.Window, editor *widget.Entry, style ExportStyle, withTOC bool) {
+	opts := HTMLExportOptions{TableOfContents: withTOC, Style: style}
+	if CurrentFile != nil {
+		opts.BaseDir = filepath.Dir(CurrentFile.Path())
+	}
+
+	data, err := RenderHTML([]byte(editorText(editor)), opts)
+	if err != nil {
+		dialog.ShowError(err, window)
+		return
+	}
+
+	saveDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		if writer == nil {
+			return
+		}
+		defer writer.Close()
+
+		_, err = writer.Write(data)
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+	}, window)
+	saveDialog.SetFileName(exportFileName(".html"))
+	saveDialog.Show()
+}
+
+// suggests an export file name based on the current file.
+func exportFileName(ext string) string {
+	if CurrentFile == nil {
+		return "untitled" + ext
+	}
+	name := CurrentFile.Name()
+	return strings.TrimSuffix(name, filepath.Ext(name)) + ext
+}
@@ -0,0 +1,162 @@
+package handling
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// pngPixel is a 1x1 PNG image.
+var pngPixel = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\b\x00\x00\x00\x00:~\x9bU\x00\x00\x00\x0fIDATx\x9c\x00\x02\x00\xfd\xff\x02\x00\x03\x00\x00\x06\x00\x03!\xfc\xac\x06\x00\x00\x00\x00IEND\xaeB`\x82")
+
+func TestRenderHTML(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "pixel.png"), pngPixel, 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		source   string
+		opts     HTMLExportOptions
+		contains []string
+		excludes []string
+	}{
+		{
+			"title from the first heading", "# Hello & bye\n\ntext\n", HTMLExportOptions{},
+			[]string{"<title>Hello &amp; bye</title>", `<h1 id="hello--bye">`, "<!DOCTYPE html>"},
+			[]string{`class="toc"`},
+		},
+		{"untitled", "just text\n", HTMLExportOptions{}, []string{"<title>Untitled</title>"}, nil},
+		{"given title", "# Heading\n", HTMLExportOptions{Title: "Mine"}, []string{"<title>Mine</title>"}, nil},
+		{
+			"table of contents", "# One\n## Two\n", HTMLExportOptions{TableOfContents: true},
+			[]string{`<li class="toc-h1"><a href="#one">One</a></li>`, `<li class="toc-h2"><a href="#two">Two</a></li>`},
+			nil,
+		},
+		{
+			"local images are inlined", "![dot](pixel.png) ![web](https://example.com/x.png)\n", HTMLExportOptions{BaseDir: dir},
+			[]string{`src="data:image/png;base64,`, `src="https://example.com/x.png"`},
+			[]string{`src="pixel.png"`},
+		},
+		{
+			"style colours", "text\n", HTMLExportOptions{Style: ExportStyle{Background: "#101010", Text: "#EEEEEE", Primary: "#FF0000", Code: "#202020"}},
+			[]string{"background: #101010", "color: #EEEEEE"},
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := RenderHTML([]byte(tt.source), tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(string(out), want) {
+					t.Errorf("RenderHTML(%q) doesn't contain %q:\n%s", tt.source, want, out)
+				}
+			}
+			for _, unwanted := range tt.excludes {
+				if strings.Contains(string(out), unwanted) {
+					t.Errorf("RenderHTML(%q) contains %q", tt.source, unwanted)
+				}
+			}
+		})
+	}
+}
+
+func TestResolveImagePath(t *testing.T) {
+	tests := []struct {
+		dest    string
+		want    string
+		wantErr bool
+	}{
+		{"pixel.png", filepath.Join("/docs", "pixel.png"), false},
+		{"images/my%20pixel.png", filepath.Join("/docs", "images", "my pixel.png"), false},
+		{"/abs/pixel.png", "/abs/pixel.png", false},
+		{"https://example.com/pixel.png", "", true},
+		{"data:image/png;base64,AAAA", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ResolveImagePath(tt.dest, "/docs")
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ResolveImagePath(%q) = %q, %v, want %q", tt.dest, got, err, tt.want)
+		}
+	}
+}
+
+func TestRunExportCommand(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "notes.md")
+	if err := os.WriteFile(in, []byte("# Notes\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := RunExportCommand([]string{"-toc", "-config", filepath.Join(dir, "none.json"), in}); err != nil {
+		t.Fatal(err)
+	}
+	out, err := os.ReadFile(filepath.Join(dir, "notes.html"))
+	if err != nil || !strings.Contains(string(out), `class="toc"`) {
+		t.Errorf("exported %q, %v", out, err)
+	}
+
+	for _, args := range [][]string{{}, {"-format", "doc", in}, {"-o", "out.html", in, in}, {filepath.Join(dir, "missing.md")}} {
+		if err := RunExportCommand(args); err == nil {
+			t.Errorf("RunExportCommand(%q) didn't fail", args)
+		}
+	}
+}
+
+func TestRenderHTMLGFM(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{"table", "| a | b |\n|---|--:|\n| 1 | 2 |\n", `<td style="text-align:right">2</td>`},
+		{"task list", "- [x] done\n- [ ] todo\n", `<li><input checked="" disabled="" type="checkbox"> done</li>`},
+		{"strikethrough", "~~old~~\n", "<del>old</del>"},
+		{"autolink", "see https://example.com\n", `<a href="https://example.com">https://example.com</a>`},
+		{"footnote", "Note[^1]\n\n[^1]: The note.\n", `<div class="footnotes" role="doc-endnotes">`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := RenderHTML([]byte(tt.source), HTMLExportOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(out), tt.want) {
+				t.Errorf("RenderHTML(%q) doesn't contain %q:\n%s", tt.source, tt.want, out)
+			}
+		})
+	}
+}
+
+func TestDocumentHeadings(t *testing.T) {
+	got := DocumentHeadings([]byte("# One *two*\n\ntext\n\n## Three\n"))
+	want := []Heading{{Level: 1, Text: "One two", ID: "one-two"}, {Level: 2, Text: "Three", ID: "three"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DocumentHeadings = %+v, want %+v", got, want)
+	}
+}
+
+func TestDocumentHeadingIDs(t *testing.T) {
+	// Completion offers these IDs after "#" in links, so they must match what the exported page uses.
+	source := []byte("# Setup\n## Setup\n## Go & Fyne\n")
+	var ids []string
+	for _, h := range DocumentHeadings(source) {
+		ids = append(ids, h.ID)
+	}
+	out, err := RenderHTML(source, HTMLExportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range ids {
+		if !strings.Contains(string(out), `id="`+id+`"`) {
+			t.Errorf("the exported page has no heading with id %q:\n%s", id, out)
+		}
+	}
+	if want := []string{"setup", "setup-1", "go--fyne"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("heading IDs = %q, want %q", ids, want)
+	}
+}
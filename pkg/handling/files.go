@@ -3,6 +3,7 @@ package handling
 import (
 	"fmt"
 	"io"
+	"os"
 	"time"
 
 	"fyne.io/fyne/v2"
@@ -17,8 +18,28 @@ var (
 	autoSaveDelay   time.Duration = 5 * time.Second // default 5 seconds
 	CurrentFile     fyne.URI
 	OnFileChanged func(fyne.URI)
+	// OnFileLoading is called before a file's text is put in the editor, so it isn't taken for an edit.
+	OnFileLoading func(fyne.URI)
+	// BeforeSave is called with the path of the current file before Save writes it, and can change the editor's text.
+	// If it fails, nothing is written.
+	BeforeSave func(path string) error
+	// OnSavedAs is called once Save As has written the editor's text to a new file, previous is nil for a new buffer.
+	OnSavedAs func(previous, uri fyne.URI)
+	// BeforeAutoSave is called with the path of the current file before auto-save writes it, and mustn't change the text.
+	// If it fails, that auto-save is skipped.
+	BeforeAutoSave func(path string) error
+	// FullText returns what the editor's text stands for, it can hide folded regions.
+	FullText func(text string) string
 )
 
+// editorText returns the editor's text as it's saved, with any folded regions put back.
+func editorText(editor *widget.Entry) string {
+	if FullText != nil {
+		return FullText(editor.Text)
+	}
+	return editor.Text
+}
+
 // opens a file dialog and loads the selected file's content into the editor.
 func OpenFile(window fyne.Window, editor *widget.Entry) {
 	dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
@@ -37,21 +58,58 @@ func OpenFile(window fyne.Window, editor *widget.Entry) {
 			return
 		}
 
-		editor.SetText(string(data))
-		CurrentFile = reader.URI() //stores current url
-
-		if OnFileChanged != nil {
-			OnFileChanged(CurrentFile)
-		}
+		setCurrentFile(editor, reader.URI(), data)
 	}, window)
 }
 
+// loads the file at path into the editor, used by the workspace tree.
+func OpenPath(window fyne.Window, editor *widget.Entry, path string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		dialog.ShowError(err, window)
+		return
+	}
+	setCurrentFile(editor, storage.NewFileURI(path), data)
+}
+
+// shows the loaded content and updates the current file.
+func setCurrentFile(editor *widget.Entry, uri fyne.URI, data []byte) {
+	CurrentFile = uri //stores current url, set first so the editor's change handlers see the new file
+	if OnFileLoading != nil {
+		OnFileLoading(uri)
+	}
+	editor.SetText(string(data))
+	RecordRecent(uri.Path())
+
+	if OnFileChanged != nil {
+		OnFileChanged(CurrentFile)
+	}
+}
+
 // saves to the currently open file
 func SaveFile(window fyne.Window, editor *widget.Entry) {
+	saveFile(window, editor, true)
+}
+
+// saves to the currently open file, running the BeforeSave hook first unless this is an auto-save
+func saveFile(window fyne.Window, editor *widget.Entry, hook bool) {
 	if CurrentFile == nil {
 		SaveFileAs(window, editor) // no file is open, so use SaveFileAs instead
 		return
 	}
+	// auto-save doesn't run the hook, it would change the text while it's being typed
+	if hook && BeforeSave != nil {
+		if err := BeforeSave(CurrentFile.Path()); err != nil {
+			dialog.ShowError(fmt.Errorf("not saved: %w", err), window)
+			return
+		}
+	}
+	// nor does it write what Save would refuse to, but it waits quietly for the next try
+	if !hook && BeforeAutoSave != nil {
+		if err := BeforeAutoSave(CurrentFile.Path()); err != nil {
+			return
+		}
+	}
 	// open the current file for writing
 	writer, err := storage.Writer(CurrentFile)
 	if err != nil {
@@ -60,7 +118,7 @@ func SaveFile(window fyne.Window, editor *widget.Entry) {
 	}
 	defer writer.Close()
 	// write the content
-	_, err = writer.Write([]byte(editor.Text))
+	_, err = writer.Write([]byte(editorText(editor)))
 	if err != nil {
 		dialog.ShowError(err, window)
 		return
@@ -79,12 +137,16 @@ func SaveFileAs(window fyne.Window, editor *widget.Entry) {
 		}
 		defer writer.Close()
 
-		_, err = writer.Write([]byte(editor.Text)) // converts to bytes and writes to file
+		_, err = writer.Write([]byte(editorText(editor))) // converts to bytes and writes to file
 		if err != nil {
 			dialog.ShowError(err, window)
 			return
 		}
+		previous := CurrentFile
 		CurrentFile = writer.URI() // change current file to new location
+		if OnSavedAs != nil {
+			OnSavedAs(previous, CurrentFile)
+		}
 	}, window)
 }
 
@@ -125,18 +187,21 @@ func StartAutoSave(window fyne.Window, editor *widget.Entry) {
 	var scheduleAutoSave func()
 	scheduleAutoSave = func() {
 		autoSaveTimer = time.AfterFunc(autoSaveDelay, func() {
-			// only save if you have a current file and auto-save enabled
-			if CurrentFile != nil {
-				SaveFile(window, editor)
-				fmt.Println("saved!")
-			} else {
-				StopAutoSave() // Stop auto-save if there's no file
-			}
-			// schedule next save
-			if autoSaveEnabled {
-				scheduleAutoSave()
-				fmt.Println("auto-saving in: ", autoSaveDelay)
-			}
+			// saving reads the editor and the UI's state, so it's done on the UI goroutine
+			fyne.Do(func() {
+				// only save if you have a current file and auto-save enabled
+				if CurrentFile != nil {
+					saveFile(window, editor, false)
+					fmt.Println("saved!")
+				} else {
+					StopAutoSave() // Stop auto-save if there's no file
+				}
+				// schedule next save
+				if autoSaveEnabled {
+					scheduleAutoSave()
+					fmt.Println("auto-saving in: ", autoSaveDelay)
+				}
+			})
 		})
 	}
 	scheduleAutoSave()
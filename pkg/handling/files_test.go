@@ -0,0 +1,45 @@
+package handling
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/widget"
+)
+
+func TestAutoSaveSkipsWhatSaveWouldRefuse(t *testing.T) {
+	app := test.NewTempApp(t)
+	window := app.NewWindow("")
+	path := filepath.Join(t.TempDir(), "main.go")
+	if err := os.WriteFile(path, []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	file, hook := CurrentFile, BeforeAutoSave
+	t.Cleanup(func() { CurrentFile, BeforeAutoSave = file, hook })
+	CurrentFile = storage.NewFileURI(path)
+	editor := widget.NewMultiLineEntry()
+	editor.SetText("package main\nfunc {\n")
+
+	saved := func() string {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	BeforeAutoSave = func(string) error { return errors.New("syntax error") }
+	saveFile(window, editor, false)
+	if got := saved(); got != "package main\n" {
+		t.Errorf("auto-save wrote %q although the check failed", got)
+	}
+
+	BeforeAutoSave = func(string) error { return nil }
+	saveFile(window, editor, false)
+	if got := saved(); got != editor.Text {
+		t.Errorf("auto-save wrote %q, want %q", got, editor.Text)
+	}
+}
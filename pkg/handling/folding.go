@@ -0,0 +1,281 @@
+package handling
+
+import (
+	"encoding/json"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2"
+)
+
+// foldsKey is the preference the folded lines of each file are stored under.
+const foldsKey = "folds"
+
+// FoldRange is a region of lines that can be folded away under its first line.
+type FoldRange struct {
+	// Start is the line that stays visible, zero based.
+	Start int
+	// End is the last line hidden by the fold.
+	End int
+	// Level is how deeply the region is nested, starting from 1.
+	Level int
+}
+
+// FoldRanges finds the regions of text that can be folded: sections under headings in markdown,
+// and for other files blocks between brackets and lines indented further than the one before them.
+// Unsaved files are taken to be markdown. Ranges are ordered by their start, outer ones first.
+func FoldRanges(path, text string) []FoldRange {
+	lines := strings.Split(text, "\n")
+	ends := map[int]int{}
+	if path == "" || IsMarkdownFile(path) {
+		headingFolds(lines, ends)
+	} else {
+		bracketFolds(lines, codeSpans(text, CommentSyntaxFor(path)), ends)
+		indentFolds(lines, ends)
+	}
+
+	ranges := make([]FoldRange, 0, len(ends))
+	for start, end := range ends {
+		ranges = append(ranges, FoldRange{Start: start, End: end})
+	}
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].Start < ranges[j].Start })
+	var open []int
+	for i := range ranges {
+		for len(open) > 0 && open[len(open)-1] < ranges[i].Start {
+			open = open[:len(open)-1]
+		}
+		ranges[i].Level = len(open) + 1
+		open = append(open, ranges[i].End)
+	}
+	return ranges
+}
+
+// addFold records a region, keeping the larger one when two start on the same line.
+func addFold(ends map[int]int, start, end int) {
+	if end > start && end > ends[start] {
+		ends[start] = end
+	}
+}
+
+// headingPattern matches a markdown heading, capturing its level.
+var headingPattern = regexp.MustCompile(`^ {0,3}(#{1,6})(\s|$)`)
+
+// headingFolds adds a region for each heading, running until the next heading of the same or a higher level.
+// Blank lines before the next heading stay visible.
+func headingFolds(lines []string, ends map[int]int) {
+	type heading struct{ row, level int }
+	var open []heading
+	lastText := -1
+	fence := ""
+	closeTo := func(level int) {
+		for len(open) > 0 && open[len(open)-1].level >= level {
+			addFold(ends, open[len(open)-1].row, lastText)
+			open = open[:len(open)-1]
+		}
+	}
+	for row, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+		} else if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+		} else if match := headingPattern.FindStringSubmatch(line); match != nil {
+			level := len(match[1])
+			closeTo(level)
+			open = append(open, heading{row, level})
+		}
+		if trimmed != "" {
+			lastText = row
+		}
+	}
+	closeTo(1)
+}
+
+// bracketFolds adds a region for each pair of brackets on different lines, leaving out brackets in the comments and strings spans.
+// A line starting with the closing bracket stays visible, so a folded block reads "{ ⋯ }".
+func bracketFolds(lines []string, spans []codeSpan, ends map[int]int) {
+	type opening struct {
+		bracket rune
+		row     int
+	}
+	var stack []opening
+	offset := 0
+	for row, line := range lines {
+		for i, r := range line {
+			for len(spans) > 0 && spans[0].outer[1] <= offset+i {
+				spans = spans[1:]
+			}
+			if len(spans) > 0 && spans[0].outer[0] <= offset+i {
+				continue
+			}
+			if IsOpenBracket(r) {
+				stack = append(stack, opening{r, row})
+				continue
+			}
+			if !IsCloseBracket(r) || len(stack) == 0 || brackets[r] != stack[len(stack)-1].bracket {
+				continue
+			}
+			start := stack[len(stack)-1].row
+			stack = stack[:len(stack)-1]
+			end := row
+			if strings.HasPrefix(strings.TrimSpace(line), string(r)) {
+				end--
+			}
+			addFold(ends, start, end)
+		}
+		offset += len(line) + 1
+	}
+}
+
+// indentFolds adds a region for each line followed by lines indented further, blank lines between them included.
+func indentFolds(lines []string, ends map[int]int) {
+	indents := make([]int, len(lines))
+	for row, line := range lines {
+		indents[row] = -1
+		if strings.TrimSpace(line) != "" {
+			indents[row] = indentWidth(line)
+		}
+	}
+	for row := range lines {
+		if indents[row] < 0 {
+			continue
+		}
+		end := row
+		for next := row + 1; next < len(lines); next++ {
+			if indents[next] < 0 {
+				continue
+			}
+			if indents[next] <= indents[row] {
+				break
+			}
+			end = next
+		}
+		addFold(ends, row, end)
+	}
+}
+
+// indentWidth returns how many columns a line's indentation takes, with tabs as four.
+func indentWidth(line string) int {
+	width := 0
+	for _, r := range line {
+		switch r {
+		case ' ':
+			width++
+		case '\t':
+			width += 4 - width%4
+		default:
+			return width
+		}
+	}
+	return width
+}
+
+// FoldText returns text with the lines hidden by folded regions left out, and the line of text each line left is.
+func FoldText(text string, folded []FoldRange) (shown string, rows []int) {
+	lines := strings.Split(text, "\n")
+	hidden := make([]bool, len(lines))
+	for _, f := range folded {
+		for row := f.Start + 1; row <= min(f.End, len(lines)-1); row++ {
+			hidden[row] = true
+		}
+	}
+	visible := make([]string, 0, len(lines))
+	for row, line := range lines {
+		if !hidden[row] {
+			visible = append(visible, line)
+			rows = append(rows, row)
+		}
+	}
+	return strings.Join(visible, "\n"), rows
+}
+
+// AdjustFolds moves folded regions of text along with a change made to it. A region the change reaches into
+// is dropped, unless the change is within the line that stays visible and doesn't add or remove lines.
+func AdjustFolds(folded []FoldRange, text string, c TextChange) []FoldRange {
+	runes := []rune(text)
+	// lineStarts holds the position each line starts at, and one past the end of the text.
+	lineStarts := []int{0}
+	for i, r := range runes {
+		if r == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+	lineStarts = append(lineStarts, len(runes)+1)
+	lineEnd := func(row int) int {
+		return lineStarts[min(row+1, len(lineStarts)-1)] - 1
+	}
+	start, end := c.Offset, c.Offset+len([]rune(c.Removed))
+	lines := strings.Count(c.Inserted, "\n") - strings.Count(c.Removed, "\n")
+
+	var adjusted []FoldRange
+	for _, f := range folded {
+		switch {
+		case f.Start >= len(lineStarts)-1:
+		case end <= lineStarts[f.Start]:
+			f.Start += lines
+			f.End += lines
+			adjusted = append(adjusted, f)
+		case start > lineEnd(f.End):
+			adjusted = append(adjusted, f)
+		case start >= lineStarts[f.Start] && end <= lineEnd(f.Start) && !strings.Contains(c.Inserted, "\n"):
+			adjusted = append(adjusted, f)
+		}
+	}
+	return adjusted
+}
+
+// Folds holds the folded lines of each file, so they're folded again when the file is opened.
+type Folds struct {
+	lines map[string][]int
+	prefs fyne.Preferences
+}
+
+// LoadFolds reads the saved folds, prefs may be nil to keep them in memory only.
+func LoadFolds(prefs fyne.Preferences) *Folds {
+	f := &Folds{lines: map[string][]int{}, prefs: prefs}
+	if prefs == nil {
+		return f
+	}
+	if saved := prefs.String(foldsKey); saved != "" {
+		if err := json.Unmarshal([]byte(saved), &f.lines); err != nil {
+			fyne.LogError("Failed to read folds", err)
+		}
+	}
+	return f
+}
+
+// Lines returns the first lines of a file's folded regions in ascending order.
+func (f *Folds) Lines(path string) []int {
+	return f.lines[path]
+}
+
+// Set replaces the folded lines of a file and saves them, files without a path aren't remembered.
+func (f *Folds) Set(path string, lines []int) {
+	if path == "" {
+		return
+	}
+	if len(lines) == 0 {
+		if _, ok := f.lines[path]; !ok {
+			return
+		}
+		delete(f.lines, path)
+	} else {
+		if slices.Equal(f.lines[path], lines) {
+			return
+		}
+		f.lines[path] = lines
+	}
+	if f.prefs == nil {
+		return
+	}
+	data, err := json.Marshal(f.lines)
+	if err != nil {
+		fyne.LogError("Failed to save folds", err)
+		return
+	}
+	f.prefs.SetString(foldsKey, string(data))
+}
@@ -0,0 +1,122 @@
+package handling
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFoldRanges(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		text string
+		want []FoldRange
+	}{
+		{
+			name: "brackets",
+			path: "f.go",
+			text: "func f() {\n\treturn\n}",
+			want: []FoldRange{{Start: 0, End: 1, Level: 1}},
+		},
+		{
+			name: "bracket in a string",
+			path: "f.go",
+			text: "func f() {\n\ts := \"{\"\n\treturn\n}",
+			want: []FoldRange{{Start: 0, End: 2, Level: 1}},
+		},
+		{
+			name: "bracket in a rune",
+			path: "f.go",
+			text: "func f() {\n\tr := '('\n\treturn\n}",
+			want: []FoldRange{{Start: 0, End: 2, Level: 1}},
+		},
+		{
+			name: "brackets in comments",
+			path: "f.go",
+			text: "func f() { // {\n\t/* [\n\t*/\n\treturn\n}",
+			want: []FoldRange{{Start: 0, End: 3, Level: 1}},
+		},
+		{
+			name: "bracket in a python comment",
+			path: "f.py",
+			text: "x = [\n    1,  # ]\n    2,\n]",
+			want: []FoldRange{{Start: 0, End: 2, Level: 1}},
+		},
+		{
+			name: "apostrophe isn't a string",
+			path: "f.rs",
+			text: "fn f(x: &'a str) {\n    x\n}",
+			want: []FoldRange{{Start: 0, End: 1, Level: 1}},
+		},
+		{
+			name: "indentation",
+			path: "f.py",
+			text: "def f():\n    if x:\n        y\n\n    z",
+			want: []FoldRange{{Start: 0, End: 4, Level: 1}, {Start: 1, End: 2, Level: 2}},
+		},
+		{
+			name: "headings",
+			path: "f.md",
+			text: "# A\ntext\n## B\nmore\n\n# C\nlast",
+			want: []FoldRange{{Start: 0, End: 3, Level: 1}, {Start: 2, End: 3, Level: 2}, {Start: 5, End: 6, Level: 1}},
+		},
+		{
+			name: "heading in a code block",
+			path: "f.md",
+			text: "# A\n```\n# not a heading\n```\ntext",
+			want: []FoldRange{{Start: 0, End: 4, Level: 1}},
+		},
+		{
+			name: "nothing to fold",
+			path: "f.go",
+			text: "x := 1",
+			want: []FoldRange{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FoldRanges(tt.path, tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FoldRanges(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFoldText(t *testing.T) {
+	text := "a {\n\tb {\n\t\tc\n\t}\n}\nd"
+	shown, rows := FoldText(text, []FoldRange{{Start: 1, End: 2}, {Start: 0, End: 3}})
+	if shown != "a {\n}\nd" || !reflect.DeepEqual(rows, []int{0, 4, 5}) {
+		t.Errorf("FoldText() = %q, %v", shown, rows)
+	}
+	shown, rows = FoldText(text, nil)
+	if shown != text || !reflect.DeepEqual(rows, []int{0, 1, 2, 3, 4, 5}) {
+		t.Errorf("FoldText() without folds = %q, %v", shown, rows)
+	}
+}
+
+func TestAdjustFolds(t *testing.T) {
+	// The region under "b {" is folded, lines 2 and 3.
+	text := "a\nb {\n\tc\n\td\n}\ne"
+	folded := []FoldRange{{Start: 1, End: 3, Level: 1}}
+	tests := []struct {
+		name   string
+		change TextChange
+		want   []FoldRange
+	}{
+		{"line added above", TextChange{Offset: 0, Inserted: "z\n"}, []FoldRange{{Start: 2, End: 4, Level: 1}}},
+		{"line removed above", TextChange{Offset: 0, Removed: "a\n"}, []FoldRange{{Start: 0, End: 2, Level: 1}}},
+		{"typed on the first line", TextChange{Offset: 3, Inserted: "x"}, folded},
+		{"first line split", TextChange{Offset: 3, Inserted: "\n"}, nil},
+		{"hidden line changed", TextChange{Offset: 7, Inserted: "x"}, nil},
+		{"line below joined to the region", TextChange{Offset: 11, Removed: "\n"}, nil},
+		{"typed below", TextChange{Offset: 13, Inserted: "x"}, folded},
+		{"line added below", TextChange{Offset: 15, Inserted: "\nf"}, folded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AdjustFolds(folded, text, tt.change); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("AdjustFolds() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package handling
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"go/format"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// formatIndent is one level of indentation in formatted JSON and XML.
+const formatIndent = "    "
+
+// formatTimeout is how long an external formatter may run.
+const formatTimeout = 10 * time.Second
+
+// ErrNoFormatter is returned when there is no formatter for a kind of file.
+var ErrNoFormatter = errors.New("no formatter for this kind of file")
+
+// builtinFormatters format source by file extension without running anything.
+var builtinFormatters = map[string]func(source string) (string, error){
+	".go":   formatGo,
+	".json": formatJSON,
+	".xml":  formatXML,
+	".svg":  formatXML,
+}
+
+// CanFormat reports whether there is a formatter for a file, overrides are commands keyed by extension from config.json.
+func CanFormat(path string, overrides map[string][]string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	if command, ok := overrides[ext]; ok {
+		return len(command) > 0
+	}
+	_, ok := builtinFormatters[ext]
+	return ok
+}
+
+// Format formats source as the kind of file at path. A command from config.json, which reads the source
+// on its standard input and writes the result to its output, takes priority over the built-in formatters.
+// "${file}" in its arguments is replaced by the path. An empty command turns formatting off.
+func Format(path, source string, overrides map[string][]string) (string, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if command, ok := overrides[ext]; ok {
+		if len(command) == 0 {
+			return "", ErrNoFormatter
+		}
+		return formatWithCommand(command, path, source)
+	}
+	if formatter, ok := builtinFormatters[ext]; ok {
+		return formatter(source)
+	}
+	return "", ErrNoFormatter
+}
+
+// formatWithCommand pipes source through an external formatter.
+func formatWithCommand(command []string, path, source string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), formatTimeout)
+	defer cancel()
+	args := make([]string, len(command)-1)
+	for i, arg := range command[1:] {
+		args[i] = strings.ReplaceAll(arg, "${file}", path)
+	}
+	cmd := exec.CommandContext(ctx, command[0], args...)
+	cmd.Dir = filepath.Dir(path)
+	cmd.Stdin = strings.NewReader(source)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("%s: %s", command[0], message)
+		}
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("%s took too long", command[0])
+		}
+		return "", fmt.Errorf("%s: %w", command[0], err)
+	}
+	return stdout.String(), nil
+}
+
+// formatGo formats Go source like gofmt, it works on parts of files too.
+func formatGo(source string) (string, error) {
+	formatted, err := format.Source([]byte(source))
+	return string(formatted), err
+}
+
+// formatJSON indents JSON, keeping the order of keys and how numbers are written.
+func formatJSON(source string) (string, error) {
+	var out bytes.Buffer
+	if err := json.Indent(&out, []byte(source), "", formatIndent); err != nil {
+		return "", err
+	}
+	out.WriteByte('\n')
+	return out.String(), nil
+}
+
+// xmlToken is a token of an XML document with the text it was read from.
+type xmlToken struct {
+	token xml.Token
+	raw   string
+}
+
+// formatXML puts each element on its own line, indented by depth. Only the whitespace between tags changes,
+// everything else is written as it was in the source. Elements holding text, whether only text or text
+// mixed with elements, stay as they are, as do those with xml:space="preserve". Empty ones are closed with "/>".
+func formatXML(source string) (string, error) {
+	// Check the document first, the tokens used for writing it don't match up start and end tags.
+	check := xml.NewDecoder(strings.NewReader(source))
+	for {
+		if _, err := check.Token(); err == io.EOF {
+			break
+		} else if err != nil {
+			return "", err
+		}
+	}
+
+	var tokens []xmlToken
+	d := xml.NewDecoder(strings.NewReader(source))
+	for {
+		start := d.InputOffset()
+		token, err := d.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		tokens = append(tokens, xmlToken{xml.CopyToken(token), source[start:d.InputOffset()]})
+	}
+
+	// Find the end of each element and whether it holds text, CDATA sections count as text even if they're blank.
+	ends := map[int]int{}
+	text := map[int]bool{}
+	var open []int
+	for i, t := range tokens {
+		switch token := t.token.(type) {
+		case xml.StartElement:
+			open = append(open, i)
+		case xml.EndElement:
+			if len(open) > 0 {
+				ends[open[len(open)-1]] = i
+				open = open[:len(open)-1]
+			}
+		case xml.CharData:
+			if len(open) > 0 && (len(bytes.TrimSpace(token)) > 0 || strings.HasPrefix(t.raw, "<![CDATA[")) {
+				text[open[len(open)-1]] = true
+			}
+		}
+	}
+
+	var out strings.Builder
+	depth := 0
+	indent := func() { out.WriteString(strings.Repeat(formatIndent, depth)) }
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		switch token := t.token.(type) {
+		case xml.CharData:
+			// Whitespace between tags is replaced by the indentation.
+			if len(bytes.TrimSpace(token)) == 0 && !strings.HasPrefix(t.raw, "<![CDATA[") {
+				continue
+			}
+			indent()
+			out.WriteString(strings.TrimSpace(t.raw) + "\n")
+		case xml.StartElement:
+			end, closed := ends[i]
+			indent()
+			switch {
+			case !closed:
+				out.WriteString(t.raw + "\n")
+				depth++
+			case strings.HasSuffix(t.raw, "/>"):
+				// An empty element tag is followed by an end element that isn't in the source.
+				out.WriteString(t.raw + "\n")
+				i = end
+			case end == i+1 || end == i+2 && !text[i] && isXMLSpace(tokens[i+1].token):
+				out.WriteString(strings.TrimSuffix(t.raw, ">") + "/>\n")
+				i = end
+			case text[i] || preservesSpace(token):
+				for _, inner := range tokens[i : end+1] {
+					out.WriteString(inner.raw)
+				}
+				out.WriteString("\n")
+				i = end
+			default:
+				out.WriteString(t.raw + "\n")
+				depth++
+			}
+		case xml.EndElement:
+			depth--
+			indent()
+			out.WriteString(t.raw + "\n")
+		default:
+			// Comments, processing instructions and directives.
+			indent()
+			out.WriteString(t.raw + "\n")
+		}
+	}
+	return out.String(), nil
+}
+
+// isXMLSpace reports whether a token is whitespace between tags.
+func isXMLSpace(token xml.Token) bool {
+	text, ok := token.(xml.CharData)
+	return ok && len(bytes.TrimSpace(text)) == 0
+}
+
+// preservesSpace reports whether an element asks for its whitespace to be kept with xml:space="preserve".
+func preservesSpace(element xml.StartElement) bool {
+	for _, attr := range element.Attr {
+		if attr.Name.Space == "xml" && attr.Name.Local == "space" {
+			return attr.Value == "preserve"
+		}
+	}
+	return false
+}
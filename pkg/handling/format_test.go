@@ -0,0 +1,128 @@
+package handling
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFormatXML(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{
+			name:   "nested elements",
+			source: `<?xml version="1.0"?><a><b><c/></b></a>`,
+			want:   "<?xml version=\"1.0\"?>\n<a>\n    <b>\n        <c/>\n    </b>\n</a>\n",
+		},
+		{
+			name:   "text stays on its line",
+			source: "<a>\n  <b>text</b>\n</a>",
+			want:   "<a>\n    <b>text</b>\n</a>\n",
+		},
+		{
+			name:   "empty elements",
+			source: "<a><b></b><c> </c><d x=\"1\"/></a>",
+			want:   "<a>\n    <b/>\n    <c/>\n    <d x=\"1\"/>\n</a>\n",
+		},
+		{
+			name:   "multi-line text",
+			source: "<a><b>line one\n  line two</b></a>",
+			want:   "<a>\n    <b>line one\n  line two</b>\n</a>\n",
+		},
+		{
+			name:   "CDATA",
+			source: "<a><b><![CDATA[x < y]]></b><c><![CDATA[ ]]></c></a>",
+			want:   "<a>\n    <b><![CDATA[x < y]]></b>\n    <c><![CDATA[ ]]></c>\n</a>\n",
+		},
+		{
+			name:   "mixed content",
+			source: "<div><p>hello <b>w</b> there</p></div>",
+			want:   "<div>\n    <p>hello <b>w</b> there</p>\n</div>\n",
+		},
+		{
+			name:   "escapes are kept as written",
+			source: "<a t='it&apos;s'><b>it's &amp; &#39;that&#39;</b></a>",
+			want:   "<a t='it&apos;s'>\n    <b>it's &amp; &#39;that&#39;</b>\n</a>\n",
+		},
+		{
+			name:   "preserved whitespace",
+			source: "<a><pre xml:space=\"preserve\">\n  <b/>\n</pre></a>",
+			want:   "<a>\n    <pre xml:space=\"preserve\">\n  <b/>\n</pre>\n</a>\n",
+		},
+		{
+			name:   "comments and namespaces",
+			source: "<svg:svg xmlns:svg=\"http://www.w3.org/2000/svg\"><!-- icon --><svg:g/></svg:svg>",
+			want:   "<svg:svg xmlns:svg=\"http://www.w3.org/2000/svg\">\n    <!-- icon -->\n    <svg:g/>\n</svg:svg>\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := formatXML(tt.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("formatXML(%q) =\n%s\nwant\n%s", tt.source, got, tt.want)
+			}
+			// Formatting formatted XML changes nothing.
+			if again, err := formatXML(got); err != nil || again != got {
+				t.Errorf("formatting again = %q, %v", again, err)
+			}
+		})
+	}
+}
+
+func TestFormatXMLErrors(t *testing.T) {
+	for _, source := range []string{"<a><b></a>", "<a>", "<a x=1/>"} {
+		if _, err := formatXML(source); err == nil {
+			t.Errorf("formatXML(%q) succeeded", source)
+		}
+	}
+}
+
+func TestFormatJSON(t *testing.T) {
+	tests := []struct {
+		source, want string
+	}{
+		{`{"b":1,"a":[1.50,2]}`, "{\n    \"b\": 1,\n    \"a\": [\n        1.50,\n        2\n    ]\n}\n"},
+		{`[]`, "[]\n"},
+	}
+	for _, tt := range tests {
+		got, err := formatJSON(tt.source)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("formatJSON(%q) = %q, want %q", tt.source, got, tt.want)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	overrides := map[string][]string{".json": nil, ".txt": {"cat"}}
+	tests := []struct {
+		name, path, source, want string
+		err                      error
+	}{
+		{"go", "main.go", "package main\nfunc  main( ) {}\n", "package main\n\nfunc main() {}\n", nil},
+		{"turned off", "a.json", "{}", "", ErrNoFormatter},
+		{"unknown", "a.unknown", "x", "", ErrNoFormatter},
+		{"command", "a.txt", "as is", "as is", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Format(tt.path, tt.source, overrides)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Format error = %v, want %v", err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("Format(%q) = %q, want %q", tt.source, got, tt.want)
+			}
+			if canFormat := CanFormat(tt.path, overrides); canFormat != (tt.err == nil) {
+				t.Errorf("CanFormat(%q) = %v", tt.path, canFormat)
+			}
+		})
+	}
+}
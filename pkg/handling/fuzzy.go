@@ -0,0 +1,160 @@
+package handling
+
+import (
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// maxIndexedFiles stops indexing runaway workspaces.
+const maxIndexedFiles = 50000
+
+// RecordRecent moves path to the front of the recently opened files.
+func RecordRecent(path string) {
+	RecentFiles.Add(path)
+}
+
+// recencyBonus favours files that were opened recently.
+func recencyBonus(path string) int {
+	if i := RecentFiles.index(path); i >= 0 {
+		return max(0, 50-i*5)
+	}
+	return 0
+}
+
+// IndexWorkspace lists the files below root, skipping ignored ones, as paths relative to root.
+// It only reads what it's passed, so it can run in the background.
+func IndexWorkspace(root string, ignores Ignores) []string {
+	var files []string
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if path != root && ignores.Match(root, path) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if rel, err := filepath.Rel(root, path); err == nil {
+			files = append(files, rel)
+		}
+		if len(files) >= maxIndexedFiles {
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return files
+}
+
+// FuzzyScore matches the characters of pattern in order within candidate.
+// Matches at the start of path segments or words, consecutive runs and
+// matches in the file name score higher. ok is false if there is no match.
+func FuzzyScore(pattern, candidate string) (score int, ok bool) {
+	if pattern == "" {
+		return 0, true
+	}
+	p := []rune(strings.ToLower(pattern))
+	c := []rune(candidate)
+	nameStart := len([]rune(candidate[:strings.LastIndexAny(candidate, `/\`)+1]))
+
+	// Matching greedily from the first occurrence misses better matches later
+	// on, such as in the file name, so try every starting point.
+	best := -1
+	for start := range c {
+		if unicode.ToLower(c[start]) != p[0] {
+			continue
+		}
+		if s, ok := fuzzyScoreFrom(p, c, start, nameStart); ok && s > best {
+			best = s
+		}
+	}
+	if best < 0 {
+		return 0, false
+	}
+	// Prefer shorter candidates when everything else is equal.
+	return best*10 - len(c)/4, true
+}
+
+// fuzzyScoreFrom greedily matches p in c starting at index start.
+func fuzzyScoreFrom(p, c []rune, start, nameStart int) (score int, ok bool) {
+	pi := 0
+	last := -1
+	for ci := start; ci < len(c) && pi < len(p); ci++ {
+		if unicode.ToLower(c[ci]) != p[pi] {
+			continue
+		}
+		points := 1
+		if ci == 0 || strings.ContainsRune(`/\_-. `, c[ci-1]) {
+			points += 8
+		} else if unicode.IsUpper(c[ci]) && unicode.IsLower(c[ci-1]) {
+			points += 6
+		}
+		if last == ci-1 {
+			points += 5
+		} else if last >= 0 {
+			points -= min(ci-last-1, 3)
+		}
+		if ci >= nameStart {
+			points += 3
+		}
+		score += points
+		last = ci
+		pi++
+	}
+	return score, pi == len(p)
+}
+
+// FuzzyMatch ranks candidates by fuzzy score plus recency, best first. root is used to look up recency.
+func FuzzyMatch(pattern, root string, candidates []string, limit int) []string {
+	type scored struct {
+		path  string
+		score int
+	}
+	var matches []scored
+	for _, candidate := range candidates {
+		score, ok := FuzzyScore(pattern, candidate)
+		if !ok {
+			continue
+		}
+		score += recencyBonus(filepath.Join(root, candidate)) * 10
+		matches = append(matches, scored{candidate, score})
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	result := make([]string, len(matches))
+	for i, m := range matches {
+		result[i] = m.path
+	}
+	return result
+}
+
+// ParseLineSuffix splits "file:line" or "file:line:column" into its parts, line and column are 0 when absent.
+func ParseLineSuffix(query string) (path string, line, column int) {
+	path = query
+	parts := strings.Split(query, ":")
+	if len(parts) >= 3 {
+		l, errL := strconv.Atoi(parts[len(parts)-2])
+		c, errC := strconv.Atoi(parts[len(parts)-1])
+		if errL == nil && errC == nil {
+			return strings.Join(parts[:len(parts)-2], ":"), l, c
+		}
+	}
+	if len(parts) >= 2 {
+		if l, err := strconv.Atoi(parts[len(parts)-1]); err == nil {
+			return strings.Join(parts[:len(parts)-1], ":"), l, 0
+		}
+	}
+	return path, 0, 0
+}
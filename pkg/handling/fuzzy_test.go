@@ -0,0 +1,114 @@
+package handling
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestFuzzyScore(t *testing.T) {
+	tests := []struct {
+		pattern, candidate string
+		ok                 bool
+	}{
+		{"", "anything", true},
+		{"mgo", "main.go", true},
+		{"MGO", "main.go", true},
+		{"gom", "main.go", false},
+		{"uig", "pkg/ui/ui.go", true},
+		{"xyz", "pkg/ui/ui.go", false},
+	}
+	for _, tt := range tests {
+		if _, ok := FuzzyScore(tt.pattern, tt.candidate); ok != tt.ok {
+			t.Errorf("FuzzyScore(%q, %q) matched = %v, want %v", tt.pattern, tt.candidate, ok, tt.ok)
+		}
+	}
+}
+
+func TestFuzzyScoreRanking(t *testing.T) {
+	tests := []struct {
+		pattern, better, worse string
+	}{
+		// Matches in the file name beat matches in the folders.
+		{"ui", "pkg/handling/ui.go", "pkg/ui/handler.go"},
+		// Consecutive runs beat scattered letters.
+		{"main", "main.go", "my_anim.go"},
+		// Matches at the start of words beat ones within them.
+		{"fb", "foo_bar.go", "fabric.go"},
+		// camelCase humps count as the start of words.
+		{"fb", "fooBar.go", "fabric.go"},
+		// Shorter candidates win otherwise.
+		{"a", "a.go", "a_long_name.go"},
+	}
+	for _, tt := range tests {
+		better, _ := FuzzyScore(tt.pattern, tt.better)
+		worse, _ := FuzzyScore(tt.pattern, tt.worse)
+		if better <= worse {
+			t.Errorf("FuzzyScore(%q): %q scored %d, not more than %q with %d", tt.pattern, tt.better, better, tt.worse, worse)
+		}
+	}
+}
+
+func TestFuzzyMatch(t *testing.T) {
+	recent := RecentFiles
+	RecentFiles = &RecentList{}
+	t.Cleanup(func() { RecentFiles = recent })
+
+	candidates := []string{"docs/readme.md", "pkg/ui/ui.go", "pkg/handling/files.go", "README.md"}
+	if got, want := FuzzyMatch("readme", "/root", candidates, 10), []string{"README.md", "docs/readme.md"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FuzzyMatch = %q, want %q", got, want)
+	}
+	// Recently opened files come first.
+	RecordRecent(filepath.Join("/root", "docs/readme.md"))
+	if got, want := FuzzyMatch("readme", "/root", candidates, 10), []string{"docs/readme.md", "README.md"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FuzzyMatch after opening docs/readme.md = %q, want %q", got, want)
+	}
+	if got := FuzzyMatch("", "/root", candidates, 2); len(got) != 2 {
+		t.Errorf("FuzzyMatch with a limit of 2 = %q", got)
+	}
+}
+
+func TestIndexWorkspace(t *testing.T) {
+	root := t.TempDir()
+	for _, path := range []string{"main.go", "pkg/ui/ui.go", ".git/config", "node_modules/x/index.js", "debug.log", ".gitignore"} {
+		path = filepath.Join(root, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(root, ".gitignore"), []byte("*.log\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// The workspace open in the editor doesn't matter, only the ignores passed in.
+	files := IndexWorkspace(root, LoadIgnores(root))
+	sort.Strings(files)
+	if want := []string{".gitignore", "main.go", filepath.FromSlash("pkg/ui/ui.go")}; !reflect.DeepEqual(files, want) {
+		t.Errorf("IndexWorkspace = %q, want %q", files, want)
+	}
+}
+
+func TestParseLineSuffix(t *testing.T) {
+	tests := []struct {
+		query        string
+		path         string
+		line, column int
+	}{
+		{"main.go", "main.go", 0, 0},
+		{"main.go:12", "main.go", 12, 0},
+		{"main.go:12:4", "main.go", 12, 4},
+		{"main.go:", "main.go:", 0, 0},
+		{`C:\src\main.go:3`, `C:\src\main.go`, 3, 0},
+		{"a:b", "a:b", 0, 0},
+	}
+	for _, tt := range tests {
+		path, line, column := ParseLineSuffix(tt.query)
+		if path != tt.path || line != tt.line || column != tt.column {
+			t.Errorf("ParseLineSuffix(%q) = %q, %d, %d, want %q, %d, %d", tt.query, path, line, column, tt.path, tt.line, tt.column)
+		}
+	}
+}
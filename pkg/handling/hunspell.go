@@ -0,0 +1,397 @@
+package handling
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// Dictionary is a Hunspell dictionary with every word expanded with its prefixes and suffixes.
+type Dictionary struct {
+	// words are the spellings the dictionary knows.
+	words map[string]bool
+	// noSuggest are known words never suggested, such as rude ones.
+	noSuggest map[string]bool
+	// try are the letters suggestions try in place of others, most common first.
+	try []rune
+	// replacements are common misspellings and what they should be, such as "f" for "ph".
+	replacements [][2]string
+}
+
+// affixRule is one rule of a prefix or suffix class, what it takes off a word and adds to it.
+type affixRule struct {
+	strip, add string
+	// next are the classes that can be added after this rule, Hunspell's continuation classes.
+	next      []string
+	condition []conditionPart
+}
+
+// affixClass is the prefix or suffix rules a dictionary word's flag lets it take.
+type affixClass struct {
+	prefix bool
+	// cross lets a prefix and a suffix be added to a word together.
+	cross bool
+	rules []affixRule
+}
+
+// conditionPart matches one letter of a rule's condition: any letter, one of chars or, with not, none of them.
+type conditionPart struct {
+	chars string
+	not   bool
+	any   bool
+}
+
+// affixFile holds what the words of a .dic file need from its .aff file.
+type affixFile struct {
+	flagMode string
+	classes  map[string]*affixClass
+	// needAffix, onlyInCompound, forbidden and noSuggest are the flags of words that aren't words by themselves,
+	// that are only parts of compounds, that are misspellings and that are never suggested.
+	needAffix, onlyInCompound, forbidden, noSuggest string
+	try                                             []rune
+	replacements                                    [][2]string
+}
+
+// DictionariesDir returns the folder Hunspell dictionaries are read from,
+// the one set in config.json or the dictionaries folder next to it.
+func DictionariesDir(config *Config) string {
+	if config != nil && config.Spelling.Dictionaries != "" {
+		return config.Spelling.Dictionaries
+	}
+	return filepath.Join(ConfigDir(), "dictionaries")
+}
+
+// DictionaryLanguages lists the dictionaries in a folder by name, such as "en_US", those with both a .dic and an .aff file.
+func DictionaryLanguages(dir string) []string {
+	matches, _ := filepath.Glob(filepath.Join(dir, "*.dic"))
+	var languages []string
+	for _, dic := range matches {
+		language := strings.TrimSuffix(filepath.Base(dic), ".dic")
+		if _, err := os.Stat(filepath.Join(dir, language+".aff")); err == nil {
+			languages = append(languages, language)
+		}
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// LoadDictionary reads a language's dictionary from its .dic and .aff files in a folder.
+func LoadDictionary(dir, language string) (*Dictionary, error) {
+	aff, err := readDictionaryFile(filepath.Join(dir, language+".aff"), "")
+	if err != nil {
+		return nil, err
+	}
+	affixes, encoding := parseAffixes(aff)
+	if encoding != "" {
+		// The .aff file names its encoding in itself, it's read again now that it's known.
+		if aff, err = readDictionaryFile(filepath.Join(dir, language+".aff"), encoding); err != nil {
+			return nil, err
+		}
+		affixes, _ = parseAffixes(aff)
+	}
+	dic, err := readDictionaryFile(filepath.Join(dir, language+".dic"), encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Dictionary{
+		words:        map[string]bool{},
+		noSuggest:    map[string]bool{},
+		try:          affixes.try,
+		replacements: affixes.replacements,
+	}
+	forbidden := map[string]bool{}
+	for i, line := range strings.Split(dic, "\n") {
+		line = strings.TrimSpace(line)
+		// The first line is the number of words.
+		if _, err := strconv.Atoi(line); line == "" || i == 0 && err == nil {
+			continue
+		}
+		// Anything after a tab or a space describes the word.
+		if end := strings.IndexAny(line, "\t "); end >= 0 {
+			line = line[:end]
+		}
+		word, flags := splitDictionaryWord(line)
+		if word == "" {
+			continue
+		}
+		d.expand(word, affixes.parseFlags(flags), affixes, forbidden)
+	}
+	for word := range forbidden {
+		delete(d.words, word)
+	}
+	return d, nil
+}
+
+// readDictionaryFile reads a .dic or .aff file as UTF-8, converting it from encoding if it's given.
+func readDictionaryFile(path, encoding string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	if encoding == "" || strings.EqualFold(encoding, "UTF-8") {
+		return strings.ReplaceAll(string(data), "\r", ""), nil
+	}
+	// Hunspell writes encodings such as ISO8859-1 and microsoft-cp1251.
+	name := strings.ToLower(encoding)
+	name = strings.Replace(name, "iso8859-", "iso-8859-", 1)
+	name = strings.Replace(name, "microsoft-cp", "windows-", 1)
+	enc, err := htmlindex.Get(name)
+	if err != nil {
+		return "", fmt.Errorf("%s: unsupported encoding %q", path, encoding)
+	}
+	decoded, err := enc.NewDecoder().Bytes(data)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
+	}
+	return strings.ReplaceAll(string(decoded), "\r", ""), nil
+}
+
+// parseAffixes reads the rules of an .aff file, and the encoding it says its files are in if it isn't UTF-8.
+func parseAffixes(text string) (*affixFile, string) {
+	a := &affixFile{classes: map[string]*affixClass{}}
+	encoding := ""
+	for _, line := range strings.Split(text, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		switch fields[0] {
+		case "SET":
+			if !strings.EqualFold(fields[1], "UTF-8") {
+				encoding = fields[1]
+			}
+		case "FLAG":
+			a.flagMode = fields[1]
+		case "TRY":
+			a.try = []rune(fields[1])
+		case "REP":
+			// The first REP line is the number of replacements, "_" stands for a space.
+			if len(fields) >= 3 {
+				a.replacements = append(a.replacements, [2]string{
+					strings.ReplaceAll(fields[1], "_", " "),
+					strings.ReplaceAll(fields[2], "_", " "),
+				})
+			}
+		case "NEEDAFFIX":
+			a.needAffix = fields[1]
+		case "ONLYINCOMPOUND":
+			a.onlyInCompound = fields[1]
+		case "FORBIDDENWORD":
+			a.forbidden = fields[1]
+		case "NOSUGGEST":
+			a.noSuggest = fields[1]
+		case "PFX", "SFX":
+			a.parseAffixLine(fields)
+		}
+	}
+	return a, encoding
+}
+
+// parseAffixLine reads a class's first line, "SFX flag cross count", or one of its rules, "SFX flag strip add condition".
+func (a *affixFile) parseAffixLine(fields []string) {
+	if len(fields) < 4 {
+		return
+	}
+	flag := fields[1]
+	class := a.classes[flag]
+	if _, err := strconv.Atoi(fields[3]); len(fields) == 4 && err == nil && (fields[2] == "Y" || fields[2] == "N") {
+		if class == nil {
+			class = &affixClass{}
+			a.classes[flag] = class
+		}
+		class.prefix = fields[0] == "PFX"
+		class.cross = fields[2] == "Y"
+		return
+	}
+	if class == nil {
+		return
+	}
+	rule := affixRule{strip: fields[2], condition: parseCondition(".")}
+	add, next, _ := strings.Cut(fields[3], "/")
+	rule.add = add
+	rule.next = a.parseFlags(next)
+	if rule.strip == "0" {
+		rule.strip = ""
+	}
+	if rule.add == "0" {
+		rule.add = ""
+	}
+	if len(fields) > 4 {
+		rule.condition = parseCondition(fields[4])
+	}
+	class.rules = append(class.rules, rule)
+}
+
+// parseFlags splits the flags of a word or rule the way the .aff file's FLAG says they're written.
+func (a *affixFile) parseFlags(flags string) []string {
+	if flags == "" {
+		return nil
+	}
+	var parsed []string
+	switch a.flagMode {
+	case "long":
+		runes := []rune(flags)
+		for i := 0; i+1 < len(runes); i += 2 {
+			parsed = append(parsed, string(runes[i:i+2]))
+		}
+	case "num":
+		parsed = strings.Split(flags, ",")
+	default:
+		for _, r := range flags {
+			parsed = append(parsed, string(r))
+		}
+	}
+	return parsed
+}
+
+// splitDictionaryWord splits a line of a .dic file into the word and its flags, "\/" is a slash in the word.
+func splitDictionaryWord(line string) (word, flags string) {
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '/':
+			word += "/"
+			i++
+		case line[i] == '/':
+			return word, line[i+1:]
+		default:
+			word += line[i : i+1]
+		}
+	}
+	return word, ""
+}
+
+// parseCondition reads a rule's condition, letters, "." for any letter and groups such as "[aeiou]" or "[^y]".
+func parseCondition(condition string) []conditionPart {
+	var parts []conditionPart
+	runes := []rune(condition)
+	for i := 0; i < len(runes); i++ {
+		switch runes[i] {
+		case '.':
+			parts = append(parts, conditionPart{any: true})
+		case '[':
+			end := i + 1
+			for end < len(runes) && runes[end] != ']' {
+				end++
+			}
+			group := runes[i+1 : min(end, len(runes))]
+			part := conditionPart{}
+			if len(group) > 0 && group[0] == '^' {
+				part.not = true
+				group = group[1:]
+			}
+			part.chars = string(group)
+			parts = append(parts, part)
+			i = end
+		default:
+			parts = append(parts, conditionPart{chars: string(runes[i])})
+		}
+	}
+	return parts
+}
+
+// matches reports whether the start of word, for a prefix, or its end, for a suffix, meets the rule's condition.
+func (r affixRule) matches(word string, prefix bool) bool {
+	runes := []rune(word)
+	if len(runes) < len(r.condition) {
+		return false
+	}
+	offset := 0
+	if !prefix {
+		offset = len(runes) - len(r.condition)
+	}
+	for i, part := range r.condition {
+		if part.any {
+			continue
+		}
+		if strings.ContainsRune(part.chars, runes[offset+i]) == part.not {
+			return false
+		}
+	}
+	return true
+}
+
+// apply adds the rule to word, reporting false if word doesn't meet its condition.
+func (r affixRule) apply(word string, prefix bool) (string, bool) {
+	// Stripping can't take away the whole word.
+	if len(r.strip) >= len(word) || !r.matches(word, prefix) {
+		return "", false
+	}
+	if prefix {
+		if !strings.HasPrefix(word, r.strip) {
+			return "", false
+		}
+		return r.add + word[len(r.strip):], true
+	}
+	if !strings.HasSuffix(word, r.strip) {
+		return "", false
+	}
+	return word[:len(word)-len(r.strip)] + r.add, true
+}
+
+// expand adds a word of the .dic file and every form its flags give it: with each suffix,
+// then a suffix that can follow that one, with each prefix, and with a prefix and suffix together.
+func (d *Dictionary) expand(word string, flags []string, a *affixFile, forbidden map[string]bool) {
+	has := map[string]bool{}
+	for _, flag := range flags {
+		has[flag] = true
+	}
+	if a.forbidden != "" && has[a.forbidden] {
+		forbidden[word] = true
+		return
+	}
+	add := func(form string) {
+		d.words[form] = true
+		if a.noSuggest != "" && has[a.noSuggest] {
+			d.noSuggest[form] = true
+		}
+	}
+	if !(a.needAffix != "" && has[a.needAffix]) && !(a.onlyInCompound != "" && has[a.onlyInCompound]) {
+		add(word)
+	}
+
+	prefixes := func(form string, crossOnly bool) {
+		for _, flag := range flags {
+			class := a.classes[flag]
+			if class == nil || !class.prefix || crossOnly && !class.cross {
+				continue
+			}
+			for _, rule := range class.rules {
+				if prefixed, ok := rule.apply(form, true); ok {
+					add(prefixed)
+				}
+			}
+		}
+	}
+	for _, flag := range flags {
+		class := a.classes[flag]
+		if class == nil || class.prefix {
+			continue
+		}
+		for _, rule := range class.rules {
+			suffixed, ok := rule.apply(word, false)
+			if !ok {
+				continue
+			}
+			add(suffixed)
+			for _, next := range rule.next {
+				if nextClass := a.classes[next]; nextClass != nil && !nextClass.prefix {
+					for _, nextRule := range nextClass.rules {
+						if twice, ok := nextRule.apply(suffixed, false); ok {
+							add(twice)
+						}
+					}
+				}
+			}
+			if class.cross {
+				prefixes(suffixed, true)
+			}
+		}
+	}
+	prefixes(word, false)
+}
@@ -0,0 +1,80 @@
+package handling
+
+import (
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/srwiley/oksvg"
+	"github.com/srwiley/rasterx"
+)
+
+// cachedImage is a decoded image along with the modification time it was read at.
+type cachedImage struct {
+	modTime time.Time
+	image   image.Image
+}
+
+// maxCachedImages is how many decoded images are kept for the preview, the least recently shown are dropped first.
+const maxCachedImages = 64
+
+var imageCache = newLRUCache[cachedImage](maxCachedImages)
+
+// LoadImage decodes a PNG, JPEG, GIF or SVG file, reusing the previous result while the file is unchanged.
+func LoadImage(path string) (image.Image, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if cached, ok := imageCache.get(path); ok && cached.modTime.Equal(info.ModTime()) {
+		return cached.image, nil
+	}
+
+	img, err := decodeImage(path)
+	if err != nil {
+		return nil, err
+	}
+
+	imageCache.put(path, cachedImage{modTime: info.ModTime(), image: img})
+	return img, nil
+}
+
+func decodeImage(path string) (image.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if strings.ToLower(filepath.Ext(path)) == ".svg" {
+		return rasterizeSVG(file)
+	}
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode %s: %w", filepath.Base(path), err)
+	}
+	return img, nil
+}
+
+// rasterizeSVG draws an SVG at its view box size.
+func rasterizeSVG(file *os.File) (image.Image, error) {
+	icon, err := oksvg.ReadIconStream(file)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode %s: %w", filepath.Base(file.Name()), err)
+	}
+	w, h := int(icon.ViewBox.W), int(icon.ViewBox.H)
+	if w <= 0 || h <= 0 {
+		return nil, fmt.Errorf("%s has no size", filepath.Base(file.Name()))
+	}
+	icon.SetTarget(0, 0, float64(w), float64(h))
+	rgba := image.NewRGBA(image.Rect(0, 0, w, h))
+	icon.Draw(rasterx.NewDasher(w, h, rasterx.NewScannerGV(w, h, rgba, rgba.Bounds())), 1)
+	return rgba, nil
+}
@@ -0,0 +1,101 @@
+package handling
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadImage(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	tests := []struct {
+		name    string
+		path    string
+		size    image.Point
+		wantErr bool
+	}{
+		{"png", write("pixel.png", string(pngPixel)), image.Pt(1, 1), false},
+		{"svg", write("box.svg", `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 20 10"><rect width="20" height="10" fill="red"/></svg>`), image.Pt(20, 10), false},
+		{"svg without a size", write("empty.svg", `<svg xmlns="http://www.w3.org/2000/svg"></svg>`), image.Point{}, true},
+		{"not an image", write("notes.png", "text"), image.Point{}, true},
+		{"missing", filepath.Join(dir, "missing.png"), image.Point{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := LoadImage(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadImage error = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && img.Bounds().Size() != tt.size {
+				t.Errorf("LoadImage size = %v, want %v", img.Bounds().Size(), tt.size)
+			}
+		})
+	}
+}
+
+func TestLoadImageReloadsChangedFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "image.png")
+	save := func(width int, modTime time.Time) {
+		file, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		img := image.NewRGBA(image.Rect(0, 0, width, 1))
+		img.Set(0, 0, color.White)
+		if err := png.Encode(file, img); err != nil {
+			t.Fatal(err)
+		}
+		file.Close()
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	start := time.Now().Add(-time.Hour)
+	save(2, start)
+	first, err := LoadImage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := LoadImage(path); again != first {
+		t.Error("LoadImage decoded an unchanged file again")
+	}
+	save(3, start.Add(time.Minute))
+	if changed, err := LoadImage(path); err != nil || changed.Bounds().Dx() != 3 {
+		t.Errorf("LoadImage after a change = %v, %v, want the new image", changed.Bounds(), err)
+	}
+}
+
+func TestLoadImageCacheIsBounded(t *testing.T) {
+	cache := imageCache
+	imageCache = newLRUCache[cachedImage](2)
+	t.Cleanup(func() { imageCache = cache })
+
+	dir := t.TempDir()
+	for _, name := range []string{"a.png", "b.png", "c.png"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, pngPixel, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadImage(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if imageCache.len() != 2 {
+		t.Errorf("the image cache holds %d images, want 2", imageCache.len())
+	}
+	if _, ok := imageCache.get(filepath.Join(dir, "a.png")); ok {
+		t.Error("the least recently loaded image is still cached")
+	}
+}
@@ -0,0 +1,142 @@
+package handling
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// maxBracketScan is how far, in runes, a matching bracket is looked for.
+const maxBracketScan = 100000
+
+// Indentation is how the lines of a kind of file are indented.
+type Indentation struct {
+	// Tabs indents with tab characters rather than spaces.
+	Tabs bool `json:"tabs"`
+	// Width is how many spaces make a level, and how wide a tab is taken to be.
+	Width int `json:"width"`
+}
+
+// defaultIndentation is used for extensions that aren't in defaultIndentations.
+var defaultIndentation = Indentation{Width: 4}
+
+// defaultIndentations follow each language's usual style, keyed by extension.
+var defaultIndentations = map[string]Indentation{
+	".go":   {Tabs: true, Width: 4},
+	".mk":   {Tabs: true, Width: 4},
+	".html": {Width: 2},
+	".css":  {Width: 2},
+	".js":   {Width: 2},
+	".jsx":  {Width: 2},
+	".ts":   {Width: 2},
+	".tsx":  {Width: 2},
+	".json": {Width: 2},
+	".yaml": {Width: 2},
+	".yml":  {Width: 2},
+	".rb":   {Width: 2},
+	".md":   {Width: 2},
+}
+
+// IndentationFor returns how a file is indented, overrides are keyed by extension from config.json.
+func IndentationFor(path string, overrides map[string]Indentation) Indentation {
+	ext := strings.ToLower(filepath.Ext(path))
+	if filepath.Base(path) == "Makefile" {
+		ext = ".mk"
+	}
+	indentation, ok := overrides[ext]
+	if !ok {
+		indentation, ok = defaultIndentations[ext]
+	}
+	if !ok {
+		indentation = defaultIndentation
+	}
+	if indentation.Width <= 0 {
+		indentation.Width = defaultIndentation.Width
+	}
+	return indentation
+}
+
+// Unit returns one level of indentation.
+func (i Indentation) Unit() string {
+	if i.Tabs {
+		return "\t"
+	}
+	return strings.Repeat(" ", i.Width)
+}
+
+// colonIndents lists the languages whose blocks start at the end of a line with ":".
+var colonIndents = map[string]bool{".py": true, ".yaml": true, ".yml": true}
+
+// IndentsAfter reports whether the line after one ending like before is indented a level further,
+// because it opens a bracket or, in Python and YAML, ends with ":".
+func IndentsAfter(path, before string) bool {
+	before = strings.TrimRight(before, " \t")
+	if before == "" {
+		return false
+	}
+	switch before[len(before)-1] {
+	case '{', '[', '(':
+		return true
+	case ':':
+		return colonIndents[strings.ToLower(filepath.Ext(path))]
+	}
+	return false
+}
+
+// brackets maps each bracket to the one it pairs with.
+var brackets = map[rune]rune{'(': ')', '[': ']', '{': '}', ')': '(', ']': '[', '}': '{'}
+
+// IsOpenBracket reports whether r opens a pair of brackets.
+func IsOpenBracket(r rune) bool {
+	return r == '(' || r == '[' || r == '{'
+}
+
+// IsCloseBracket reports whether r closes a pair of brackets.
+func IsCloseBracket(r rune) bool {
+	return r == ')' || r == ']' || r == '}'
+}
+
+// MatchingBracket finds the bracket pairing with the one at offset in text, in runes.
+// Brackets of the same kind in between are counted so nested pairs are skipped.
+func MatchingBracket(text []rune, offset int) (int, bool) {
+	if offset < 0 || offset >= len(text) {
+		return 0, false
+	}
+	bracket := text[offset]
+	match, ok := brackets[bracket]
+	if !ok {
+		return 0, false
+	}
+	step := 1
+	if IsCloseBracket(bracket) {
+		step = -1
+	}
+	depth := 0
+	for i, scanned := offset+step, 0; i >= 0 && i < len(text) && scanned < maxBracketScan; i, scanned = i+step, scanned+1 {
+		switch text[i] {
+		case bracket:
+			depth++
+		case match:
+			if depth == 0 {
+				return i, true
+			}
+			depth--
+		}
+	}
+	return 0, false
+}
+
+// BracketAt returns the bracket next to the cursor at offset, the one after it first, and the bracket pairing with it.
+func BracketAt(text []rune, offset int) (bracket, match int, ok bool) {
+	for _, at := range []int{offset, offset - 1} {
+		if at < 0 || at >= len(text) {
+			continue
+		}
+		if _, isBracket := brackets[text[at]]; !isBracket {
+			continue
+		}
+		if match, ok := MatchingBracket(text, at); ok {
+			return at, match, true
+		}
+	}
+	return 0, 0, false
+}
@@ -0,0 +1,95 @@
+package handling
+
+import "testing"
+
+func TestIndentationFor(t *testing.T) {
+	overrides := map[string]Indentation{".py": {Width: 2}, ".c": {Tabs: true}}
+	tests := []struct {
+		path string
+		want Indentation
+		unit string
+	}{
+		{"main.go", Indentation{Tabs: true, Width: 4}, "\t"},
+		{"Makefile", Indentation{Tabs: true, Width: 4}, "\t"},
+		{"app.JS", Indentation{Width: 2}, "  "},
+		{"notes.txt", Indentation{Width: 4}, "    "},
+		{"script.py", Indentation{Width: 2}, "  "},
+		{"main.c", Indentation{Tabs: true, Width: 4}, "\t"},
+	}
+	for _, tt := range tests {
+		got := IndentationFor(tt.path, overrides)
+		if got != tt.want || got.Unit() != tt.unit {
+			t.Errorf("IndentationFor(%q) = %+v with unit %q, want %+v with %q", tt.path, got, got.Unit(), tt.want, tt.unit)
+		}
+	}
+}
+
+func TestIndentsAfter(t *testing.T) {
+	tests := []struct {
+		path, before string
+		want         bool
+	}{
+		{"main.go", "func main() {", true},
+		{"main.go", "x := []int{  ", true},
+		{"main.go", "fmt.Println(", true},
+		{"main.go", "case 1:", false},
+		{"main.go", "}", false},
+		{"main.go", "", false},
+		{"app.py", "def main():", true},
+		{"config.yaml", "items:", true},
+		{"app.py", "x = 1", false},
+	}
+	for _, tt := range tests {
+		if got := IndentsAfter(tt.path, tt.before); got != tt.want {
+			t.Errorf("IndentsAfter(%q, %q) = %v, want %v", tt.path, tt.before, got, tt.want)
+		}
+	}
+}
+
+func TestMatchingBracket(t *testing.T) {
+	text := []rune("f(a[1], {b: (c)}) ]")
+	tests := []struct {
+		offset int
+		want   int
+		ok     bool
+	}{
+		{1, 16, true},
+		{16, 1, true},
+		{3, 5, true},
+		{8, 15, true},
+		{12, 14, true},
+		{0, 0, false},
+		{18, 0, false},
+		{-1, 0, false},
+		{len(text), 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := MatchingBracket(text, tt.offset)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("MatchingBracket at %d = %d, %v, want %d, %v", tt.offset, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestBracketAt(t *testing.T) {
+	text := []rune("a(b)c")
+	tests := []struct {
+		offset         int
+		bracket, match int
+		ok             bool
+	}{
+		// The bracket after the cursor comes first.
+		{1, 1, 3, true},
+		{2, 1, 3, true},
+		{3, 3, 1, true},
+		{4, 3, 1, true},
+		{0, 0, 0, false},
+		{5, 0, 0, false},
+	}
+	for _, tt := range tests {
+		bracket, match, ok := BracketAt(text, tt.offset)
+		if bracket != tt.bracket || match != tt.match || ok != tt.ok {
+			t.Errorf("BracketAt(%d) = %d, %d, %v, want %d, %d, %v", tt.offset, bracket, match, ok, tt.bracket, tt.match, tt.ok)
+		}
+	}
+}
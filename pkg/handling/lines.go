@@ -0,0 +1,286 @@
+package handling
+
+import (
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// SortOptions picks how SortLines orders lines.
+type SortOptions struct {
+	// IgnoreCase compares lines without regard to case.
+	IgnoreCase bool
+	// Numeric compares lines by the number they start with, lines without one go first.
+	Numeric bool
+	// Unique keeps only the first of the lines that compare equal.
+	Unique bool
+}
+
+// leadingNumber matches the number a line starts with for numeric sorting.
+var leadingNumber = regexp.MustCompile(`^\s*[-+]?(\d+(\.\d*)?|\.\d+)`)
+
+// SortLines returns lines in ascending order, ties keep the order they were in.
+func SortLines(lines []string, options SortOptions) []string {
+	key := func(line string) string {
+		if options.IgnoreCase {
+			return strings.ToLower(line)
+		}
+		return line
+	}
+	number := func(line string) (float64, bool) {
+		match := leadingNumber.FindString(line)
+		if match == "" {
+			return 0, false
+		}
+		value, err := strconv.ParseFloat(strings.TrimSpace(match), 64)
+		return value, err == nil
+	}
+	compare := func(a, b string) int {
+		if options.Numeric {
+			x, xOK := number(a)
+			y, yOK := number(b)
+			switch {
+			case xOK != yOK && !xOK:
+				return -1
+			case xOK != yOK:
+				return 1
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+		}
+		return strings.Compare(key(a), key(b))
+	}
+	sorted := slices.Clone(lines)
+	sort.SliceStable(sorted, func(i, j int) bool { return compare(sorted[i], sorted[j]) < 0 })
+	if options.Unique {
+		sorted = slices.CompactFunc(sorted, func(a, b string) bool { return compare(a, b) == 0 })
+	}
+	return sorted
+}
+
+// ReverseLines returns lines in the opposite order.
+func ReverseLines(lines []string) []string {
+	reversed := slices.Clone(lines)
+	slices.Reverse(reversed)
+	return reversed
+}
+
+// JoinLines joins lines into one, each separated by a space with the indentation of the lines after the first dropped.
+func JoinLines(lines []string) string {
+	joined := strings.TrimRight(lines[0], " \t")
+	for _, line := range lines[1:] {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if strings.TrimSpace(joined) != "" {
+			joined += " "
+		}
+		joined += line
+	}
+	return joined
+}
+
+// TrimTrailingWhitespace removes the spaces and tabs at the end of each line.
+func TrimTrailingWhitespace(lines []string) []string {
+	trimmed := make([]string, len(lines))
+	for i, line := range lines {
+		trimmed[i] = strings.TrimRight(line, " \t")
+	}
+	return trimmed
+}
+
+// IndentLines indents each line that isn't blank by unit.
+func IndentLines(lines []string, unit string) []string {
+	indented := make([]string, len(lines))
+	for i, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			line = unit + line
+		}
+		indented[i] = line
+	}
+	return indented
+}
+
+// OutdentLines removes a level of indentation from each line, a tab or up to width spaces.
+func OutdentLines(lines []string, width int) []string {
+	outdented := make([]string, len(lines))
+	for i, line := range lines {
+		if strings.HasPrefix(line, "\t") {
+			line = line[1:]
+		} else {
+			spaces := len(line) - len(strings.TrimLeft(line, " "))
+			line = line[min(spaces, width):]
+		}
+		outdented[i] = line
+	}
+	return outdented
+}
+
+// ToggleLineComment comments out the lines, or uncomments them if they all are already.
+// The comment marker goes after the indentation the lines have in common, blank lines are left alone.
+// Languages without line comments get a block comment on each line instead.
+func ToggleLineComment(lines []string, syntax CommentSyntax) []string {
+	indent, blank := "", true
+	commented := true
+	for _, line := range lines {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed == "" {
+			continue
+		}
+		// Tabs and spaces only match themselves, so the marker never splits a line's indentation.
+		lead := line[:len(line)-len(trimmed)]
+		if blank {
+			indent, blank = lead, false
+		}
+		common := 0
+		for common < min(len(indent), len(lead)) && indent[common] == lead[common] {
+			common++
+		}
+		indent = indent[:common]
+		if syntax.Line != "" {
+			commented = commented && strings.HasPrefix(trimmed, syntax.Line)
+		} else {
+			commented = commented && isBlockComment(trimmed, syntax)
+		}
+	}
+	if blank {
+		return lines
+	}
+	toggled := make([]string, len(lines))
+	for i, line := range lines {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed == "" {
+			toggled[i] = line
+			continue
+		}
+		lead := line[:len(line)-len(trimmed)]
+		switch {
+		case commented && syntax.Line != "":
+			trimmed = strings.TrimPrefix(trimmed, syntax.Line)
+			toggled[i] = lead + strings.TrimPrefix(trimmed, " ")
+		case commented:
+			toggled[i] = lead + uncommentBlock(trimmed, syntax)
+		case syntax.Line != "":
+			toggled[i] = indent + syntax.Line + " " + line[len(indent):]
+		default:
+			toggled[i] = lead + syntax.BlockStart + " " + trimmed + " " + syntax.BlockEnd
+		}
+	}
+	return toggled
+}
+
+// ToggleBlockComment surrounds text with a block comment, or takes the one surrounding it away.
+// Languages without block comments get line comments instead.
+func ToggleBlockComment(text string, syntax CommentSyntax) string {
+	if syntax.BlockStart == "" {
+		return strings.Join(ToggleLineComment(strings.Split(text, "\n"), syntax), "\n")
+	}
+	trimmed := strings.TrimSpace(text)
+	if isBlockComment(trimmed, syntax) {
+		start := strings.Index(text, trimmed)
+		return text[:start] + uncommentBlock(trimmed, syntax) + text[start+len(trimmed):]
+	}
+	return syntax.BlockStart + " " + text + " " + syntax.BlockEnd
+}
+
+// isBlockComment reports whether text is surrounded by a block comment.
+func isBlockComment(text string, syntax CommentSyntax) bool {
+	return syntax.BlockStart != "" && len(text) >= len(syntax.BlockStart)+len(syntax.BlockEnd) &&
+		strings.HasPrefix(text, syntax.BlockStart) && strings.HasSuffix(text, syntax.BlockEnd)
+}
+
+// uncommentBlock takes away the block comment surrounding text, with the spaces just inside it.
+func uncommentBlock(text string, syntax CommentSyntax) string {
+	text = strings.TrimSuffix(strings.TrimPrefix(text, syntax.BlockStart), syntax.BlockEnd)
+	return strings.TrimSuffix(strings.TrimPrefix(text, " "), " ")
+}
+
+// Case is a way of writing the letters of words.
+type Case int
+
+const (
+	UpperCase Case = iota
+	LowerCase
+	TitleCase
+	SnakeCase
+	CamelCase
+)
+
+// ConvertCase rewrites text in a case. Snake and camel case join the words of each line,
+// finding them at spaces, punctuation and changes of case such as in "parseHTTPRequest".
+func ConvertCase(text string, c Case) string {
+	switch c {
+	case UpperCase:
+		return strings.ToUpper(text)
+	case LowerCase:
+		return strings.ToLower(text)
+	case TitleCase:
+		runes := []rune(text)
+		for i, r := range runes {
+			if i == 0 || !unicode.IsLetter(runes[i-1]) && runes[i-1] != '\'' {
+				runes[i] = unicode.ToUpper(r)
+			} else {
+				runes[i] = unicode.ToLower(r)
+			}
+		}
+		return string(runes)
+	}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lead := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		words := splitWords(line)
+		for j, word := range words {
+			word = strings.ToLower(word)
+			if c == CamelCase && j > 0 {
+				runes := []rune(word)
+				runes[0] = unicode.ToUpper(runes[0])
+				word = string(runes)
+			}
+			words[j] = word
+		}
+		separator := "_"
+		if c == CamelCase {
+			separator = ""
+		}
+		lines[i] = lead + strings.Join(words, separator)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// splitWords splits text into words at anything that isn't a letter or digit,
+// before a capital following a lower case letter or digit, and before the last capital of a run followed by a lower case letter.
+func splitWords(text string) []string {
+	var words []string
+	runes := []rune(text)
+	start := -1
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if start >= 0 {
+				words = append(words, string(runes[start:i]))
+				start = -1
+			}
+			continue
+		}
+		if start >= 0 && unicode.IsUpper(r) {
+			previous := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(previous) || unicode.IsDigit(previous) || (unicode.IsUpper(previous) && nextLower) {
+				words = append(words, string(runes[start:i]))
+				start = i
+			}
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
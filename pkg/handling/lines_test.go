@@ -0,0 +1,155 @@
+package handling
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSortLines(t *testing.T) {
+	tests := []struct {
+		name    string
+		lines   []string
+		options SortOptions
+		want    []string
+	}{
+		{"plain", []string{"b", "C", "a"}, SortOptions{}, []string{"C", "a", "b"}},
+		{"ignore case", []string{"b", "C", "a"}, SortOptions{IgnoreCase: true}, []string{"a", "b", "C"}},
+		{"numeric", []string{"10 x", "9 y", "z", "-1"}, SortOptions{Numeric: true}, []string{"z", "-1", "9 y", "10 x"}},
+		{"unique", []string{"b", "a", "b", "a"}, SortOptions{Unique: true}, []string{"a", "b"}},
+		{"unique ignoring case keeps the first", []string{"B", "a", "b"}, SortOptions{IgnoreCase: true, Unique: true}, []string{"a", "B"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SortLines(tt.lines, tt.options); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SortLines(%q) = %q, want %q", tt.lines, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJoinLines(t *testing.T) {
+	tests := []struct {
+		lines []string
+		want  string
+	}{
+		{[]string{"a", "b"}, "a b"},
+		{[]string{"\tif x {  ", "\t\treturn", "\t}"}, "\tif x { return }"},
+		{[]string{"a", "", "b"}, "a b"},
+		{[]string{"", "b"}, "b"},
+	}
+	for _, tt := range tests {
+		if got := JoinLines(tt.lines); got != tt.want {
+			t.Errorf("JoinLines(%q) = %q, want %q", tt.lines, got, tt.want)
+		}
+	}
+}
+
+func TestIndentLines(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		got   func([]string) []string
+		want  []string
+	}{
+		{"indent", []string{"a", "", "\tb"}, func(l []string) []string { return IndentLines(l, "  ") }, []string{"  a", "", "  \tb"}},
+		{"outdent tab", []string{"\t\ta", "b"}, func(l []string) []string { return OutdentLines(l, 4) }, []string{"\ta", "b"}},
+		{"outdent spaces", []string{"      a", "  b"}, func(l []string) []string { return OutdentLines(l, 4) }, []string{"  a", "b"}},
+		{"trim", []string{"a \t", " b ", ""}, TrimTrailingWhitespace, []string{"a", " b", ""}},
+		{"reverse", []string{"a", "b", "c"}, ReverseLines, []string{"c", "b", "a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.got(tt.lines); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToggleLineComment(t *testing.T) {
+	tests := []struct {
+		name   string
+		lines  []string
+		syntax CommentSyntax
+		want   []string
+	}{
+		{"comment", []string{"a", "b"}, cComments, []string{"// a", "// b"}},
+		{"uncomment", []string{"// a", "//b"}, cComments, []string{"a", "b"}},
+		{"some commented", []string{"// a", "b"}, cComments, []string{"// // a", "// b"}},
+		{"least indented", []string{"    a", "  b"}, cComments, []string{"  //   a", "  // b"}},
+		{"blank lines", []string{"\ta", "", "\tb"}, cComments, []string{"\t// a", "", "\t// b"}},
+		{"uncomment indented", []string{"\t// a", "\t\t// b"}, cComments, []string{"\ta", "\t\tb"}},
+		{"mixed tabs and spaces", []string{"  a", "\tb"}, cComments, []string{"//   a", "// \tb"}},
+		{"tabs then spaces", []string{"\t  a", "\t\tb"}, cComments, []string{"\t//   a", "\t// \tb"}},
+		{"hash", []string{"x = 1"}, hashComments, []string{"# x = 1"}},
+		{"block comments", []string{"<p>", "  <b>"}, htmlComments, []string{"<!-- <p> -->", "  <!-- <b> -->"}},
+		{"block uncomment", []string{"<!-- <p> -->"}, htmlComments, []string{"<p>"}},
+		{"only blank lines", []string{"", "  "}, cComments, []string{"", "  "}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ToggleLineComment(tt.lines, tt.syntax); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ToggleLineComment(%q) = %q, want %q", tt.lines, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToggleBlockComment(t *testing.T) {
+	tests := []struct {
+		text   string
+		syntax CommentSyntax
+		want   string
+	}{
+		{"a + b", cComments, "/* a + b */"},
+		{"/* a + b */", cComments, "a + b"},
+		{"  /* a */\n", cComments, "  a\n"},
+		{"a\nb", hashComments, "# a\n# b"},
+	}
+	for _, tt := range tests {
+		if got := ToggleBlockComment(tt.text, tt.syntax); got != tt.want {
+			t.Errorf("ToggleBlockComment(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestCommentSyntaxFor(t *testing.T) {
+	tests := []struct {
+		path string
+		want CommentSyntax
+	}{
+		{"main.go", cComments},
+		{"script.PY", hashComments},
+		{"Makefile", hashComments},
+		{"", htmlComments},
+		{"notes.unknown", hashComments},
+		{"q.sql", CommentSyntax{Line: "--", BlockStart: "/*", BlockEnd: "*/"}},
+	}
+	for _, tt := range tests {
+		if got := CommentSyntaxFor(tt.path); got != tt.want {
+			t.Errorf("CommentSyntaxFor(%q) = %+v, want %+v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestConvertCase(t *testing.T) {
+	tests := []struct {
+		text string
+		c    Case
+		want string
+	}{
+		{"Hello World", UpperCase, "HELLO WORLD"},
+		{"Hello World", LowerCase, "hello world"},
+		{"it's a TEST", TitleCase, "It's A Test"},
+		{"parseHTTPRequest", SnakeCase, "parse_http_request"},
+		{"  Hello, world-wide", SnakeCase, "  hello_world_wide"},
+		{"parse_http_request", CamelCase, "parseHttpRequest"},
+		{"a b\n\tc d", CamelCase, "aB\n\tcD"},
+		{"version2Beta", SnakeCase, "version2_beta"},
+	}
+	for _, tt := range tests {
+		if got := ConvertCase(tt.text, tt.c); got != tt.want {
+			t.Errorf("ConvertCase(%q, %v) = %q, want %q", tt.text, tt.c, got, tt.want)
+		}
+	}
+}
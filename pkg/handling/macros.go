@@ -0,0 +1,135 @@
+package handling
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+)
+
+// MacroStep is one thing done in the editor while a macro was recorded, only one of its fields is set.
+type MacroStep struct {
+	// Text is what was typed.
+	Text string `json:"text,omitempty"`
+	// Key is a key that was pressed, such as "Return" or "Left".
+	Key fyne.KeyName `json:"key,omitempty"`
+	// Shift records whether shift was held down with Key, selecting as the cursor moved.
+	Shift bool `json:"shift,omitempty"`
+	// Shortcut is a shortcut that was used, such as "Paste" or "Ctrl+Shift+K".
+	Shortcut string `json:"shortcut,omitempty"`
+}
+
+// Macro is a recorded sequence of editor actions that can be played back.
+type Macro struct {
+	// Key is the shortcut that plays the macro, such as "Ctrl+Alt+1", empty for none.
+	Key   string      `json:"key,omitempty"`
+	Steps []MacroStep `json:"steps"`
+}
+
+// AddMacroStep adds a step to a recording, typing is joined into the step before it.
+func AddMacroStep(steps []MacroStep, step MacroStep) []MacroStep {
+	if n := len(steps); n > 0 && step.Text != "" && steps[n-1].Text != "" {
+		steps[n-1].Text += step.Text
+		return steps
+	}
+	return append(steps, step)
+}
+
+// MacrosPath returns the file the named macros are saved in, next to config.json.
+func MacrosPath() string {
+	return filepath.Join(ConfigDir(), "macros.json")
+}
+
+// LoadMacros reads the named macros, there are none if the file doesn't exist.
+func LoadMacros() (map[string]Macro, error) {
+	macros := map[string]Macro{}
+	data, err := os.ReadFile(MacrosPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return macros, nil
+	}
+	if err != nil {
+		return macros, err
+	}
+	if err := json.Unmarshal(data, &macros); err != nil {
+		return macros, fmt.Errorf("%s: %w", MacrosPath(), err)
+	}
+	return macros, nil
+}
+
+// SaveMacro adds a named macro to the macros file, replacing any with the same name.
+func SaveMacro(name string, macro Macro) error {
+	macros, err := LoadMacros()
+	if err != nil {
+		return err
+	}
+	macros[name] = macro
+	data, err := json.MarshalIndent(macros, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(MacrosPath(), append(data, '\n'), 0644)
+}
+
+// keyModifiers are the modifiers of a key binding in the order they're written.
+var keyModifiers = []struct {
+	name     string
+	modifier fyne.KeyModifier
+}{
+	{"Ctrl", fyne.KeyModifierControl},
+	{"Alt", fyne.KeyModifierAlt},
+	{"Shift", fyne.KeyModifierShift},
+	{"Super", fyne.KeyModifierSuper},
+}
+
+// FormatKeyBinding writes a key and its modifiers the way ParseKeyBinding reads them, such as "Ctrl+Shift+K".
+func FormatKeyBinding(key fyne.KeyName, modifier fyne.KeyModifier) string {
+	var parts []string
+	for _, m := range keyModifiers {
+		if modifier&m.modifier != 0 {
+			parts = append(parts, m.name)
+		}
+	}
+	return strings.Join(append(parts, string(key)), "+")
+}
+
+// ParseKeyBinding reads a key binding such as "Ctrl+Alt+1". It needs Ctrl, Alt or Super, so typing isn't taken over.
+func ParseKeyBinding(binding string) (fyne.KeyName, fyne.KeyModifier, error) {
+	parts := strings.Split(strings.TrimSpace(binding), "+")
+	key := strings.TrimSpace(parts[len(parts)-1])
+	if len([]rune(key)) == 1 {
+		key = strings.ToUpper(key)
+	}
+	var modifier fyne.KeyModifier
+	for _, part := range parts[:len(parts)-1] {
+		name := strings.ToLower(strings.TrimSpace(part))
+		switch name {
+		case "control":
+			name = "ctrl"
+		case "cmd", "command":
+			name = "super"
+		case "option":
+			name = "alt"
+		}
+		found := false
+		for _, m := range keyModifiers {
+			if strings.ToLower(m.name) == name {
+				modifier |= m.modifier
+				found = true
+			}
+		}
+		if !found {
+			return "", 0, fmt.Errorf("%q isn't a modifier key in %q", part, binding)
+		}
+	}
+	if key == "" {
+		return "", 0, fmt.Errorf("%q has no key", binding)
+	}
+	if modifier&^fyne.KeyModifierShift == 0 {
+		return "", 0, fmt.Errorf("%q needs Ctrl, Alt or Super", binding)
+	}
+	return fyne.KeyName(key), modifier, nil
+}
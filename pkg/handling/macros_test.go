@@ -0,0 +1,63 @@
+package handling
+
+import (
+	"reflect"
+	"testing"
+
+	"fyne.io/fyne/v2"
+)
+
+func TestParseKeyBinding(t *testing.T) {
+	tests := []struct {
+		binding  string
+		key      fyne.KeyName
+		modifier fyne.KeyModifier
+		wantErr  bool
+	}{
+		{"Ctrl+Alt+1", fyne.Key1, fyne.KeyModifierControl | fyne.KeyModifierAlt, false},
+		{"ctrl + shift + k", fyne.KeyK, fyne.KeyModifierControl | fyne.KeyModifierShift, false},
+		{"Control+F5", fyne.KeyF5, fyne.KeyModifierControl, false},
+		{"Option+Up", fyne.KeyUp, fyne.KeyModifierAlt, false},
+		{"Cmd+J", fyne.KeyJ, fyne.KeyModifierSuper, false},
+		{"Command+Shift+J", fyne.KeyJ, fyne.KeyModifierSuper | fyne.KeyModifierShift, false},
+		{"Super+J", fyne.KeyJ, fyne.KeyModifierSuper, false},
+		{"Shift+K", "", 0, true},
+		{"K", "", 0, true},
+		{"Hyper+K", "", 0, true},
+		{"Ctrl+", "", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.binding, func(t *testing.T) {
+			key, modifier, err := ParseKeyBinding(tt.binding)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseKeyBinding(%q) error = %v, want error %v", tt.binding, err, tt.wantErr)
+			}
+			if key != tt.key || modifier != tt.modifier {
+				t.Errorf("ParseKeyBinding(%q) = %q, %v, want %q, %v", tt.binding, key, modifier, tt.key, tt.modifier)
+			}
+		})
+	}
+}
+
+func TestFormatKeyBinding(t *testing.T) {
+	for _, binding := range []string{"Ctrl+Alt+1", "Ctrl+Shift+K", "Alt+Up", "Super+J", "Ctrl+Alt+Shift+Super+F5"} {
+		key, modifier, err := ParseKeyBinding(binding)
+		if err != nil {
+			t.Fatalf("ParseKeyBinding(%q): %v", binding, err)
+		}
+		if got := FormatKeyBinding(key, modifier); got != binding {
+			t.Errorf("FormatKeyBinding(ParseKeyBinding(%q)) = %q", binding, got)
+		}
+	}
+}
+
+func TestAddMacroStep(t *testing.T) {
+	var steps []MacroStep
+	for _, step := range []MacroStep{{Text: "a"}, {Text: "b"}, {Key: fyne.KeyReturn}, {Text: "c"}, {Shortcut: "Paste"}, {Text: "d"}} {
+		steps = AddMacroStep(steps, step)
+	}
+	want := []MacroStep{{Text: "ab"}, {Key: fyne.KeyReturn}, {Text: "c"}, {Shortcut: "Paste"}, {Text: "d"}}
+	if !reflect.DeepEqual(steps, want) {
+		t.Errorf("AddMacroStep joined %+v, want %+v", steps, want)
+	}
+}
@@ -0,0 +1,79 @@
+package handling
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// maxHistory limits how many locations are kept in each direction.
+const maxHistory = 100
+
+// Location is a cursor position in a file. Path is empty for an unsaved buffer.
+type Location struct {
+	Path string
+	// Row and Column are zero based.
+	Row, Column int
+}
+
+// NavigationHistory remembers the cursor locations left behind by jumps, like a browser history.
+type NavigationHistory struct {
+	back    []Location
+	forward []Location
+}
+
+// Push records the location being left and forgets anything that could be gone forward to.
+func (h *NavigationHistory) Push(loc Location) {
+	h.forward = nil
+	if n := len(h.back); n > 0 && h.back[n-1] == loc {
+		return
+	}
+	h.back = append(h.back, loc)
+	if len(h.back) > maxHistory {
+		h.back = h.back[1:]
+	}
+}
+
+// Back returns the most recent location that valid accepts, current is kept so Forward can return to it.
+// Locations that are no longer valid, such as closed unsaved buffers, are skipped and forgotten.
+func (h *NavigationHistory) Back(current Location, valid func(Location) bool) (Location, bool) {
+	for len(h.back) > 0 {
+		loc := h.back[len(h.back)-1]
+		h.back = h.back[:len(h.back)-1]
+		if valid(loc) {
+			h.forward = append(h.forward, current)
+			return loc, true
+		}
+	}
+	return Location{}, false
+}
+
+// Forward undoes a Back.
+func (h *NavigationHistory) Forward(current Location, valid func(Location) bool) (Location, bool) {
+	for len(h.forward) > 0 {
+		loc := h.forward[len(h.forward)-1]
+		h.forward = h.forward[:len(h.forward)-1]
+		if valid(loc) {
+			h.back = append(h.back, current)
+			return loc, true
+		}
+	}
+	return Location{}, false
+}
+
+// ParseLineColumn reads "line" or "line:column", both one based as typed by the user.
+func ParseLineColumn(input string) (line, column int, err error) {
+	lineText, columnText, hasColumn := strings.Cut(strings.TrimSpace(input), ":")
+	line, err = strconv.Atoi(strings.TrimSpace(lineText))
+	if err != nil || line < 1 {
+		return 0, 0, fmt.Errorf("%q is not a line number", lineText)
+	}
+	column = 1
+	if hasColumn {
+		column, err = strconv.Atoi(strings.TrimSpace(columnText))
+		if err != nil || column < 1 {
+			return 0, 0, fmt.Errorf("%q is not a column number", columnText)
+		}
+	}
+	return line, column, nil
+}
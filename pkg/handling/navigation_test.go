@@ -0,0 +1,80 @@
+package handling
+
+import "testing"
+
+func TestNavigationHistory(t *testing.T) {
+	valid := func(Location) bool { return true }
+	a, b, c := Location{Path: "a.go"}, Location{Path: "b.go", Row: 3}, Location{Path: "c.go", Row: 7, Column: 2}
+
+	var h NavigationHistory
+	if _, ok := h.Back(a, valid); ok {
+		t.Fatal("Back with no history succeeded")
+	}
+	h.Push(a)
+	h.Push(a)
+	h.Push(b)
+	if loc, ok := h.Back(c, valid); !ok || loc != b {
+		t.Fatalf("Back = %v, %v, want %v", loc, ok, b)
+	}
+	if loc, ok := h.Back(b, valid); !ok || loc != a {
+		t.Fatalf("second Back = %v, %v, want %v, a repeated location is kept once", loc, ok, a)
+	}
+	if loc, ok := h.Forward(a, valid); !ok || loc != b {
+		t.Fatalf("Forward = %v, %v, want %v", loc, ok, b)
+	}
+	if loc, ok := h.Forward(b, valid); !ok || loc != c {
+		t.Fatalf("second Forward = %v, %v, want %v", loc, ok, c)
+	}
+	if _, ok := h.Forward(c, valid); ok {
+		t.Fatal("Forward past the end succeeded")
+	}
+
+	// A new jump forgets what could be gone forward to.
+	h.Back(c, valid)
+	h.Push(a)
+	if _, ok := h.Forward(a, valid); ok {
+		t.Error("Forward after a new jump succeeded")
+	}
+}
+
+func TestNavigationHistorySkipsInvalid(t *testing.T) {
+	var h NavigationHistory
+	saved, unsaved := Location{Path: "a.go"}, Location{Row: 4}
+	h.Push(saved)
+	h.Push(unsaved)
+	loc, ok := h.Back(Location{Path: "b.go"}, func(loc Location) bool { return loc.Path != "" })
+	if !ok || loc != saved {
+		t.Errorf("Back = %v, %v, want %v", loc, ok, saved)
+	}
+}
+
+func TestNavigationHistoryLimit(t *testing.T) {
+	var h NavigationHistory
+	for i := 0; i < maxHistory+10; i++ {
+		h.Push(Location{Row: i})
+	}
+	if len(h.back) != maxHistory || h.back[0].Row != 10 {
+		t.Errorf("kept %d locations from row %d", len(h.back), h.back[0].Row)
+	}
+}
+
+func TestParseLineColumn(t *testing.T) {
+	tests := []struct {
+		input        string
+		line, column int
+		wantErr      bool
+	}{
+		{"12", 12, 1, false},
+		{" 12 : 4 ", 12, 4, false},
+		{"0", 0, 0, true},
+		{"x", 0, 0, true},
+		{"3:", 0, 0, true},
+		{"3:0", 0, 0, true},
+	}
+	for _, tt := range tests {
+		line, column, err := ParseLineColumn(tt.input)
+		if (err != nil) != tt.wantErr || line != tt.line || column != tt.column {
+			t.Errorf("ParseLineColumn(%q) = %d, %d, %v, want %d, %d", tt.input, line, column, err, tt.line, tt.column)
+		}
+	}
+}
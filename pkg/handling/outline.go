@@ -0,0 +1,190 @@
+package handling
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	mdast "github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// OutlineItem is a heading or symbol in a document's outline.
+type OutlineItem struct {
+	Name string
+	// Kind is "heading", "func", "type", "class" and so on.
+	Kind string
+	// Line is the zero based line the item starts on.
+	Line     int
+	Children []*OutlineItem
+}
+
+// symbolPattern matches a top-level declaration, the first group is its name.
+type symbolPattern struct {
+	kind    string
+	pattern *regexp.Regexp
+}
+
+// symbolPatterns lists declaration patterns for languages without a dedicated parser.
+var symbolPatterns = map[string][]symbolPattern{
+	".py": {
+		{"class", regexp.MustCompile(`^class\s+(\w+)`)},
+		{"func", regexp.MustCompile(`^(?:async\s+)?def\s+(\w+)`)},
+	},
+	".js": {
+		{"class", regexp.MustCompile(`^(?:export\s+)?(?:default\s+)?class\s+(\w+)`)},
+		{"func", regexp.MustCompile(`^(?:export\s+)?(?:default\s+)?(?:async\s+)?function\s*\*?\s*(\w+)`)},
+		{"func", regexp.MustCompile(`^(?:export\s+)?(?:const|let|var)\s+(\w+)\s*=\s*(?:async\s+)?(?:function|\([^)]*\)\s*=>|\w+\s*=>)`)},
+	},
+	".rs": {
+		{"func", regexp.MustCompile(`^(?:pub(?:\([^)]*\))?\s+)?(?:async\s+)?fn\s+(\w+)`)},
+		{"type", regexp.MustCompile(`^(?:pub(?:\([^)]*\))?\s+)?(?:struct|enum|trait|type)\s+(\w+)`)},
+		{"impl", regexp.MustCompile(`^impl(?:<[^>]*>)?\s+(.+?)\s*\{?$`)},
+	},
+	".java": {
+		{"class", regexp.MustCompile(`^(?:public\s+|abstract\s+|final\s+)*(?:class|interface|enum|record)\s+(\w+)`)},
+	},
+	".sh": {
+		{"func", regexp.MustCompile(`^(?:function\s+)?(\w+)\s*\(\)`)},
+	},
+	".c": {
+		{"func", regexp.MustCompile(`^[A-Za-z_][\w\s\*]*?\b(\w+)\s*\([^;]*$`)},
+		{"type", regexp.MustCompile(`^(?:typedef\s+)?(?:struct|enum|union)\s+(\w+)`)},
+	},
+}
+
+func init() {
+	symbolPatterns[".ts"] = symbolPatterns[".js"]
+	symbolPatterns[".jsx"] = symbolPatterns[".js"]
+	symbolPatterns[".tsx"] = symbolPatterns[".js"]
+	symbolPatterns[".bash"] = symbolPatterns[".sh"]
+	symbolPatterns[".h"] = symbolPatterns[".c"]
+	symbolPatterns[".cpp"] = symbolPatterns[".c"]
+}
+
+// BuildOutline lists the headings or top-level symbols of content, choosing the parser by file name.
+// Markdown is assumed when the name is empty.
+func BuildOutline(name, content string) []*OutlineItem {
+	ext := strings.ToLower(filepath.Ext(name))
+	switch {
+	case name == "" || IsMarkdownFile(name):
+		return markdownOutline(content)
+	case ext == ".go":
+		return goOutline(content)
+	case symbolPatterns[ext] != nil:
+		return patternOutline(content, symbolPatterns[ext])
+	}
+	return nil
+}
+
+// markdownOutline nests headings under the closest preceding heading of a lower level.
+func markdownOutline(content string) []*OutlineItem {
+	source := []byte(content)
+	doc := NewMarkdown().Parser().Parse(text.NewReader(source))
+
+	var roots []*OutlineItem
+	var stack []*OutlineItem
+	var levels []int
+	for n := doc.FirstChild(); n != nil; n = n.NextSibling() {
+		heading, ok := n.(*mdast.Heading)
+		if !ok {
+			continue
+		}
+		line := 0
+		if heading.Lines().Len() > 0 {
+			line = bytes.Count(source[:heading.Lines().At(0).Start], []byte("\n"))
+		}
+		item := &OutlineItem{Name: NodeText(heading, source), Kind: "heading", Line: line}
+
+		for len(levels) > 0 && levels[len(levels)-1] >= heading.Level {
+			stack, levels = stack[:len(stack)-1], levels[:len(levels)-1]
+		}
+		if len(stack) == 0 {
+			roots = append(roots, item)
+		} else {
+			parent := stack[len(stack)-1]
+			parent.Children = append(parent.Children, item)
+		}
+		stack, levels = append(stack, item), append(levels, heading.Level)
+	}
+	return roots
+}
+
+// goOutline lists top-level functions, methods and types, using whatever parses while the file is being edited.
+func goOutline(content string) []*OutlineItem {
+	fset := token.NewFileSet()
+	file, _ := parser.ParseFile(fset, "", content, parser.SkipObjectResolution)
+	if file == nil {
+		return nil
+	}
+
+	var items []*OutlineItem
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			name := d.Name.Name
+			kind := "func"
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				name = fmt.Sprintf("(%s) %s", receiverType(d.Recv.List[0].Type), name)
+				kind = "method"
+			}
+			items = append(items, &OutlineItem{Name: name, Kind: kind, Line: fset.Position(d.Pos()).Line - 1})
+		case *ast.GenDecl:
+			if d.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range d.Specs {
+				if ts, ok := spec.(*ast.TypeSpec); ok {
+					items = append(items, &OutlineItem{Name: ts.Name.Name, Kind: "type", Line: fset.Position(ts.Pos()).Line - 1})
+				}
+			}
+		}
+	}
+	return items
+}
+
+func receiverType(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return "*" + receiverType(t.X)
+	case *ast.Ident:
+		return t.Name
+	case *ast.IndexExpr:
+		return receiverType(t.X)
+	case *ast.IndexListExpr:
+		return receiverType(t.X)
+	}
+	return "?"
+}
+
+// patternOutline matches declarations at the start of unindented lines.
+func patternOutline(content string, patterns []symbolPattern) []*OutlineItem {
+	var items []*OutlineItem
+	for i, line := range strings.Split(content, "\n") {
+		if line == "" || line[0] == ' ' || line[0] == '\t' {
+			continue
+		}
+		for _, p := range patterns {
+			if m := p.pattern.FindStringSubmatch(line); m != nil {
+				items = append(items, &OutlineItem{Name: m[1], Kind: p.kind, Line: i})
+				break
+			}
+		}
+	}
+	return items
+}
+
+// FlattenOutline lists every item in document order.
+func FlattenOutline(items []*OutlineItem) []*OutlineItem {
+	var flat []*OutlineItem
+	for _, item := range items {
+		flat = append(flat, item)
+		flat = append(flat, FlattenOutline(item.Children)...)
+	}
+	return flat
+}
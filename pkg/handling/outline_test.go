@@ -0,0 +1,72 @@
+package handling
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// describeOutline writes each item as "name kind line", indented by how deeply it's nested.
+func describeOutline(items []*OutlineItem, depth int) []string {
+	var lines []string
+	for _, item := range items {
+		lines = append(lines, fmt.Sprintf("%s%s %s %d", strings.Repeat("  ", depth), item.Name, item.Kind, item.Line))
+		lines = append(lines, describeOutline(item.Children, depth+1)...)
+	}
+	return lines
+}
+
+func TestBuildOutline(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    []string
+	}{
+		{
+			"markdown nests headings", "notes.md",
+			"# Title\ntext\n## One\n### Deep\n## Two\n# Other\n",
+			[]string{"Title heading 0", "  One heading 2", "    Deep heading 3", "  Two heading 4", "Other heading 5"},
+		},
+		{
+			"markdown skips headings in code", "",
+			"# Title\n```\n# not a heading\n```\n",
+			[]string{"Title heading 0"},
+		},
+		{
+			"go", "main.go",
+			"package main\n\ntype T struct{}\n\nfunc (t *T) M() {}\n\nfunc main() {}\n",
+			[]string{"T type 2", "(*T) M method 4", "main func 6"},
+		},
+		{
+			"python", "app.py",
+			"class A:\n    def inner(self):\n        pass\n\nasync def run():\n    pass\n",
+			[]string{"A class 0", "run func 4"},
+		},
+		{
+			"javascript", "app.js",
+			"export function a() {}\nconst b = (x) => x\nclass C {}\n",
+			[]string{"a func 0", "b func 1", "C class 2"},
+		},
+		{"unknown kind of file", "data.bin", "func main() {}\n", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := describeOutline(BuildOutline(tt.file, tt.content), 0); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BuildOutline(%q) = %q, want %q", tt.file, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlattenOutline(t *testing.T) {
+	items := BuildOutline("notes.md", "# A\n## B\n### C\n# D\n")
+	var names []string
+	for _, item := range FlattenOutline(items) {
+		names = append(names, item.Name)
+	}
+	if want := []string{"A", "B", "C", "D"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("FlattenOutline = %q, want %q", names, want)
+	}
+}
@@ -0,0 +1,643 @@
+package handling
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/jung-kurt/gofpdf"
+	"github.com/yuin/goldmark/ast"
+	east "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// PDFFonts holds the TTF files used when generating PDFs, empty paths fall back to built-in fonts.
+type PDFFonts struct {
+	Default   string
+	Bold      string
+	Italic    string
+	Monospace string
+}
+
+// PDFExportOptions controls PDF generation.
+type PDFExportOptions struct {
+	// Title is printed in the header of every page, usually the file name.
+	Title string
+	// BaseDir is the directory relative image paths are resolved against.
+	BaseDir string
+	// LineNumbers prefixes each line of plain text exports with its number.
+	LineNumbers bool
+	Fonts       PDFFonts
+}
+
+// PDFFontsFromConfig takes the font paths from the fonts section of config.json.
+func PDFFontsFromConfig(config *Config) PDFFonts {
+	return PDFFonts{
+		Default:   config.Fonts.Default,
+		Bold:      config.Fonts.Bold,
+		Italic:    config.Fonts.Italic,
+		Monospace: config.Fonts.Monospace,
+	}
+}
+
+const (
+	pdfMargin     = 20.0
+	pdfBodySize   = 11.0
+	pdfCodeSize   = 9.5
+	pdfLineHeight = 6.0
+	pdfCodeHeight = 4.8
+)
+
+var pdfHeadingSizes = []float64{22, 18, 15, 13, 12, 11}
+
+// pdfWriter wraps gofpdf with the fonts and text encoding chosen for a document.
+type pdfWriter struct {
+	pdf        *gofpdf.Fpdf
+	opts       PDFExportOptions
+	textFamily string
+	textUTF8   bool
+	monoFamily string
+	monoUTF8   bool
+	cp1252     func(string) string
+	images     int
+}
+
+func newPDFWriter(opts PDFExportOptions) (*pdfWriter, error) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetAutoPageBreak(true, pdfMargin)
+	pdf.AliasNbPages("")
+
+	w := &pdfWriter{
+		pdf:        pdf,
+		opts:       opts,
+		textFamily: "Helvetica",
+		monoFamily: "Courier",
+		cp1252:     pdf.UnicodeTranslatorFromDescriptor(""),
+	}
+
+	// Register the configured fonts, any missing style reuses the regular font.
+	if fileExists(opts.Fonts.Default) {
+		bold, italic := opts.Fonts.Bold, opts.Fonts.Italic
+		if !fileExists(bold) {
+			bold = opts.Fonts.Default
+		}
+		if !fileExists(italic) {
+			italic = opts.Fonts.Default
+		}
+		styles := map[string]string{"": opts.Fonts.Default, "B": bold, "I": italic, "BI": bold}
+		if err := w.addFont("leda", styles); err != nil {
+			return nil, err
+		}
+		w.textFamily, w.textUTF8 = "leda", true
+	}
+	if fileExists(opts.Fonts.Monospace) {
+		// Code is never italic, but may be bold.
+		styles := map[string]string{"": opts.Fonts.Monospace, "B": opts.Fonts.Monospace}
+		if err := w.addFont("ledamono", styles); err != nil {
+			return nil, err
+		}
+		w.monoFamily, w.monoUTF8 = "ledamono", true
+	}
+
+	pdf.SetHeaderFunc(w.header)
+	return w, nil
+}
+
+// addFont registers a TTF file for each style of a font family.
+// The files are read here, as gofpdf would look for any path in its own font folder.
+func (w *pdfWriter) addFont(family string, styles map[string]string) error {
+	for style, path := range styles {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read font: %w", err)
+		}
+		w.pdf.AddUTF8FontFromBytes(family, style, data)
+		// gofpdf skips a file it can't parse without an error, selecting the font finds that out.
+		w.pdf.SetFont(family, style, pdfBodySize)
+		if err := w.pdf.Error(); err != nil {
+			return fmt.Errorf("failed to load font %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+func fileExists(path string) bool {
+	if path == "" {
+		return false
+	}
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// header prints the document title and page number at the top of each page.
+func (w *pdfWriter) header() {
+	pageWidth, _ := w.pdf.GetPageSize()
+	w.pdf.SetFont(w.textFamily, "", 9)
+	w.pdf.SetTextColor(120, 120, 120)
+	w.pdf.SetXY(pdfMargin, 10)
+	width := pageWidth - 2*pdfMargin
+	w.pdf.CellFormat(width, 5, w.text(w.opts.Title), "", 0, "L", false, 0, "")
+	w.pdf.SetX(pdfMargin)
+	w.pdf.CellFormat(width, 5, w.text(fmt.Sprintf("Page %d of {nb}", w.pdf.PageNo())), "", 0, "R", false, 0, "")
+	w.pdf.SetDrawColor(200, 200, 200)
+	w.pdf.Line(pdfMargin, 16, pageWidth-pdfMargin, 16)
+	w.pdf.SetY(pdfMargin)
+}
+
+// text encodes s for the proportional font.
+func (w *pdfWriter) text(s string) string {
+	if w.textUTF8 {
+		return s
+	}
+	return w.cp1252(s)
+}
+
+// mono encodes s for the monospace font.
+func (w *pdfWriter) mono(s string) string {
+	if w.monoUTF8 {
+		return s
+	}
+	return w.cp1252(s)
+}
+
+// contentWidth returns the usable width between the current margins.
+func (w *pdfWriter) contentWidth() float64 {
+	pageWidth, _ := w.pdf.GetPageSize()
+	left, _, right, _ := w.pdf.GetMargins()
+	return pageWidth - left - right
+}
+
+// wrap breaks s into lines no wider than width using the current font.
+func (w *pdfWriter) wrap(s string, width float64, encode func(string) string) []string {
+	var lines []string
+	line := ""
+	for _, r := range s {
+		candidate := line + string(r)
+		if line != "" && w.pdf.GetStringWidth(encode(candidate)) > width {
+			// Prefer breaking at the last space on the line.
+			if i := strings.LastIndex(line, " "); i > 0 && r != ' ' {
+				lines = append(lines, line[:i])
+				line = line[i+1:] + string(r)
+				continue
+			}
+			lines = append(lines, line)
+			line = string(r)
+			continue
+		}
+		line = candidate
+	}
+	return append(lines, line)
+}
+
+func (w *pdfWriter) output() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := w.pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// RenderTextPDF lays out plain text in a monospace font.
+func RenderTextPDF(content string, opts PDFExportOptions) ([]byte, error) {
+	w, err := newPDFWriter(opts)
+	if err != nil {
+		return nil, err
+	}
+	w.pdf.AddPage()
+	w.pdf.SetFont(w.monoFamily, "", pdfCodeSize)
+
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	gutter := 0.0
+	if opts.LineNumbers {
+		gutter = w.pdf.GetStringWidth(strconv.Itoa(len(lines))) + 4
+	}
+
+	for i, line := range lines {
+		line = strings.ReplaceAll(line, "\t", "    ")
+		for j, part := range w.wrap(line, w.contentWidth()-gutter, w.mono) {
+			if opts.LineNumbers {
+				number := ""
+				if j == 0 {
+					number = strconv.Itoa(i + 1)
+				}
+				w.pdf.SetTextColor(150, 150, 150)
+				w.pdf.CellFormat(gutter-2, pdfCodeHeight, number, "", 0, "R", false, 0, "")
+				w.pdf.CellFormat(2, pdfCodeHeight, "", "", 0, "L", false, 0, "")
+			}
+			w.pdf.SetTextColor(0, 0, 0)
+			w.pdf.CellFormat(0, pdfCodeHeight, w.mono(part), "", 1, "L", false, 0, "")
+		}
+	}
+	return w.output()
+}
+
+// RenderMarkdownPDF renders markdown with headings, lists, code blocks, tables and images.
+func RenderMarkdownPDF(source []byte, opts PDFExportOptions) ([]byte, error) {
+	doc := NewMarkdown().Parser().Parse(text.NewReader(source))
+
+	w, err := newPDFWriter(opts)
+	if err != nil {
+		return nil, err
+	}
+	w.pdf.AddPage()
+	w.pdf.SetFont(w.textFamily, "", pdfBodySize)
+	w.renderBlocks(doc, source)
+	return w.output()
+}
+
+func (w *pdfWriter) renderBlocks(n ast.Node, source []byte) {
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		w.renderBlock(c, source)
+	}
+}
+
+func (w *pdfWriter) renderBlock(n ast.Node, source []byte) {
+	switch n := n.(type) {
+	case *ast.Heading:
+		size := pdfHeadingSizes[min(n.Level, len(pdfHeadingSizes))-1]
+		w.pdf.Ln(3)
+		w.pdf.SetFont(w.textFamily, "B", size)
+		w.renderInline(n, source, inlineStyle{bold: true, size: size})
+		w.pdf.Ln(size * 0.5)
+		w.pdf.SetFont(w.textFamily, "", pdfBodySize)
+	case *ast.Paragraph:
+		w.renderInline(n, source, inlineStyle{size: pdfBodySize})
+		w.pdf.Ln(pdfLineHeight * 1.5)
+	case *ast.TextBlock:
+		w.renderInline(n, source, inlineStyle{size: pdfBodySize})
+		w.pdf.Ln(pdfLineHeight)
+	case *ast.List:
+		w.renderList(n, source)
+	case *ast.FencedCodeBlock, *ast.CodeBlock:
+		w.renderCode(n, source)
+	case *ast.Blockquote:
+		left, top, right, _ := w.pdf.GetMargins()
+		w.pdf.SetLeftMargin(left + 6)
+		w.pdf.SetX(left + 6)
+		w.pdf.SetTextColor(90, 90, 90)
+		w.renderBlocks(n, source)
+		w.pdf.SetTextColor(0, 0, 0)
+		w.pdf.SetMargins(left, top, right)
+		w.pdf.SetX(left)
+	case *ast.ThematicBreak:
+		left, _, right, _ := w.pdf.GetMargins()
+		pageWidth, _ := w.pdf.GetPageSize()
+		y := w.pdf.GetY() + 2
+		w.pdf.SetDrawColor(160, 160, 160)
+		w.pdf.Line(left, y, pageWidth-right, y)
+		w.pdf.Ln(6)
+	case *east.Table:
+		w.renderTable(n, source)
+	case *ast.HTMLBlock:
+		// Raw HTML has no PDF equivalent.
+	default:
+		w.renderBlocks(n, source)
+	}
+}
+
+func (w *pdfWriter) renderList(list *ast.List, source []byte) {
+	left, top, right, _ := w.pdf.GetMargins()
+	indent := 7.0
+	number := list.Start
+	for item := list.FirstChild(); item != nil; item = item.NextSibling() {
+		marker := "•"
+		if list.IsOrdered() {
+			marker = strconv.Itoa(number) + "."
+			number++
+		}
+		w.pdf.SetFont(w.textFamily, "", pdfBodySize)
+		w.pdf.SetX(left)
+		w.pdf.CellFormat(indent, pdfLineHeight, w.text(marker), "", 0, "L", false, 0, "")
+		w.pdf.SetLeftMargin(left + indent)
+		w.renderBlocks(item, source)
+		w.pdf.SetMargins(left, top, right)
+	}
+	w.pdf.SetX(left)
+	w.pdf.Ln(2)
+}
+
+func (w *pdfWriter) renderCode(n ast.Node, source []byte) {
+	w.pdf.SetFont(w.monoFamily, "", pdfCodeSize)
+	w.pdf.SetFillColor(242, 242, 242)
+	width := w.contentWidth()
+	lines := n.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		segment := lines.At(i)
+		line := strings.TrimRight(string(segment.Value(source)), "\r\n")
+		line = strings.ReplaceAll(line, "\t", "    ")
+		for _, part := range w.wrap(line, width-4, w.mono) {
+			w.pdf.CellFormat(width, pdfCodeHeight, w.mono(" "+part), "", 1, "L", true, 0, "")
+		}
+	}
+	w.pdf.SetFont(w.textFamily, "", pdfBodySize)
+	w.pdf.Ln(4)
+}
+
+func (w *pdfWriter) renderTable(table *east.Table, source []byte) {
+	columns := len(table.Alignments)
+	if columns == 0 {
+		return
+	}
+	left, _, _, bottom := w.pdf.GetMargins()
+	_, pageHeight := w.pdf.GetPageSize()
+	colWidth := w.contentWidth() / float64(columns)
+	lineHeight := 5.0
+
+	for row := table.FirstChild(); row != nil; row = row.NextSibling() {
+		_, header := row.(*east.TableHeader)
+		style := ""
+		if header {
+			style = "B"
+		}
+		w.pdf.SetFont(w.textFamily, style, pdfBodySize-1)
+
+		// Wrap every cell first so the row height is known.
+		var cells [][]string
+		rowLines := 1
+		for cell := row.FirstChild(); cell != nil; cell = cell.NextSibling() {
+			wrapped := w.wrap(NodeText(cell, source), colWidth-2, w.text)
+			cells = append(cells, wrapped)
+			rowLines = max(rowLines, len(wrapped))
+		}
+		rowHeight := float64(rowLines)*lineHeight + 2
+
+		if w.pdf.GetY()+rowHeight > pageHeight-bottom {
+			w.pdf.AddPage()
+		}
+		y := w.pdf.GetY()
+		for i := 0; i < columns; i++ {
+			x := left + float64(i)*colWidth
+			if header {
+				w.pdf.SetFillColor(235, 235, 235)
+				w.pdf.Rect(x, y, colWidth, rowHeight, "FD")
+			} else {
+				w.pdf.Rect(x, y, colWidth, rowHeight, "D")
+			}
+			if i >= len(cells) {
+				continue
+			}
+			align := "L"
+			switch table.Alignments[i] {
+			case east.AlignCenter:
+				align = "C"
+			case east.AlignRight:
+				align = "R"
+			}
+			for j, line := range cells[i] {
+				w.pdf.SetXY(x+1, y+1+float64(j)*lineHeight)
+				w.pdf.CellFormat(colWidth-2, lineHeight, w.text(line), "", 0, align, false, 0, "")
+			}
+		}
+		w.pdf.SetXY(left, y+rowHeight)
+	}
+	w.pdf.SetFont(w.textFamily, "", pdfBodySize)
+	w.pdf.Ln(4)
+}
+
+// inlineStyle tracks formatting while walking inline nodes.
+type inlineStyle struct {
+	bold, italic, code, strike bool
+	link                       string
+	size                       float64
+}
+
+func (w *pdfWriter) renderInline(n ast.Node, source []byte, style inlineStyle) {
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		switch c := c.(type) {
+		case *ast.Text:
+			w.write(string(c.Segment.Value(source)), style)
+			if c.HardLineBreak() {
+				w.pdf.Ln(pdfLineHeight)
+			} else if c.SoftLineBreak() {
+				w.write(" ", style)
+			}
+		case *ast.String:
+			w.write(string(c.Value), style)
+		case *ast.Emphasis:
+			s := style
+			if c.Level >= 2 {
+				s.bold = true
+			} else {
+				s.italic = true
+			}
+			w.renderInline(c, source, s)
+		case *ast.CodeSpan:
+			s := style
+			s.code = true
+			w.renderInline(c, source, s)
+		case *ast.Link:
+			s := style
+			s.link = string(c.Destination)
+			w.renderInline(c, source, s)
+		case *ast.AutoLink:
+			s := style
+			s.link = string(c.URL(source))
+			w.write(string(c.Label(source)), s)
+		case *ast.Image:
+			w.renderImage(c, source)
+		case *east.Strikethrough:
+			s := style
+			s.strike = true
+			w.renderInline(c, source, s)
+		case *east.FootnoteLink:
+			w.write(fmt.Sprintf("[%d]", c.Index), style)
+		case *east.FootnoteBacklink:
+			// Links back to the reference make no sense on paper.
+		case *east.TaskCheckBox:
+			if c.IsChecked {
+				w.write("[x] ", style)
+			} else {
+				w.write("[ ] ", style)
+			}
+		case *ast.RawHTML:
+			// Skipped, see HTMLBlock.
+		default:
+			w.renderInline(c, source, style)
+		}
+	}
+}
+
+// write outputs a run of text in the given style, wrapping at the margins.
+func (w *pdfWriter) write(s string, style inlineStyle) {
+	fontStyle := ""
+	if style.bold {
+		fontStyle += "B"
+	}
+	if style.italic {
+		fontStyle += "I"
+	}
+	if style.strike {
+		fontStyle += "S"
+	}
+	if style.link != "" {
+		fontStyle += "U"
+	}
+
+	encode := w.text
+	if style.code {
+		w.pdf.SetFont(w.monoFamily, strings.ReplaceAll(fontStyle, "I", ""), style.size-1)
+		encode = w.mono
+	} else {
+		w.pdf.SetFont(w.textFamily, fontStyle, style.size)
+	}
+
+	r, g, b := w.pdf.GetTextColor()
+	if style.link != "" {
+		w.pdf.SetTextColor(98, 0, 238)
+		w.pdf.WriteLinkString(pdfLineHeight, encode(s), style.link)
+	} else {
+		w.pdf.Write(pdfLineHeight, encode(s))
+	}
+	w.pdf.SetTextColor(r, g, b)
+}
+
+// renderImage places a local image scaled to fit the page width.
+func (w *pdfWriter) renderImage(img *ast.Image, source []byte) {
+	alt := NodeText(img, source)
+	data, err := w.loadImage(string(img.Destination))
+	if err != nil {
+		w.write(fmt.Sprintf("[image: %s]", alt), inlineStyle{italic: true, size: pdfBodySize})
+		return
+	}
+
+	w.images++
+	name := fmt.Sprintf("image%d", w.images)
+	info := w.pdf.RegisterImageOptionsReader(name, gofpdf.ImageOptions{ImageType: "PNG", ReadDpi: true}, bytes.NewReader(data))
+	if info == nil {
+		return
+	}
+	width, height := info.Extent()
+	if maxWidth := w.contentWidth(); width > maxWidth {
+		height = height * maxWidth / width
+		width = maxWidth
+	}
+
+	left, _, _, _ := w.pdf.GetMargins()
+	if w.pdf.GetX() > left {
+		w.pdf.Ln(pdfLineHeight)
+	}
+	w.pdf.ImageOptions(name, left, w.pdf.GetY(), width, height, true, gofpdf.ImageOptions{}, 0, "")
+	w.pdf.Ln(2)
+}
+
+// loadImage decodes a local image and re-encodes it as a plain 8-bit PNG,
+// which gofpdf can embed regardless of the original format or interlacing.
+func (w *pdfWriter) loadImage(dest string) ([]byte, error) {
+	path, err := ResolveImagePath(dest, w.opts.BaseDir)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	decoded, _, err := image.Decode(file)
+	if err != nil {
+		return nil, err
+	}
+	rgba := image.NewNRGBA(decoded.Bounds())
+	draw.Draw(rgba, rgba.Bounds(), decoded, decoded.Bounds().Min, draw.Src)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, rgba); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// IsMarkdownFile reports whether a file name looks like a markdown document.
+func IsMarkdownFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".md", ".markdown", ".mdown", ".mkd":
+		return true
+	}
+	return false
+}
+
+// ExportPDFFile converts a file on disk into a PDF, rendering markdown files.
+func ExportPDFFile(inPath, outPath string, opts PDFExportOptions) error {
+	source, err := os.ReadFile(inPath)
+	if err != nil {
+		return err
+	}
+	if opts.Title == "" {
+		opts.Title = filepath.Base(inPath)
+	}
+	if opts.BaseDir == "" {
+		opts.BaseDir = filepath.Dir(inPath)
+	}
+
+	var data []byte
+	if IsMarkdownFile(inPath) {
+		data, err = RenderMarkdownPDF(source, opts)
+	} else {
+		data, err = RenderTextPDF(string(source), opts)
+	}
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(outPath, data, 0644)
+}
+
+// opens a save dialog and exports the editor's content as a PDF.
+func ExportPDF(window fyne.Window, editor *widget.Entry, markdown, lineNumbers bool) {
+	config, err := LoadConfig("config.json")
+	if err != nil {
+		fmt.Println("Error loading config:", err)
+		config = &Config{}
+	}
+
+	opts := PDFExportOptions{
+		Title:       "Untitled",
+		LineNumbers: lineNumbers,
+		Fonts:       PDFFontsFromConfig(config),
+	}
+	if CurrentFile != nil {
+		opts.Title = CurrentFile.Name()
+		opts.BaseDir = filepath.Dir(CurrentFile.Path())
+	}
+
+	var data []byte
+	if markdown {
+		data, err = RenderMarkdownPDF([]byte(editorText(editor)), opts)
+	} else {
+		data, err = RenderTextPDF(editorText(editor), opts)
+	}
+	if err != nil {
+		dialog.ShowError(err, window)
+		return
+	}
+
+	saveDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		if writer == nil {
+			return
+		}
+		defer writer.Close()
+
+		_, err = writer.Write(data)
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+	}, window)
+	saveDialog.SetFileName(exportFileName(".pdf"))
+	saveDialog.Show()
+}
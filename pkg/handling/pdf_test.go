@@ -0,0 +1,56 @@
+package handling
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+)
+
+func TestRenderPDFWithFonts(t *testing.T) {
+	fonts, err := filepath.Abs(filepath.Join("..", "..", "assets", "fonts"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	configured := PDFFonts{
+		Default:   filepath.Join(fonts, "OpenSans-Regular.ttf"),
+		Bold:      filepath.Join(fonts, "OpenSans-Bold.ttf"),
+		Italic:    filepath.Join(fonts, "OpenSans-Italic.ttf"),
+		Monospace: filepath.Join(fonts, "Roboto-Regular.ttf"),
+	}
+
+	tests := []struct {
+		name     string
+		fonts    PDFFonts
+		markdown string
+	}{
+		{"built-in fonts", PDFFonts{}, "# Title\n\nSome *text*."},
+		{"configured fonts", configured, "# Title\n\nSome *text* and `code`, ünïcödé."},
+		{"styled inline code", configured, "[***`y`***](http://a) **`b`** *`i`* ~~`s`~~"},
+		{"styled inline code with built-in fonts", PDFFonts{}, "[***`y`***](http://a)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := PDFExportOptions{Title: "test.md", Fonts: tt.fonts}
+			for _, render := range []func() ([]byte, error){
+				func() ([]byte, error) { return RenderMarkdownPDF([]byte(tt.markdown), opts) },
+				func() ([]byte, error) { return RenderTextPDF(tt.markdown, opts) },
+			} {
+				data, err := render()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.HasPrefix(data, []byte("%PDF")) {
+					t.Errorf("output doesn't start with %%PDF: %q", data[:min(len(data), 10)])
+				}
+			}
+		})
+	}
+}
+
+func TestRenderPDFWithMissingFont(t *testing.T) {
+	// A font that can't be loaded is an error rather than a panic.
+	opts := PDFExportOptions{Fonts: PDFFonts{Default: "pdf_test.go"}}
+	if _, err := RenderMarkdownPDF([]byte("text"), opts); err == nil {
+		t.Error("RenderMarkdownPDF with a font that isn't a TTF file succeeded")
+	}
+}
@@ -0,0 +1,138 @@
+package handling
+
+import (
+	"os"
+	"slices"
+
+	"fyne.io/fyne/v2"
+)
+
+const (
+	recent_files   = "recent_files"
+	recent_folders = "recent_folders"
+	// pinned entries are stored under the list's key with this suffix.
+	pinned_suffix = "_pinned"
+
+	// maxRecent is how many unpinned entries are remembered.
+	maxRecent = 50
+)
+
+var (
+	// RecentFiles and RecentFolders are the recently opened files and workspace folders.
+	// They're kept in memory until LoadRecent connects them to the app preferences.
+	RecentFiles   = &RecentList{}
+	RecentFolders = &RecentList{}
+	// OnRecentChanged is called whenever either list changes.
+	OnRecentChanged func()
+)
+
+// RecentList is a most recent first list of paths, pinned paths are kept separately and never drop off.
+type RecentList struct {
+	key    string
+	prefs  fyne.Preferences
+	paths  []string
+	pinned []string
+}
+
+// LoadRecent reads the recent files and folders from the app preferences.
+func LoadRecent(prefs fyne.Preferences) {
+	RecentFiles = loadRecentList(prefs, recent_files)
+	RecentFolders = loadRecentList(prefs, recent_folders)
+}
+
+func loadRecentList(prefs fyne.Preferences, key string) *RecentList {
+	return &RecentList{
+		key:    key,
+		prefs:  prefs,
+		paths:  prefs.StringList(key),
+		pinned: prefs.StringList(key + pinned_suffix),
+	}
+}
+
+// save stores the list and tells the UI.
+func (r *RecentList) save() {
+	if r.prefs != nil {
+		r.prefs.SetStringList(r.key, r.paths)
+		r.prefs.SetStringList(r.key+pinned_suffix, r.pinned)
+	}
+	if OnRecentChanged != nil {
+		OnRecentChanged()
+	}
+}
+
+// Add moves path to the front of the list, pinned paths keep their place.
+func (r *RecentList) Add(path string) {
+	if slices.Contains(r.pinned, path) {
+		return
+	}
+	r.paths = append([]string{path}, slices.DeleteFunc(r.paths, func(p string) bool { return p == path })...)
+	if len(r.paths) > maxRecent {
+		r.paths = r.paths[:maxRecent]
+	}
+	r.save()
+}
+
+// Paths returns the unpinned entries, most recent first.
+func (r *RecentList) Paths() []string {
+	return r.paths
+}
+
+// Pinned returns the pinned entries in the order they were pinned.
+func (r *RecentList) Pinned() []string {
+	return r.pinned
+}
+
+// IsPinned reports whether path is pinned.
+func (r *RecentList) IsPinned(path string) bool {
+	return slices.Contains(r.pinned, path)
+}
+
+// SetPinned pins or unpins path, an unpinned path goes back to the top of the recent entries.
+func (r *RecentList) SetPinned(path string, pinned bool) {
+	r.pinned = slices.DeleteFunc(r.pinned, func(p string) bool { return p == path })
+	r.paths = slices.DeleteFunc(r.paths, func(p string) bool { return p == path })
+	if pinned {
+		r.pinned = append(r.pinned, path)
+	} else {
+		r.paths = append([]string{path}, r.paths...)
+	}
+	r.save()
+}
+
+// Remove forgets path, pinned or not.
+func (r *RecentList) Remove(path string) {
+	r.pinned = slices.DeleteFunc(r.pinned, func(p string) bool { return p == path })
+	r.paths = slices.DeleteFunc(r.paths, func(p string) bool { return p == path })
+	r.save()
+}
+
+// Clear forgets every entry that isn't pinned.
+func (r *RecentList) Clear() {
+	r.paths = nil
+	r.save()
+}
+
+// Prune removes entries whose files or folders no longer exist.
+func (r *RecentList) Prune() {
+	missing := func(path string) bool {
+		_, err := os.Stat(path)
+		return os.IsNotExist(err)
+	}
+	pinned, paths := len(r.pinned), len(r.paths)
+	r.pinned = slices.DeleteFunc(r.pinned, missing)
+	r.paths = slices.DeleteFunc(r.paths, missing)
+	if len(r.pinned) != pinned || len(r.paths) != paths {
+		r.save()
+	}
+}
+
+// index returns the position of path counting pinned entries first, -1 if it isn't listed.
+func (r *RecentList) index(path string) int {
+	if i := slices.Index(r.pinned, path); i >= 0 {
+		return i
+	}
+	if i := slices.Index(r.paths, path); i >= 0 {
+		return len(r.pinned) + i
+	}
+	return -1
+}
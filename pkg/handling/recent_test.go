@@ -0,0 +1,89 @@
+package handling
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"fyne.io/fyne/v2/test"
+)
+
+func TestRecentList(t *testing.T) {
+	r := &RecentList{}
+	r.Add("a")
+	r.Add("b")
+	r.Add("a")
+	if want := []string{"a", "b"}; !reflect.DeepEqual(r.Paths(), want) {
+		t.Errorf("Paths = %q, want %q", r.Paths(), want)
+	}
+
+	r.SetPinned("b", true)
+	r.Add("b")
+	if !r.IsPinned("b") || !reflect.DeepEqual(r.Pinned(), []string{"b"}) || !reflect.DeepEqual(r.Paths(), []string{"a"}) {
+		t.Errorf("after pinning b: pinned %q, paths %q", r.Pinned(), r.Paths())
+	}
+	if r.index("b") != 0 || r.index("a") != 1 || r.index("c") != -1 {
+		t.Errorf("index counts pinned entries first: b %d, a %d, c %d", r.index("b"), r.index("a"), r.index("c"))
+	}
+
+	r.Clear()
+	if len(r.Paths()) != 0 || !r.IsPinned("b") {
+		t.Errorf("Clear left paths %q and pinned %q", r.Paths(), r.Pinned())
+	}
+	r.SetPinned("b", false)
+	if r.IsPinned("b") || !reflect.DeepEqual(r.Paths(), []string{"b"}) {
+		t.Errorf("after unpinning b: pinned %q, paths %q", r.Pinned(), r.Paths())
+	}
+	r.Remove("b")
+	if len(r.Paths()) != 0 {
+		t.Errorf("Remove left %q", r.Paths())
+	}
+}
+
+func TestRecentListLimit(t *testing.T) {
+	r := &RecentList{}
+	for i := 0; i < maxRecent+5; i++ {
+		r.Add(string(rune('a'+i%26)) + string(rune('0'+i/26)))
+	}
+	if len(r.Paths()) != maxRecent {
+		t.Errorf("kept %d paths, want %d", len(r.Paths()), maxRecent)
+	}
+}
+
+func TestRecentListPrune(t *testing.T) {
+	dir := t.TempDir()
+	kept := filepath.Join(dir, "kept.md")
+	if err := os.WriteFile(kept, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	r := &RecentList{}
+	r.Add(filepath.Join(dir, "gone.md"))
+	r.Add(kept)
+	r.SetPinned(filepath.Join(dir, "gone"), true)
+	r.Prune()
+	if !reflect.DeepEqual(r.Paths(), []string{kept}) || len(r.Pinned()) != 0 {
+		t.Errorf("Prune left paths %q and pinned %q", r.Paths(), r.Pinned())
+	}
+}
+
+func TestLoadRecent(t *testing.T) {
+	files, folders, onChanged := RecentFiles, RecentFolders, OnRecentChanged
+	t.Cleanup(func() { RecentFiles, RecentFolders, OnRecentChanged = files, folders, onChanged })
+	changes := 0
+	OnRecentChanged = func() { changes++ }
+
+	prefs := test.NewTempApp(t).Preferences()
+	LoadRecent(prefs)
+	RecentFiles.Add("a.md")
+	RecentFiles.SetPinned("b.md", true)
+	RecentFolders.Add("src")
+
+	LoadRecent(prefs)
+	if !reflect.DeepEqual(RecentFiles.Paths(), []string{"a.md"}) || !RecentFiles.IsPinned("b.md") || !reflect.DeepEqual(RecentFolders.Paths(), []string{"src"}) {
+		t.Errorf("reloaded files %q pinned %q and folders %q", RecentFiles.Paths(), RecentFiles.Pinned(), RecentFolders.Paths())
+	}
+	if changes != 3 {
+		t.Errorf("OnRecentChanged was called %d times, want 3", changes)
+	}
+}
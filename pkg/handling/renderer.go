@@ -1 +1,348 @@
 package handling
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/yuin/goldmark/ast"
+	east "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// MarkdownRenderer converts a goldmark document into rich text segments for the preview.
+// Unlike widget.RichText.ParseMarkdown it understands the GFM extensions and footnotes.
+type MarkdownRenderer struct {
+	// Image returns the segment showing an image, images are left out without it.
+	Image func(*ast.Image) widget.RichTextSegment
+	// ToggleTask is called when a task's checkbox is clicked, with the position of its marker's "[" in the source, in bytes.
+	ToggleTask func(offset int, checked bool)
+
+	source []byte
+}
+
+// Segments builds the preview segments for the given source.
+func (r *MarkdownRenderer) Segments(input string) []widget.RichTextSegment {
+	r.source = []byte(input)
+	doc := NewMarkdown().Parser().Parse(text.NewReader(r.source))
+	return r.blocks(doc, false)
+}
+
+func (r *MarkdownRenderer) blocks(n ast.Node, blockquote bool) []widget.RichTextSegment {
+	var segs []widget.RichTextSegment
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		segs = append(segs, r.block(c, blockquote)...)
+	}
+	return segs
+}
+
+func (r *MarkdownRenderer) block(n ast.Node, blockquote bool) []widget.RichTextSegment {
+	base := widget.RichTextStyleInline
+	if blockquote {
+		base = widget.RichTextStyleBlockquote
+	}
+
+	switch n := n.(type) {
+	case *ast.Paragraph:
+		segs := r.inlines(n, base)
+		if !blockquote {
+			segs = append(segs, &widget.TextSegment{Style: widget.RichTextStyleParagraph})
+		}
+		return segs
+	case *ast.TextBlock:
+		return r.inlines(n, base)
+	case *ast.Heading:
+		text := NodeText(n, r.source)
+		switch n.Level {
+		case 1:
+			return []widget.RichTextSegment{&widget.TextSegment{Style: widget.RichTextStyleHeading, Text: text}}
+		case 2:
+			return []widget.RichTextSegment{&widget.TextSegment{Style: widget.RichTextStyleSubHeading, Text: text}}
+		default:
+			style := widget.RichTextStyleParagraph
+			style.TextStyle.Bold = true
+			return []widget.RichTextSegment{&widget.TextSegment{Style: style, Text: text}}
+		}
+	case *ast.List:
+		var items []widget.RichTextSegment
+		for item := n.FirstChild(); item != nil; item = item.NextSibling() {
+			items = append(items, &widget.ParagraphSegment{Texts: r.blocks(item, blockquote)})
+		}
+		return []widget.RichTextSegment{&widget.ListSegment{Items: items, Ordered: n.IsOrdered()}}
+	case *ast.ThematicBreak:
+		return []widget.RichTextSegment{&widget.SeparatorSegment{}}
+	case *ast.CodeBlock, *ast.FencedCodeBlock:
+		var code strings.Builder
+		lines := n.Lines()
+		for i := 0; i < lines.Len(); i++ {
+			segment := lines.At(i)
+			code.Write(segment.Value(r.source))
+		}
+		if code.Len() == 0 {
+			return nil
+		}
+		return []widget.RichTextSegment{&widget.TextSegment{
+			Style: widget.RichTextStyleCodeBlock,
+			Text:  strings.TrimSuffix(code.String(), "\n"),
+		}}
+	case *ast.Blockquote:
+		return r.blocks(n, true)
+	case *east.Table:
+		return []widget.RichTextSegment{r.table(n)}
+	case *east.FootnoteList:
+		segs := []widget.RichTextSegment{&widget.SeparatorSegment{}}
+		for fn := n.FirstChild(); fn != nil; fn = fn.NextSibling() {
+			index := 0
+			if footnote, ok := fn.(*east.Footnote); ok {
+				index = footnote.Index
+			}
+			texts := []widget.RichTextSegment{&widget.TextSegment{
+				Style: widget.RichTextStyleStrong,
+				Text:  fmt.Sprintf("[%d] ", index),
+			}}
+			texts = append(texts, r.blocks(fn, true)...)
+			segs = append(segs, &widget.ParagraphSegment{Texts: texts})
+		}
+		return segs
+	case *ast.HTMLBlock:
+		// Raw HTML is not rendered in the preview.
+		return nil
+	}
+	return r.blocks(n, blockquote)
+}
+
+// inlines renders the inline children of n, applying style to plain text.
+func (r *MarkdownRenderer) inlines(n ast.Node, style widget.RichTextStyle) []widget.RichTextSegment {
+	var segs []widget.RichTextSegment
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		switch c := c.(type) {
+		case *ast.Text:
+			text := string(c.Segment.Value(r.source))
+			if c.SoftLineBreak() || c.HardLineBreak() {
+				text += " "
+			}
+			if text == "" {
+				continue
+			}
+			segs = append(segs, &widget.TextSegment{Style: style, Text: text})
+		case *ast.String:
+			segs = append(segs, &widget.TextSegment{Style: style, Text: string(c.Value)})
+		case *ast.Emphasis:
+			s := style
+			if c.Level >= 2 {
+				s.TextStyle.Bold = true
+			} else {
+				s.TextStyle.Italic = true
+			}
+			segs = append(segs, r.inlines(c, s)...)
+		case *ast.CodeSpan:
+			segs = append(segs, &widget.TextSegment{
+				Style: widget.RichTextStyleCodeInline,
+				Text:  NodeText(c, r.source),
+			})
+		case *east.Strikethrough:
+			s := style
+			s.ColorName = theme.ColorNameDisabled
+			for _, seg := range r.inlines(c, s) {
+				if t, ok := seg.(*widget.TextSegment); ok {
+					t.Text = strikeThrough(t.Text)
+				}
+				segs = append(segs, seg)
+			}
+		case *ast.Link:
+			link, _ := url.Parse(string(c.Destination))
+			segs = append(segs, &widget.HyperlinkSegment{
+				Alignment: fyne.TextAlignLeading,
+				Text:      NodeText(c, r.source),
+				URL:       link,
+			})
+		case *ast.AutoLink:
+			link, _ := url.Parse(string(c.URL(r.source)))
+			segs = append(segs, &widget.HyperlinkSegment{
+				Alignment: fyne.TextAlignLeading,
+				Text:      string(c.Label(r.source)),
+				URL:       link,
+			})
+		case *ast.Image:
+			if r.Image != nil {
+				segs = append(segs, r.Image(c))
+			}
+		case *east.TaskCheckBox:
+			segs = append(segs, r.taskCheckBox(c))
+		case *east.FootnoteLink:
+			s := style
+			s.ColorName = theme.ColorNamePrimary
+			segs = append(segs, &widget.TextSegment{Style: s, Text: fmt.Sprintf("[%d]", c.Index)})
+		case *east.FootnoteBacklink, *ast.RawHTML:
+			// Not shown in the preview.
+		default:
+			segs = append(segs, r.inlines(c, style)...)
+		}
+	}
+	return segs
+}
+
+// strikeThrough overlays a combining long stroke on every character.
+func strikeThrough(s string) string {
+	var sb strings.Builder
+	for _, c := range s {
+		sb.WriteRune(c)
+		if c != ' ' {
+			sb.WriteRune('̶')
+		}
+	}
+	return sb.String()
+}
+
+// taskCheckBox creates a clickable checkbox that toggles the marker in the source.
+func (r *MarkdownRenderer) taskCheckBox(box *east.TaskCheckBox) widget.RichTextSegment {
+	seg := &TaskSegment{Checked: box.IsChecked, Offset: -1}
+
+	// The checkbox has no position of its own, its block's first line starts with the marker.
+	if parent := box.Parent(); parent != nil && parent.Lines().Len() > 0 {
+		start := parent.Lines().At(0).Start
+		if i := strings.IndexByte(string(r.source[start:]), '['); i >= 0 {
+			seg.Offset = start + i
+		}
+	}
+
+	if toggle := r.ToggleTask; toggle != nil {
+		seg.OnChanged = func(checked bool) {
+			toggle(seg.Offset, checked)
+		}
+	}
+	return seg
+}
+
+// TaskSegment is an inline checkbox for GFM task list items.
+type TaskSegment struct {
+	Checked bool
+	// Offset is the position of the marker's "[" in the source, in bytes, -1 if it wasn't found.
+	Offset    int
+	OnChanged func(bool)
+}
+
+// Inline returns true as the checkbox sits at the start of the item text.
+func (t *TaskSegment) Inline() bool {
+	return true
+}
+
+// Textual returns the markdown marker for this checkbox.
+func (t *TaskSegment) Textual() string {
+	if t.Checked {
+		return "[x]"
+	}
+	return "[ ]"
+}
+
+// Visual returns a new checkbox widget.
+func (t *TaskSegment) Visual() fyne.CanvasObject {
+	check := widget.NewCheck("", nil)
+	t.Update(check)
+	return check
+}
+
+// Update applies the checked state to an existing checkbox.
+func (t *TaskSegment) Update(o fyne.CanvasObject) {
+	check := o.(*widget.Check)
+	check.OnChanged = nil
+	check.SetChecked(t.Checked)
+	check.OnChanged = t.OnChanged
+}
+
+func (t *TaskSegment) Select(_, _ fyne.Position) {}
+
+func (t *TaskSegment) SelectedText() string {
+	return ""
+}
+
+func (t *TaskSegment) Unselect() {}
+
+// TableSegment shows a GFM table as an aligned grid.
+type TableSegment struct {
+	Header     []string
+	Rows       [][]string
+	Alignments []fyne.TextAlign
+}
+
+func (r *MarkdownRenderer) table(table *east.Table) widget.RichTextSegment {
+	seg := &TableSegment{}
+	for _, a := range table.Alignments {
+		switch a {
+		case east.AlignCenter:
+			seg.Alignments = append(seg.Alignments, fyne.TextAlignCenter)
+		case east.AlignRight:
+			seg.Alignments = append(seg.Alignments, fyne.TextAlignTrailing)
+		default:
+			seg.Alignments = append(seg.Alignments, fyne.TextAlignLeading)
+		}
+	}
+	for row := table.FirstChild(); row != nil; row = row.NextSibling() {
+		var cells []string
+		for cell := row.FirstChild(); cell != nil; cell = cell.NextSibling() {
+			cells = append(cells, NodeText(cell, r.source))
+		}
+		if _, ok := row.(*east.TableHeader); ok {
+			seg.Header = cells
+		} else {
+			seg.Rows = append(seg.Rows, cells)
+		}
+	}
+	return seg
+}
+
+// Inline returns false as tables are blocks.
+func (t *TableSegment) Inline() bool {
+	return false
+}
+
+// Textual returns the table as tab separated lines.
+func (t *TableSegment) Textual() string {
+	lines := []string{strings.Join(t.Header, "\t")}
+	for _, row := range t.Rows {
+		lines = append(lines, strings.Join(row, "\t"))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Visual returns a grid of labels, one column per table column.
+func (t *TableSegment) Visual() fyne.CanvasObject {
+	columns := len(t.Alignments)
+	if columns == 0 {
+		return container.NewVBox()
+	}
+
+	var cells []fyne.CanvasObject
+	addRow := func(row []string, style fyne.TextStyle) {
+		for i := 0; i < columns; i++ {
+			text := ""
+			if i < len(row) {
+				text = row[i]
+			}
+			cells = append(cells, widget.NewLabelWithStyle(text, t.Alignments[i], style))
+		}
+	}
+	addRow(t.Header, fyne.TextStyle{Bold: true})
+	for i := 0; i < columns; i++ {
+		cells = append(cells, widget.NewSeparator())
+	}
+	for _, row := range t.Rows {
+		addRow(row, fyne.TextStyle{})
+	}
+	return container.NewGridWithColumns(columns, cells...)
+}
+
+// Update is a no-op, a new grid is created whenever the table changes.
+func (t *TableSegment) Update(fyne.CanvasObject) {}
+
+func (t *TableSegment) Select(_, _ fyne.Position) {}
+
+func (t *TableSegment) SelectedText() string {
+	return ""
+}
+
+func (t *TableSegment) Unselect() {}
@@ -0,0 +1,97 @@
+package handling
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+	"github.com/yuin/goldmark/ast"
+)
+
+// findSegments returns the segments of type T in segs, looking inside lists and paragraphs.
+func findSegments[T widget.RichTextSegment](segs []widget.RichTextSegment) []T {
+	var found []T
+	for _, seg := range segs {
+		switch s := seg.(type) {
+		case T:
+			found = append(found, s)
+		case *widget.ListSegment:
+			found = append(found, findSegments[T](s.Items)...)
+		case *widget.ParagraphSegment:
+			found = append(found, findSegments[T](s.Texts)...)
+		}
+	}
+	return found
+}
+
+func TestMarkdownRendererTasks(t *testing.T) {
+	source := "- [x] done\n- [ ] todo\n  - [ ] nested\n\n> - [X] quoted\n>   - [ ] quoted nested\n\n1. [ ] ordered ünïcode\n"
+	var toggled []int
+	r := &MarkdownRenderer{ToggleTask: func(offset int, checked bool) { toggled = append(toggled, offset) }}
+	tasks := findSegments[*TaskSegment](r.Segments(source))
+
+	want := []string{"done", "todo", "nested", "quoted", "quoted nested", "ordered ünïcode"}
+	if len(tasks) != len(want) {
+		t.Fatalf("found %d tasks, want %d", len(tasks), len(want))
+	}
+	for i, task := range tasks {
+		if task.Offset < 0 || source[task.Offset] != '[' || source[task.Offset+2] != ']' {
+			t.Errorf("task %q is at %d", want[i], task.Offset)
+			continue
+		}
+		if rest := source[task.Offset+4:]; !strings.HasPrefix(rest, want[i]) {
+			t.Errorf("task at %d is followed by %q, want %q", task.Offset, rest, want[i])
+		}
+		task.OnChanged(!task.Checked)
+	}
+	if len(toggled) != len(tasks) || toggled[2] != tasks[2].Offset {
+		t.Errorf("toggled %v", toggled)
+	}
+	if !tasks[0].Checked || tasks[1].Checked || !tasks[3].Checked {
+		t.Errorf("checked = %v, %v, %v", tasks[0].Checked, tasks[1].Checked, tasks[3].Checked)
+	}
+}
+
+func TestMarkdownRendererTable(t *testing.T) {
+	r := &MarkdownRenderer{}
+	tables := findSegments[*TableSegment](r.Segments("| a | b | c |\n|:--|:-:|--:|\n| 1 | **2** | 3 |\n"))
+	if len(tables) != 1 {
+		t.Fatalf("found %d tables", len(tables))
+	}
+	want := &TableSegment{
+		Header:     []string{"a", "b", "c"},
+		Rows:       [][]string{{"1", "2", "3"}},
+		Alignments: []fyne.TextAlign{fyne.TextAlignLeading, fyne.TextAlignCenter, fyne.TextAlignTrailing},
+	}
+	if !reflect.DeepEqual(tables[0], want) {
+		t.Errorf("table = %+v, want %+v", tables[0], want)
+	}
+	if got := tables[0].Textual(); got != "a\tb\tc\n1\t2\t3" {
+		t.Errorf("Textual() = %q", got)
+	}
+}
+
+func TestMarkdownRendererInlines(t *testing.T) {
+	var images []string
+	r := &MarkdownRenderer{Image: func(img *ast.Image) widget.RichTextSegment {
+		images = append(images, string(img.Destination))
+		return &widget.TextSegment{Text: "image"}
+	}}
+	texts := findSegments[*widget.TextSegment](r.Segments("~~no way~~ *yes* ![x](pic.png)\n"))
+	var got []string
+	for _, text := range texts {
+		got = append(got, text.Text)
+	}
+	want := []string{"n̶o̶ w̶a̶y̶", " ", "yes", " ", "image", ""}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("texts = %q, want %q", got, want)
+	}
+	if !texts[2].Style.TextStyle.Italic {
+		t.Error("emphasis isn't italic")
+	}
+	if !reflect.DeepEqual(images, []string{"pic.png"}) {
+		t.Errorf("images = %q", images)
+	}
+}
@@ -0,0 +1,94 @@
+package handling
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// interpreters maps file extensions to the command that runs them, the file path is appended.
+var interpreters = map[string]string{
+	".sh":   "sh",
+	".bash": "bash",
+	".zsh":  "zsh",
+	".fish": "fish",
+	".py":   "python3",
+	".rb":   "ruby",
+	".pl":   "perl",
+	".php":  "php",
+	".lua":  "lua",
+	".r":    "Rscript",
+	".js":   "node",
+	".mjs":  "node",
+	".ts":   "npx tsx",
+	".go":   "go run",
+	".java": "java",
+	".ps1":  "pwsh -File",
+}
+
+// Bracketed paste markers tell the shell that text was pasted, so it isn't run line by line.
+const (
+	bracketedPasteStart = "\x1b[200~"
+	bracketedPasteEnd   = "\x1b[201~"
+)
+
+// RunCommand returns the shell command that runs the file at path.
+// A "#!" line picks the interpreter, otherwise it's chosen by extension, with overrides from config.json.
+func RunCommand(path string, overrides map[string]string) (string, error) {
+	interpreter := shebang(path)
+	if interpreter == "" {
+		ext := strings.ToLower(filepath.Ext(path))
+		interpreter = overrides[ext]
+		if interpreter == "" {
+			interpreter = interpreters[ext]
+		}
+	}
+	if interpreter == "" {
+		return "", fmt.Errorf("don't know how to run %s files, add an interpreter for them to config.json", filepath.Ext(path))
+	}
+	return interpreter + " " + ShellQuote(path), nil
+}
+
+// shebang returns the interpreter named on the first line of a script, "" if there is none.
+func shebang(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	line, _ := bufio.NewReader(file).ReadString('\n')
+	if !strings.HasPrefix(line, "#!") {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimPrefix(line, "#!"))
+}
+
+// ShellQuote quotes a path for the shell the terminal runs, leaving plain paths as they are.
+func ShellQuote(path string) string {
+	plain := "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789/._-+:@"
+	if runtime.GOOS == "windows" {
+		plain += `\`
+	}
+	if strings.Trim(path, plain) == "" {
+		return path
+	}
+	if runtime.GOOS == "windows" {
+		return `"` + path + `"`
+	}
+	return "'" + strings.ReplaceAll(path, "'", `'\''`) + "'"
+}
+
+// TerminalInput prepares text to be typed into a shell, ending it with a newline so it runs.
+// Multi-line text is wrapped in bracketed paste markers when bracketed is set.
+func TerminalInput(text string, bracketed bool) string {
+	text = strings.TrimRight(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	if bracketed && strings.Contains(text, "\n") {
+		text = bracketedPasteStart + text + bracketedPasteEnd
+	}
+	// Shells read Enter as a carriage return.
+	return strings.ReplaceAll(text, "\n", "\r") + "\r"
+}
@@ -0,0 +1,79 @@
+package handling
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestRunCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("quoting differs on Windows")
+	}
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	tests := []struct {
+		name      string
+		path      string
+		overrides map[string]string
+		want      string
+		wantErr   bool
+	}{
+		{"by extension", write("hello.py", "print(1)\n"), nil, "python3 " + filepath.Join(dir, "hello.py"), false},
+		{"upper case extension", write("HELLO.PY", "print(1)\n"), nil, "python3 " + filepath.Join(dir, "HELLO.PY"), false},
+		{"shebang", write("tool", "#!/usr/bin/env python3\nprint(1)\n"), nil, "/usr/bin/env python3 " + filepath.Join(dir, "tool"), false},
+		{"override", write("main.go", "package main\n"), map[string]string{".go": "go run -race"}, "go run -race " + filepath.Join(dir, "main.go"), false},
+		{"quoted path", write("my script.sh", "echo hi\n"), nil, "sh '" + filepath.Join(dir, "my script.sh") + "'", false},
+		{"unknown", write("notes.txt", "text\n"), nil, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RunCommand(tt.path, tt.overrides)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("RunCommand(%q) = %q, %v, want %q", tt.path, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("quoting differs on Windows")
+	}
+	tests := []struct{ path, want string }{
+		{"/src/main.go", "/src/main.go"},
+		{"/src/my file.go", "'/src/my file.go'"},
+		{"/src/it's.go", `'/src/it'\''s.go'`},
+		{"$HOME/x", "'$HOME/x'"},
+	}
+	for _, tt := range tests {
+		if got := ShellQuote(tt.path); got != tt.want {
+			t.Errorf("ShellQuote(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestTerminalInput(t *testing.T) {
+	tests := []struct {
+		text      string
+		bracketed bool
+		want      string
+	}{
+		{"ls", true, "ls\r"},
+		{"ls\n\n", false, "ls\r"},
+		{"a\r\nb\n", false, "a\rb\r"},
+		{"a\nb", true, "\x1b[200~a\rb\x1b[201~\r"},
+	}
+	for _, tt := range tests {
+		if got := TerminalInput(tt.text, tt.bracketed); got != tt.want {
+			t.Errorf("TerminalInput(%q, %v) = %q, want %q", tt.text, tt.bracketed, got, tt.want)
+		}
+	}
+}
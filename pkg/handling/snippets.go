@@ -0,0 +1,518 @@
+package handling
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// GlobalSnippets is the language of the snippets file used for every kind of file.
+const GlobalSnippets = "global"
+
+// Snippet is boilerplate inserted by typing its prefix or picking it from the list.
+type Snippet struct {
+	Name        string
+	Prefixes    []string
+	Body        string
+	Description string
+	// Language is the name of the file the snippet came from, such as "go" or "global".
+	Language string
+}
+
+// snippetLanguages names the snippets file used for each file extension.
+var snippetLanguages = map[string]string{
+	".go":       "go",
+	".py":       "python",
+	".rs":       "rust",
+	".c":        "c",
+	".h":        "c",
+	".cpp":      "cpp",
+	".hpp":      "cpp",
+	".js":       "javascript",
+	".jsx":      "javascript",
+	".ts":       "typescript",
+	".tsx":      "typescript",
+	".sh":       "shellscript",
+	".bash":     "shellscript",
+	".json":     "json",
+	".html":     "html",
+	".css":      "css",
+	".yaml":     "yaml",
+	".yml":      "yaml",
+	".txt":      "plaintext",
+	".md":       "markdown",
+	".markdown": "markdown",
+	".mdown":    "markdown",
+	".mkd":      "markdown",
+}
+
+// SnippetLanguage returns the language whose snippets are offered in a file.
+// Unsaved files are treated as markdown, like the outline does.
+func SnippetLanguage(path string) string {
+	if path == "" {
+		return "markdown"
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	if language, ok := snippetLanguages[ext]; ok {
+		return language
+	}
+	if ext == "" {
+		return "plaintext"
+	}
+	return strings.TrimPrefix(ext, ".")
+}
+
+// ConfigDir returns the folder holding config.json: next to the executable if it's there,
+// otherwise the working directory, the same places OpenConfigFile looks.
+func ConfigDir() string {
+	if execPath, err := os.Executable(); err == nil {
+		if _, err := os.Stat(filepath.Join(filepath.Dir(execPath), "config.json")); err == nil {
+			return filepath.Dir(execPath)
+		}
+	}
+	workdir, _ := os.Getwd()
+	return workdir
+}
+
+// SnippetsPath returns the snippets file of a language, in the snippets folder next to config.json.
+func SnippetsPath(language string) string {
+	return filepath.Join(ConfigDir(), "snippets", language+".json")
+}
+
+// stringList is a JSON string or list of strings.
+type stringList []string
+
+func (l *stringList) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*l = stringList{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*l = many
+	return nil
+}
+
+// LoadSnippets reads the global snippets and those of a language, sorted by name.
+// Missing files are fine, ones that can't be read are reported along with the snippets that could be.
+func LoadSnippets(language string) ([]Snippet, error) {
+	var snippets []Snippet
+	var errs []error
+	for _, lang := range []string{GlobalSnippets, language} {
+		data, err := os.ReadFile(SnippetsPath(lang))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		var file map[string]struct {
+			Prefix      stringList `json:"prefix"`
+			Body        stringList `json:"body"`
+			Description string     `json:"description"`
+		}
+		if err := json.Unmarshal(data, &file); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", SnippetsPath(lang), err))
+			continue
+		}
+		for name, s := range file {
+			snippets = append(snippets, Snippet{
+				Name:        name,
+				Prefixes:    s.Prefix,
+				Body:        strings.Join(s.Body, "\n"),
+				Description: s.Description,
+				Language:    lang,
+			})
+		}
+	}
+	sort.Slice(snippets, func(i, j int) bool {
+		return snippets[i].Name < snippets[j].Name
+	})
+	return snippets, errors.Join(errs...)
+}
+
+// exampleSnippets are written to a new snippets file, so there's something to start from.
+var exampleSnippets = map[string]string{
+	"go": `{
+	"Test function": {
+		"prefix": "test",
+		"body": [
+			"func Test${1:Name}(t *testing.T) {",
+			"\t$0",
+			"}"
+		],
+		"description": "Go test function"
+	},
+	"Error check": {
+		"prefix": "iferr",
+		"body": [
+			"if err != nil {",
+			"\treturn ${1:err}",
+			"}"
+		]
+	}
+}
+`,
+	"markdown": `{
+	"Front matter": {
+		"prefix": "front",
+		"body": [
+			"---",
+			"title: ${1:$FILENAME_BASE}",
+			"date: $DATE",
+			"tags: [${2|draft,notes,published|}]",
+			"---",
+			"",
+			"# ${1}",
+			"",
+			"$0"
+		],
+		"description": "YAML front matter"
+	}
+}
+`,
+	GlobalSnippets: `{
+	"License header": {
+		"prefix": "license",
+		"body": [
+			"Copyright (c) $YEAR ${1:Author}",
+			"Licensed under the ${2|MIT,Apache-2.0,GPL-3.0|} license.",
+			"$0"
+		],
+		"description": "Copyright and license notice"
+	}
+}
+`,
+}
+
+// CreateSnippetsFile returns the snippets file of a language, creating it with an example if it doesn't exist.
+func CreateSnippetsFile(language string) (string, error) {
+	path := SnippetsPath(language)
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	example, ok := exampleSnippets[language]
+	if !ok {
+		example = `{
+	"Example": {
+		"prefix": "example",
+		"body": ["${1:first} ${2:second} $0"],
+		"description": "Tab moves from placeholder to placeholder"
+	}
+}
+`
+	}
+	return path, os.WriteFile(path, []byte(example), 0644)
+}
+
+// SnippetVariables returns the values of the variables a snippet can use, such as $FILENAME and $DATE.
+// The TextMate and VS Code names, such as $TM_FILENAME and $CURRENT_YEAR, work too.
+func SnippetVariables(path, selection, clipboard, line string, row int, now time.Time) map[string]string {
+	name := filepath.Base(path)
+	if path == "" {
+		name = "Untitled"
+	}
+	vars := map[string]string{
+		"FILENAME":       name,
+		"FILENAME_BASE":  strings.TrimSuffix(name, filepath.Ext(name)),
+		"FILEPATH":       path,
+		"DIRECTORY":      filepath.Dir(path),
+		"SELECTION":      selection,
+		"CLIPBOARD":      clipboard,
+		"LINE":           line,
+		"LINE_NUMBER":    strconv.Itoa(row + 1),
+		"WORKSPACE_NAME": filepath.Base(WorkspaceRoot),
+		"DATE":           now.Format("2006-01-02"),
+		"TIME":           now.Format("15:04:05"),
+		"YEAR":           now.Format("2006"),
+		"MONTH":          now.Format("01"),
+		"DAY":            now.Format("02"),
+		"HOUR":           now.Format("15"),
+		"MINUTE":         now.Format("04"),
+		"SECOND":         now.Format("05"),
+		"MONTH_NAME":     now.Format("January"),
+		"DAY_NAME":       now.Format("Monday"),
+	}
+	if path == "" {
+		vars["DIRECTORY"] = ""
+	}
+	if WorkspaceRoot == "" {
+		vars["WORKSPACE_NAME"] = ""
+	}
+	aliases := map[string]string{
+		"TM_FILENAME":        "FILENAME",
+		"TM_FILENAME_BASE":   "FILENAME_BASE",
+		"TM_FILEPATH":        "FILEPATH",
+		"TM_DIRECTORY":       "DIRECTORY",
+		"TM_SELECTED_TEXT":   "SELECTION",
+		"TM_CURRENT_LINE":    "LINE",
+		"TM_LINE_NUMBER":     "LINE_NUMBER",
+		"CURRENT_YEAR":       "YEAR",
+		"CURRENT_MONTH":      "MONTH",
+		"CURRENT_DATE":       "DAY",
+		"CURRENT_HOUR":       "HOUR",
+		"CURRENT_MINUTE":     "MINUTE",
+		"CURRENT_SECOND":     "SECOND",
+		"CURRENT_MONTH_NAME": "MONTH_NAME",
+		"CURRENT_DAY_NAME":   "DAY_NAME",
+	}
+	for alias, name := range aliases {
+		vars[alias] = vars[name]
+	}
+	return vars
+}
+
+// Span is a range of a text in runes.
+type Span struct {
+	Start, End int
+}
+
+// TabStop is a place Tab moves to after a snippet is inserted.
+type TabStop struct {
+	Index int
+	// Spans are where the stop's text is, the first is edited and the rest mirror it.
+	Spans []Span
+	// Choices are the values offered for the stop, the first is inserted.
+	Choices []string
+}
+
+// Expansion is a snippet's text with its variables filled in and where its tab stops are.
+type Expansion struct {
+	Text string
+	// Stops are in the order Tab visits them, ending with $0.
+	Stops []TabStop
+}
+
+// ExpandSnippet fills in a snippet body's variables and finds its tab stops.
+// It understands $1, ${1}, ${1:placeholder}, ${1|one,two|}, $NAME, ${NAME} and ${NAME:default},
+// with \ escaping "$", "}" and "\". Lines after the first are indented by indent.
+func ExpandSnippet(body string, vars map[string]string, indent string) Expansion {
+	p := &snippetParser{body: []rune(body), vars: vars, indent: indent, stops: map[int]*TabStop{}, placeholders: map[int]string{}}
+	p.parse(&p.out, false)
+
+	var stops []TabStop
+	for _, stop := range p.stops {
+		stops = append(stops, *stop)
+	}
+	sort.Slice(stops, func(i, j int) bool {
+		// $0 is where the cursor ends up, after every other stop.
+		if stops[i].Index == 0 || stops[j].Index == 0 {
+			return stops[j].Index == 0 && stops[i].Index != 0
+		}
+		return stops[i].Index < stops[j].Index
+	})
+	if len(stops) == 0 || stops[len(stops)-1].Index != 0 {
+		end := len(p.out)
+		stops = append(stops, TabStop{Index: 0, Spans: []Span{{end, end}}})
+	}
+	return Expansion{Text: string(p.out), Stops: stops}
+}
+
+// snippetParser reads a snippet body.
+type snippetParser struct {
+	body   []rune
+	pos    int
+	vars   map[string]string
+	indent string
+	out    []rune
+	stops  map[int]*TabStop
+	// placeholders holds the text of each stop, so stops repeated without one mirror it.
+	placeholders map[int]string
+}
+
+// write adds text to out, indenting the lines after the first.
+func (p *snippetParser) write(out *[]rune, text string) {
+	for _, r := range text {
+		*out = append(*out, r)
+		if r == '\n' {
+			*out = append(*out, []rune(p.indent)...)
+		}
+	}
+}
+
+// parse reads until the end of the body, or the "}" closing a placeholder when nested.
+func (p *snippetParser) parse(out *[]rune, nested bool) {
+	for p.pos < len(p.body) {
+		r := p.body[p.pos]
+		switch {
+		case r == '\\' && p.pos+1 < len(p.body) && strings.ContainsRune(`$}\`, p.body[p.pos+1]):
+			*out = append(*out, p.body[p.pos+1])
+			p.pos += 2
+		case r == '}' && nested:
+			p.pos++
+			return
+		case r == '$':
+			p.pos++
+			p.dollar(out)
+		default:
+			p.write(out, string(r))
+			p.pos++
+		}
+	}
+}
+
+// dollar reads what follows a "$": a tab stop, a placeholder, choices or a variable.
+func (p *snippetParser) dollar(out *[]rune) {
+	if p.pos < len(p.body) && unicode.IsDigit(p.body[p.pos]) {
+		p.stop(out, p.number(), "")
+		return
+	}
+	if name := p.name(); name != "" {
+		p.variable(out, name)
+		return
+	}
+	if p.pos >= len(p.body) || p.body[p.pos] != '{' {
+		*out = append(*out, '$')
+		return
+	}
+
+	open := p.pos
+	p.pos++
+	if p.pos < len(p.body) && unicode.IsDigit(p.body[p.pos]) {
+		index := p.number()
+		switch {
+		case p.next('}'):
+			p.stop(out, index, "")
+			return
+		case p.next(':'):
+			start := len(*out)
+			p.parse(out, true)
+			text := string((*out)[start:])
+			*out = (*out)[:start]
+			p.stop(out, index, text)
+			return
+		case p.next('|'):
+			if choices, ok := p.choices(); ok {
+				p.addStop(index, len(*out), choices)
+				p.write(out, choices[0])
+				p.stops[index].Spans[len(p.stops[index].Spans)-1].End = len(*out)
+				return
+			}
+		}
+	} else if name := p.name(); name != "" {
+		switch {
+		case p.next('}'):
+			p.variable(out, name)
+			return
+		case p.next(':'):
+			// The default is only used when the variable has no value.
+			var fallback []rune
+			p.parse(&fallback, true)
+			if value := p.vars[name]; value != "" {
+				p.write(out, value)
+			} else {
+				*out = append(*out, fallback...)
+			}
+			return
+		}
+	}
+	// Not snippet syntax after all, keep it as text.
+	p.pos = open
+	*out = append(*out, '$')
+}
+
+// stop writes a tab stop's placeholder, or that of an earlier stop with the same number.
+func (p *snippetParser) stop(out *[]rune, index int, placeholder string) {
+	if placeholder == "" {
+		placeholder = p.placeholders[index]
+	} else if _, ok := p.placeholders[index]; !ok {
+		p.placeholders[index] = placeholder
+	}
+	p.addStop(index, len(*out), nil)
+	*out = append(*out, []rune(placeholder)...)
+	p.stops[index].Spans[len(p.stops[index].Spans)-1].End = len(*out)
+}
+
+// addStop records a span of a tab stop starting at start.
+func (p *snippetParser) addStop(index, start int, choices []string) {
+	stop, ok := p.stops[index]
+	if !ok {
+		stop = &TabStop{Index: index}
+		p.stops[index] = stop
+	}
+	if len(choices) > 0 && len(stop.Choices) == 0 {
+		stop.Choices = choices
+	}
+	stop.Spans = append(stop.Spans, Span{start, start})
+}
+
+// variable writes a variable's value, or its name if it isn't known.
+func (p *snippetParser) variable(out *[]rune, name string) {
+	if value, ok := p.vars[name]; ok {
+		p.write(out, value)
+		return
+	}
+	*out = append(*out, []rune(name)...)
+}
+
+// number reads a tab stop number.
+func (p *snippetParser) number() int {
+	start := p.pos
+	for p.pos < len(p.body) && unicode.IsDigit(p.body[p.pos]) {
+		p.pos++
+	}
+	n, _ := strconv.Atoi(string(p.body[start:p.pos]))
+	return n
+}
+
+// name reads a variable name, "" if there isn't one.
+func (p *snippetParser) name() string {
+	start := p.pos
+	for p.pos < len(p.body) {
+		r := p.body[p.pos]
+		if r == '_' || unicode.IsLetter(r) || (p.pos > start && unicode.IsDigit(r)) {
+			p.pos++
+			continue
+		}
+		break
+	}
+	return string(p.body[start:p.pos])
+}
+
+// next skips r if it's next.
+func (p *snippetParser) next(r rune) bool {
+	if p.pos < len(p.body) && p.body[p.pos] == r {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// choices reads the comma separated values of "${1|one,two|}" after the first "|".
+func (p *snippetParser) choices() ([]string, bool) {
+	var choices []string
+	var current []rune
+	for p.pos < len(p.body) {
+		r := p.body[p.pos]
+		switch {
+		case r == '\\' && p.pos+1 < len(p.body):
+			current = append(current, p.body[p.pos+1])
+			p.pos += 2
+			continue
+		case r == ',':
+			choices = append(choices, string(current))
+			current = nil
+		case r == '|' && p.pos+1 < len(p.body) && p.body[p.pos+1] == '}':
+			p.pos += 2
+			return append(choices, string(current)), true
+		default:
+			current = append(current, r)
+		}
+		p.pos++
+	}
+	return nil, false
+}
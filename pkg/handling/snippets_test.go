@@ -0,0 +1,144 @@
+package handling
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// inConfigDir runs the rest of a test in a new folder, which ConfigDir returns.
+func inConfigDir(t *testing.T) string {
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	return dir
+}
+
+func TestExpandSnippet(t *testing.T) {
+	vars := map[string]string{"FILENAME": "main.go", "SELECTION": "", "CLIPBOARD": "copied"}
+	tests := []struct {
+		name   string
+		body   string
+		indent string
+		text   string
+		stops  []TabStop
+	}{
+		{"plain text ends with the cursor", "fmt.Println()", "", "fmt.Println()",
+			[]TabStop{{Index: 0, Spans: []Span{{13, 13}}}}},
+		{"tab stops in order with $0 last", "for $1 := range $2 {\n\t$0\n}", "\t", "for  := range  {\n\t\t\n\t}",
+			[]TabStop{{Index: 1, Spans: []Span{{4, 4}}}, {Index: 2, Spans: []Span{{14, 14}}}, {Index: 0, Spans: []Span{{19, 19}}}}},
+		{"placeholders and mirrors", "${1:name} = $1", "", "name = name",
+			[]TabStop{{Index: 1, Spans: []Span{{0, 4}, {7, 11}}}, {Index: 0, Spans: []Span{{11, 11}}}}},
+		{"nested placeholders", "${1:a ${2:b}}", "", "a b",
+			[]TabStop{{Index: 1, Spans: []Span{{0, 3}}}, {Index: 2, Spans: []Span{{2, 3}}}, {Index: 0, Spans: []Span{{3, 3}}}}},
+		{"choices", "${1|yes,no|}", "", "yes",
+			[]TabStop{{Index: 1, Spans: []Span{{0, 3}}, Choices: []string{"yes", "no"}}, {Index: 0, Spans: []Span{{3, 3}}}}},
+		{"variables", "// $FILENAME ${CLIPBOARD}", "", "// main.go copied",
+			[]TabStop{{Index: 0, Spans: []Span{{17, 17}}}}},
+		{"variable defaults", "${SELECTION:none} ${FILENAME:x}", "", "none main.go",
+			[]TabStop{{Index: 0, Spans: []Span{{12, 12}}}}},
+		{"unknown variables are written by name", "$UNKNOWN", "", "UNKNOWN",
+			[]TabStop{{Index: 0, Spans: []Span{{7, 7}}}}},
+		{"escapes", `\$1 \} \\ $`, "", `$1 } \ $`,
+			[]TabStop{{Index: 0, Spans: []Span{{8, 8}}}}},
+		{"unclosed braces stay text", "${1 x", "", "${1 x",
+			[]TabStop{{Index: 0, Spans: []Span{{5, 5}}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ExpandSnippet(tt.body, vars, tt.indent)
+			if got.Text != tt.text || !reflect.DeepEqual(got.Stops, tt.stops) {
+				t.Errorf("ExpandSnippet(%q) = %q %+v, want %q %+v", tt.body, got.Text, got.Stops, tt.text, tt.stops)
+			}
+		})
+	}
+}
+
+func TestSnippetLanguage(t *testing.T) {
+	tests := []struct{ path, want string }{
+		{"", "markdown"},
+		{"main.go", "go"},
+		{"App.TSX", "typescript"},
+		{"Makefile", "plaintext"},
+		{"query.sql", "sql"},
+	}
+	for _, tt := range tests {
+		if got := SnippetLanguage(tt.path); got != tt.want {
+			t.Errorf("SnippetLanguage(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestSnippetVariables(t *testing.T) {
+	now := time.Date(2024, 3, 9, 14, 5, 7, 0, time.UTC)
+	vars := SnippetVariables(filepath.Join("src", "main.go"), "sel", "clip", "line", 4, now)
+	want := map[string]string{
+		"FILENAME": "main.go", "TM_FILENAME_BASE": "main", "DIRECTORY": "src", "TM_SELECTED_TEXT": "sel",
+		"CLIPBOARD": "clip", "LINE_NUMBER": "5", "DATE": "2024-03-09", "CURRENT_MONTH_NAME": "March", "DAY_NAME": "Saturday",
+	}
+	for name, value := range want {
+		if vars[name] != value {
+			t.Errorf("$%s = %q, want %q", name, vars[name], value)
+		}
+	}
+	if untitled := SnippetVariables("", "", "", "", 0, now); untitled["FILENAME"] != "Untitled" || untitled["DIRECTORY"] != "" {
+		t.Errorf("an unsaved file's name is %q in %q", untitled["FILENAME"], untitled["DIRECTORY"])
+	}
+}
+
+func TestLoadSnippets(t *testing.T) {
+	dir := inConfigDir(t)
+	if snippets, err := LoadSnippets("go"); err != nil || len(snippets) != 0 {
+		t.Fatalf("LoadSnippets without files = %v, %v", snippets, err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "snippets"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"global": `{"Date": {"prefix": "date", "body": "$DATE"}}`,
+		"go":     `{"Error check": {"prefix": ["iferr", "err"], "body": ["if err != nil {", "\treturn err", "}"], "description": "Return the error"}}`,
+		"python": `not json`,
+	}
+	for language, content := range files {
+		if err := os.WriteFile(SnippetsPath(language), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	snippets, err := LoadSnippets("go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Snippet{
+		{Name: "Date", Prefixes: []string{"date"}, Body: "$DATE", Language: "global"},
+		{Name: "Error check", Prefixes: []string{"iferr", "err"}, Body: "if err != nil {\n\treturn err\n}", Description: "Return the error", Language: "go"},
+	}
+	if !reflect.DeepEqual(snippets, want) {
+		t.Errorf("LoadSnippets = %+v, want %+v", snippets, want)
+	}
+	// A broken file is reported, the global snippets still load.
+	if snippets, err := LoadSnippets("python"); err == nil || len(snippets) != 1 {
+		t.Errorf("LoadSnippets with a broken file = %+v, %v", snippets, err)
+	}
+}
+
+func TestCreateSnippetsFile(t *testing.T) {
+	inConfigDir(t)
+	path, err := CreateSnippetsFile("go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != SnippetsPath("go") {
+		t.Errorf("CreateSnippetsFile = %s, want %s", path, SnippetsPath("go"))
+	}
+	if _, err := LoadSnippets("go"); err != nil {
+		t.Errorf("the example snippets don't load: %v", err)
+	}
+}
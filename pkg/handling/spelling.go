@@ -0,0 +1,400 @@
+package handling
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// maxSuggestions is how many spellings Suggest offers.
+	maxSuggestions = 8
+	// maxTwoEditsWord is the longest word two edits away from words are looked for, it gets slow beyond that.
+	maxTwoEditsWord = 16
+)
+
+var (
+	// spellWord matches a word and the digits and underscores stuck to it, which make it code rather than a word.
+	spellWord = regexp.MustCompile(`[\p{L}\p{M}\p{N}_'’]+`)
+	// spellSkip matches what isn't checked within prose and comments: code spans, URLs, email addresses,
+	// link targets and HTML tags.
+	spellSkip = regexp.MustCompile("`[^`\n]*`" + `|\b[a-zA-Z][a-zA-Z0-9+.-]*://\S+|\bwww\.\S+|[\w.+-]+@[\w-]+\.[\w.]+|\]\([^)\n]*\)|</?[a-zA-Z][^>\n]*>`)
+)
+
+// proseExtensions are the files checked throughout rather than only in comments and strings.
+var proseExtensions = map[string]bool{
+	"": true, ".md": true, ".markdown": true, ".txt": true, ".text": true, ".rst": true, ".adoc": true, ".org": true, ".tex": true,
+}
+
+// Misspelling is a word the spell checker doesn't know, by its offsets in runes.
+type Misspelling struct {
+	Start, End int
+	Word       string
+}
+
+// SpellChecker checks words against a dictionary and the words of the user's and the project's word lists.
+type SpellChecker struct {
+	dictionary *Dictionary
+	words      map[string]bool
+}
+
+// NewSpellChecker creates a spell checker that knows the words of a dictionary and word lists.
+func NewSpellChecker(dictionary *Dictionary, wordLists ...[]string) *SpellChecker {
+	s := &SpellChecker{dictionary: dictionary, words: map[string]bool{}}
+	for _, list := range wordLists {
+		for _, word := range list {
+			s.AddWord(word)
+		}
+	}
+	return s
+}
+
+// AddWord makes a word known, such as one ignored until the editor closes.
+func (s *SpellChecker) AddWord(word string) {
+	s.words[normaliseApostrophes(word)] = true
+}
+
+// has reports whether a spelling is in the dictionary or a word list.
+func (s *SpellChecker) has(word string) bool {
+	return s.words[word] || s.dictionary != nil && s.dictionary.words[word]
+}
+
+// Correct reports whether a word is spelled right. A capitalised word, such as one starting a sentence,
+// and a word in capitals are right if they're known in lower case, capitals can be a known proper noun.
+func (s *SpellChecker) Correct(word string) bool {
+	word = normaliseApostrophes(word)
+	if s.has(word) {
+		return true
+	}
+	lower := strings.ToLower(word)
+	if lower != word && s.has(lower) {
+		return true
+	}
+	return word == strings.ToUpper(word) && s.has(capitalise(lower))
+}
+
+// Suggest returns spellings that may have been meant instead of a misspelled word, the likeliest first.
+// They're the dictionary's common replacements, then words one edit away, the word split in two,
+// then words two edits away, all in the case the word was written in.
+func (s *SpellChecker) Suggest(word string) []string {
+	word = normaliseApostrophes(word)
+	lower := strings.ToLower(word)
+	var found []string
+	seen := map[string]bool{}
+	suggest := func(candidate string) {
+		if seen[candidate] || len(found) >= maxSuggestions {
+			return
+		}
+		if spelling, ok := s.suggestable(candidate); ok && !seen[spelling] && spelling != word {
+			seen[candidate], seen[spelling] = true, true
+			found = append(found, spelling)
+		}
+	}
+
+	// A proper noun written in lower case only needs its capital.
+	suggest(lower)
+	if s.dictionary != nil {
+		for _, rep := range s.dictionary.replacements {
+			for i := 0; i < len(lower); {
+				at := strings.Index(lower[i:], rep[0])
+				if at < 0 {
+					break
+				}
+				at += i
+				suggest(lower[:at] + rep[1] + lower[at+len(rep[0]):])
+				i = at + 1
+			}
+		}
+	}
+	letters := s.tryLetters(lower)
+	edits := oneEditAway(lower, letters)
+	for _, edit := range edits {
+		suggest(edit)
+	}
+	runes := []rune(lower)
+	for i := 1; i < len(runes) && len(found) < maxSuggestions; i++ {
+		first, second := string(runes[:i]), string(runes[i:])
+		if len(runes[:i]) > 1 && len(runes[i:]) > 1 && s.Correct(first) && s.Correct(second) && !seen[first+" "+second] {
+			seen[first+" "+second] = true
+			found = append(found, first+" "+second)
+		}
+	}
+	if len(found) == 0 && len(runes) <= maxTwoEditsWord {
+		for _, edit := range edits {
+			for _, twice := range oneEditAway(edit, letters) {
+				suggest(twice)
+			}
+		}
+	}
+
+	for i, spelling := range found {
+		switch {
+		case word == strings.ToUpper(word) && len(runes) > 1:
+			found[i] = strings.ToUpper(spelling)
+		case word != lower && word == capitalise(lower):
+			found[i] = capitalise(spelling)
+		}
+	}
+	return found
+}
+
+// suggestable returns how a candidate is spelled if it's a known word that can be suggested, such as a proper noun in capitals.
+func (s *SpellChecker) suggestable(candidate string) (string, bool) {
+	for _, spelling := range []string{candidate, capitalise(candidate)} {
+		if s.has(spelling) && (s.dictionary == nil || !s.dictionary.noSuggest[spelling]) {
+			return spelling, true
+		}
+	}
+	return "", false
+}
+
+// tryLetters returns the letters suggestions try in a word, the dictionary's in lower case, or the alphabet and the word's own.
+func (s *SpellChecker) tryLetters(word string) []rune {
+	var letters []rune
+	if s.dictionary != nil {
+		for _, r := range s.dictionary.try {
+			if !unicode.IsUpper(r) {
+				letters = append(letters, r)
+			}
+		}
+	}
+	if len(letters) == 0 {
+		letters = []rune("esianrtolcdugmphbyfvkwzxjq'")
+	}
+	for _, r := range word {
+		if !strings.ContainsRune(string(letters), r) {
+			letters = append(letters, r)
+		}
+	}
+	return letters
+}
+
+// oneEditAway returns the spellings one edit from word: two letters swapped, a letter changed, left out or added.
+func oneEditAway(word string, letters []rune) []string {
+	runes := []rune(word)
+	var edits []string
+	for i := 0; i+1 < len(runes); i++ {
+		swapped := append([]rune{}, runes...)
+		swapped[i], swapped[i+1] = swapped[i+1], swapped[i]
+		edits = append(edits, string(swapped))
+	}
+	for i := range runes {
+		for _, r := range letters {
+			if r != runes[i] {
+				edits = append(edits, string(runes[:i])+string(r)+string(runes[i+1:]))
+			}
+		}
+	}
+	for i := range runes {
+		edits = append(edits, string(runes[:i])+string(runes[i+1:]))
+	}
+	for i := 0; i <= len(runes); i++ {
+		for _, r := range letters {
+			edits = append(edits, string(runes[:i])+string(r)+string(runes[i:]))
+		}
+	}
+	return edits
+}
+
+// Misspellings returns the words of a file's text the spell checker doesn't know. Prose such as markdown is checked
+// throughout but for code blocks and spans, URLs and tags, code only in its comments and strings.
+// Words with digits, underscores or capitals after the first letter, and words joined by dots or slashes, are code and skipped.
+func (s *SpellChecker) Misspellings(text, path string) []Misspelling {
+	var found []Misspelling
+	runes, bytes := 0, 0
+	runeOffset := func(offset int) int {
+		runes += utf8.RuneCountInString(text[bytes:offset])
+		bytes = offset
+		return runes
+	}
+	for _, region := range spellRegions(text, path) {
+		part := text[region[0]:region[1]]
+		skip := spellSkip.FindAllStringIndex(part, -1)
+		for _, match := range spellWord.FindAllStringIndex(part, -1) {
+			start, end := match[0], match[1]
+			// Quotes around a word aren't part of it.
+			for start < end {
+				r, size := utf8.DecodeRuneInString(part[start:end])
+				if r != '\'' && r != '’' {
+					break
+				}
+				start += size
+			}
+			for end > start {
+				r, size := utf8.DecodeLastRuneInString(part[:end])
+				if r != '\'' && r != '’' {
+					break
+				}
+				end -= size
+			}
+			word := part[start:end]
+			if !spellable(word) || joinedWord(part, start, end) || overlaps(skip, start, end) || s.Correct(word) {
+				continue
+			}
+			found = append(found, Misspelling{
+				Start: runeOffset(region[0] + start),
+				End:   runeOffset(region[0] + end),
+				Word:  word,
+			})
+		}
+	}
+	return found
+}
+
+// spellable reports whether a word is one to check, rather than code or an acronym.
+func spellable(word string) bool {
+	if utf8.RuneCountInString(word) < 2 {
+		return false
+	}
+	for i, r := range word {
+		if unicode.IsDigit(r) || r == '_' || i > 0 && unicode.IsUpper(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// joinedWord reports whether the word at start to end of text is joined to another by a dot or slash,
+// such as a file name, a package or a path.
+func joinedWord(text string, start, end int) bool {
+	before, _ := utf8.DecodeLastRuneInString(text[:start])
+	after, _ := utf8.DecodeRuneInString(text[end:])
+	if strings.ContainsRune(`/\`, before) || strings.ContainsRune(`/\`, after) {
+		return true
+	}
+	if before == '.' {
+		previous, _ := utf8.DecodeLastRuneInString(text[:start-1])
+		if unicode.IsLetter(previous) || unicode.IsDigit(previous) {
+			return true
+		}
+	}
+	if after == '.' {
+		next, _ := utf8.DecodeRuneInString(text[end+1:])
+		if unicode.IsLetter(next) || unicode.IsDigit(next) {
+			return true
+		}
+	}
+	return false
+}
+
+// overlaps reports whether start to end overlaps any of the ranges.
+func overlaps(ranges [][]int, start, end int) bool {
+	for _, r := range ranges {
+		if start < r[1] && r[0] < end {
+			return true
+		}
+	}
+	return false
+}
+
+// spellRegions returns the byte ranges of text that are spell checked, in order: the lines of prose
+// outside fenced code blocks, or the comments and strings of code.
+func spellRegions(text, path string) [][2]int {
+	if proseExtensions[strings.ToLower(filepath.Ext(path))] {
+		return proseRegions(text)
+	}
+	return codeRegions(text, CommentSyntaxFor(path))
+}
+
+// proseRegions returns each line of text outside fenced code blocks.
+func proseRegions(text string) [][2]int {
+	var regions [][2]int
+	fence := ""
+	for start := 0; start <= len(text); {
+		end := strings.IndexByte(text[start:], '\n')
+		if end < 0 {
+			end = len(text)
+		} else {
+			end += start
+		}
+		line := strings.TrimSpace(text[start:end])
+		switch {
+		case fence != "":
+			if strings.HasPrefix(line, fence) {
+				fence = ""
+			}
+		case strings.HasPrefix(line, "```"), strings.HasPrefix(line, "~~~"):
+			fence = line[:3]
+		default:
+			regions = append(regions, [2]int{start, end})
+		}
+		start = end + 1
+	}
+	return regions
+}
+
+// codeRegions returns the insides of the comments and strings of code.
+func codeRegions(text string, syntax CommentSyntax) [][2]int {
+	var regions [][2]int
+	for _, span := range codeSpans(text, syntax) {
+		regions = append(regions, span.inner)
+	}
+	return regions
+}
+
+// UserWordsPath returns the user's word list, next to config.json.
+func UserWordsPath() string {
+	return filepath.Join(ConfigDir(), "words.txt")
+}
+
+// ProjectWordsPath returns the open folder's word list, next to its tasks, or "" if no folder is open.
+func ProjectWordsPath() string {
+	if WorkspaceRoot == "" {
+		return ""
+	}
+	return filepath.Join(WorkspaceRoot, ".leda", "words.txt")
+}
+
+// LoadWordList reads a word list, a word on each line. There are none if the file doesn't exist.
+func LoadWordList(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var words []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if word := strings.TrimSpace(scanner.Text()); word != "" && !strings.HasPrefix(word, "#") {
+			words = append(words, word)
+		}
+	}
+	return words, scanner.Err()
+}
+
+// AddToWordList adds a word to the end of a word list, creating it and its folder if needed.
+func AddToWordList(path, word string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		data = append(data, '\n')
+	}
+	return os.WriteFile(path, append(data, word+"\n"...), 0644)
+}
+
+// normaliseApostrophes writes typographic apostrophes as the plain ones dictionaries use.
+func normaliseApostrophes(word string) string {
+	return strings.ReplaceAll(word, "’", "'")
+}
+
+// capitalise returns a word with its first letter in upper case.
+func capitalise(word string) string {
+	r, size := utf8.DecodeRuneInString(word)
+	return string(unicode.ToUpper(r)) + word[size:]
+}
@@ -0,0 +1,203 @@
+package handling
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// testAffixes are the rules of the test dictionary: plurals with S, "un" with U, which goes with
+// a suffix too, "re" with R, which only goes on its own, and -ing with G, which can be followed by S.
+const testAffixes = `SET UTF-8
+TRY esianrtolcdugmphbyfvkwzxjq
+REP 1
+REP f ph
+NEEDAFFIX N
+FORBIDDENWORD X
+NOSUGGEST B
+
+SFX S Y 2
+SFX S y ies [^aeiou]y
+SFX S 0 s [^y]
+
+SFX G Y 1
+SFX G 0 ing/S .
+
+PFX U Y 1
+PFX U 0 un .
+
+PFX R N 1
+PFX R 0 re .
+`
+
+const testWords = `10
+word/S
+city/S
+do/UGR
+phone/S
+Paris
+NASA
+blah/B
+wordz/X
+mend/N
+New\/York
+`
+
+// writeTestDictionary writes the test dictionary to a folder as en_TEST.
+func writeTestDictionary(t *testing.T, affixes, words string) string {
+	dir := t.TempDir()
+	for name, content := range map[string]string{"en_TEST.aff": affixes, "en_TEST.dic": words} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadDictionary(t *testing.T) {
+	dir := writeTestDictionary(t, testAffixes, testWords)
+	if languages := DictionaryLanguages(dir); !reflect.DeepEqual(languages, []string{"en_TEST"}) {
+		t.Errorf("DictionaryLanguages = %q", languages)
+	}
+	d, err := LoadDictionary(dir, "en_TEST")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checker := NewSpellChecker(d)
+	tests := []struct {
+		word string
+		want bool
+	}{
+		{"word", true},
+		{"words", true},
+		{"cities", true},
+		{"citys", false},
+		{"undo", true},
+		{"redo", true},
+		{"doing", true},
+		{"doings", true},
+		{"undoing", true},
+		{"redoing", false},
+		{"Word", true},
+		{"WORDS", true},
+		{"paris", false},
+		{"PARIS", true},
+		{"NASA", true},
+		{"Nasa", false},
+		{"wordz", false},
+		{"mend", false},
+		{"New/York", true},
+		{"wordd", false},
+	}
+	for _, tt := range tests {
+		if got := checker.Correct(tt.word); got != tt.want {
+			t.Errorf("Correct(%q) = %v, want %v", tt.word, got, tt.want)
+		}
+	}
+	if _, err := LoadDictionary(dir, "fr_TEST"); err == nil {
+		t.Error("LoadDictionary of a missing dictionary didn't fail")
+	}
+}
+
+func TestLoadDictionaryEncoding(t *testing.T) {
+	// "café" in ISO 8859-1.
+	dir := writeTestDictionary(t, "SET ISO8859-1\n", "1\ncaf\xe9\n")
+	d, err := LoadDictionary(dir, "en_TEST")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !NewSpellChecker(d).Correct("café") {
+		t.Error("a word of an ISO 8859-1 dictionary isn't known")
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	d, err := LoadDictionary(writeTestDictionary(t, testAffixes, testWords), "en_TEST")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checker := NewSpellChecker(d)
+	tests := []struct {
+		word string
+		want string
+	}{
+		{"wrod", "word"},
+		{"wor", "word"},
+		{"Wrod", "Word"},
+		{"WROD", "WORD"},
+		{"fone", "phone"},
+		{"paris", "Paris"},
+		{"wordcity", "word city"},
+		{"wrdos", "words"},
+	}
+	for _, tt := range tests {
+		suggestions := checker.Suggest(tt.word)
+		if len(suggestions) == 0 || suggestions[0] != tt.want {
+			t.Errorf("Suggest(%q) = %q, want %q first", tt.word, suggestions, tt.want)
+		}
+	}
+	for _, suggestion := range checker.Suggest("blahh") {
+		if suggestion == "blah" {
+			t.Error("Suggest offered a word that's never suggested")
+		}
+	}
+}
+
+func TestMisspellings(t *testing.T) {
+	d, err := LoadDictionary(writeTestDictionary(t, testAffixes, testWords), "en_TEST")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checker := NewSpellChecker(d, []string{"leda"})
+	tests := []struct {
+		name, path, text string
+		want             []string
+	}{
+		{"prose", "notes.md", "Words wrod, ‘citys’ leda’ Paris.\n", []string{"wrod", "citys"}},
+		{"skipped in prose", "notes.md", "`wrod` https://wrod.com wrod.go a/wrod <wrod> [x](wrod) wrod@mail.com\n", nil},
+		{"code blocks", "notes.md", "```\nwrod\n```\nwrod\n", []string{"wrod"}},
+		{"code words", "notes.txt", "wrodWord wrod2 wrod_x WROD x\n", nil},
+		{"comments and strings in code", "main.go", "// a wrod\nwrod := \"citys\"\n", []string{"wrod", "citys"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var words []string
+			for _, m := range checker.Misspellings(tt.text, tt.path) {
+				words = append(words, m.Word)
+				if got := string([]rune(tt.text)[m.Start:m.End]); got != m.Word {
+					t.Errorf("misspelling %q is at %d-%d, which is %q", m.Word, m.Start, m.End, got)
+				}
+			}
+			if !reflect.DeepEqual(words, tt.want) {
+				t.Errorf("Misspellings(%q) = %q, want %q", tt.text, words, tt.want)
+			}
+		})
+	}
+}
+
+func TestWordLists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".leda", "words.txt")
+	if words, err := LoadWordList(path); err != nil || words != nil {
+		t.Fatalf("LoadWordList of a missing file = %q, %v", words, err)
+	}
+	for _, word := range []string{"leda", "fyne"} {
+		if err := AddToWordList(path, word); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(path, []byte("# words\nleda\n\n  fyne  \ngofmt"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := AddToWordList(path, "goroutine"); err != nil {
+		t.Fatal(err)
+	}
+	words, err := LoadWordList(path)
+	if want := []string{"leda", "fyne", "gofmt", "goroutine"}; err != nil || !reflect.DeepEqual(words, want) {
+		t.Errorf("LoadWordList = %q, %v, want %q", words, err, want)
+	}
+	checker := NewSpellChecker(nil, words)
+	if !checker.Correct("Fyne") || !checker.Correct("goroutine") || checker.Correct("goroutines") {
+		t.Error("a spell checker without a dictionary doesn't know just its word lists")
+	}
+}
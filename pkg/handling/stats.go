@@ -0,0 +1,185 @@
+package handling
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"fyne.io/fyne/v2"
+)
+
+const (
+	// writingGoalsKey and writingHistoryKey are the preferences the word goals and the daily words written are stored under.
+	writingGoalsKey   = "writing_goals"
+	writingHistoryKey = "writing_history"
+
+	// readingWordsPerMinute is how fast reading time is estimated to go.
+	readingWordsPerMinute = 230
+	// maxWritingHistory is how many days of words written are kept.
+	maxWritingHistory = 365
+	// dayLayout is how days are written in the history.
+	dayLayout = "2006-01-02"
+)
+
+// TextStats counts the words and other parts of a text.
+type TextStats struct {
+	// Words are runs of letters and digits, with the apostrophes and hyphens joining them.
+	Words int
+	// Characters counts every character, line breaks aside, CharactersNoSpaces leaves out whitespace as well.
+	Characters         int
+	CharactersNoSpaces int
+	// Sentences end with a full stop, question mark or exclamation mark, or the end of the text.
+	Sentences int
+	// Paragraphs are separated by blank lines.
+	Paragraphs int
+}
+
+// CountText counts the words, characters, sentences and paragraphs of a text.
+func CountText(text string) TextStats {
+	var s TextStats
+	runes := []rune(text)
+	inWord, inParagraph, blankLine, sentenceWords := false, false, true, false
+	for i, r := range runes {
+		if r != '\n' {
+			s.Characters++
+		}
+		if !unicode.IsSpace(r) {
+			s.CharactersNoSpaces++
+		}
+
+		// Numbers such as "3.14" and "1,000" are one word.
+		number := i > 0 && unicode.IsDigit(runes[i-1]) && i+1 < len(runes) && unicode.IsDigit(runes[i+1]) && strings.ContainsRune(".,", r)
+		word := unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r) ||
+			inWord && (strings.ContainsRune("'’-", r) || number)
+		if word && !inWord {
+			s.Words++
+			sentenceWords = true
+		}
+		inWord = word
+
+		// A full stop within a word or number, such as in "3.14", doesn't end a sentence.
+		if strings.ContainsRune(".!?", r) && sentenceWords && (i+1 == len(runes) || !unicode.IsLetter(runes[i+1]) && !unicode.IsDigit(runes[i+1])) {
+			s.Sentences++
+			sentenceWords = false
+		}
+
+		switch {
+		case r == '\n':
+			if blankLine {
+				inParagraph = false
+			}
+			blankLine = true
+		case !unicode.IsSpace(r):
+			blankLine = false
+			if !inParagraph {
+				s.Paragraphs++
+				inParagraph = true
+			}
+		}
+	}
+	// The last sentence needn't end with a full stop.
+	if sentenceWords {
+		s.Sentences++
+	}
+	return s
+}
+
+// ReadingTime estimates how long the text takes to read.
+func (s TextStats) ReadingTime() time.Duration {
+	return time.Duration(s.Words) * time.Minute / readingWordsPerMinute
+}
+
+// DailyWords is how many words were written on a day, written as "2006-01-02".
+type DailyWords struct {
+	Day   string
+	Words int
+}
+
+// WritingGoals holds the word count goal of each file and how many words were written each day.
+// The goal of an unsaved buffer uses the empty path and isn't persisted.
+type WritingGoals struct {
+	prefs   fyne.Preferences
+	goals   map[string]int
+	written map[string]int
+}
+
+// LoadWritingGoals reads the saved goals and history, prefs may be nil to keep them in memory only.
+func LoadWritingGoals(prefs fyne.Preferences) *WritingGoals {
+	g := &WritingGoals{prefs: prefs, goals: map[string]int{}, written: map[string]int{}}
+	if prefs == nil {
+		return g
+	}
+	for key, value := range map[string]*map[string]int{writingGoalsKey: &g.goals, writingHistoryKey: &g.written} {
+		if saved := prefs.String(key); saved != "" {
+			if err := json.Unmarshal([]byte(saved), value); err != nil {
+				fyne.LogError("Failed to read "+key, err)
+			}
+		}
+	}
+	return g
+}
+
+// save writes the goals of saved files and the history to the preferences.
+func (g *WritingGoals) save() {
+	if g.prefs == nil {
+		return
+	}
+	goals := map[string]int{}
+	for path, goal := range g.goals {
+		if path != "" {
+			goals[path] = goal
+		}
+	}
+	for key, value := range map[string]map[string]int{writingGoalsKey: goals, writingHistoryKey: g.written} {
+		data, err := json.Marshal(value)
+		if err != nil {
+			fyne.LogError("Failed to save "+key, err)
+			continue
+		}
+		g.prefs.SetString(key, string(data))
+	}
+}
+
+// Goal returns the number of words a file is meant to reach, 0 if it has no goal.
+func (g *WritingGoals) Goal(path string) int {
+	return g.goals[path]
+}
+
+// SetGoal sets the number of words a file is meant to reach, 0 removes its goal.
+func (g *WritingGoals) SetGoal(path string, words int) {
+	if words > 0 {
+		g.goals[path] = words
+	} else {
+		delete(g.goals, path)
+	}
+	g.save()
+}
+
+// AddWritten adds words to those written on a day, forgetting the days too long ago to keep.
+func (g *WritingGoals) AddWritten(day time.Time, words int) {
+	g.written[day.Format(dayLayout)] += words
+	if len(g.written) > maxWritingHistory {
+		history := g.History()
+		for _, old := range history[maxWritingHistory:] {
+			delete(g.written, old.Day)
+		}
+	}
+	g.save()
+}
+
+// Written returns how many words were written on a day.
+func (g *WritingGoals) Written(day time.Time) int {
+	return g.written[day.Format(dayLayout)]
+}
+
+// History returns the words written each day anything was, the most recent day first.
+func (g *WritingGoals) History() []DailyWords {
+	history := make([]DailyWords, 0, len(g.written))
+	for day, words := range g.written {
+		history = append(history, DailyWords{Day: day, Words: words})
+	}
+	sort.Slice(history, func(i, j int) bool { return history[i].Day > history[j].Day })
+	return history
+}
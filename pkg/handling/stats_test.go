@@ -0,0 +1,67 @@
+package handling
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCountText(t *testing.T) {
+	tests := []struct {
+		text string
+		want TextStats
+	}{
+		{"", TextStats{}},
+		{"Hello world.", TextStats{Words: 2, Characters: 12, CharactersNoSpaces: 11, Sentences: 1, Paragraphs: 1}},
+		{"It's a well-known fact", TextStats{Words: 4, Characters: 22, CharactersNoSpaces: 19, Sentences: 1, Paragraphs: 1}},
+		{"Pi is 3.14 and 1,000 is big", TextStats{Words: 7, Characters: 27, CharactersNoSpaces: 21, Sentences: 1, Paragraphs: 1}},
+		{"One. Two! Three? Four", TextStats{Words: 4, Characters: 21, CharactersNoSpaces: 18, Sentences: 4, Paragraphs: 1}},
+		{"a\nb\n\n\nc", TextStats{Words: 3, Characters: 3, CharactersNoSpaces: 3, Sentences: 1, Paragraphs: 2}},
+		{"...", TextStats{Characters: 3, CharactersNoSpaces: 3, Paragraphs: 1}},
+		{"naïve café", TextStats{Words: 2, Characters: 10, CharactersNoSpaces: 9, Sentences: 1, Paragraphs: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := CountText(tt.text); got != tt.want {
+				t.Errorf("CountText(%q) = %+v, want %+v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadingTime(t *testing.T) {
+	if got := (TextStats{Words: 460}).ReadingTime(); got != 2*time.Minute {
+		t.Errorf("ReadingTime of 460 words = %v, want 2m", got)
+	}
+}
+
+func TestWritingGoals(t *testing.T) {
+	g := LoadWritingGoals(nil)
+	g.SetGoal("a.md", 500)
+	g.SetGoal("b.md", 100)
+	g.SetGoal("b.md", 0)
+	if g.Goal("a.md") != 500 || g.Goal("b.md") != 0 {
+		t.Errorf("goals are %d and %d, want 500 and 0", g.Goal("a.md"), g.Goal("b.md"))
+	}
+
+	day := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	g.AddWritten(day, 10)
+	g.AddWritten(day, 5)
+	g.AddWritten(day.AddDate(0, 0, 1), 7)
+	if got := g.Written(day); got != 15 {
+		t.Errorf("Written = %d, want 15", got)
+	}
+	want := []DailyWords{{"2024-03-02", 7}, {"2024-03-01", 15}}
+	if got := g.History(); !reflect.DeepEqual(got, want) {
+		t.Errorf("History = %v, want %v", got, want)
+	}
+
+	// Only the most recent days are kept.
+	for i := 0; i < maxWritingHistory+5; i++ {
+		g.AddWritten(day.AddDate(0, 0, i), 1)
+	}
+	history := g.History()
+	if len(history) != maxWritingHistory || history[len(history)-1].Day != day.AddDate(0, 0, 5).Format(dayLayout) {
+		t.Errorf("kept %d days back to %s", len(history), history[len(history)-1].Day)
+	}
+}
@@ -0,0 +1,215 @@
+package handling
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// tasksFile is where a workspace defines its tasks.
+const tasksFile = ".leda/tasks.json"
+
+// defaultProblemMatcher finds compiler errors in the "file:line:col: message" form, the column is optional.
+var defaultProblemMatcher = regexp.MustCompile(`^(?P<file>(?:[A-Za-z]:)?[^\s:][^:]*):(?P<line>\d+):(?:(?P<column>\d+):)?\s*(?P<message>.+)$`)
+
+// Task is a command defined in .leda/tasks.json.
+type Task struct {
+	Label   string `json:"label"`
+	Command string `json:"command"`
+	// Cwd is relative to the workspace, the workspace itself when empty.
+	Cwd string            `json:"cwd"`
+	Env map[string]string `json:"env"`
+	// ProblemMatcher is a regular expression with the named groups file, line, column and message.
+	// Errors in the "file:line:col: message" form are matched when it's empty.
+	ProblemMatcher string `json:"problemMatcher"`
+}
+
+// Problem is an error reported by a task or a language server.
+type Problem struct {
+	Location
+	Message string
+	// Warning marks problems that aren't errors.
+	Warning bool
+}
+
+// TasksPath returns the tasks file of a workspace.
+func TasksPath(root string) string {
+	return filepath.Join(root, filepath.FromSlash(tasksFile))
+}
+
+// LoadTasks reads the tasks defined in a workspace.
+func LoadTasks(root string) ([]Task, error) {
+	data, err := os.ReadFile(TasksPath(root))
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Tasks []Task `json:"tasks"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	for i, task := range file.Tasks {
+		if task.Label == "" {
+			file.Tasks[i].Label = task.Command
+		}
+	}
+	return file.Tasks, nil
+}
+
+// CreateTasksFile writes an example tasks file to a workspace that doesn't have one yet and returns its path.
+func CreateTasksFile(root string) (string, error) {
+	path := TasksPath(root)
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	example := `{
+	"tasks": [
+		{
+			"label": "Build",
+			"command": "go build ./...",
+			"cwd": "",
+			"env": {},
+			"problemMatcher": ""
+		},
+		{
+			"label": "Make",
+			"command": "make"
+		}
+	]
+}
+`
+	return path, os.WriteFile(path, []byte(example), 0644)
+}
+
+// Dir returns the folder the task runs in.
+func (t Task) Dir(root string) string {
+	if t.Cwd == "" {
+		return root
+	}
+	if filepath.IsAbs(t.Cwd) {
+		return t.Cwd
+	}
+	return filepath.Join(root, t.Cwd)
+}
+
+// Matcher compiles the task's problem matcher.
+func (t Task) Matcher() (*regexp.Regexp, error) {
+	if t.ProblemMatcher == "" {
+		return defaultProblemMatcher, nil
+	}
+	matcher, err := regexp.Compile(t.ProblemMatcher)
+	if err != nil {
+		return nil, err
+	}
+	if matcher.SubexpIndex("file") < 0 || matcher.SubexpIndex("line") < 0 {
+		return nil, errors.New("the problem matcher needs the named groups file and line")
+	}
+	return matcher, nil
+}
+
+// ParseProblem reads a problem from a line of task output, relative paths are taken from dir.
+func ParseProblem(line, dir string, matcher *regexp.Regexp) (Problem, bool) {
+	match := matcher.FindStringSubmatch(line)
+	if match == nil {
+		return Problem{}, false
+	}
+	group := func(name string) string {
+		if i := matcher.SubexpIndex(name); i >= 0 {
+			return match[i]
+		}
+		return ""
+	}
+	lineNumber, err := strconv.Atoi(group("line"))
+	if err != nil || lineNumber < 1 {
+		return Problem{}, false
+	}
+	column, _ := strconv.Atoi(group("column"))
+
+	path := filepath.FromSlash(group("file"))
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	// Lines that only look like errors, such as timestamps, name files that don't exist.
+	if info, err := os.Stat(path); err != nil || info.IsDir() {
+		return Problem{}, false
+	}
+	message := group("message")
+	return Problem{
+		Location: Location{Path: path, Row: lineNumber - 1, Column: max(column-1, 0)},
+		Message:  message,
+		Warning:  strings.HasPrefix(strings.ToLower(message), "warning"),
+	}, true
+}
+
+// TaskRun is a running task.
+type TaskRun struct {
+	Task Task
+	cmd  *exec.Cmd
+}
+
+// StartTask runs a task in the shell, calling onLine with each line of its output
+// and onExit once it has finished and all output has been read.
+func StartTask(task Task, root string, onLine func(line string), onExit func(err error)) (*TaskRun, error) {
+	cmd := taskCommand(task.Command)
+	cmd.Dir = task.Dir(root)
+	cmd.Env = os.Environ()
+	keys := make([]string, 0, len(task.Env))
+	for key := range task.Env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		cmd.Env = append(cmd.Env, key+"="+task.Env[key])
+	}
+	// Don't wait forever on output held open by something the task left running.
+	cmd.WaitDelay = time.Second
+
+	reader, writer := io.Pipe()
+	cmd.Stdout = writer
+	cmd.Stderr = writer
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	read := make(chan struct{})
+	go func() {
+		defer close(read)
+		output := bufio.NewReader(reader)
+		for {
+			line, err := output.ReadString('\n')
+			if line != "" {
+				onLine(strings.TrimRight(line, "\r\n"))
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	go func() {
+		err := cmd.Wait()
+		writer.Close()
+		<-read
+		onExit(err)
+	}()
+	return &TaskRun{Task: task, cmd: cmd}, nil
+}
+
+// Stop kills the task along with anything it started.
+func (r *TaskRun) Stop() {
+	if r.cmd.Process != nil {
+		killTask(r.cmd)
+	}
+}
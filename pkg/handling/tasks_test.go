@@ -0,0 +1,127 @@
+package handling
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestParseProblem(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "main.go")
+	if err := os.WriteFile(source, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	custom, err := Task{ProblemMatcher: `^(?P<message>.+) at (?P<file>\S+) line (?P<line>\d+)$`}.Matcher()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		line    string
+		matcher Task
+		want    Problem
+		ok      bool
+	}{
+		{"file, line and column", "main.go:12:5: undefined: x", Task{},
+			Problem{Location: Location{Path: source, Row: 11, Column: 4}, Message: "undefined: x"}, true},
+		{"no column", "main.go:3: missing return", Task{},
+			Problem{Location: Location{Path: source, Row: 2}, Message: "missing return"}, true},
+		{"absolute path", source + ":1:1: warning: unused", Task{},
+			Problem{Location: Location{Path: source}, Message: "warning: unused", Warning: true}, true},
+		{"missing file", "other.go:1:1: error", Task{}, Problem{}, false},
+		{"timestamp", "12:30:45 starting build", Task{}, Problem{}, false},
+		{"line zero", "main.go:0: error", Task{}, Problem{}, false},
+		{"not an error", "ok  	example.com/pkg	0.01s", Task{}, Problem{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matcher, err := tt.matcher.Matcher()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, ok := ParseProblem(tt.line, dir, matcher)
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("ParseProblem(%q) = %+v, %v, want %+v, %v", tt.line, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+
+	got, ok := ParseProblem("Died at main.go line 7", dir, custom)
+	if want := (Problem{Location: Location{Path: source, Row: 6}, Message: "Died"}); !ok || got != want {
+		t.Errorf("ParseProblem with a custom matcher = %+v, %v, want %+v", got, ok, want)
+	}
+}
+
+func TestTaskMatcher(t *testing.T) {
+	for _, pattern := range []string{`(?P<file>.+):(?P<message>.+)`, `(`} {
+		if _, err := (Task{ProblemMatcher: pattern}).Matcher(); err == nil {
+			t.Errorf("Matcher(%q) didn't fail", pattern)
+		}
+	}
+}
+
+func TestLoadTasks(t *testing.T) {
+	root := t.TempDir()
+	if _, err := LoadTasks(root); err == nil {
+		t.Error("LoadTasks without a tasks file didn't fail")
+	}
+	path, err := CreateTasksFile(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tasks, err := LoadTasks(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Task{{Label: "Build", Command: "go build ./...", Env: map[string]string{}}, {Label: "Make", Command: "make"}}
+	if !reflect.DeepEqual(tasks, want) {
+		t.Errorf("LoadTasks = %+v, want %+v", tasks, want)
+	}
+
+	// An existing tasks file is left alone, and tasks without a label are named by their command.
+	if err := os.WriteFile(path, []byte(`{"tasks": [{"command": "make test", "cwd": "sub"}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CreateTasksFile(root); err != nil {
+		t.Fatal(err)
+	}
+	tasks, err = LoadTasks(root)
+	if err != nil || len(tasks) != 1 || tasks[0].Label != "make test" || tasks[0].Dir(root) != filepath.Join(root, "sub") {
+		t.Errorf("LoadTasks = %+v, %v", tasks, err)
+	}
+	if dir := (Task{}).Dir(root); dir != root {
+		t.Errorf("Dir without a cwd = %s, want %s", dir, root)
+	}
+}
+
+func TestStartTask(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the command is for sh")
+	}
+	lines := make(chan string, 10)
+	exited := make(chan error, 1)
+	task := Task{Command: `echo "$GREETING"; echo oops >&2; exit 3`, Env: map[string]string{"GREETING": "hello"}}
+	if _, err := StartTask(task, t.TempDir(), func(line string) { lines <- line }, func(err error) { exited <- err }); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-exited:
+		if err == nil {
+			t.Error("a task that failed exited without an error")
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("the task didn't finish")
+	}
+	close(lines)
+	var output []string
+	for line := range lines {
+		output = append(output, line)
+	}
+	if want := []string{"hello", "oops"}; !reflect.DeepEqual(output, want) {
+		t.Errorf("output = %q, want %q", output, want)
+	}
+}
@@ -0,0 +1,23 @@
+//go:build !windows
+
+package handling
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// taskCommand runs a task's command in the shell, leading a process group of its own
+// so stopping the task stops what it started too.
+func taskCommand(command string) *exec.Cmd {
+	cmd := exec.Command("sh", "-c", command)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	return cmd
+}
+
+// killTask kills a task's shell and the rest of its process group.
+func killTask(cmd *exec.Cmd) {
+	if err := syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL); err != nil {
+		cmd.Process.Kill()
+	}
+}
@@ -0,0 +1,61 @@
+//go:build !windows
+
+package handling
+
+import (
+	"bytes"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestStopTask(t *testing.T) {
+	lines := make(chan string, 10)
+	exited := make(chan error, 1)
+	// The shell waits on a child, which would keep running if only the shell were killed.
+	task := Task{Command: `sleep 30 & echo $!; wait`}
+	run, err := StartTask(task, t.TempDir(), func(line string) { lines <- line }, func(err error) { exited <- err })
+	if err != nil {
+		t.Fatal(err)
+	}
+	var child int
+	select {
+	case line := <-lines:
+		if child, err = strconv.Atoi(line); err != nil {
+			t.Fatalf("the task printed %q, not its child's pid", line)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("the task didn't start its child")
+	}
+
+	run.Stop()
+	select {
+	case <-exited:
+	case <-time.After(10 * time.Second):
+		t.Fatal("the task didn't stop")
+	}
+	// The child is gone once it can't be signalled, it may take a moment to be reaped.
+	deadline := time.Now().Add(5 * time.Second)
+	for processExists(child) {
+		if time.Now().After(deadline) {
+			t.Fatalf("the task's child %d is still running", child)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// processExists reports whether a process is still running, one that has exited but not been reaped isn't.
+func processExists(pid int) bool {
+	if syscall.Kill(pid, 0) != nil {
+		return false
+	}
+	stat, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return true
+	}
+	// The state follows the command name, which is in parentheses.
+	state := stat[bytes.LastIndexByte(stat, ')')+2:]
+	return len(state) == 0 || state[0] != 'Z'
+}
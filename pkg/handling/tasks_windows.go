@@ -0,0 +1,20 @@
+//go:build windows
+
+package handling
+
+import (
+	"os/exec"
+	"strconv"
+)
+
+// taskCommand runs a task's command in cmd.
+func taskCommand(command string) *exec.Cmd {
+	return exec.Command("cmd", "/C", command)
+}
+
+// killTask kills a task's cmd and the processes it started, Windows has no process groups to signal.
+func killTask(cmd *exec.Cmd) {
+	if err := exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(cmd.Process.Pid)).Run(); err != nil {
+		cmd.Process.Kill()
+	}
+}
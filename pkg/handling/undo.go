@@ -0,0 +1,138 @@
+package handling
+
+import (
+	"strings"
+	"unicode"
+)
+
+// TextChange replaces Removed with Inserted at Offset in a text, counted in runes.
+type TextChange struct {
+	Offset   int
+	Removed  string
+	Inserted string
+}
+
+// DiffText returns the change that turns before into after, everything between what they start and end with in common.
+// It reports false when they're the same.
+func DiffText(before, after string) (TextChange, bool) {
+	old, updated := []rune(before), []rune(after)
+	prefix := 0
+	for prefix < min(len(old), len(updated)) && old[prefix] == updated[prefix] {
+		prefix++
+	}
+	if prefix == len(old) && prefix == len(updated) {
+		return TextChange{}, false
+	}
+	suffix := 0
+	for suffix < min(len(old), len(updated))-prefix && old[len(old)-1-suffix] == updated[len(updated)-1-suffix] {
+		suffix++
+	}
+	return TextChange{
+		Offset:   prefix,
+		Removed:  string(old[prefix : len(old)-suffix]),
+		Inserted: string(updated[prefix : len(updated)-suffix]),
+	}, true
+}
+
+// Apply returns text with the change made to it.
+func (c TextChange) Apply(text string) string {
+	runes := []rune(text)
+	return string(runes[:c.Offset]) + c.Inserted + string(runes[c.Offset+len([]rune(c.Removed)):])
+}
+
+// Reverse returns the change that undoes c.
+func (c TextChange) Reverse() TextChange {
+	return TextChange{Offset: c.Offset, Removed: c.Inserted, Inserted: c.Removed}
+}
+
+// End returns the position just after the text the change inserts, where the cursor goes once it's made.
+func (c TextChange) End() int {
+	return c.Offset + len([]rune(c.Inserted))
+}
+
+// EditHistory holds the changes made to a text so they can be undone and redone.
+// Characters typed or deleted one after another are undone a word at a time.
+type EditHistory struct {
+	changes []TextChange
+	// done counts the changes that haven't been undone, the rest can be redone.
+	done int
+	// typing is set while the last change can still take more typing.
+	typing bool
+}
+
+// Add records a change made to the text, dropping the changes that were undone.
+// typed is set for a character typed or deleted, which joins the change before it when that was typed
+// right next to it, unless it starts a new word or line.
+func (h *EditHistory) Add(c TextChange, typed bool) {
+	h.changes = h.changes[:h.done]
+	if typed && h.typing && h.done > 0 {
+		if joined, ok := joinTyping(h.changes[h.done-1], c); ok {
+			h.changes[h.done-1] = joined
+			return
+		}
+	}
+	h.changes = append(h.changes, c)
+	h.done++
+	h.typing = typed
+}
+
+// joinTyping joins a typed change onto the one before it, reporting false if it doesn't carry on from it.
+func joinTyping(last, next TextChange) (TextChange, bool) {
+	if strings.Contains(next.Inserted+next.Removed, "\n") {
+		return TextChange{}, false
+	}
+	switch {
+	case next.Removed == "" && next.Offset == last.End():
+		// Typing on, a new word is a step of its own.
+		if startsWord(last.Inserted, next.Inserted) {
+			return TextChange{}, false
+		}
+		last.Inserted += next.Inserted
+		return last, true
+	case last.Inserted == "" && next.Inserted == "" && next.Offset+len([]rune(next.Removed)) == last.Offset:
+		// Backspace.
+		if startsWord(next.Removed, last.Removed) {
+			return TextChange{}, false
+		}
+		return TextChange{Offset: next.Offset, Removed: next.Removed + last.Removed}, true
+	case last.Inserted == "" && next.Inserted == "" && next.Offset == last.Offset:
+		// Delete.
+		if startsWord(last.Removed, next.Removed) {
+			return TextChange{}, false
+		}
+		last.Removed += next.Removed
+		return last, true
+	}
+	return TextChange{}, false
+}
+
+// startsWord reports whether after begins a word where before ends with a space.
+func startsWord(before, after string) bool {
+	b, a := []rune(before), []rune(after)
+	return len(b) > 0 && len(a) > 0 && unicode.IsSpace(b[len(b)-1]) && !unicode.IsSpace(a[0])
+}
+
+// Undo returns the change that undoes the last change made, reporting false if there's none.
+func (h *EditHistory) Undo() (TextChange, bool) {
+	h.typing = false
+	if h.done == 0 {
+		return TextChange{}, false
+	}
+	h.done--
+	return h.changes[h.done].Reverse(), true
+}
+
+// Redo returns the last change undone, reporting false if there's none.
+func (h *EditHistory) Redo() (TextChange, bool) {
+	h.typing = false
+	if h.done == len(h.changes) {
+		return TextChange{}, false
+	}
+	h.done++
+	return h.changes[h.done-1], true
+}
+
+// Clear forgets every change, such as when another file is opened.
+func (h *EditHistory) Clear() {
+	*h = EditHistory{}
+}
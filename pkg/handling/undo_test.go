@@ -0,0 +1,89 @@
+package handling
+
+import "testing"
+
+func TestDiffText(t *testing.T) {
+	c, ok := DiffText("one two three", "one 2 three")
+	if !ok || c != (TextChange{Offset: 4, Removed: "two", Inserted: "2"}) {
+		t.Errorf("DiffText() = %+v, %v", c, ok)
+	}
+	if got := c.Apply("one two three"); got != "one 2 three" {
+		t.Errorf("Apply() = %q", got)
+	}
+	if got := c.Reverse().Apply("one 2 three"); got != "one two three" {
+		t.Errorf("Reverse().Apply() = %q", got)
+	}
+	if _, ok := DiffText("same", "same"); ok {
+		t.Error("DiffText() of the same text reported a change")
+	}
+	// Repeated characters are taken from the end, positions count runes.
+	if c, _ := DiffText("ééé", "éé"); c != (TextChange{Offset: 2, Removed: "é"}) {
+		t.Errorf("DiffText() = %+v", c)
+	}
+}
+
+// typeText records each rune of s typed at offset as a change of its own.
+func typeText(h *EditHistory, text, s string, offset int) string {
+	for _, r := range s {
+		c := TextChange{Offset: offset, Inserted: string(r)}
+		text = c.Apply(text)
+		h.Add(c, true)
+		offset++
+	}
+	return text
+}
+
+func TestEditHistory(t *testing.T) {
+	var h EditHistory
+	text := typeText(&h, "", "one two", 0)
+	if text != "one two" {
+		t.Fatalf("typed %q", text)
+	}
+	// Undo takes a word at a time.
+	c, ok := h.Undo()
+	if !ok || c != (TextChange{Offset: 4, Removed: "two"}) {
+		t.Fatalf("Undo() = %+v, %v", c, ok)
+	}
+	text = c.Apply(text)
+	c, _ = h.Redo()
+	if text = c.Apply(text); text != "one two" || c.End() != 7 {
+		t.Errorf("Redo() gave %q with the cursor at %d", text, c.End())
+	}
+	if _, ok := h.Redo(); ok {
+		t.Error("Redo() with nothing undone reported a change")
+	}
+
+	// Backspace is joined like typing, a change that isn't typed is a step of its own.
+	for i := 0; i < 2; i++ {
+		c := TextChange{Offset: len([]rune(text)) - 1, Removed: text[len(text)-1:]}
+		text = c.Apply(text)
+		h.Add(c, true)
+	}
+	h.Add(TextChange{Offset: 0, Removed: "one", Inserted: "1"}, false)
+	text = "1 t"
+	var steps []string
+	for {
+		c, ok := h.Undo()
+		if !ok {
+			break
+		}
+		text = c.Apply(text)
+		steps = append(steps, text)
+	}
+	want := []string{"one t", "one two", "one ", ""}
+	if len(steps) != len(want) {
+		t.Fatalf("undo steps = %q, want %q", steps, want)
+	}
+	for i := range want {
+		if steps[i] != want[i] {
+			t.Errorf("undo steps = %q, want %q", steps, want)
+			break
+		}
+	}
+
+	// A change after undoing drops what could be redone.
+	h.Add(TextChange{Offset: 0, Inserted: "x"}, false)
+	if _, ok := h.Redo(); ok {
+		t.Error("Redo() after a new change reported a change")
+	}
+}
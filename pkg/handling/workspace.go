@@ -0,0 +1,234 @@
+package handling
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"github.com/fsnotify/fsnotify"
+)
+
+var (
+	// WorkspaceRoot is the folder opened with File > Open Folder, empty if none.
+	WorkspaceRoot string
+	// OnWorkspaceChanged is called after a new folder has been opened.
+	OnWorkspaceChanged func(string)
+
+	// ignore patterns from the workspace's .gitignore, plus the defaults below.
+	workspaceIgnores Ignores
+)
+
+// Ignores are the patterns of a workspace's .gitignore. They don't change once loaded,
+// so a copy can be used off the UI goroutine while another folder is opened.
+type Ignores struct {
+	patterns []string
+}
+
+// defaultIgnores are never shown in the workspace tree.
+var defaultIgnores = []string{".git", ".hg", ".svn", ".DS_Store", "node_modules", "__pycache__", ".idea", ".vscode"}
+
+// opens a folder dialog and makes the selection the workspace root.
+func OpenFolder(window fyne.Window) {
+	dialog.ShowFolderOpen(func(uri fyne.ListableURI, err error) {
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		if uri == nil {
+			return
+		}
+		SetWorkspace(uri.Path())
+	}, window)
+}
+
+// SetWorkspace changes the workspace root and reloads its ignore rules.
+func SetWorkspace(root string) {
+	WorkspaceRoot = root
+	workspaceIgnores = LoadIgnores(root)
+	RecentFolders.Add(root)
+	if OnWorkspaceChanged != nil {
+		OnWorkspaceChanged(root)
+	}
+}
+
+// LoadIgnores reads the simple glob patterns of root's .gitignore file. Negations are not supported.
+func LoadIgnores(root string) Ignores {
+	file, err := os.Open(filepath.Join(root, ".gitignore"))
+	if err != nil {
+		return Ignores{}
+	}
+	defer file.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "!") {
+			continue
+		}
+		patterns = append(patterns, strings.TrimSuffix(line, "/"))
+	}
+	return Ignores{patterns}
+}
+
+// WorkspaceIgnores returns the ignore patterns of the workspace, to pass to work done in the background.
+func WorkspaceIgnores() Ignores {
+	return workspaceIgnores
+}
+
+// IsIgnored reports whether a path inside the workspace should be hidden.
+func IsIgnored(path string) bool {
+	return workspaceIgnores.Match(WorkspaceRoot, path)
+}
+
+// Match reports whether a path inside the workspace at root should be hidden.
+func (ig Ignores) Match(root, path string) bool {
+	name := filepath.Base(path)
+	for _, ignored := range defaultIgnores {
+		if name == ignored {
+			return true
+		}
+	}
+
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		rel = name
+	}
+	rel = filepath.ToSlash(rel)
+	for _, pattern := range ig.patterns {
+		// Patterns containing a slash are relative to the root, others match the name anywhere.
+		if strings.Contains(pattern, "/") {
+			if ok, _ := filepath.Match(strings.TrimPrefix(pattern, "/"), rel); ok {
+				return true
+			}
+		} else if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ListDir returns the visible entries of a directory, folders first.
+func ListDir(dir string) ([]os.DirEntry, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	visible := entries[:0]
+	for _, entry := range entries {
+		if !IsIgnored(filepath.Join(dir, entry.Name())) {
+			visible = append(visible, entry)
+		}
+	}
+	sort.SliceStable(visible, func(i, j int) bool {
+		if visible[i].IsDir() != visible[j].IsDir() {
+			return visible[i].IsDir()
+		}
+		return strings.ToLower(visible[i].Name()) < strings.ToLower(visible[j].Name())
+	})
+	return visible, nil
+}
+
+// CreateFile creates an empty file, failing if it already exists.
+func CreateFile(path string) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// CreateFolder creates a new directory.
+func CreateFolder(path string) error {
+	return os.Mkdir(path, 0755)
+}
+
+// MovePath renames or moves a file or folder, refusing to overwrite.
+func MovePath(from, to string) error {
+	if _, err := os.Stat(to); err == nil {
+		return fmt.Errorf("%s already exists", filepath.Base(to))
+	}
+	if err := os.Rename(from, to); err != nil {
+		return err
+	}
+	// Keep the open file pointing at its new location.
+	if CurrentFile != nil && isWithin(CurrentFile.Path(), from) {
+		CurrentFile = storage.NewFileURI(to + strings.TrimPrefix(CurrentFile.Path(), from))
+		if OnFileChanged != nil {
+			OnFileChanged(CurrentFile)
+		}
+	}
+	return nil
+}
+
+// DeletePath removes a file or a folder with everything in it.
+func DeletePath(path string) error {
+	if err := os.RemoveAll(path); err != nil {
+		return err
+	}
+	if CurrentFile != nil && isWithin(CurrentFile.Path(), path) {
+		CurrentFile = nil
+		if OnFileChanged != nil {
+			OnFileChanged(nil)
+		}
+	}
+	return nil
+}
+
+// isWithin reports whether path is dir or inside it.
+func isWithin(path, dir string) bool {
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
+// WorkspaceWatcher reports changes to the directories shown in the workspace tree.
+type WorkspaceWatcher struct {
+	watcher *fsnotify.Watcher
+}
+
+// WatchWorkspace starts watching for changes, onChange receives the directory whose contents changed.
+func WatchWorkspace(onChange func(dir string)) (*WorkspaceWatcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Op == fsnotify.Chmod {
+					continue
+				}
+				onChange(filepath.Dir(event.Name))
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				fyne.LogError("Workspace watcher failed", err)
+			}
+		}
+	}()
+	return &WorkspaceWatcher{watcher: watcher}, nil
+}
+
+// Add watches a directory, fsnotify is not recursive so each expanded folder is added.
+func (w *WorkspaceWatcher) Add(dir string) {
+	if err := w.watcher.Add(dir); err != nil {
+		fyne.LogError("Failed to watch "+dir, err)
+	}
+}
+
+// Close stops watching.
+func (w *WorkspaceWatcher) Close() {
+	w.watcher.Close()
+}
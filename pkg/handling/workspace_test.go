@@ -0,0 +1,146 @@
+package handling
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/storage"
+)
+
+// useWorkspace makes dir the workspace for the rest of a test.
+func useWorkspace(t *testing.T, dir string) {
+	root, ignores, folders := WorkspaceRoot, workspaceIgnores, RecentFolders
+	RecentFolders = &RecentList{}
+	SetWorkspace(dir)
+	t.Cleanup(func() { WorkspaceRoot, workspaceIgnores, RecentFolders = root, ignores, folders })
+}
+
+func TestIsIgnored(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, ".gitignore"), []byte("# build output\n*.log\n/dist/\nbuild/cache\n!keep.log\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	useWorkspace(t, root)
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"main.go", false},
+		{".git", true},
+		{"web/node_modules", true},
+		{"debug.log", true},
+		{"logs/debug.log", true},
+		{"keep.log", true},
+		{"dist", true},
+		{"web/dist", false},
+		{"build/cache", true},
+		{"web/build/cache", false},
+	}
+	for _, tt := range tests {
+		if got := IsIgnored(filepath.Join(root, filepath.FromSlash(tt.path))); got != tt.want {
+			t.Errorf("IsIgnored(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestListDir(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"b", "A", ".git"} {
+		if err := CreateFolder(filepath.Join(root, dir)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, file := range []string{"c.txt", "B.txt", "a.txt"} {
+		if err := CreateFile(filepath.Join(root, file)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	useWorkspace(t, root)
+	entries, err := ListDir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if want := []string{"A", "b", "a.txt", "B.txt", "c.txt"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("ListDir = %q, want %q", names, want)
+	}
+	if err := CreateFile(filepath.Join(root, "a.txt")); err == nil {
+		t.Error("CreateFile replaced a file that exists")
+	}
+}
+
+func TestMoveAndDeletePath(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "docs")
+	file := filepath.Join(dir, "notes.md")
+	if err := CreateFolder(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := CreateFile(file); err != nil {
+		t.Fatal(err)
+	}
+	current, onChanged := CurrentFile, OnFileChanged
+	t.Cleanup(func() { CurrentFile, OnFileChanged = current, onChanged })
+	var changed []string
+	OnFileChanged = func(uri fyne.URI) {
+		if uri == nil {
+			changed = append(changed, "")
+		} else {
+			changed = append(changed, uri.Path())
+		}
+	}
+	CurrentFile = storage.NewFileURI(file)
+
+	moved := filepath.Join(root, "papers")
+	if err := MovePath(dir, moved); err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(moved, "notes.md"); CurrentFile.Path() != want {
+		t.Errorf("after moving its folder the current file is %s, want %s", CurrentFile.Path(), want)
+	}
+	if err := CreateFolder(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := MovePath(moved, dir); err == nil {
+		t.Error("MovePath replaced a folder that exists")
+	}
+
+	if err := DeletePath(moved); err != nil {
+		t.Fatal(err)
+	}
+	if CurrentFile != nil {
+		t.Errorf("after deleting its folder the current file is %s", CurrentFile.Path())
+	}
+	if want := []string{filepath.Join(moved, "notes.md"), ""}; !reflect.DeepEqual(changed, want) {
+		t.Errorf("OnFileChanged got %q, want %q", changed, want)
+	}
+}
+
+func TestWatchWorkspace(t *testing.T) {
+	root := t.TempDir()
+	changes := make(chan string, 10)
+	watcher, err := WatchWorkspace(func(dir string) { changes <- dir })
+	if err != nil {
+		t.Skip("can't watch files here:", err)
+	}
+	defer watcher.Close()
+	watcher.Add(root)
+	if err := CreateFile(filepath.Join(root, "new.txt")); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case dir := <-changes:
+		if dir != root {
+			t.Errorf("change reported in %s, want %s", dir, root)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("creating a file wasn't reported")
+	}
+}
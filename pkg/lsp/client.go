@@ -0,0 +1,346 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// shutdownTimeout is how long a server gets to shut down before it's killed.
+const shutdownTimeout = 2 * time.Second
+
+// Client talks to one language server about its documents, which can be in several languages,
+// such as C and C++ for clangd.
+type Client struct {
+	conn *conn
+	cmd  *exec.Cmd
+	// capabilities is what the server said it can do in its reply to initialize.
+	capabilities map[string]json.RawMessage
+
+	lock sync.Mutex
+	// versions holds the version of each open document, keyed by path.
+	versions map[string]int
+}
+
+// Start runs a language server and connects to it over its standard input and output.
+func Start(command []string, root string, onDiagnostics func(path string, diagnostics []Diagnostic)) (*Client, error) {
+	if len(command) == 0 {
+		return nil, errors.New("no language server command")
+	}
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Dir = root
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	client, err := NewClient(&stdio{stdout, stdin}, root, onDiagnostics)
+	if err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return nil, err
+	}
+	client.cmd = cmd
+	return client, nil
+}
+
+// stdio joins a server's output and input into one connection.
+type stdio struct {
+	io.ReadCloser
+	io.WriteCloser
+}
+
+func (s *stdio) Close() error {
+	s.WriteCloser.Close()
+	return s.ReadCloser.Close()
+}
+
+// NewClient initializes a server on an existing connection, such as a server running in the same process.
+// onDiagnostics is called from the connection's goroutine whenever the server publishes diagnostics.
+func NewClient(rw io.ReadWriteCloser, root string, onDiagnostics func(path string, diagnostics []Diagnostic)) (*Client, error) {
+	c := &Client{versions: map[string]int{}}
+	c.conn = newConn(rw, func(method string, params json.RawMessage) (any, error) {
+		return c.handle(method, params, onDiagnostics)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	var result struct {
+		Capabilities map[string]json.RawMessage `json:"capabilities"`
+	}
+	err := c.conn.Call(ctx, "initialize", map[string]any{
+		"processId":    os.Getpid(),
+		"clientInfo":   map[string]string{"name": "Leda"},
+		"rootUri":      PathToURI(root),
+		"capabilities": clientCapabilities,
+		"workspaceFolders": []map[string]string{
+			{"uri": PathToURI(root), "name": root},
+		},
+	}, &result)
+	if err != nil {
+		c.conn.Close()
+		return nil, err
+	}
+	c.capabilities = result.Capabilities
+	if err := c.conn.Notify("initialized", map[string]any{}); err != nil {
+		c.conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// clientCapabilities tells servers what Leda understands.
+var clientCapabilities = map[string]any{
+	"textDocument": map[string]any{
+		"synchronization":    map[string]any{"didSave": true},
+		"publishDiagnostics": map[string]any{},
+		"hover":              map[string]any{"contentFormat": []string{"markdown", "plaintext"}},
+		"definition":         map[string]any{"linkSupport": true},
+		"references":         map[string]any{},
+		"rename":             map[string]any{},
+		"completion": map[string]any{
+			"completionItem": map[string]any{
+				"snippetSupport":      true,
+				"documentationFormat": []string{"markdown", "plaintext"},
+			},
+		},
+	},
+	"workspace": map[string]any{
+		"workspaceEdit":    map[string]any{"documentChanges": true},
+		"configuration":    true,
+		"workspaceFolders": true,
+	},
+}
+
+// handle answers the server's requests and notifications.
+func (c *Client) handle(method string, params json.RawMessage, onDiagnostics func(string, []Diagnostic)) (any, error) {
+	switch method {
+	case "textDocument/publishDiagnostics":
+		var p struct {
+			URI         string       `json:"uri"`
+			Diagnostics []Diagnostic `json:"diagnostics"`
+		}
+		if err := json.Unmarshal(params, &p); err == nil && onDiagnostics != nil {
+			onDiagnostics(URIToPath(p.URI), p.Diagnostics)
+		}
+		return nil, nil
+	case "workspace/configuration":
+		// No settings, one null per item asked for.
+		var p struct {
+			Items []json.RawMessage `json:"items"`
+		}
+		json.Unmarshal(params, &p)
+		return make([]any, len(p.Items)), nil
+	case "window/workDoneProgress/create", "client/registerCapability", "client/unregisterCapability":
+		return nil, nil
+	case "workspace/applyEdit":
+		// Edits the server starts itself, such as from code actions, aren't supported yet.
+		return map[string]bool{"applied": false}, nil
+	case "window/showMessage", "window/logMessage", "$/progress", "telemetry/event":
+		return nil, nil
+	}
+	return nil, &ResponseError{Code: codeMethodNotFound, Message: "method not supported: " + method}
+}
+
+// Supports reports whether the server has a capability, such as "hoverProvider".
+func (c *Client) Supports(capability string) bool {
+	value, ok := c.capabilities[capability]
+	return ok && string(value) != "false" && string(value) != "null"
+}
+
+// Open tells the server a document in a language, such as "go", is open in the editor.
+func (c *Client) Open(path, languageID, text string) error {
+	c.lock.Lock()
+	c.versions[path] = 1
+	c.lock.Unlock()
+	return c.conn.Notify("textDocument/didOpen", map[string]any{
+		"textDocument": map[string]any{
+			"uri":        PathToURI(path),
+			"languageId": languageID,
+			"version":    1,
+			"text":       text,
+		},
+	})
+}
+
+// Change sends the full new text of a document, opening it first in its language if needed.
+func (c *Client) Change(path, languageID, text string) error {
+	c.lock.Lock()
+	version, open := c.versions[path]
+	version++
+	c.versions[path] = version
+	c.lock.Unlock()
+	if !open {
+		return c.Open(path, languageID, text)
+	}
+	return c.conn.Notify("textDocument/didChange", map[string]any{
+		"textDocument":   map[string]any{"uri": PathToURI(path), "version": version},
+		"contentChanges": []map[string]string{{"text": text}},
+	})
+}
+
+// Save tells the server a document was saved.
+func (c *Client) Save(path, text string) error {
+	return c.conn.Notify("textDocument/didSave", map[string]any{
+		"textDocument": map[string]string{"uri": PathToURI(path)},
+		"text":         text,
+	})
+}
+
+// Close tells the server a document is no longer open in the editor.
+func (c *Client) Close(path string) error {
+	c.lock.Lock()
+	_, open := c.versions[path]
+	delete(c.versions, path)
+	c.lock.Unlock()
+	if !open {
+		return nil
+	}
+	return c.conn.Notify("textDocument/didClose", map[string]any{
+		"textDocument": map[string]string{"uri": PathToURI(path)},
+	})
+}
+
+// IsOpen reports whether a document has been opened with the server.
+func (c *Client) IsOpen(path string) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	_, open := c.versions[path]
+	return open
+}
+
+// positionParams identifies a position in a document.
+func positionParams(path string, pos Position) map[string]any {
+	return map[string]any{
+		"textDocument": map[string]string{"uri": PathToURI(path)},
+		"position":     pos,
+	}
+}
+
+// Hover returns the documentation of the symbol at a position as markdown, "" if there is none.
+func (c *Client) Hover(ctx context.Context, path string, pos Position) (string, error) {
+	var result *struct {
+		Contents Markup `json:"contents"`
+	}
+	if err := c.conn.Call(ctx, "textDocument/hover", positionParams(path, pos), &result); err != nil || result == nil {
+		return "", err
+	}
+	return string(result.Contents), nil
+}
+
+// Definition returns where the symbol at a position is defined.
+func (c *Client) Definition(ctx context.Context, path string, pos Position) ([]Location, error) {
+	var result json.RawMessage
+	if err := c.conn.Call(ctx, "textDocument/definition", positionParams(path, pos), &result); err != nil {
+		return nil, err
+	}
+	return decodeLocations(result)
+}
+
+// References returns every use of the symbol at a position, including its declaration.
+func (c *Client) References(ctx context.Context, path string, pos Position) ([]Location, error) {
+	params := positionParams(path, pos)
+	params["context"] = map[string]bool{"includeDeclaration": true}
+	var result []Location
+	err := c.conn.Call(ctx, "textDocument/references", params, &result)
+	return result, err
+}
+
+// Rename returns the edits that rename the symbol at a position.
+func (c *Client) Rename(ctx context.Context, path string, pos Position, newName string) (*WorkspaceEdit, error) {
+	params := positionParams(path, pos)
+	params["newName"] = newName
+	var result WorkspaceEdit
+	err := c.conn.Call(ctx, "textDocument/rename", params, &result)
+	return &result, err
+}
+
+// Completion returns the suggestions for a position.
+func (c *Client) Completion(ctx context.Context, path string, pos Position) ([]CompletionItem, error) {
+	var result json.RawMessage
+	if err := c.conn.Call(ctx, "textDocument/completion", positionParams(path, pos), &result); err != nil {
+		return nil, err
+	}
+	// The result is either a list of items or a CompletionList holding them.
+	var items []CompletionItem
+	if json.Unmarshal(result, &items) == nil {
+		return items, nil
+	}
+	var list struct {
+		Items []CompletionItem `json:"items"`
+	}
+	if err := json.Unmarshal(result, &list); err != nil && string(result) != "null" {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// Done returns a channel that's closed when the connection to the server closes, such as when it exits.
+func (c *Client) Done() <-chan struct{} {
+	return c.conn.closed
+}
+
+// Shutdown asks the server to exit, killing it if it doesn't.
+func (c *Client) Shutdown() {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if c.conn.Call(ctx, "shutdown", nil, nil) == nil {
+		c.conn.Notify("exit", nil)
+	}
+	c.conn.Close()
+	if c.cmd != nil {
+		done := make(chan struct{})
+		go func() {
+			c.cmd.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(shutdownTimeout):
+			c.cmd.Process.Kill()
+		}
+	}
+}
+
+// decodeLocations reads a definition result, which may be a Location, a list of them or a list of LocationLinks.
+func decodeLocations(result json.RawMessage) ([]Location, error) {
+	if len(result) == 0 || string(result) == "null" {
+		return nil, nil
+	}
+	var one Location
+	if result[0] == '{' {
+		if err := json.Unmarshal(result, &one); err != nil {
+			return nil, err
+		}
+		return []Location{one}, nil
+	}
+	var items []struct {
+		Location
+		TargetURI            string `json:"targetUri"`
+		TargetSelectionRange Range  `json:"targetSelectionRange"`
+	}
+	if err := json.Unmarshal(result, &items); err != nil {
+		return nil, err
+	}
+	locations := make([]Location, len(items))
+	for i, item := range items {
+		if item.TargetURI != "" {
+			locations[i] = Location{URI: item.TargetURI, Range: item.TargetSelectionRange}
+		} else {
+			locations[i] = item.Location
+		}
+	}
+	return locations, nil
+}
@@ -0,0 +1,387 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeServer is a language server running in the test, answering requests with canned results.
+type fakeServer struct {
+	conn *conn
+
+	lock sync.Mutex
+	// opened holds the languageId each document was opened with, keyed by URI.
+	opened map[string]string
+	// changes holds the last text sent for each document, keyed by URI.
+	changes map[string]string
+	// initialize holds the parameters the client initialized the server with.
+	initialize map[string]any
+	// results are what requests are answered with, keyed by method.
+	results map[string]any
+	// diagnostics are published for each document when it's opened.
+	diagnostics []Diagnostic
+}
+
+// newFakeServer connects a client to a new fake server.
+func newFakeServer(t *testing.T, results map[string]any, onDiagnostics func(string, []Diagnostic)) (*fakeServer, *Client) {
+	t.Helper()
+	clientSide, serverSide := net.Pipe()
+	s := &fakeServer{opened: map[string]string{}, changes: map[string]string{}, results: results}
+	s.conn = newConn(serverSide, s.handle)
+	client, err := NewClient(clientSide, t.TempDir(), onDiagnostics)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		client.conn.Close()
+		s.conn.Close()
+	})
+	return s, client
+}
+
+func (s *fakeServer) handle(method string, params json.RawMessage) (any, error) {
+	var p struct {
+		TextDocument struct {
+			URI        string `json:"uri"`
+			LanguageID string `json:"languageId"`
+			Text       string `json:"text"`
+		} `json:"textDocument"`
+		ContentChanges []struct {
+			Text string `json:"text"`
+		} `json:"contentChanges"`
+	}
+	json.Unmarshal(params, &p)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	switch method {
+	case "initialize":
+		json.Unmarshal(params, &s.initialize)
+		return map[string]any{"capabilities": map[string]any{
+			"hoverProvider":      true,
+			"definitionProvider": map[string]any{},
+			"renameProvider":     false,
+		}}, nil
+	case "textDocument/didOpen":
+		s.opened[p.TextDocument.URI] = p.TextDocument.LanguageID
+		s.changes[p.TextDocument.URI] = p.TextDocument.Text
+		if s.diagnostics != nil {
+			go s.conn.Notify("textDocument/publishDiagnostics", map[string]any{
+				"uri":         p.TextDocument.URI,
+				"diagnostics": s.diagnostics,
+			})
+		}
+	case "textDocument/didChange":
+		s.changes[p.TextDocument.URI] = p.ContentChanges[0].Text
+	}
+	return s.results[method], nil
+}
+
+// languageOf returns the languageId a document was opened with.
+func (s *fakeServer) languageOf(path string) string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.opened[PathToURI(path)]
+}
+
+// textOf returns the last text the server was sent for a document.
+func (s *fakeServer) textOf(path string) string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.changes[PathToURI(path)]
+}
+
+// waitForServer waits for the notifications sent so far to reach the server, as a request is answered after them.
+func waitForServer(t *testing.T, client *Client) {
+	t.Helper()
+	if err := client.conn.Call(context.Background(), "leda/sync", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInitialize(t *testing.T) {
+	s, client := newFakeServer(t, nil, nil)
+	tests := []struct {
+		capability string
+		want       bool
+	}{
+		{"hoverProvider", true},
+		{"definitionProvider", true},
+		{"renameProvider", false},
+		{"completionProvider", false},
+	}
+	for _, tt := range tests {
+		if got := client.Supports(tt.capability); got != tt.want {
+			t.Errorf("Supports(%q) = %v, want %v", tt.capability, got, tt.want)
+		}
+	}
+	waitForServer(t, client)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if root, _ := s.initialize["rootUri"].(string); root == "" {
+		t.Errorf("initialize had no rootUri: %v", s.initialize)
+	}
+}
+
+func TestOpenAndChange(t *testing.T) {
+	s, client := newFakeServer(t, nil, nil)
+	dir := t.TempDir()
+	tests := []struct {
+		name, languageID string
+	}{
+		{"a.js", "javascript"},
+		{"b.ts", "typescript"},
+		{"c.tsx", "typescriptreact"},
+	}
+	for _, tt := range tests {
+		if err := client.Open(filepath.Join(dir, tt.name), tt.languageID, "text"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Changing a document that isn't open opens it.
+	if err := client.Change(filepath.Join(dir, "d.c"), "c", "int x;"); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Change(filepath.Join(dir, "a.js"), "javascript", "changed"); err != nil {
+		t.Fatal(err)
+	}
+	waitForServer(t, client)
+	for _, tt := range append(tests, struct{ name, languageID string }{"d.c", "c"}) {
+		if got := s.languageOf(filepath.Join(dir, tt.name)); got != tt.languageID {
+			t.Errorf("%s opened as %q, want %q", tt.name, got, tt.languageID)
+		}
+	}
+	if got := s.textOf(filepath.Join(dir, "a.js")); got != "changed" {
+		t.Errorf("a.js text = %q, want %q", got, "changed")
+	}
+
+	path := filepath.Join(dir, "b.ts")
+	if err := client.Close(path); err != nil {
+		t.Fatal(err)
+	}
+	if client.IsOpen(path) {
+		t.Errorf("%s is still open after Close", path)
+	}
+}
+
+func TestPublishDiagnostics(t *testing.T) {
+	type published struct {
+		path        string
+		diagnostics []Diagnostic
+	}
+	received := make(chan published, 1)
+	s, client := newFakeServer(t, nil, func(path string, diagnostics []Diagnostic) {
+		received <- published{path, diagnostics}
+	})
+	want := []Diagnostic{{
+		Range:    Range{Start: Position{Line: 1, Character: 2}, End: Position{Line: 1, Character: 5}},
+		Severity: SeverityWarning,
+		Source:   "vet",
+		Message:  "unused",
+	}}
+	s.lock.Lock()
+	s.diagnostics = want
+	s.lock.Unlock()
+
+	path := filepath.Join(t.TempDir(), "main.go")
+	if err := client.Open(path, "go", "package main"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case got := <-received:
+		if got.path != path || !reflect.DeepEqual(got.diagnostics, want) {
+			t.Errorf("published %v for %s, want %v for %s", got.diagnostics, got.path, want, path)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no diagnostics were published")
+	}
+}
+
+func TestHover(t *testing.T) {
+	tests := []struct {
+		name   string
+		result any
+		want   string
+	}{
+		{"markup content", map[string]any{"contents": map[string]string{"kind": "markdown", "value": "**x**"}}, "**x**"},
+		{"string", map[string]any{"contents": "plain"}, "plain"},
+		{"marked strings", map[string]any{"contents": []any{map[string]string{"language": "go", "value": "func f()"}, "doc"}}, "```go\nfunc f()\n```\n\ndoc"},
+		{"nothing", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, client := newFakeServer(t, map[string]any{"textDocument/hover": tt.result}, nil)
+			got, err := client.Hover(context.Background(), "/a.go", Position{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Hover = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefinition(t *testing.T) {
+	target := Range{Start: Position{Line: 3, Character: 5}, End: Position{Line: 3, Character: 8}}
+	location := Location{URI: PathToURI("/src/a.go"), Range: target}
+	tests := []struct {
+		name   string
+		result any
+		want   []Location
+	}{
+		{"location", location, []Location{location}},
+		{"locations", []Location{location, location}, []Location{location, location}},
+		{"links", []map[string]any{{
+			"targetUri":            location.URI,
+			"targetRange":          Range{End: Position{Line: 9}},
+			"targetSelectionRange": target,
+		}}, []Location{location}},
+		{"nothing", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, client := newFakeServer(t, map[string]any{"textDocument/definition": tt.result}, nil)
+			got, err := client.Definition(context.Background(), "/a.go", Position{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Definition = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRename(t *testing.T) {
+	// "😀" takes two UTF-16 code units, so "x" after it starts at character 11, not 10.
+	text := "a := \"😀\"; x := 1\nprint(x)\n"
+	path := "/src/main.go"
+	edit := map[string]any{
+		"changes": map[string][]TextEdit{PathToURI(path): {
+			{Range: Range{Start: Position{Line: 0, Character: 11}, End: Position{Line: 0, Character: 12}}, NewText: "count"},
+			{Range: Range{Start: Position{Line: 1, Character: 6}, End: Position{Line: 1, Character: 7}}, NewText: "count"},
+		}},
+		"documentChanges": []map[string]any{{
+			"textDocument": map[string]any{"uri": PathToURI("/src/other.go"), "version": 1},
+			"edits":        []TextEdit{{Range: Range{End: Position{Character: 1}}, NewText: "y"}},
+		}},
+	}
+	_, client := newFakeServer(t, map[string]any{"textDocument/rename": edit}, nil)
+	result, err := client.Rename(context.Background(), path, PositionFor(text, 0, 10), "count")
+	if err != nil {
+		t.Fatal(err)
+	}
+	edits := result.Edits()
+	if len(edits) != 2 || len(edits[filepath.FromSlash("/src/other.go")]) != 1 {
+		t.Errorf("Edits = %v, want edits of main.go and other.go", edits)
+	}
+	want := "a := \"😀\"; count := 1\nprint(count)\n"
+	if got := ApplyEdits(text, edits[filepath.FromSlash(path)]); got != want {
+		t.Errorf("ApplyEdits = %q, want %q", got, want)
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	items := []CompletionItem{{Label: "Println", Kind: CompletionFunction, InsertText: "Println($1)", InsertTextFormat: InsertTextFormatSnippet}}
+	tests := []struct {
+		name   string
+		result any
+		want   []CompletionItem
+	}{
+		{"list of items", items, items},
+		{"completion list", map[string]any{"isIncomplete": false, "items": items}, items},
+		{"nothing", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, client := newFakeServer(t, map[string]any{"textDocument/completion": tt.result}, nil)
+			got, err := client.Completion(context.Background(), "/a.go", Position{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Completion = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPositions(t *testing.T) {
+	text := "a😀b\nxyz"
+	tests := []struct {
+		row, column int
+		want        Position
+	}{
+		{0, 0, Position{0, 0}},
+		{0, 2, Position{0, 3}},
+		{0, 3, Position{0, 4}},
+		{1, 2, Position{1, 2}},
+	}
+	for _, tt := range tests {
+		got := PositionFor(text, tt.row, tt.column)
+		if got != tt.want {
+			t.Errorf("PositionFor(%d, %d) = %v, want %v", tt.row, tt.column, got, tt.want)
+		}
+		if row, column := ColumnFor(text, got); row != tt.row || column != tt.column {
+			t.Errorf("ColumnFor(%v) = %d, %d, want %d, %d", got, row, column, tt.row, tt.column)
+		}
+	}
+}
+
+func TestManager(t *testing.T) {
+	m := NewManager(t.TempDir(), nil)
+	var lock sync.Mutex
+	var servers []*fakeServer
+	m.SetStarter(func(server Server, root string, onDiagnostics func(string, []Diagnostic)) (*Client, error) {
+		s, client := newFakeServer(t, nil, onDiagnostics)
+		lock.Lock()
+		servers = append(servers, s)
+		lock.Unlock()
+		return client, nil
+	})
+
+	c := m.ClientFor("/src/a.c")
+	if c == nil {
+		t.Fatal("no client for a.c")
+	}
+	// C and C++ share clangd, but each file is sent in its own language.
+	if cpp := m.ClientFor("/src/b.cpp"); cpp != c {
+		t.Errorf("b.cpp has another client than a.c")
+	}
+	for path, want := range map[string]string{"/src/a.c": "c", "/src/b.cpp": "cpp", "/src/c.tsx": "typescriptreact", "/src/d.txt": ""} {
+		if got := m.LanguageID(path); got != want {
+			t.Errorf("LanguageID(%q) = %q, want %q", path, got, want)
+		}
+	}
+	if m.ClientFor("/src/notes.txt") != nil {
+		t.Errorf("a client was started for a file without a server")
+	}
+
+	// A server that goes away is started again when next needed.
+	lock.Lock()
+	servers[0].conn.Close()
+	lock.Unlock()
+	select {
+	case <-c.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("the client didn't see its server go away")
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for m.Running("/src/a.c") == c && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if again := m.ClientFor("/src/a.c"); again == nil || again == c {
+		t.Errorf("ClientFor after the server exited = %p, want a new client", again)
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	if len(servers) != 2 {
+		t.Errorf("started %d servers, want 2", len(servers))
+	}
+}
@@ -0,0 +1,191 @@
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"sync"
+)
+
+// ErrClosed is returned by calls on a connection whose server has gone away.
+var ErrClosed = errors.New("language server connection closed")
+
+// codeMethodNotFound is the JSON-RPC error for requests the client doesn't handle.
+const codeMethodNotFound = -32601
+
+// ResponseError is an error returned by the server.
+type ResponseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("language server error %d: %s", e.Code, e.Message)
+}
+
+// message is any JSON-RPC message: a request, a notification or a response.
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *ResponseError  `json:"error,omitempty"`
+}
+
+// handler answers requests and notifications from the server.
+// The result of a notification is ignored.
+type handler func(method string, params json.RawMessage) (any, error)
+
+// conn is a JSON-RPC 2.0 connection framed with Content-Length headers, as LSP uses over stdio.
+type conn struct {
+	rw      io.ReadWriteCloser
+	handle  handler
+	writing sync.Mutex
+
+	lock    sync.Mutex
+	nextID  int
+	pending map[string]chan *message
+	closed  chan struct{}
+}
+
+// newConn starts reading messages from rw, passing the server's requests and notifications to handle.
+func newConn(rw io.ReadWriteCloser, handle handler) *conn {
+	c := &conn{rw: rw, handle: handle, pending: map[string]chan *message{}, closed: make(chan struct{})}
+	go c.read()
+	return c
+}
+
+// Call sends a request and decodes its result into result, which may be nil.
+func (c *conn) Call(ctx context.Context, method string, params, result any) error {
+	c.lock.Lock()
+	c.nextID++
+	id := strconv.Itoa(c.nextID)
+	reply := make(chan *message, 1)
+	c.pending[id] = reply
+	c.lock.Unlock()
+	defer func() {
+		c.lock.Lock()
+		delete(c.pending, id)
+		c.lock.Unlock()
+	}()
+
+	request := map[string]any{"jsonrpc": "2.0", "id": json.RawMessage(id), "method": method}
+	if params != nil {
+		request["params"] = params
+	}
+	if err := c.send(request); err != nil {
+		return err
+	}
+	select {
+	case msg := <-reply:
+		if msg.Error != nil {
+			return msg.Error
+		}
+		if result == nil || len(msg.Result) == 0 {
+			return nil
+		}
+		return json.Unmarshal(msg.Result, result)
+	case <-ctx.Done():
+		// Tell the server it can stop working on the request.
+		c.Notify("$/cancelRequest", map[string]any{"id": json.RawMessage(id)})
+		return ctx.Err()
+	case <-c.closed:
+		return ErrClosed
+	}
+}
+
+// Notify sends a notification, which has no reply.
+func (c *conn) Notify(method string, params any) error {
+	notification := map[string]any{"jsonrpc": "2.0", "method": method}
+	if params != nil {
+		notification["params"] = params
+	}
+	return c.send(notification)
+}
+
+// Close closes the connection, failing calls that are waiting for a reply.
+func (c *conn) Close() error {
+	return c.rw.Close()
+}
+
+// send writes one framed message.
+func (c *conn) send(msg any) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	c.writing.Lock()
+	defer c.writing.Unlock()
+	select {
+	case <-c.closed:
+		return ErrClosed
+	default:
+	}
+	if _, err := fmt.Fprintf(c.rw, "Content-Length: %d\r\n\r\n", len(data)); err != nil {
+		return err
+	}
+	_, err = c.rw.Write(data)
+	return err
+}
+
+// read takes messages from the server until the connection closes.
+func (c *conn) read() {
+	defer close(c.closed)
+	r := bufio.NewReader(c.rw)
+	headers := textproto.NewReader(r)
+	for {
+		header, err := headers.ReadMIMEHeader()
+		if err != nil {
+			return
+		}
+		length, err := strconv.Atoi(header.Get("Content-Length"))
+		if err != nil {
+			return
+		}
+		data := make([]byte, length)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return
+		}
+		var msg message
+		if err := json.Unmarshal(data, &msg); err != nil {
+			continue
+		}
+
+		switch {
+		case msg.Method == "":
+			c.lock.Lock()
+			reply := c.pending[string(msg.ID)]
+			c.lock.Unlock()
+			if reply != nil {
+				reply <- &msg
+			}
+		case msg.ID == nil:
+			c.handle(msg.Method, msg.Params)
+		default:
+			// Answering mustn't hold up reading, the server may be writing to us at the same time.
+			go c.reply(msg.ID, msg.Method, msg.Params)
+		}
+	}
+}
+
+// reply answers a request from the server.
+func (c *conn) reply(id json.RawMessage, method string, params json.RawMessage) {
+	result, err := c.handle(method, params)
+	response := map[string]any{"jsonrpc": "2.0", "id": id}
+	var rpcErr *ResponseError
+	switch {
+	case errors.As(err, &rpcErr):
+		response["error"] = rpcErr
+	case err != nil:
+		response["error"] = &ResponseError{Code: -32603, Message: err.Error()}
+	default:
+		response["result"] = result
+	}
+	c.send(response)
+}
@@ -0,0 +1,204 @@
+package lsp
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Server is the language server used for a kind of file.
+type Server struct {
+	LanguageID string
+	Command    []string
+}
+
+// DefaultServers are the servers started for each file extension unless config.json says otherwise.
+var DefaultServers = map[string]Server{
+	".go":   {"go", []string{"gopls"}},
+	".py":   {"python", []string{"pylsp"}},
+	".rs":   {"rust", []string{"rust-analyzer"}},
+	".c":    {"c", []string{"clangd"}},
+	".h":    {"c", []string{"clangd"}},
+	".cpp":  {"cpp", []string{"clangd"}},
+	".hpp":  {"cpp", []string{"clangd"}},
+	".js":   {"javascript", []string{"typescript-language-server", "--stdio"}},
+	".jsx":  {"javascriptreact", []string{"typescript-language-server", "--stdio"}},
+	".ts":   {"typescript", []string{"typescript-language-server", "--stdio"}},
+	".tsx":  {"typescriptreact", []string{"typescript-language-server", "--stdio"}},
+	".sh":   {"shellscript", []string{"bash-language-server", "start"}},
+	".bash": {"shellscript", []string{"bash-language-server", "start"}},
+	".md":   {"markdown", []string{"marksman", "server"}},
+}
+
+// ServerFor returns the server for a file, overrides are commands keyed by extension from config.json.
+func ServerFor(path string, overrides map[string][]string) (Server, bool) {
+	ext := strings.ToLower(filepath.Ext(path))
+	server, ok := DefaultServers[ext]
+	if command, overridden := overrides[ext]; overridden {
+		if len(command) == 0 {
+			// An empty command turns the server off.
+			return Server{}, false
+		}
+		if !ok {
+			server.LanguageID = strings.TrimPrefix(ext, ".")
+		}
+		return Server{LanguageID: server.LanguageID, Command: command}, true
+	}
+	return server, ok
+}
+
+// Manager starts language servers as files that need them are opened, one per command.
+// A server that exits is started again the next time a file needs it.
+type Manager struct {
+	// OnDiagnostics is called from the servers' goroutines whenever diagnostics are published.
+	OnDiagnostics func(path string, diagnostics []Diagnostic)
+	// OnError is called when a server can't be started, once per server.
+	OnError func(err error)
+
+	lock      sync.Mutex
+	root      string
+	overrides map[string][]string
+	// servers holds the servers that have been started, keyed by command.
+	// Ones that couldn't be started are kept too, so they aren't tried again for every file,
+	// but ones that have exited are dropped.
+	servers map[string]*runningServer
+	// start creates clients, it's replaced to connect to servers running in the same process.
+	start StartFunc
+}
+
+// StartFunc starts the server for a workspace.
+type StartFunc func(server Server, root string, onDiagnostics func(string, []Diagnostic)) (*Client, error)
+
+// runningServer is a server that is starting or has started.
+type runningServer struct {
+	// ready is closed once the server has started or failed to.
+	ready  chan struct{}
+	client *Client
+}
+
+// NewManager creates a manager for the servers of a workspace.
+func NewManager(root string, overrides map[string][]string) *Manager {
+	return &Manager{
+		root:      root,
+		overrides: overrides,
+		servers:   map[string]*runningServer{},
+		start: func(server Server, root string, onDiagnostics func(string, []Diagnostic)) (*Client, error) {
+			if _, err := exec.LookPath(server.Command[0]); err != nil {
+				return nil, fmt.Errorf("%s language server %s isn't installed", server.LanguageID, server.Command[0])
+			}
+			return Start(server.Command, root, onDiagnostics)
+		},
+	}
+}
+
+// SetStarter replaces how servers are started, for example to use a fake server running in the same process.
+func (m *Manager) SetStarter(start StartFunc) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.start = start
+}
+
+// ClientFor returns the client for a file's language, starting its server if needed.
+// It returns nil if there is no server for the file or it couldn't be started.
+// Starting a server can take a while, so this shouldn't be called from the UI goroutine.
+func (m *Manager) ClientFor(path string) *Client {
+	server, ok := ServerFor(path, m.overrides)
+	if !ok {
+		return nil
+	}
+	key := strings.Join(server.Command, " ")
+
+	m.lock.Lock()
+	if running, ok := m.servers[key]; ok {
+		m.lock.Unlock()
+		<-running.ready
+		return running.client
+	}
+	running := &runningServer{ready: make(chan struct{})}
+	m.servers[key] = running
+	root, start := m.root, m.start
+	m.lock.Unlock()
+
+	if root == "" {
+		root = filepath.Dir(path)
+	}
+	client, err := start(server, root, func(path string, diagnostics []Diagnostic) {
+		if m.OnDiagnostics != nil {
+			m.OnDiagnostics(path, diagnostics)
+		}
+	})
+	if err != nil && m.OnError != nil {
+		m.OnError(err)
+	}
+	running.client = client
+	close(running.ready)
+	if client != nil {
+		go func() {
+			<-client.Done()
+			m.lock.Lock()
+			if m.servers[key] == running {
+				delete(m.servers, key)
+			}
+			m.lock.Unlock()
+		}()
+	}
+	return client
+}
+
+// LanguageID returns the language a file is sent to its server as, such as "typescript", "" if it has no server.
+func (m *Manager) LanguageID(path string) string {
+	server, _ := ServerFor(path, m.overrides)
+	return server.LanguageID
+}
+
+// Handles reports whether there is a server for a file.
+func (m *Manager) Handles(path string) bool {
+	_, ok := ServerFor(path, m.overrides)
+	return ok
+}
+
+// Running returns the client for a file's language if its server has already started, without waiting.
+func (m *Manager) Running(path string) *Client {
+	server, ok := ServerFor(path, m.overrides)
+	if !ok {
+		return nil
+	}
+	m.lock.Lock()
+	running, ok := m.servers[strings.Join(server.Command, " ")]
+	m.lock.Unlock()
+	if !ok {
+		return nil
+	}
+	select {
+	case <-running.ready:
+		return running.client
+	default:
+		return nil
+	}
+}
+
+// SetRoot shuts the servers down so they start again for a new workspace.
+func (m *Manager) SetRoot(root string) {
+	m.lock.Lock()
+	m.root = root
+	m.lock.Unlock()
+	m.Shutdown()
+}
+
+// Shutdown stops every server in the background, they start again when next needed.
+func (m *Manager) Shutdown() {
+	m.lock.Lock()
+	servers := m.servers
+	m.servers = map[string]*runningServer{}
+	m.lock.Unlock()
+	for _, running := range servers {
+		go func() {
+			<-running.ready
+			if running.client != nil {
+				running.client.Shutdown()
+			}
+		}()
+	}
+}
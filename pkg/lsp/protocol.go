@@ -0,0 +1,255 @@
+// Package lsp is a client for the Language Server Protocol, used to get diagnostics,
+// hover information, definitions, references, renames and completions from language servers.
+package lsp
+
+import (
+	"encoding/json"
+	"net/url"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Position is a zero based line and character offset, counted in UTF-16 code units as the protocol requires.
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// Range is the text between two positions, the end is exclusive.
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// Location is a range in a document.
+type Location struct {
+	URI   string `json:"uri"`
+	Range Range  `json:"range"`
+}
+
+// Diagnostic severities.
+const (
+	SeverityError       = 1
+	SeverityWarning     = 2
+	SeverityInformation = 3
+	SeverityHint        = 4
+)
+
+// Diagnostic is an error or warning reported by a server.
+type Diagnostic struct {
+	Range    Range  `json:"range"`
+	Severity int    `json:"severity,omitempty"`
+	Source   string `json:"source,omitempty"`
+	Message  string `json:"message"`
+}
+
+// TextEdit replaces a range of a document.
+type TextEdit struct {
+	Range   Range  `json:"range"`
+	NewText string `json:"newText"`
+}
+
+// WorkspaceEdit is a set of edits across documents, as returned by a rename.
+type WorkspaceEdit struct {
+	Changes         map[string][]TextEdit `json:"changes,omitempty"`
+	DocumentChanges []struct {
+		TextDocument struct {
+			URI string `json:"uri"`
+		} `json:"textDocument"`
+		Edits []TextEdit `json:"edits"`
+	} `json:"documentChanges,omitempty"`
+}
+
+// Edits returns the edits of each file, keyed by path.
+func (e *WorkspaceEdit) Edits() map[string][]TextEdit {
+	edits := map[string][]TextEdit{}
+	for uri, changes := range e.Changes {
+		path := URIToPath(uri)
+		edits[path] = append(edits[path], changes...)
+	}
+	// Creating, renaming and deleting files come without a text document and are left out.
+	for _, change := range e.DocumentChanges {
+		if change.TextDocument.URI != "" {
+			path := URIToPath(change.TextDocument.URI)
+			edits[path] = append(edits[path], change.Edits...)
+		}
+	}
+	return edits
+}
+
+// Completion item kinds, the ones Leda shows differently.
+const (
+	CompletionText     = 1
+	CompletionMethod   = 2
+	CompletionFunction = 3
+	CompletionField    = 5
+	CompletionVariable = 6
+	CompletionKeyword  = 14
+	CompletionSnippet  = 15
+)
+
+// InsertTextFormatSnippet marks insert text written in the snippet syntax, with tab stops such as $1.
+const InsertTextFormatSnippet = 2
+
+// CompletionItem is a suggestion from a server.
+type CompletionItem struct {
+	Label            string    `json:"label"`
+	Kind             int       `json:"kind,omitempty"`
+	Detail           string    `json:"detail,omitempty"`
+	Documentation    Markup    `json:"documentation,omitempty"`
+	SortText         string    `json:"sortText,omitempty"`
+	FilterText       string    `json:"filterText,omitempty"`
+	InsertText       string    `json:"insertText,omitempty"`
+	InsertTextFormat int       `json:"insertTextFormat,omitempty"`
+	TextEdit         *TextEdit `json:"textEdit,omitempty"`
+}
+
+// Markup is documentation or hover text, which servers send as a plain string,
+// a MarkupContent object, a MarkedString or a list of MarkedStrings.
+type Markup string
+
+// UnmarshalJSON accepts every form of markup and keeps its text as markdown.
+func (m *Markup) UnmarshalJSON(data []byte) error {
+	var text string
+	if json.Unmarshal(data, &text) == nil {
+		*m = Markup(text)
+		return nil
+	}
+	var list []Markup
+	if json.Unmarshal(data, &list) == nil {
+		parts := make([]string, len(list))
+		for i, part := range list {
+			parts[i] = string(part)
+		}
+		*m = Markup(strings.Join(parts, "\n\n"))
+		return nil
+	}
+	var content struct {
+		Kind     string `json:"kind"`
+		Language string `json:"language"`
+		Value    string `json:"value"`
+	}
+	if err := json.Unmarshal(data, &content); err != nil {
+		return err
+	}
+	if content.Language != "" {
+		// A MarkedString with a language is a code block.
+		*m = Markup("```" + content.Language + "\n" + content.Value + "\n```")
+	} else {
+		*m = Markup(content.Value)
+	}
+	return nil
+}
+
+// URIToPath turns a file URI into a path.
+func URIToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	path := u.Path
+	// file:///C:/dir on Windows.
+	if runtime.GOOS == "windows" && len(path) > 2 && path[0] == '/' && path[2] == ':' {
+		path = path[1:]
+	}
+	return filepath.FromSlash(path)
+}
+
+// PathToURI turns a path into a file URI.
+func PathToURI(path string) string {
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
+
+// PositionFor converts a row and a column counted in runes, as the editor counts them, to a protocol position.
+func PositionFor(text string, row, column int) Position {
+	line := lineOf(text, row)
+	character := 0
+	for i, r := range []rune(line) {
+		if i == column {
+			break
+		}
+		character += utf16Len(r)
+	}
+	return Position{Line: row, Character: character}
+}
+
+// ColumnFor converts a protocol position in text to the row and column in runes the editor uses.
+func ColumnFor(text string, pos Position) (row, column int) {
+	line := lineOf(text, pos.Line)
+	units := 0
+	for _, r := range line {
+		if units >= pos.Character {
+			break
+		}
+		units += utf16Len(r)
+		column++
+	}
+	return pos.Line, column
+}
+
+// OffsetFor returns the byte offset of a protocol position in text, clamped to the text.
+func OffsetFor(text string, pos Position) int {
+	offset := 0
+	for i := 0; i < pos.Line; i++ {
+		next := strings.IndexByte(text[offset:], '\n')
+		if next < 0 {
+			return len(text)
+		}
+		offset += next + 1
+	}
+	units := 0
+	for offset < len(text) && text[offset] != '\n' && units < pos.Character {
+		r, size := utf8.DecodeRuneInString(text[offset:])
+		units += utf16Len(r)
+		offset += size
+	}
+	return offset
+}
+
+// ApplyEdits applies text edits to text, the edits mustn't overlap.
+func ApplyEdits(text string, edits []TextEdit) string {
+	type span struct {
+		start, end int
+		text       string
+	}
+	spans := make([]span, len(edits))
+	for i, edit := range edits {
+		spans[i] = span{OffsetFor(text, edit.Range.Start), OffsetFor(text, edit.Range.End), edit.NewText}
+	}
+	// Apply from the end so earlier offsets stay valid.
+	sort.SliceStable(spans, func(i, j int) bool { return spans[i].start > spans[j].start })
+	for _, s := range spans {
+		text = text[:s.start] + s.text + text[max(s.end, s.start):]
+	}
+	return text
+}
+
+// lineOf returns a line of text without its line ending, "" if there is no such line.
+func lineOf(text string, row int) string {
+	for i := 0; i < row; i++ {
+		next := strings.IndexByte(text, '\n')
+		if next < 0 {
+			return ""
+		}
+		text = text[next+1:]
+	}
+	if end := strings.IndexByte(text, '\n'); end >= 0 {
+		text = text[:end]
+	}
+	return strings.TrimSuffix(text, "\r")
+}
+
+// utf16Len returns how many UTF-16 code units a rune takes.
+func utf16Len(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
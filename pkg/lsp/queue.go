@@ -0,0 +1,88 @@
+package lsp
+
+import "sync"
+
+// Queue runs the jobs that keep the servers in step with the editor in order, on a goroutine of its own,
+// so the editor never waits for a server that's slow to start or answer.
+// A file's text still waiting to be sent is replaced by newer text rather than queued again.
+type Queue struct {
+	lock sync.Mutex
+	jobs []func()
+	// changes are the jobs sending a file's text that haven't started yet, by path.
+	changes map[string]*func()
+	wake    chan struct{}
+}
+
+// NewQueue creates a queue and starts running its jobs.
+func NewQueue() *Queue {
+	q := &Queue{changes: map[string]*func(){}, wake: make(chan struct{}, 1)}
+	go q.run()
+	return q
+}
+
+// Add queues a job, such as opening or closing a file, to run after the ones before it.
+func (q *Queue) Add(job func()) {
+	q.lock.Lock()
+	q.jobs = append(q.jobs, job)
+	// Text sent from now on must follow this job, rather than join a change before it.
+	clear(q.changes)
+	q.lock.Unlock()
+	q.signal()
+}
+
+// Change queues a job sending the text of path, or replaces the one for path that's still waiting.
+func (q *Queue) Change(path string, job func()) {
+	q.lock.Lock()
+	defer q.signal()
+	defer q.lock.Unlock()
+	if pending, ok := q.changes[path]; ok {
+		*pending = job
+		return
+	}
+	pending := &job
+	q.changes[path] = pending
+	q.jobs = append(q.jobs, func() {
+		q.lock.Lock()
+		job := *pending
+		if q.changes[path] == pending {
+			delete(q.changes, path)
+		}
+		q.lock.Unlock()
+		job()
+	})
+}
+
+// Synced returns a channel that's closed once the jobs queued so far have run.
+func (q *Queue) Synced() <-chan struct{} {
+	synced := make(chan struct{})
+	q.lock.Lock()
+	q.jobs = append(q.jobs, func() { close(synced) })
+	q.lock.Unlock()
+	q.signal()
+	return synced
+}
+
+// signal wakes the goroutine running the jobs, unless it's already been woken.
+func (q *Queue) signal() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run runs the jobs as they're queued.
+func (q *Queue) run() {
+	for range q.wake {
+		for {
+			q.lock.Lock()
+			if len(q.jobs) == 0 {
+				q.lock.Unlock()
+				break
+			}
+			job := q.jobs[0]
+			q.jobs = q.jobs[1:]
+			q.lock.Unlock()
+			job()
+		}
+	}
+}
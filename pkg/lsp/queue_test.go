@@ -0,0 +1,46 @@
+package lsp
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestQueue(t *testing.T) {
+	q := NewQueue()
+	var lock sync.Mutex
+	var ran []string
+	record := func(name string) func() {
+		return func() {
+			lock.Lock()
+			ran = append(ran, name)
+			lock.Unlock()
+		}
+	}
+
+	// Hold the queue up like a server that's slow to start, so the jobs after wait.
+	release := make(chan struct{})
+	q.Add(func() { <-release })
+	q.Change("a.go", record("a1"))
+	q.Change("b.go", record("b1"))
+	q.Change("a.go", record("a2"))
+	q.Add(record("close a.go"))
+	q.Change("a.go", record("a3"))
+	synced := q.Synced()
+	q.Change("a.go", record("a4"))
+	close(release)
+
+	select {
+	case <-synced:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the queue didn't run its jobs")
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	// Newer text replaces what's waiting, but never moves before a job queued in between.
+	want := []string{"a2", "b1", "close a.go", "a4"}
+	if !reflect.DeepEqual(ran, want) {
+		t.Errorf("ran %q, want %q", ran, want)
+	}
+}
@@ -0,0 +1,146 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	handling "github.com/Leda-Editor/Leda-Text-Editor/pkg/handling"
+)
+
+// BookmarkPanel lists the bookmarks of every file.
+type BookmarkPanel struct {
+	// List shows one row per bookmark.
+	List *widget.List
+	// Visible indicates whether the panel is shown.
+	Visible bool
+
+	ui      *UI
+	entries []handling.Bookmark
+	// snippets holds the text of each bookmarked line.
+	snippets []string
+}
+
+// newBookmarkPanel creates the bookmarks list for the given UI.
+func newBookmarkPanel(ui *UI) *BookmarkPanel {
+	p := &BookmarkPanel{ui: ui}
+	p.List = widget.NewList(
+		func() int { return len(p.entries) },
+		func() fyne.CanvasObject {
+			return widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{})
+		},
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			o.(*widget.Label).SetText(p.label(id))
+		},
+	)
+	p.List.OnSelected = func(id widget.ListItemID) {
+		p.List.Unselect(id)
+		if id < len(p.entries) {
+			b := p.entries[id]
+			ui.jumpTo(handling.Location{Path: b.Path, Row: b.Line})
+		}
+	}
+	return p
+}
+
+// label describes a bookmark as "file:line  text".
+func (p *BookmarkPanel) label(id int) string {
+	b := p.entries[id]
+	name := "Untitled"
+	if b.Path != "" {
+		name = filepath.Base(b.Path)
+	}
+	return fmt.Sprintf("%s:%d  %s", name, b.Line+1, p.snippets[id])
+}
+
+// refresh reloads the bookmarks and the text of their lines.
+func (p *BookmarkPanel) refresh() {
+	if !p.Visible {
+		return
+	}
+	current := p.ui.currentLocation().Path
+	p.entries = nil
+	for _, b := range p.ui.Bookmarks.All() {
+		// The unsaved buffer's bookmarks only mean something while it's open, it has no file to read.
+		if b.Path == "" && current != "" {
+			continue
+		}
+		p.entries = append(p.entries, b)
+	}
+	p.snippets = make([]string, len(p.entries))
+
+	texts := map[string]string{current: p.ui.text()}
+	for i, b := range p.entries {
+		text, ok := texts[b.Path]
+		if !ok {
+			data, err := os.ReadFile(b.Path)
+			if err != nil {
+				fyne.LogError("Failed to read bookmarked file", err)
+			}
+			text = string(data)
+			texts[b.Path] = text
+		}
+		snippet := strings.TrimSpace(lineText(text, b.Line))
+		if runes := []rune(snippet); len(runes) > 60 {
+			snippet = string(runes[:60]) + "…"
+		}
+		p.snippets[i] = snippet
+	}
+	p.List.Refresh()
+}
+
+// content returns the panel with its toolbar.
+func (p *BookmarkPanel) content() fyne.CanvasObject {
+	toolbar := container.NewHBox(
+		widget.NewLabelWithStyle("🔖 Bookmarks", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		layout.NewSpacer(),
+		widget.NewButtonWithIcon("", theme.DeleteIcon(), func() { p.ui.clearBookmarks() }),
+		widget.NewButtonWithIcon("", theme.CancelIcon(), func() { p.ui.toggleBookmarkPanel() }),
+	)
+	return container.NewBorder(toolbar, nil, nil, nil, p.List)
+}
+
+// Toggle the bookmark on a zero based line of the current file.
+func (ui *UI) toggleBookmark(row int) {
+	ui.Bookmarks.Toggle(ui.currentLocation().Path, row)
+	ui.bookmarksChanged()
+}
+
+// Remove the bookmarks of the current file.
+func (ui *UI) clearBookmarks() {
+	ui.Bookmarks.Clear(ui.currentLocation().Path)
+	ui.bookmarksChanged()
+}
+
+// Move the cursor to the next bookmark in the current file.
+func (ui *UI) nextBookmark() {
+	if line, ok := ui.Bookmarks.Next(ui.currentLocation().Path, ui.currentLocation().Row); ok {
+		ui.moveCursor(line, 0)
+	}
+}
+
+// Move the cursor to the previous bookmark in the current file.
+func (ui *UI) previousBookmark() {
+	if line, ok := ui.Bookmarks.Previous(ui.currentLocation().Path, ui.currentLocation().Row); ok {
+		ui.moveCursor(line, 0)
+	}
+}
+
+// bookmarksChanged updates the gutter and the bookmarks list.
+func (ui *UI) bookmarksChanged() {
+	ui.Gutter.Refresh()
+	ui.BookmarkPanel.refresh()
+}
+
+// Toggle visibility of the bookmarks list.
+func (ui *UI) toggleBookmarkPanel() {
+	ui.BookmarkPanel.Visible = !ui.BookmarkPanel.Visible
+	ui.BookmarkPanel.refresh()
+	ui.UpdateLayout()
+}
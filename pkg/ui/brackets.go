@@ -0,0 +1,163 @@
+package ui
+
+import (
+	"unicode"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	handling "github.com/Leda-Editor/Leda-Text-Editor/pkg/handling"
+)
+
+// auto_close is the preference for closing brackets and quotes as they're opened.
+const auto_close = "editor_auto_close"
+
+// closingPairs maps what opens a pair to what closes it.
+var closingPairs = map[rune]rune{'(': ')', '[': ']', '{': '}', '"': '"', '\'': '\'', '`': '`'}
+
+// isQuote reports whether r is one of the quotes that are closed automatically.
+func isQuote(r rune) bool {
+	return r == '"' || r == '\'' || r == '`'
+}
+
+// autoClose types over the closing half of a pair, wraps the selection in a pair,
+// or closes a bracket or quote as it's opened. It reports whether it typed r.
+func (ui *UI) autoClose(r rune) bool {
+	if !ui.App.Preferences().BoolWithFallback(auto_close, true) {
+		return false
+	}
+	line, column := ui.cursorLine()
+	var previous, next rune
+	if column > 0 {
+		previous = line[column-1]
+	}
+	if column < len(line) {
+		next = line[column]
+	}
+	selection := ui.Editor.SelectedText()
+
+	if selection == "" && next == r && (handling.IsCloseBracket(r) || isQuote(r)) {
+		ui.code.Entry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyRight})
+		return true
+	}
+	close, ok := closingPairs[r]
+	if !ok {
+		return false
+	}
+	if selection != "" {
+		start, end := ui.selectionRange()
+		ui.replaceRange(start, end, string(r)+selection+string(close))
+		ui.selectText(start+1, end+1)
+		return true
+	}
+	// Pairs are only closed where the closing half can't belong to a word, such as at the end of one.
+	if next != 0 && !unicode.IsSpace(next) && !handling.IsCloseBracket(next) && !isQuote(next) {
+		return false
+	}
+	if isQuote(r) && (handling.IsWordRune(previous) || previous == r) {
+		return false
+	}
+	cursor := ui.cursorOffset()
+	ui.replaceRange(cursor, cursor, string(r)+string(close))
+	ui.selectText(cursor+1, cursor+1)
+	return true
+}
+
+// Move the cursor to the bracket pairing with the one next to it (Ctrl + Shift + \).
+func (ui *UI) goToMatchingBracket() {
+	cursor := ui.cursorOffset()
+	at, match, ok := handling.BracketAt([]rune(ui.Editor.Text), cursor)
+	if !ok {
+		return
+	}
+	// Stay on the same side of the bracket, so going again comes back.
+	if at < cursor {
+		match++
+	}
+	ui.selectText(match, match)
+	ui.Window.Canvas().Focus(ui.code)
+}
+
+// Turn closing brackets and quotes as they're typed on or off.
+func (ui *UI) toggleAutoClose(item *fyne.MenuItem) {
+	prefs := ui.App.Preferences()
+	enabled := !prefs.BoolWithFallback(auto_close, true)
+	prefs.SetBool(auto_close, enabled)
+	item.Checked = enabled
+	ui.mainMenu.Refresh()
+}
+
+// bracketLayer outlines the bracket next to the cursor and the one pairing with it, on top of the editor.
+type bracketLayer struct {
+	widget.BaseWidget
+	ui *UI
+}
+
+// newBracketLayer creates the bracket outlines for ui's editor.
+func newBracketLayer(ui *UI) *bracketLayer {
+	l := &bracketLayer{ui: ui}
+	l.ExtendBaseWidget(l)
+	return l
+}
+
+func (l *bracketLayer) CreateRenderer() fyne.WidgetRenderer {
+	return &bracketRenderer{layer: l}
+}
+
+// bracketRenderer draws a box around each bracket of the pair at the cursor.
+type bracketRenderer struct {
+	layer   *bracketLayer
+	objects []fyne.CanvasObject
+}
+
+func (r *bracketRenderer) MinSize() fyne.Size {
+	return fyne.Size{}
+}
+
+func (r *bracketRenderer) Layout(fyne.Size) {
+	r.layoutBoxes()
+}
+
+func (r *bracketRenderer) Refresh() {
+	r.layoutBoxes()
+	canvas.Refresh(r.layer)
+}
+
+// layoutBoxes finds the pair at the cursor and boxes both brackets.
+func (r *bracketRenderer) layoutBoxes() {
+	ui := r.layer.ui
+	r.objects = nil
+	if ui.Editor.SelectedText() != "" {
+		return
+	}
+	text := ui.Editor.Text
+	at, match, ok := handling.BracketAt([]rune(text), ui.cursorOffset())
+	if !ok {
+		return
+	}
+	th := r.layer.Theme()
+	v := fyne.CurrentApp().Settings().ThemeVariant()
+	pad := th.Size(theme.SizeNameInnerPadding)
+	textSize := th.Size(theme.SizeNameText)
+	lineHeight := ui.lineHeight()
+	measure := func(s string) float32 {
+		return fyne.MeasureText(s, textSize, ui.Editor.TextStyle).Width
+	}
+	for _, offset := range []int{at, match} {
+		row, column := rowColumnAt(text, offset)
+		line := []rune(lineText(text, row))
+		box := canvas.NewRectangle(th.Color(theme.ColorNameSelection, v))
+		box.StrokeColor = th.Color(theme.ColorNamePrimary, v)
+		box.StrokeWidth = 1
+		box.Move(fyne.NewPos(pad+measure(string(line[:column])), pad+float32(row)*lineHeight))
+		box.Resize(fyne.NewSize(measure(string(line[column:column+1])), lineHeight))
+		r.objects = append(r.objects, box)
+	}
+}
+
+func (r *bracketRenderer) Objects() []fyne.CanvasObject {
+	return r.objects
+}
+
+func (r *bracketRenderer) Destroy() {}
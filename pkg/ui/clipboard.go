@@ -0,0 +1,177 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// clipboard_persist is the preference for keeping the clipboard history between sessions, off by default.
+const clipboard_persist = "clipboard_history_persist"
+
+// maxClipboardPreview is how many characters of an entry the clipboard history shows.
+const maxClipboardPreview = 80
+
+// rememberCopy adds what a copy or cut put on the clipboard to the clipboard history.
+func (ui *UI) rememberCopy(shortcut fyne.Shortcut) {
+	switch s := shortcut.(type) {
+	case *fyne.ShortcutCopy:
+		ui.Clipboard.Add(s.Clipboard.Content())
+	case *fyne.ShortcutCut:
+		ui.Clipboard.Add(s.Clipboard.Content())
+	}
+}
+
+// pasteFromHistory pastes text in the editor, at every cursor, and puts it back on the clipboard.
+func (ui *UI) pasteFromHistory(text string) {
+	ui.App.Clipboard().SetContent(text)
+	ui.Window.Canvas().Focus(ui.code)
+	ui.code.TypedShortcut(&fyne.ShortcutPaste{Clipboard: textClipboard(text)})
+}
+
+// clipboardPreview returns the first line of an entry, shortened, and how many more lines it has.
+func clipboardPreview(text string) (string, string) {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	first := []rune(strings.TrimSpace(lines[0]))
+	if len(first) > maxClipboardPreview {
+		first = append(first[:maxClipboardPreview], '…')
+	}
+	more := ""
+	if len(lines) > 1 {
+		more = fmt.Sprintf("+%d lines", len(lines)-1)
+	}
+	return string(first), more
+}
+
+// ShowClipboardHistory lists what was copied and cut in the editor, pinned entries first,
+// and pastes the one picked (Ctrl + Shift + V).
+func ShowClipboardHistory(ui *UI) {
+	history := ui.Clipboard
+	if len(history.Pinned())+len(history.Entries()) == 0 {
+		dialog.ShowInformation("Paste from History", "Nothing has been copied or cut in the editor yet.", ui.Window)
+		return
+	}
+
+	var results []string
+	selected := 0
+
+	entry := newPickerEntry()
+	entry.SetPlaceHolder("Search clipboard history")
+	var list *widget.List
+	var update func()
+	list = widget.NewList(
+		func() int { return len(results) },
+		func() fyne.CanvasObject {
+			detail := widget.NewLabel("")
+			detail.Importance = widget.LowImportance
+			buttons := container.NewHBox(detail, widget.NewButton("", nil), widget.NewButtonWithIcon("", theme.DeleteIcon(), nil))
+			return container.NewBorder(nil, nil, nil, buttons, widget.NewLabel(""))
+		},
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			row := o.(*fyne.Container)
+			text := results[id]
+			preview, more := clipboardPreview(text)
+			pinned := history.IsPinned(text)
+			if pinned {
+				preview = "📌 " + preview
+			}
+			row.Objects[0].(*widget.Label).SetText(preview)
+			buttons := row.Objects[1].(*fyne.Container)
+			buttons.Objects[0].(*widget.Label).SetText(more)
+			pin := buttons.Objects[1].(*widget.Button)
+			if pinned {
+				pin.SetText("Unpin")
+			} else {
+				pin.SetText("Pin")
+			}
+			pin.OnTapped = func() {
+				history.SetPinned(text, !pinned)
+				update()
+			}
+			buttons.Objects[2].(*widget.Button).OnTapped = func() {
+				history.Remove(text)
+				update()
+			}
+		},
+	)
+
+	update = func() {
+		query := strings.ToLower(strings.TrimSpace(entry.Text))
+		results = nil
+		for _, text := range append(append([]string{}, history.Pinned()...), history.Entries()...) {
+			if strings.Contains(strings.ToLower(text), query) {
+				results = append(results, text)
+			}
+		}
+		selected = min(selected, len(results)-1)
+		list.Refresh()
+		if selected >= 0 {
+			list.Select(selected)
+		}
+	}
+
+	var popup *widget.PopUp
+	paste := func() {
+		if selected < 0 || selected >= len(results) {
+			return
+		}
+		popup.Hide()
+		ui.pasteFromHistory(results[selected])
+	}
+
+	list.OnSelected = func(id widget.ListItemID) { selected = id }
+	entry.OnChanged = func(string) {
+		selected = 0
+		update()
+	}
+	entry.OnSubmitted = func(string) { paste() }
+	entry.onUp = func() {
+		if selected > 0 {
+			list.Select(selected - 1)
+		}
+	}
+	entry.onDown = func() {
+		if selected < len(results)-1 {
+			list.Select(selected + 1)
+		}
+	}
+	entry.onEscape = func() { popup.Hide() }
+
+	clearButton := widget.NewButton("Clear Unpinned", func() {
+		history.Clear()
+		update()
+	})
+	content := container.NewBorder(
+		container.NewVBox(widget.NewLabel("📋 Paste from History"), entry),
+		container.NewHBox(clearButton, layout.NewSpacer(), widget.NewButton("Paste", paste), widget.NewButton("Close", func() { popup.Hide() })),
+		nil, nil,
+		list,
+	)
+	popup = widget.NewModalPopUp(content, ui.Window.Canvas())
+	size := ui.Window.Canvas().Size()
+	popup.Resize(fyne.NewSize(size.Width*0.6, size.Height*0.6))
+	popup.Show()
+	ui.Window.Canvas().Focus(entry)
+	update()
+}
+
+// Forget every clipboard history entry that isn't pinned.
+func (ui *UI) clearClipboardHistory() {
+	ui.Clipboard.Clear()
+}
+
+// Turn keeping the clipboard history between sessions on or off.
+func (ui *UI) toggleClipboardPersist(item *fyne.MenuItem) {
+	prefs := ui.App.Preferences()
+	enabled := !prefs.Bool(clipboard_persist)
+	prefs.SetBool(clipboard_persist, enabled)
+	ui.Clipboard.SetPersist(enabled)
+	item.Checked = enabled
+	ui.mainMenu.Refresh()
+}
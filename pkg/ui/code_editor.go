@@ -0,0 +1,670 @@
+package ui
+
+import (
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	handling "github.com/Leda-Editor/Leda-Text-Editor/pkg/handling"
+	"github.com/Leda-Editor/Leda-Text-Editor/pkg/lsp"
+)
+
+// codeEditor is the multi-line entry used as the editor.
+// It doesn't scroll by itself, the UI puts it in a scroll container so the gutter can follow the scrolling.
+type codeEditor struct {
+	widget.Entry
+	ui *UI
+}
+
+// newCodeEditor creates the editor entry for ui.
+func newCodeEditor(ui *UI) *codeEditor {
+	e := &codeEditor{ui: ui}
+	e.MultiLine = true
+	e.Wrapping = fyne.TextWrapOff
+	e.Scroll = container.ScrollNone
+	e.ExtendBaseWidget(e)
+	return e
+}
+
+// TypedShortcut passes custom shortcuts on to the window as well, the entry would
+// otherwise swallow the window's shortcuts while it has focus.
+// Copying or cutting folded regions copies the text they hide, and undo uses the UI's history, which knows about folding.
+// With several cursors the clipboard works at all of them. What's copied or cut goes in the clipboard history.
+func (e *codeEditor) TypedShortcut(shortcut fyne.Shortcut) {
+	e.ui.recordShortcut(shortcut)
+	if e.SelectedText() != "" || len(e.ui.cursors) > 0 {
+		defer e.ui.rememberCopy(shortcut)
+	}
+	if e.ui.multiCursorShortcut(shortcut) {
+		return
+	}
+	switch s := shortcut.(type) {
+	case *desktop.CustomShortcut:
+		if c, ok := e.ui.Window.Canvas().(fyne.Shortcutable); ok {
+			c.TypedShortcut(shortcut)
+		}
+	case *fyne.ShortcutCopy:
+		if selection := e.ui.selectedText(); selection != e.SelectedText() {
+			s.Clipboard.SetContent(selection)
+			return
+		}
+	case *fyne.ShortcutCut:
+		if selection := e.ui.selectedText(); selection != e.SelectedText() {
+			s.Clipboard.SetContent(selection)
+			e.Entry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyBackspace})
+			return
+		}
+	case *fyne.ShortcutUndo:
+		e.ui.undo(false)
+		return
+	case *fyne.ShortcutRedo:
+		e.ui.undo(true)
+		return
+	}
+	e.Entry.TypedShortcut(shortcut)
+}
+
+// TypedKey lets the completion popup use the keys that choose a suggestion, Tab move through
+// a snippet, keeps the indentation of new lines and handles the function keys of the language server commands.
+// Joining lines with a folded region unfolds it. With several cursors, editing and arrow keys work at all of them.
+func (e *codeEditor) TypedKey(key *fyne.KeyEvent) {
+	e.ui.recordKey(key)
+	if e.ui.completion.typedKey(key) || e.ui.multiCursorKey(key) || e.ui.snippetKey(key) {
+		return
+	}
+	switch key.Name {
+	case fyne.KeyF12:
+		if shiftPressed() {
+			e.ui.findReferences()
+		} else {
+			e.ui.goToDefinition()
+		}
+		return
+	case fyne.KeyF2:
+		e.ui.renameSymbol()
+		return
+	case fyne.KeyReturn, fyne.KeyEnter:
+		if e.ui.newline() {
+			return
+		}
+	case fyne.KeyTab:
+		if e.ui.indentSelection() {
+			return
+		}
+		if !shiftPressed() && e.ui.indentTab() {
+			return
+		}
+	case fyne.KeyDelete:
+		e.ui.unfoldForEdit(false)
+		e.ui.typed = true
+		defer func() { e.ui.typed = false }()
+	case fyne.KeyBackspace:
+		e.ui.unfoldForEdit(true)
+		e.ui.typed = true
+		defer func() { e.ui.typed = false }()
+		if e.ui.backspace() {
+			e.ui.completion.hide()
+			return
+		}
+	}
+	e.Entry.TypedKey(key)
+	if e.ui.completion.visible() {
+		e.ui.completion.filter()
+	}
+}
+
+// TypedRune closes brackets and quotes, and narrows the completion popup down as a word is typed, or opens it.
+// With several cursors r is typed at each of them.
+func (e *codeEditor) TypedRune(r rune) {
+	e.ui.recordRune(r)
+	// What a macro types was typed when it was recorded.
+	e.ui.typing = e.ui.playingMacro == 0
+	e.ui.typed = true
+	defer func() { e.ui.typing, e.ui.typed = false, false }()
+	if e.ui.multiCursorRune(r) {
+		return
+	}
+	if !e.ui.dedentClosing(r) && !e.ui.autoClose(r) {
+		e.Entry.TypedRune(r)
+	}
+	e.ui.completion.typedRune(r)
+}
+
+// TappedSecondary offers spellings for a misspelled word that's right-clicked, elsewhere the editing commands.
+// The entry's own menu would undo with the entry's history, which doesn't know about folding.
+func (e *codeEditor) TappedSecondary(pe *fyne.PointEvent) {
+	if !e.ui.showSpellingMenu(pe) {
+		e.ui.Window.Canvas().Focus(e)
+		widget.ShowPopUpMenuAtPosition(fyne.NewMenu("", e.ui.editMenuItems()...), e.ui.Window.Canvas(), pe.AbsolutePosition)
+	}
+}
+
+// editMenuItems returns Undo, Redo and the clipboard commands for the editor's context menus.
+func (ui *UI) editMenuItems() []*fyne.MenuItem {
+	clipboard := ui.App.Clipboard()
+	shortcut := func(s fyne.Shortcut) func() {
+		return func() { ui.code.TypedShortcut(s) }
+	}
+	return []*fyne.MenuItem{
+		fyne.NewMenuItem("Undo", shortcut(&fyne.ShortcutUndo{})),
+		fyne.NewMenuItem("Redo", shortcut(&fyne.ShortcutRedo{})),
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Cut", shortcut(&fyne.ShortcutCut{Clipboard: clipboard})),
+		fyne.NewMenuItem("Copy", shortcut(&fyne.ShortcutCopy{Clipboard: clipboard})),
+		fyne.NewMenuItem("Paste", shortcut(&fyne.ShortcutPaste{Clipboard: clipboard})),
+		fyne.NewMenuItem("Select All", shortcut(&fyne.ShortcutSelectAll{})),
+	}
+}
+
+// MouseDown adds or removes a cursor on Alt + click and starts a block selection on Alt + Shift + click.
+// A plain click goes back to a single cursor.
+func (e *codeEditor) MouseDown(m *desktop.MouseEvent) {
+	if m.Button == desktop.MouseButtonPrimary {
+		switch {
+		case m.Modifier&fyne.KeyModifierAlt != 0 && m.Modifier&fyne.KeyModifierShift != 0:
+			e.ui.startBlockSelection(m.Position)
+			return
+		case m.Modifier&fyne.KeyModifierAlt != 0:
+			e.ui.toggleCursorAt(m.Position)
+			e.ui.Window.Canvas().Focus(e)
+			return
+		}
+		e.ui.clearCursors()
+	}
+	e.Entry.MouseDown(m)
+}
+
+// MouseUp ends a block selection.
+func (e *codeEditor) MouseUp(m *desktop.MouseEvent) {
+	if e.ui.block != nil {
+		e.ui.block = nil
+		return
+	}
+	e.Entry.MouseUp(m)
+}
+
+// Dragged extends a block selection, or selects text as usual.
+func (e *codeEditor) Dragged(d *fyne.DragEvent) {
+	if e.ui.block != nil {
+		e.ui.dragBlockSelection(d.Position)
+		return
+	}
+	e.Entry.Dragged(d)
+}
+
+// DragEnd ends a block selection, or a selection made by dragging.
+func (e *codeEditor) DragEnd() {
+	if e.ui.block != nil {
+		e.ui.block = nil
+		return
+	}
+	e.Entry.DragEnd()
+}
+
+// shiftPressed reports whether a shift key is held down, or a macro is playing a key pressed with it.
+func shiftPressed() bool {
+	if macroShift {
+		return true
+	}
+	if d, ok := fyne.CurrentApp().Driver().(desktop.Driver); ok {
+		return d.CurrentKeyModifiers()&fyne.KeyModifierShift != 0
+	}
+	return false
+}
+
+// lineHeight returns the height of a line of text in the editor.
+func (ui *UI) lineHeight() float32 {
+	return fyne.MeasureText("M", ui.Editor.Theme().Size(theme.SizeNameText), ui.Editor.TextStyle).Height
+}
+
+// rowAt returns the editor line at a vertical position within the editor.
+func (ui *UI) rowAt(y float32) int {
+	return int((y - ui.Editor.Theme().Size(theme.SizeNameInnerPadding)) / ui.lineHeight())
+}
+
+// lineText returns a line of text, or "" if there is no such line.
+func lineText(text string, row int) string {
+	for i := 0; i < row; i++ {
+		next := strings.IndexByte(text, '\n')
+		if next < 0 {
+			return ""
+		}
+		text = text[next+1:]
+	}
+	if end := strings.IndexByte(text, '\n'); end >= 0 {
+		return text[:end]
+	}
+	return text
+}
+
+// textOffset returns the position of a row and column in text, in runes.
+func textOffset(text string, row, column int) int {
+	offset := 0
+	for i, line := range strings.Split(text, "\n") {
+		length := len([]rune(line))
+		if i == row {
+			return offset + min(column, length)
+		}
+		offset += length + 1
+	}
+	return len([]rune(text))
+}
+
+// rowColumnAt returns the row and column of a position in text, in runes.
+func rowColumnAt(text string, offset int) (row, column int) {
+	for i, r := range []rune(text) {
+		if i == offset {
+			break
+		}
+		if r == '\n' {
+			row++
+			column = 0
+		} else {
+			column++
+		}
+	}
+	return row, column
+}
+
+// selectText selects from the anchor to the cursor, positions in runes, or just moves the cursor if they're the same.
+func (ui *UI) selectText(anchor, cursor int) {
+	e := &ui.code.Entry
+	// The entry selects with the arrow keys while it thinks shift is held.
+	shift := &fyne.KeyEvent{Name: desktop.KeyShiftLeft}
+	e.KeyUp(shift)
+	if e.SelectedText() != "" {
+		e.TypedKey(&fyne.KeyEvent{Name: fyne.KeyLeft})
+	}
+	e.CursorRow, e.CursorColumn = rowColumnAt(e.Text, anchor)
+	if anchor != cursor {
+		e.KeyDown(shift)
+		if cursor > anchor {
+			e.TypedKey(&fyne.KeyEvent{Name: fyne.KeyRight})
+		} else {
+			e.TypedKey(&fyne.KeyEvent{Name: fyne.KeyLeft})
+		}
+		e.KeyUp(shift)
+		e.CursorRow, e.CursorColumn = rowColumnAt(e.Text, cursor)
+	}
+	e.Refresh()
+	ui.ensureCursorVisible()
+}
+
+// selectionRange returns where the selection starts and ends, positions in runes.
+// Without a selection both are the cursor.
+func (ui *UI) selectionRange() (start, end int) {
+	e := &ui.code.Entry
+	cursor := textOffset(e.Text, e.CursorRow, e.CursorColumn)
+	length := len([]rune(e.SelectedText()))
+	if length == 0 {
+		return cursor, cursor
+	}
+	// The entry doesn't say where its selection starts, but Left goes there.
+	e.KeyUp(&fyne.KeyEvent{Name: desktop.KeyShiftLeft})
+	e.TypedKey(&fyne.KeyEvent{Name: fyne.KeyLeft})
+	start = textOffset(e.Text, e.CursorRow, e.CursorColumn)
+	end = start + length
+	if cursor == start {
+		ui.selectText(end, start)
+	} else {
+		ui.selectText(start, end)
+	}
+	return start, end
+}
+
+// textClipboard is a clipboard of our own, pasting from it puts text in the editor
+// as one edit that can be undone, without touching the system clipboard.
+type textClipboard string
+
+func (c textClipboard) Content() string { return string(c) }
+
+func (c textClipboard) SetContent(string) {}
+
+// replaceRange replaces the text between two positions in the editor, in runes, leaving the cursor after the new text.
+func (ui *UI) replaceRange(start, end int, text string) {
+	ui.selectText(start, end)
+	if text == "" {
+		if start != end {
+			ui.code.Entry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyBackspace})
+		}
+		return
+	}
+	ui.code.Entry.TypedShortcut(&fyne.ShortcutPaste{Clipboard: textClipboard(text)})
+}
+
+// editText makes a change to the whole text as one edit that can be undone, like replaceRange but with
+// positions in the text rather than the editor. The cursor stays on the same line and column of the text.
+func (ui *UI) editText(c handling.TextChange) {
+	row, column := ui.realRow(ui.Editor.CursorRow), ui.Editor.CursorColumn
+	ui.history.Add(c, false)
+	ui.applyChange(c, func(text string) int { return textOffset(text, row, column) })
+}
+
+// applyChange makes a change to the whole text and puts the cursor where cursor says in the changed text.
+// A change that adds or removes lines unfolds the regions it reaches into, others leave them folded.
+func (ui *UI) applyChange(c handling.TextChange, cursor func(text string) int) {
+	ui.endSnippet()
+	ui.completion.hide()
+	if strings.Contains(c.Removed+c.Inserted, "\n") {
+		ui.folded = handling.AdjustFolds(ui.folded, ui.document, c)
+	}
+	ui.document = c.Apply(ui.document)
+	ui.present(cursor(ui.document))
+	ui.textChanged()
+}
+
+// undo undoes the last edit, or redoes the last one undone, leaving the cursor after the text it puts in.
+func (ui *UI) undo(redo bool) {
+	step := ui.history.Undo
+	if redo {
+		step = ui.history.Redo
+	}
+	if c, ok := step(); ok {
+		ui.applyChange(c, func(string) int { return c.End() })
+	}
+}
+
+// replaceText changes the whole text to text as one edit that can be undone, replacing only what differs so the cursor
+// stays on the same line and column. Regions that were folded and still start on the same line, with the same text, stay folded.
+func (ui *UI) replaceText(text string) {
+	c, ok := handling.DiffText(ui.document, text)
+	if !ok {
+		return
+	}
+	headers := ui.foldHeaders()
+	ui.editText(c)
+	ui.refold(headers)
+}
+
+// ensureCursorVisible scrolls the editor so the cursor is in view.
+func (ui *UI) ensureCursorVisible() {
+	th := ui.Editor.Theme()
+	pad := th.Size(theme.SizeNameInnerPadding)
+	lineHeight := ui.lineHeight()
+
+	line := []rune(lineText(ui.Editor.Text, ui.Editor.CursorRow))
+	col := min(ui.Editor.CursorColumn, len(line))
+	x := pad + fyne.MeasureText(string(line[:col]), th.Size(theme.SizeNameText), ui.Editor.TextStyle).Width
+	y := pad + float32(ui.Editor.CursorRow)*lineHeight
+
+	// Keep a little room around the cursor, like the entry does when it scrolls itself.
+	margin := fyne.MeasureText("ee", th.Size(theme.SizeNameText), ui.Editor.TextStyle).Width
+	offset := ui.EditorScroll.Offset
+	size := ui.EditorScroll.Size()
+	if x-margin < offset.X {
+		offset.X = max(x-margin, 0)
+	} else if x+margin > offset.X+size.Width {
+		offset.X = x + margin - size.Width
+	}
+	if y < offset.Y {
+		offset.Y = y
+	} else if y+lineHeight+pad > offset.Y+size.Height {
+		offset.Y = y + lineHeight + pad - size.Height
+	}
+	if offset != ui.EditorScroll.Offset {
+		// The editor may have grown since the last layout, the scroll clamps to its current size.
+		content := ui.EditorScroll.Content
+		content.Resize(content.MinSize().Max(ui.EditorScroll.Size()))
+		ui.EditorScroll.ScrollToOffset(offset)
+		ui.Gutter.Refresh()
+	}
+}
+
+// Gutter shows line numbers, bookmarks, the lines with problems and where regions fold to the left of the editor.
+type Gutter struct {
+	widget.BaseWidget
+	ui *UI
+	// lines is the number of lines in the editor, as shown with regions folded.
+	lines int
+}
+
+// newGutter creates the gutter for ui's editor.
+func newGutter(ui *UI) *Gutter {
+	g := &Gutter{ui: ui, lines: 1}
+	g.ExtendBaseWidget(g)
+	return g
+}
+
+// SetLines updates the number of lines shown.
+func (g *Gutter) SetLines(lines int) {
+	if lines != g.lines {
+		g.lines = lines
+		g.Refresh()
+	}
+}
+
+// Tapped folds or unfolds the region starting on the line that was clicked when its marker was,
+// otherwise toggles the line's bookmark.
+func (g *Gutter) Tapped(e *fyne.PointEvent) {
+	row := g.ui.rowAt(e.Position.Y + g.ui.EditorScroll.Offset.Y)
+	if row < 0 || row >= g.lines {
+		return
+	}
+	if e.Position.X >= g.Size().Width-g.foldWidth() {
+		g.ui.toggleFold(row)
+		return
+	}
+	g.ui.toggleBookmark(g.ui.realRow(row))
+}
+
+// foldWidth is the width of the column of fold markers at the right of the gutter.
+func (g *Gutter) foldWidth() float32 {
+	return g.ui.lineHeight()*0.6 + g.Theme().Size(theme.SizeNamePadding)
+}
+
+func (g *Gutter) CreateRenderer() fyne.WidgetRenderer {
+	return &gutterRenderer{gutter: g}
+}
+
+// gutterRenderer draws only the rows currently scrolled into view.
+// The gutter sits outside the editor's scroll container, so rows are offset by the scroll position.
+type gutterRenderer struct {
+	gutter  *Gutter
+	objects []fyne.CanvasObject
+}
+
+func (r *gutterRenderer) markerSize() float32 {
+	return r.gutter.ui.lineHeight() * 0.5
+}
+
+func (r *gutterRenderer) MinSize() fyne.Size {
+	th := r.gutter.Theme()
+	digits := len(strconv.Itoa(r.gutter.ui.realRow(r.gutter.lines-1) + 1))
+	numbers := fyne.MeasureText(strings.Repeat("0", digits), th.Size(theme.SizeNameText), fyne.TextStyle{Monospace: true})
+	return fyne.NewSize(r.markerSize()+numbers.Width+th.Size(theme.SizeNamePadding)*3+r.gutter.foldWidth(), 0)
+}
+
+func (r *gutterRenderer) Layout(fyne.Size) {
+	r.layoutRows()
+}
+
+func (r *gutterRenderer) Refresh() {
+	r.layoutRows()
+	canvas.Refresh(r.gutter)
+}
+
+// layoutRows creates the line numbers and markers of the visible rows.
+func (r *gutterRenderer) layoutRows() {
+	g := r.gutter
+	th := g.Theme()
+	v := fyne.CurrentApp().Settings().ThemeVariant()
+	pad := th.Size(theme.SizeNameInnerPadding)
+	lineHeight := g.ui.lineHeight()
+	size := g.Size()
+	marker := r.markerSize()
+
+	top := g.ui.EditorScroll.Offset.Y
+	first := max(int((top-pad)/lineHeight), 0)
+	last := min(int((top+size.Height)/lineHeight)+1, g.lines-1)
+
+	path := g.ui.currentLocation().Path
+	folds := map[int]bool{}
+	for _, f := range g.ui.foldRanges() {
+		folds[f.Start] = true
+	}
+	foldSize := r.markerSize() * 1.2
+	problems := map[int]int{}
+	for _, d := range g.ui.Diagnostics[path] {
+		// A problem in a folded region is shown on the fold's first line.
+		row, _ := g.ui.displayRow(d.Range.Start.Line)
+		if severity, ok := problems[row]; !ok || d.Severity < severity {
+			problems[row] = d.Severity
+		}
+	}
+	r.objects = nil
+	for row := first; row <= last; row++ {
+		y := pad + float32(row)*lineHeight - top
+		// Rows that are partly scrolled out would be drawn over the neighbouring widgets.
+		if y < 0 || y+lineHeight > size.Height {
+			continue
+		}
+		line := g.ui.realRow(row)
+		number := canvas.NewText(strconv.Itoa(line+1), th.Color(theme.ColorNameDisabled, v))
+		number.TextSize = th.Size(theme.SizeNameText)
+		number.TextStyle = fyne.TextStyle{Monospace: true}
+		number.Alignment = fyne.TextAlignTrailing
+		if severity, ok := problems[row]; ok {
+			number.Color = th.Color(theme.ColorNameWarning, v)
+			if severity <= lsp.SeverityError {
+				number.Color = th.Color(theme.ColorNameError, v)
+			}
+		}
+		number.Move(fyne.NewPos(0, y))
+		number.Resize(fyne.NewSize(size.Width-th.Size(theme.SizeNamePadding)-g.foldWidth(), lineHeight))
+		r.objects = append(r.objects, number)
+
+		if folded := g.ui.isFolded(row); folded || folds[line] {
+			icon := canvas.NewImageFromResource(theme.NewDisabledResource(theme.MenuDropDownIcon()))
+			if folded {
+				icon.Resource = theme.NewPrimaryThemedResource(theme.MenuExpandIcon())
+			}
+			icon.Move(fyne.NewPos(size.Width-g.foldWidth(), y+(lineHeight-foldSize)/2))
+			icon.Resize(fyne.NewSquareSize(foldSize))
+			r.objects = append(r.objects, icon)
+		}
+
+		if g.ui.Bookmarks.Has(path, line) {
+			number.Color = th.Color(theme.ColorNamePrimary, v)
+			dot := canvas.NewCircle(th.Color(theme.ColorNamePrimary, v))
+			dot.Move(fyne.NewPos(th.Size(theme.SizeNamePadding)/2, y+(lineHeight-marker)/2))
+			dot.Resize(fyne.NewSquareSize(marker))
+			r.objects = append(r.objects, dot)
+		}
+	}
+}
+
+func (r *gutterRenderer) Objects() []fyne.CanvasObject {
+	return r.objects
+}
+
+func (r *gutterRenderer) Destroy() {}
+
+// cursorPosition returns the position on the canvas just below the cursor, for popups.
+func (ui *UI) cursorPosition() fyne.Position {
+	th := ui.Editor.Theme()
+	pad := th.Size(theme.SizeNameInnerPadding)
+	line := []rune(lineText(ui.Editor.Text, ui.Editor.CursorRow))
+	col := min(ui.Editor.CursorColumn, len(line))
+	x := pad + fyne.MeasureText(string(line[:col]), th.Size(theme.SizeNameText), ui.Editor.TextStyle).Width
+	y := pad + float32(ui.Editor.CursorRow+1)*ui.lineHeight()
+	origin := fyne.CurrentApp().Driver().AbsolutePositionForObject(ui.code)
+	return origin.AddXY(x, y)
+}
+
+// diagnosticLayer underlines the problems the language server found, on top of the editor.
+type diagnosticLayer struct {
+	widget.BaseWidget
+	ui *UI
+}
+
+// newDiagnosticLayer creates the underlines for ui's editor.
+func newDiagnosticLayer(ui *UI) *diagnosticLayer {
+	l := &diagnosticLayer{ui: ui}
+	l.ExtendBaseWidget(l)
+	return l
+}
+
+func (l *diagnosticLayer) CreateRenderer() fyne.WidgetRenderer {
+	return &diagnosticRenderer{layer: l}
+}
+
+// diagnosticRenderer draws a line under the text of each diagnostic in the current file.
+type diagnosticRenderer struct {
+	layer   *diagnosticLayer
+	objects []fyne.CanvasObject
+}
+
+func (r *diagnosticRenderer) MinSize() fyne.Size {
+	return fyne.Size{}
+}
+
+func (r *diagnosticRenderer) Layout(fyne.Size) {
+	r.layoutLines()
+}
+
+func (r *diagnosticRenderer) Refresh() {
+	r.layoutLines()
+	canvas.Refresh(r.layer)
+}
+
+// layoutLines creates the underlines, a range over several lines is underlined on each of them.
+func (r *diagnosticRenderer) layoutLines() {
+	ui := r.layer.ui
+	th := r.layer.Theme()
+	v := fyne.CurrentApp().Settings().ThemeVariant()
+	pad := th.Size(theme.SizeNameInnerPadding)
+	textSize := th.Size(theme.SizeNameText)
+	lineHeight := ui.lineHeight()
+	text := ui.text()
+	measure := func(s string) float32 {
+		return fyne.MeasureText(s, textSize, ui.Editor.TextStyle).Width
+	}
+
+	r.objects = nil
+	for _, d := range ui.Diagnostics[ui.currentLocation().Path] {
+		colour := th.Color(theme.ColorNameError, v)
+		if d.Severity > lsp.SeverityError {
+			colour = th.Color(theme.ColorNameWarning, v)
+		}
+		startRow, startCol := lsp.ColumnFor(text, d.Range.Start)
+		endRow, endCol := lsp.ColumnFor(text, d.Range.End)
+		for row := startRow; row <= endRow; row++ {
+			// Problems in folded regions aren't underlined, the gutter marks the fold's first line instead.
+			display, hidden := ui.displayRow(row)
+			if hidden {
+				continue
+			}
+			line := []rune(lineText(text, row))
+			from, to := 0, len(line)
+			if row == startRow {
+				from = min(startCol, len(line))
+			}
+			if row == endRow {
+				to = min(endCol, len(line))
+			}
+			x1 := pad + measure(string(line[:from]))
+			x2 := pad + measure(string(line[:to]))
+			// Zero width ranges, such as a missing token at the end of a line, still get a mark.
+			if x2-x1 < measure("m") {
+				x2 = x1 + measure("m")
+			}
+			y := pad + float32(display+1)*lineHeight - 1
+			underline := canvas.NewLine(colour)
+			underline.StrokeWidth = 2
+			underline.Position1 = fyne.NewPos(x1, y)
+			underline.Position2 = fyne.NewPos(x2, y)
+			r.objects = append(r.objects, underline)
+		}
+	}
+}
+
+func (r *diagnosticRenderer) Objects() []fyne.CanvasObject {
+	return r.objects
+}
+
+func (r *diagnosticRenderer) Destroy() {}
@@ -0,0 +1,272 @@
+package ui
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+	handling "github.com/Leda-Editor/Leda-Text-Editor/pkg/handling"
+)
+
+// auto_completion is the preference for suggesting completions while typing, rather than only on Ctrl + Space.
+const auto_completion = "editor_auto_completion"
+
+const (
+	// completionRows is how many suggestions the popup shows at once.
+	completionRows = 8
+	// maxCompletions is how many suggestions the popup lists.
+	maxCompletions = 100
+	// completionDelay is how long typing pauses before suggestions are looked for.
+	completionDelay = 100 * time.Millisecond
+	// completionTimeout is how long the providers get to make their suggestions.
+	completionTimeout = 2 * time.Second
+)
+
+// completionPopup lists suggestions below the cursor, the editor keeps the keyboard
+// and passes it the keys that choose a suggestion.
+type completionPopup struct {
+	ui    *UI
+	popup *widget.PopUp
+	list  *widget.List
+	// all holds every suggestion, items the ones matching what's been typed since, best first.
+	all      []handling.CompletionItem
+	items    []handling.CompletionItem
+	selected int
+	// row is the line the suggestions are for.
+	row int
+	// generation counts requests and closes, so late results of an old request are dropped.
+	generation int
+	timer      *time.Timer
+}
+
+// newCompletionPopup creates the completion list for ui's editor.
+func newCompletionPopup(ui *UI) *completionPopup {
+	c := &completionPopup{ui: ui}
+	c.list = widget.NewList(
+		func() int { return len(c.items) },
+		func() fyne.CanvasObject {
+			detail := widget.NewLabel("")
+			detail.Importance = widget.LowImportance
+			return container.NewBorder(nil, nil, widget.NewIcon(nil), detail, widget.NewLabel(""))
+		},
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			item := c.items[id]
+			row := o.(*fyne.Container)
+			row.Objects[0].(*widget.Label).SetText(item.Label)
+			row.Objects[1].(*widget.Icon).SetResource(completionIcon(item.Kind))
+			row.Objects[2].(*widget.Label).SetText(item.Detail)
+		},
+	)
+	c.list.OnSelected = func(id widget.ListItemID) {
+		c.selected = id
+	}
+	return c
+}
+
+// visible reports whether the popup is showing.
+func (c *completionPopup) visible() bool {
+	return c.popup != nil && c.popup.Visible()
+}
+
+// show lists the suggestions matching what's before the cursor, or hides the popup if none do.
+func (c *completionPopup) show(items []handling.CompletionItem, row int) {
+	c.all = items
+	c.row = row
+	c.filter()
+}
+
+// filter ranks the suggestions against what's been typed as it changes, hiding the popup once none match.
+func (c *completionPopup) filter() {
+	editor := c.ui.Editor
+	if editor.CursorRow != c.row {
+		c.hide()
+		return
+	}
+	items := handling.FilterCompletions(c.all, lineText(editor.Text, editor.CursorRow), editor.CursorColumn, maxCompletions)
+	c.items = items
+	if len(items) == 0 {
+		c.hide()
+		return
+	}
+	if c.popup == nil {
+		c.popup = widget.NewPopUp(c.list, c.ui.Window.Canvas())
+	}
+	c.list.Refresh()
+	c.selected = 0
+	c.list.Select(0)
+	c.list.ScrollToTop()
+
+	rowHeight := c.list.MinSize().Height
+	width := min(c.ui.Window.Canvas().Size().Width*0.5, 450)
+	c.popup.Resize(fyne.NewSize(width, rowHeight*float32(min(len(items), completionRows))))
+	c.popup.ShowAtPosition(c.ui.cursorPosition())
+	// The popup mustn't take the keyboard from the editor.
+	c.ui.Window.Canvas().Focus(c.ui.code)
+}
+
+// hide closes the popup and drops suggestions still being looked for.
+func (c *completionPopup) hide() {
+	c.generation++
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+	if c.popup != nil {
+		c.popup.Hide()
+	}
+}
+
+// typedKey handles the keys that move through and pick suggestions, reporting whether it used the key.
+func (c *completionPopup) typedKey(key *fyne.KeyEvent) bool {
+	if !c.visible() {
+		return false
+	}
+	switch key.Name {
+	case fyne.KeyUp:
+		if c.selected > 0 {
+			c.list.Select(c.selected - 1)
+		}
+	case fyne.KeyDown:
+		if c.selected < len(c.items)-1 {
+			c.list.Select(c.selected + 1)
+		}
+	case fyne.KeyReturn, fyne.KeyEnter, fyne.KeyTab:
+		c.accept()
+	case fyne.KeyEscape:
+		c.hide()
+	default:
+		return false
+	}
+	return true
+}
+
+// accept replaces what's been typed with the selected suggestion.
+func (c *completionPopup) accept() {
+	if c.selected < 0 || c.selected >= len(c.items) {
+		return
+	}
+	item := c.items[c.selected]
+	c.hide()
+	if item.Snippet != nil {
+		c.ui.insertSnippet(*item.Snippet, c.ui.Editor.CursorColumn-item.Start)
+		return
+	}
+	c.ui.replaceBeforeCursor(c.ui.Editor.CursorColumn-item.Start, item.Insert)
+	// Carry on into a folder.
+	if strings.HasSuffix(item.Insert, "/") {
+		c.ui.requestCompletion(false)
+	}
+}
+
+// typedRune offers suggestions as a word, path or link is typed, unless that's been turned off.
+func (c *completionPopup) typedRune(r rune) {
+	if c.visible() {
+		c.filter()
+		if c.visible() {
+			return
+		}
+	}
+	// Macros play keys as they were recorded, without suggestions to pick from.
+	if !c.ui.App.Preferences().BoolWithFallback(auto_completion, true) || c.ui.recording || c.ui.playingMacro > 0 {
+		return
+	}
+	line := lineText(c.ui.Editor.Text, c.ui.Editor.CursorRow)
+	if len([]rune(handling.WordBefore(line, c.ui.Editor.CursorColumn))) < 2 && !isCompletionTrigger(r) {
+		return
+	}
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+	c.timer = time.AfterFunc(completionDelay, func() {
+		fyne.Do(func() { c.ui.requestCompletion(false) })
+	})
+}
+
+// isCompletionTrigger reports whether typing r may start something worth completing,
+// such as a member after "." or a path after "/".
+func isCompletionTrigger(r rune) bool {
+	switch r {
+	case '.', '/', '#', '(', ':', '>':
+		return true
+	}
+	return false
+}
+
+// replaceBeforeCursor deletes count characters before the cursor and types text in their place,
+// so the change can be undone like typing.
+func (ui *UI) replaceBeforeCursor(count int, text string) {
+	for i := 0; i < count; i++ {
+		ui.code.Entry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyBackspace})
+	}
+	for _, r := range text {
+		ui.code.Entry.TypedRune(r)
+	}
+}
+
+// Suggest completions at the cursor (Ctrl + Space).
+func (ui *UI) showCompletion() {
+	ui.requestCompletion(true)
+}
+
+// requestCompletion asks every provider for suggestions in the background and shows them once they've all answered.
+func (ui *UI) requestCompletion(manual bool) {
+	c := ui.completion
+	c.generation++
+	generation := c.generation
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+
+	row := ui.Editor.CursorRow
+	line := []rune(lineText(ui.Editor.Text, row))
+	column := min(ui.Editor.CursorColumn, len(line))
+	// Providers see the row within the whole text, the popup follows the row on screen, which folds can move.
+	request := handling.CompletionContext{
+		Path:    ui.currentLocation().Path,
+		Text:    ui.text(),
+		Row:     ui.currentLocation().Row,
+		Column:  column,
+		Before:  string(line[:column]),
+		Manual:  manual,
+		Root:    handling.WorkspaceRoot,
+		Ignores: handling.WorkspaceIgnores(),
+	}
+	recent := append(slices.Clone(handling.RecentFiles.Pinned()), handling.RecentFiles.Paths()...)
+	request.Buffers = recent[:min(len(recent), bufferWordFiles)]
+	if request.Path != "" {
+		// The language server should see what's on screen before answering.
+		ui.lspSync()
+	}
+	go func() {
+		items := ui.collectCompletions(request)
+		fyne.Do(func() {
+			// Typing has moved on if there has been another request since.
+			if generation != c.generation {
+				return
+			}
+			c.show(items, row)
+		})
+	}()
+}
+
+// collectCompletions runs the providers, giving them a while to answer.
+func (ui *UI) collectCompletions(c handling.CompletionContext) []handling.CompletionItem {
+	ctx, cancel := context.WithTimeout(context.Background(), completionTimeout)
+	defer cancel()
+	return handling.CollectCompletions(ctx, ui.completionProviders, c)
+}
+
+// Turn suggestions while typing on or off, Ctrl + Space still asks for them.
+func (ui *UI) toggleAutoCompletion(item *fyne.MenuItem) {
+	prefs := ui.App.Preferences()
+	enabled := !prefs.BoolWithFallback(auto_completion, true)
+	prefs.SetBool(auto_completion, enabled)
+	item.Checked = enabled
+	ui.mainMenu.Refresh()
+	if !enabled {
+		ui.completion.hide()
+	}
+}
@@ -0,0 +1,57 @@
+package ui
+
+import (
+	"context"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/theme"
+	handling "github.com/Leda-Editor/Leda-Text-Editor/pkg/handling"
+	"github.com/Leda-Editor/Leda-Text-Editor/pkg/lsp"
+)
+
+// bufferWordFiles is how many recently opened files lend their words to completion, besides the current one.
+const bufferWordFiles = 10
+
+// lspCompletions suggests what the current file's language server does.
+func (ui *UI) lspCompletions(ctx context.Context, c handling.CompletionContext) []handling.CompletionItem {
+	if c.Path == "" {
+		return nil
+	}
+	client := ui.LSP.Running(c.Path)
+	if client == nil {
+		return nil
+	}
+	// Wait for queued changes to reach the server.
+	select {
+	case <-ui.lspQueue.Synced():
+	case <-ctx.Done():
+		return nil
+	}
+
+	results, err := client.Completion(ctx, c.Path, lsp.PositionFor(c.Text, c.Row, c.Column))
+	if err != nil {
+		return nil
+	}
+	return handling.LSPCompletions(results, c)
+}
+
+// completionIcon picks an icon for a kind of suggestion.
+func completionIcon(kind handling.CompletionKind) fyne.Resource {
+	switch kind {
+	case handling.CompletionFile:
+		return theme.FileIcon()
+	case handling.CompletionFolder:
+		return theme.FolderIcon()
+	case handling.CompletionHeading, handling.CompletionChoice:
+		return theme.MenuIcon()
+	case handling.CompletionSnippet:
+		return theme.ContentPasteIcon()
+	case handling.CompletionFunction:
+		return theme.MailForwardIcon()
+	case handling.CompletionField:
+		return theme.ListIcon()
+	case handling.CompletionKeyword:
+		return theme.InfoIcon()
+	}
+	return theme.DocumentIcon()
+}
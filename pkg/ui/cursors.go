@@ -0,0 +1,430 @@
+package ui
+
+import (
+	"slices"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	handling "github.com/Leda-Editor/Leda-Text-Editor/pkg/handling"
+)
+
+// The editor's own cursor is the primary one, the cursors added with Alt + click, Ctrl + D or
+// a block selection are kept in ui.cursors and drawn by the cursorLayer.
+// While there are any, typing, deleting and the clipboard work at all of them; they go away
+// when the text is changed some other way. Regions are unfolded before cursors are added.
+
+// primaryCursor returns the editor's own cursor and its selection.
+func (ui *UI) primaryCursor() handling.Cursor {
+	start, end := ui.selectionRange()
+	if ui.cursorOffset() == start {
+		return handling.Cursor{Anchor: end, Position: start}
+	}
+	return handling.Cursor{Anchor: start, Position: end}
+}
+
+// allCursors returns every cursor, the primary one last.
+func (ui *UI) allCursors() []handling.Cursor {
+	return append(slices.Clone(ui.cursors), ui.primaryCursor())
+}
+
+// setCursors replaces the cursors, the last one becomes the editor's own.
+// Cursors that overlap are joined.
+func (ui *UI) setCursors(cursors []handling.Cursor) {
+	primary := cursors[len(cursors)-1]
+	merged := handling.MergeCursors(cursors)
+	ui.cursors = nil
+	for _, c := range merged {
+		if c.Start() <= primary.Position && primary.Position <= c.End() && !slices.Contains(merged, primary) {
+			primary = c
+		}
+		if c != primary {
+			ui.cursors = append(ui.cursors, c)
+		}
+	}
+	ui.selectText(primary.Anchor, primary.Position)
+	ui.cursorLayer.Refresh()
+}
+
+// clearCursors removes all but the editor's own cursor.
+func (ui *UI) clearCursors() {
+	if len(ui.cursors) > 0 {
+		ui.cursors = nil
+		ui.cursorLayer.Refresh()
+	}
+}
+
+// cursorsTextChanged removes the added cursors when the text was changed by something other than them.
+func (ui *UI) cursorsTextChanged() {
+	if !ui.editingCursors {
+		ui.clearCursors()
+	}
+}
+
+// editAtCursors makes the same kind of edit at every cursor as one change that can be undone.
+// edit is given the cursors in order of position along with the text before the change.
+func (ui *UI) editAtCursors(edit func(c handling.Cursor, text []rune) handling.CursorEdit) {
+	text := []rune(ui.Editor.Text)
+	updated, moved := handling.EditCursors(text, ui.allCursors(), func(c handling.Cursor) handling.CursorEdit {
+		return edit(c, text)
+	})
+	ui.editingCursors = true
+	ui.replaceText(string(updated))
+	ui.editingCursors = false
+	ui.setCursors(moved)
+}
+
+// insertAtCursors types text at every cursor, replacing their selections.
+func (ui *UI) insertAtCursors(text string) {
+	ui.editAtCursors(func(c handling.Cursor, _ []rune) handling.CursorEdit {
+		return handling.CursorEdit{Start: c.Start(), End: c.End(), Insert: text}
+	})
+}
+
+// multiCursorRune types r at every cursor, reporting whether there are several.
+func (ui *UI) multiCursorRune(r rune) bool {
+	if len(ui.cursors) == 0 {
+		return false
+	}
+	ui.insertAtCursors(string(r))
+	return true
+}
+
+// multiCursorKey edits at or moves every cursor for the keys that do that, reporting whether it handled the key.
+// Escape goes back to a single cursor.
+func (ui *UI) multiCursorKey(key *fyne.KeyEvent) bool {
+	if len(ui.cursors) == 0 {
+		return false
+	}
+	switch key.Name {
+	case fyne.KeyEscape:
+		ui.clearCursors()
+	case fyne.KeyBackspace, fyne.KeyDelete:
+		step := -1
+		if key.Name == fyne.KeyDelete {
+			step = 1
+		}
+		ui.editAtCursors(func(c handling.Cursor, text []rune) handling.CursorEdit {
+			if !c.Empty() {
+				return handling.CursorEdit{Start: c.Start(), End: c.End()}
+			}
+			next := min(max(c.Position+step, 0), len(text))
+			return handling.CursorEdit{Start: min(c.Position, next), End: max(c.Position, next)}
+		})
+	case fyne.KeyReturn, fyne.KeyEnter:
+		ui.editAtCursors(func(c handling.Cursor, text []rune) handling.CursorEdit {
+			row, _ := rowColumnAt(string(text), c.Start())
+			indent := leadingSpace(lineText(string(text), row))
+			return handling.CursorEdit{Start: c.Start(), End: c.End(), Insert: "\n" + indent}
+		})
+	case fyne.KeyTab:
+		if !shiftPressed() {
+			ui.insertAtCursors(ui.indentation.Unit())
+		}
+	case fyne.KeyLeft, fyne.KeyRight, fyne.KeyUp, fyne.KeyDown, fyne.KeyHome, fyne.KeyEnd:
+		ui.moveCursors(key.Name, shiftPressed())
+	default:
+		return false
+	}
+	return true
+}
+
+// moveCursors moves every cursor with an arrow, Home or End key, extending their selections while shift is held.
+func (ui *UI) moveCursors(key fyne.KeyName, selecting bool) {
+	text := ui.Editor.Text
+	length := len([]rune(text))
+	cursors := ui.allCursors()
+	for i, c := range cursors {
+		row, column := rowColumnAt(text, c.Position)
+		position := c.Position
+		switch key {
+		case fyne.KeyLeft:
+			position = max(position-1, 0)
+			if !selecting && !c.Empty() {
+				position = c.Start()
+			}
+		case fyne.KeyRight:
+			position = min(position+1, length)
+			if !selecting && !c.Empty() {
+				position = c.End()
+			}
+		case fyne.KeyUp:
+			position = 0
+			if row > 0 {
+				position = textOffset(text, row-1, column)
+			}
+		case fyne.KeyDown:
+			position = textOffset(text, row+1, column)
+		case fyne.KeyHome:
+			position = textOffset(text, row, 0)
+		case fyne.KeyEnd:
+			position = textOffset(text, row, len([]rune(lineText(text, row))))
+		}
+		anchor := position
+		if selecting {
+			anchor = c.Anchor
+		}
+		cursors[i] = handling.Cursor{Anchor: anchor, Position: position}
+	}
+	ui.setCursors(cursors)
+}
+
+// cursorSelections returns the text selected by every cursor in order of position, nothing if none have a selection.
+func (ui *UI) cursorSelections() []string {
+	text := []rune(ui.Editor.Text)
+	var selections []string
+	selected := false
+	for _, c := range handling.MergeCursors(ui.allCursors()) {
+		selections = append(selections, string(text[c.Start():c.End()]))
+		selected = selected || !c.Empty()
+	}
+	if !selected {
+		return nil
+	}
+	return selections
+}
+
+// multiCursorShortcut copies, cuts and pastes at every cursor, reporting whether it handled the shortcut.
+// Copied selections are put on separate lines, and pasting as many lines as there are cursors gives one to each.
+func (ui *UI) multiCursorShortcut(shortcut fyne.Shortcut) bool {
+	if len(ui.cursors) == 0 {
+		return false
+	}
+	switch s := shortcut.(type) {
+	case *fyne.ShortcutCopy:
+		selections := ui.cursorSelections()
+		if selections == nil {
+			return false
+		}
+		s.Clipboard.SetContent(strings.Join(selections, "\n"))
+	case *fyne.ShortcutCut:
+		selections := ui.cursorSelections()
+		if selections == nil {
+			return false
+		}
+		s.Clipboard.SetContent(strings.Join(selections, "\n"))
+		ui.insertAtCursors("")
+	case *fyne.ShortcutPaste:
+		pieces := handling.SplitForCursors(s.Clipboard.Content(), len(ui.cursors)+1)
+		next := 0
+		ui.editAtCursors(func(c handling.Cursor, _ []rune) handling.CursorEdit {
+			next++
+			return handling.CursorEdit{Start: c.Start(), End: c.End(), Insert: pieces[next-1]}
+		})
+	case *fyne.ShortcutSelectAll, *fyne.ShortcutUndo, *fyne.ShortcutRedo:
+		ui.clearCursors()
+		return false
+	default:
+		return false
+	}
+	return true
+}
+
+// startCursors gets the editor ready for more cursors, unfolding everything so every line can have one.
+func (ui *UI) startCursors() {
+	ui.unfoldAll()
+	ui.completion.hide()
+	ui.endSnippet()
+}
+
+// columnAt returns the column of a line of the editor nearest to a horizontal position within the editor.
+func (ui *UI) columnAt(row int, x float32) int {
+	th := ui.Editor.Theme()
+	x -= th.Size(theme.SizeNameInnerPadding)
+	line := []rune(lineText(ui.Editor.Text, row))
+	previous := float32(0)
+	for i := range line {
+		width := fyne.MeasureText(string(line[:i+1]), th.Size(theme.SizeNameText), ui.Editor.TextStyle).Width
+		if x < (previous+width)/2 {
+			return i
+		}
+		previous = width
+	}
+	return len(line)
+}
+
+// textPositionAt returns the line of the text and the column at a position within the editor, folded lines counted.
+func (ui *UI) textPositionAt(pos fyne.Position) (row, column int) {
+	display := min(max(ui.rowAt(pos.Y), 0), strings.Count(ui.Editor.Text, "\n"))
+	return ui.realRow(display), ui.columnAt(display, pos.X)
+}
+
+// toggleCursorAt adds a cursor where the editor was Alt + clicked, or removes the one that's there.
+func (ui *UI) toggleCursorAt(pos fyne.Position) {
+	row, column := ui.textPositionAt(pos)
+	ui.startCursors()
+	offset := textOffset(ui.Editor.Text, row, column)
+	cursors := ui.allCursors()
+	primary := cursors[len(cursors)-1]
+	for i, c := range cursors[:len(cursors)-1] {
+		if c.Position == offset {
+			ui.setCursors(slices.Delete(cursors, i, i+1))
+			return
+		}
+	}
+	if primary.Position == offset {
+		return
+	}
+	ui.setCursors(append([]handling.Cursor{{Anchor: offset, Position: offset}}, cursors...))
+}
+
+// Add a cursor selecting the next occurrence of the selection, selecting the word at the cursor first (Ctrl + D).
+func (ui *UI) addNextOccurrence() {
+	ui.startCursors()
+	text := []rune(ui.Editor.Text)
+	primary := ui.primaryCursor()
+	if primary.Empty() {
+		start, end := primary.Position, primary.Position
+		for start > 0 && handling.IsWordRune(text[start-1]) {
+			start--
+		}
+		for end < len(text) && handling.IsWordRune(text[end]) {
+			end++
+		}
+		if start != end {
+			ui.selectText(start, end)
+		}
+		return
+	}
+	needle := text[primary.Start():primary.End()]
+	cursors := ui.allCursors()
+	from := primary.End()
+	for range cursors {
+		at, ok := handling.NextOccurrence(text, needle, from)
+		if !ok {
+			return
+		}
+		taken := slices.ContainsFunc(cursors, func(c handling.Cursor) bool { return c.Start() == at })
+		if !taken {
+			ui.setCursors(append(cursors, handling.Cursor{Anchor: at, Position: at + len(needle)}))
+			return
+		}
+		from = at + len(needle)
+	}
+}
+
+// blockSelection is where a block selection made with Alt + Shift + drag started.
+type blockSelection struct {
+	row int
+	x   float32
+}
+
+// startBlockSelection starts a block selection where the editor was pressed.
+func (ui *UI) startBlockSelection(pos fyne.Position) {
+	row, _ := ui.textPositionAt(pos)
+	ui.startCursors()
+	ui.block = &blockSelection{row: row, x: pos.X}
+	ui.Window.Canvas().Focus(ui.code)
+}
+
+// dragBlockSelection puts a cursor on each line between where the block selection started and pos,
+// selecting the columns between the two. The cursor on the line being dragged to is the editor's own.
+func (ui *UI) dragBlockSelection(pos fyne.Position) {
+	text := ui.Editor.Text
+	last := strings.Count(text, "\n")
+	to := min(max(ui.rowAt(pos.Y), 0), last)
+	from := min(ui.block.row, last)
+	step := 1
+	if to < from {
+		step = -1
+	}
+	var cursors []handling.Cursor
+	for row := from; ; row += step {
+		anchor := textOffset(text, row, ui.columnAt(row, ui.block.x))
+		cursors = append(cursors, handling.Cursor{Anchor: anchor, Position: textOffset(text, row, ui.columnAt(row, pos.X))})
+		if row == to {
+			break
+		}
+	}
+	ui.setCursors(cursors)
+}
+
+// cursorLayer draws the cursors added to the editor and their selections, on top of the editor.
+type cursorLayer struct {
+	widget.BaseWidget
+	ui *UI
+}
+
+// newCursorLayer creates the added cursors of ui's editor.
+func newCursorLayer(ui *UI) *cursorLayer {
+	l := &cursorLayer{ui: ui}
+	l.ExtendBaseWidget(l)
+	return l
+}
+
+func (l *cursorLayer) CreateRenderer() fyne.WidgetRenderer {
+	return &cursorRenderer{layer: l}
+}
+
+// cursorRenderer draws a caret for each added cursor and highlights what it selects.
+type cursorRenderer struct {
+	layer   *cursorLayer
+	objects []fyne.CanvasObject
+}
+
+func (r *cursorRenderer) MinSize() fyne.Size {
+	return fyne.Size{}
+}
+
+func (r *cursorRenderer) Layout(fyne.Size) {
+	r.layoutCursors()
+}
+
+func (r *cursorRenderer) Refresh() {
+	r.layoutCursors()
+	canvas.Refresh(r.layer)
+}
+
+// layoutCursors creates the carets and the selection of each cursor, line by line.
+func (r *cursorRenderer) layoutCursors() {
+	ui := r.layer.ui
+	r.objects = nil
+	if len(ui.cursors) == 0 {
+		return
+	}
+	th := r.layer.Theme()
+	v := fyne.CurrentApp().Settings().ThemeVariant()
+	pad := th.Size(theme.SizeNameInnerPadding)
+	textSize := th.Size(theme.SizeNameText)
+	lineHeight := ui.lineHeight()
+	text := ui.Editor.Text
+	measure := func(row, column int) float32 {
+		line := []rune(lineText(text, row))
+		return pad + fyne.MeasureText(string(line[:min(column, len(line))]), textSize, ui.Editor.TextStyle).Width
+	}
+	for _, c := range ui.cursors {
+		startRow, startColumn := rowColumnAt(text, c.Start())
+		endRow, endColumn := rowColumnAt(text, c.End())
+		for row := startRow; row <= endRow && !c.Empty(); row++ {
+			from, to := 0, len([]rune(lineText(text, row)))
+			if row == startRow {
+				from = startColumn
+			}
+			if row == endRow {
+				to = endColumn
+			}
+			x1, x2 := measure(row, from), measure(row, to)
+			// A selected line break shows as a little space at the end of the line.
+			if row < endRow {
+				x2 += fyne.MeasureText(" ", textSize, ui.Editor.TextStyle).Width
+			}
+			box := canvas.NewRectangle(th.Color(theme.ColorNameSelection, v))
+			box.Move(fyne.NewPos(x1, pad+float32(row)*lineHeight))
+			box.Resize(fyne.NewSize(x2-x1, lineHeight))
+			r.objects = append(r.objects, box)
+		}
+		row, column := rowColumnAt(text, c.Position)
+		caret := canvas.NewRectangle(th.Color(theme.ColorNamePrimary, v))
+		caret.Move(fyne.NewPos(measure(row, column)-1, pad+float32(row)*lineHeight))
+		caret.Resize(fyne.NewSize(2, lineHeight))
+		r.objects = append(r.objects, caret)
+	}
+}
+
+func (r *cursorRenderer) Objects() []fyne.CanvasObject {
+	return r.objects
+}
+
+func (r *cursorRenderer) Destroy() {}
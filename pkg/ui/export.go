@@ -0,0 +1,18 @@
+package ui
+
+import (
+	"fyne.io/fyne/v2/theme"
+	handling "github.com/Leda-Editor/Leda-Text-Editor/pkg/handling"
+)
+
+// exportStyle derives export colours from the active theme.
+func (ui *UI) exportStyle() handling.ExportStyle {
+	variant := ui.App.Settings().ThemeVariant()
+	th := ui.App.Settings().Theme()
+	return handling.ExportStyle{
+		Background: formatHexColor(th.Color(theme.ColorNameBackground, variant)),
+		Text:       formatHexColor(th.Color(theme.ColorNameForeground, variant)),
+		Primary:    formatHexColor(th.Color(theme.ColorNamePrimary, variant)),
+		Code:       formatHexColor(th.Color(theme.ColorNameInputBackground, variant)),
+	}
+}
@@ -0,0 +1,303 @@
+package ui
+
+import (
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	handling "github.com/Leda-Editor/Leda-Text-Editor/pkg/handling"
+)
+
+// FileTreePanel shows the workspace folder next to the editor.
+type FileTreePanel struct {
+	// Tree lists files and folders, node IDs are absolute paths.
+	Tree *widget.Tree
+	// Visible indicates whether the panel is shown.
+	Visible bool
+
+	ui    *UI
+	title *widget.Label
+	// entries caches directory listings, filled lazily when a folder is expanded.
+	entries map[string][]string
+	dirs    map[string]bool
+	watcher *handling.WorkspaceWatcher
+}
+
+// newFileTreePanel creates an empty workspace tree.
+func newFileTreePanel(ui *UI) *FileTreePanel {
+	p := &FileTreePanel{
+		ui:      ui,
+		title:   widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		entries: map[string][]string{},
+		dirs:    map[string]bool{},
+	}
+
+	p.Tree = widget.NewTree(
+		func(id widget.TreeNodeID) []widget.TreeNodeID {
+			return p.children(id)
+		},
+		func(id widget.TreeNodeID) bool {
+			return id == "" || p.dirs[id]
+		},
+		func(branch bool) fyne.CanvasObject {
+			return newFileTreeNode(p)
+		},
+		func(id widget.TreeNodeID, branch bool, o fyne.CanvasObject) {
+			o.(*fileTreeNode).setPath(id, p.dirs[id])
+		},
+	)
+
+	p.Tree.OnSelected = func(id widget.TreeNodeID) {
+		if p.dirs[id] {
+			p.Tree.ToggleBranch(id)
+			p.Tree.Unselect(id)
+			return
+		}
+		ui.jumpTo(handling.Location{Path: id})
+	}
+	return p
+}
+
+// SetRoot shows a new workspace folder and starts watching it.
+func (p *FileTreePanel) SetRoot(root string) {
+	if p.watcher != nil {
+		p.watcher.Close()
+		p.watcher = nil
+	}
+	watcher, err := handling.WatchWorkspace(func(dir string) {
+		fyne.Do(func() { p.invalidate(dir) })
+	})
+	if err != nil {
+		fyne.LogError("Failed to watch workspace", err)
+	} else {
+		p.watcher = watcher
+	}
+
+	p.entries = map[string][]string{}
+	p.dirs = map[string]bool{}
+	p.title.SetText("📁 " + filepath.Base(root))
+	p.Visible = true
+	p.Tree.UnselectAll()
+	p.Tree.Refresh()
+}
+
+// children lists a directory on first use and watches it from then on.
+func (p *FileTreePanel) children(id widget.TreeNodeID) []widget.TreeNodeID {
+	dir := id
+	if dir == "" {
+		dir = handling.WorkspaceRoot
+	}
+	if dir == "" {
+		return nil
+	}
+	if cached, ok := p.entries[dir]; ok {
+		return cached
+	}
+
+	entries, err := handling.ListDir(dir)
+	if err != nil {
+		fyne.LogError("Failed to list "+dir, err)
+		return nil
+	}
+	paths := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		p.dirs[path] = entry.IsDir()
+		paths = append(paths, path)
+	}
+	p.entries[dir] = paths
+	if p.watcher != nil {
+		p.watcher.Add(dir)
+	}
+	return paths
+}
+
+// invalidate drops a cached listing so it's read again from disk.
+func (p *FileTreePanel) invalidate(dir string) {
+	delete(p.entries, dir)
+	p.Tree.Refresh()
+}
+
+// refresh re-reads every listing.
+func (p *FileTreePanel) refresh() {
+	p.entries = map[string][]string{}
+	p.Tree.Refresh()
+}
+
+// content returns the panel with its toolbar.
+func (p *FileTreePanel) content() fyne.CanvasObject {
+	toolbar := container.NewHBox(
+		p.title,
+		layout.NewSpacer(),
+		widget.NewButtonWithIcon("", theme.DocumentCreateIcon(), func() { p.newFile(handling.WorkspaceRoot) }),
+		widget.NewButtonWithIcon("", theme.FolderNewIcon(), func() { p.newFolder(handling.WorkspaceRoot) }),
+		widget.NewButtonWithIcon("", theme.ViewRefreshIcon(), func() { p.refresh() }),
+		widget.NewButtonWithIcon("", theme.CancelIcon(), func() { p.ui.toggleFileTree() }),
+	)
+	return container.NewBorder(toolbar, nil, nil, nil, p.Tree)
+}
+
+// showMenu offers the file operations for a tree entry.
+func (p *FileTreePanel) showMenu(path string, pos fyne.Position) {
+	dir := path
+	if !p.dirs[path] {
+		dir = filepath.Dir(path)
+	}
+	menu := fyne.NewMenu("",
+		fyne.NewMenuItem("New File…", func() { p.newFile(dir) }),
+		fyne.NewMenuItem("New Folder…", func() { p.newFolder(dir) }),
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Rename…", func() { p.rename(path) }),
+		fyne.NewMenuItem("Move…", func() { p.move(path) }),
+		fyne.NewMenuItem("Delete", func() { p.delete(path) }),
+	)
+	widget.ShowPopUpMenuAtPosition(menu, p.ui.Window.Canvas(), pos)
+}
+
+func (p *FileTreePanel) newFile(dir string) {
+	p.ui.askName("New File", "Name", "", "Create", func(name string) {
+		path := filepath.Join(dir, name)
+		if err := handling.CreateFile(path); err != nil {
+			dialog.ShowError(err, p.ui.Window)
+			return
+		}
+		p.invalidate(dir)
+		p.ui.jumpTo(handling.Location{Path: path})
+	})
+}
+
+func (p *FileTreePanel) newFolder(dir string) {
+	p.ui.askName("New Folder", "Name", "", "Create", func(name string) {
+		if err := handling.CreateFolder(filepath.Join(dir, name)); err != nil {
+			dialog.ShowError(err, p.ui.Window)
+			return
+		}
+		p.invalidate(dir)
+	})
+}
+
+func (p *FileTreePanel) rename(path string) {
+	p.ui.askName("Rename", "New name", filepath.Base(path), "Rename", func(name string) {
+		to := filepath.Join(filepath.Dir(path), name)
+		if err := handling.MovePath(path, to); err != nil {
+			dialog.ShowError(err, p.ui.Window)
+			return
+		}
+		p.ui.Bookmarks.Rename(path, to)
+		p.ui.bookmarksChanged()
+		p.invalidate(filepath.Dir(path))
+	})
+}
+
+func (p *FileTreePanel) move(path string) {
+	rel, err := filepath.Rel(handling.WorkspaceRoot, filepath.Dir(path))
+	if err != nil {
+		rel = "."
+	}
+	p.ui.askName("Move "+filepath.Base(path), "To folder", rel, "Move", func(target string) {
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(handling.WorkspaceRoot, target)
+		}
+		if info, err := os.Stat(target); err != nil || !info.IsDir() {
+			dialog.ShowError(fmt.Errorf("%s is not a folder", target), p.ui.Window)
+			return
+		}
+		to := filepath.Join(target, filepath.Base(path))
+		if err := handling.MovePath(path, to); err != nil {
+			dialog.ShowError(err, p.ui.Window)
+			return
+		}
+		p.ui.Bookmarks.Rename(path, to)
+		p.ui.bookmarksChanged()
+		p.invalidate(filepath.Dir(path))
+		p.invalidate(target)
+	})
+}
+
+func (p *FileTreePanel) delete(path string) {
+	message := fmt.Sprintf("Are you sure you want to delete %s?", filepath.Base(path))
+	if p.dirs[path] {
+		message = fmt.Sprintf("Are you sure you want to delete the folder %s and everything in it?", filepath.Base(path))
+	}
+	dialog.ShowConfirm("Delete", message, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		if err := handling.DeletePath(path); err != nil {
+			dialog.ShowError(err, p.ui.Window)
+			return
+		}
+		p.ui.Bookmarks.Clear(path)
+		p.ui.bookmarksChanged()
+		p.invalidate(filepath.Dir(path))
+	}, p.ui.Window)
+}
+
+// fileIcon picks an icon from the file's type.
+func fileIcon(path string, dir bool) fyne.Resource {
+	if dir {
+		return theme.FolderIcon()
+	}
+	mimeType := mime.TypeByExtension(filepath.Ext(path))
+	switch {
+	case strings.HasPrefix(mimeType, "image/"):
+		return theme.FileImageIcon()
+	case strings.HasPrefix(mimeType, "audio/"):
+		return theme.FileAudioIcon()
+	case strings.HasPrefix(mimeType, "video/"):
+		return theme.FileVideoIcon()
+	case strings.HasPrefix(mimeType, "text/"), handling.IsMarkdownFile(path):
+		return theme.FileTextIcon()
+	case strings.HasPrefix(mimeType, "application/") && !strings.Contains(mimeType, "json") && !strings.Contains(mimeType, "xml"):
+		return theme.FileApplicationIcon()
+	}
+	return theme.FileIcon()
+}
+
+// fileTreeNode is a tree row with an icon and a right click menu.
+type fileTreeNode struct {
+	widget.BaseWidget
+	panel *FileTreePanel
+	icon  *widget.Icon
+	label *widget.Label
+	path  string
+}
+
+func newFileTreeNode(panel *FileTreePanel) *fileTreeNode {
+	n := &fileTreeNode{panel: panel, icon: widget.NewIcon(nil), label: widget.NewLabel("")}
+	n.ExtendBaseWidget(n)
+	return n
+}
+
+func (n *fileTreeNode) setPath(path string, dir bool) {
+	n.path = path
+	n.icon.SetResource(fileIcon(path, dir))
+	n.label.SetText(filepath.Base(path))
+}
+
+func (n *fileTreeNode) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(container.NewHBox(n.icon, n.label))
+}
+
+// TappedSecondary opens the file operations menu.
+func (n *fileTreeNode) TappedSecondary(e *fyne.PointEvent) {
+	n.panel.showMenu(n.path, e.AbsolutePosition)
+}
+
+// Toggle visibility of the workspace sidebar.
+func (ui *UI) toggleFileTree() {
+	if handling.WorkspaceRoot == "" {
+		handling.OpenFolder(ui.Window)
+		return
+	}
+	ui.FileTree.Visible = !ui.FileTree.Visible
+	ui.UpdateLayout()
+}
@@ -0,0 +1,453 @@
+package ui
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	handling "github.com/Leda-Editor/Leda-Text-Editor/pkg/handling"
+)
+
+// Folding only changes what the editor shows. The whole text is kept in document and the editor shows it with
+// the lines hidden by folded regions left out, foldRows says which line of document each line of the editor is.
+// Edits made in the editor are carried over to document by textEdited, which also records them so they can be undone.
+
+// text returns the whole text, with its folded regions, which is what's saved and what line numbers count.
+func (ui *UI) text() string {
+	return ui.document
+}
+
+// fullText returns the whole text an editor text stands for, for saving and exporting.
+func (ui *UI) fullText(shown string) string {
+	if shown == ui.shown {
+		return ui.document
+	}
+	return shown
+}
+
+// realRow returns the line of the text shown on a line of the editor.
+func (ui *UI) realRow(row int) int {
+	if row < 0 || row >= len(ui.foldRows) {
+		return row
+	}
+	return ui.foldRows[row]
+}
+
+// displayRow returns the line of the editor showing a line of the text, or the first line of the region it's folded in.
+func (ui *UI) displayRow(row int) (display int, hidden bool) {
+	if len(ui.foldRows) == 0 {
+		return row, false
+	}
+	display = max(sort.SearchInts(ui.foldRows, row+1)-1, 0)
+	return display, ui.foldRows[display] != row
+}
+
+// documentOffset returns the position in the text of a position in the editor, in runes.
+func (ui *UI) documentOffset(offset int) int {
+	if len(ui.foldRows) == 0 {
+		return offset
+	}
+	row, column := rowColumnAt(ui.shown, offset)
+	return textOffset(ui.document, ui.realRow(row), column)
+}
+
+// editorOffset returns the position in the editor showing a position in the text.
+// One in a folded region is shown at the end of the region's first line.
+func (ui *UI) editorOffset(offset int) int {
+	if len(ui.foldRows) == 0 {
+		return offset
+	}
+	row, column := rowColumnAt(ui.document, offset)
+	display, hidden := ui.displayRow(row)
+	if hidden {
+		column = len([]rune(lineText(ui.shown, display)))
+	}
+	return textOffset(ui.shown, display, column)
+}
+
+// selectedText returns the selected text, with the regions folded inside the selection.
+func (ui *UI) selectedText() string {
+	selected := ui.Editor.SelectedText()
+	// Hidden lines are only ever inside a selection over several lines.
+	if len(ui.folded) == 0 || !strings.Contains(selected, "\n") || ui.measuringSelection {
+		return selected
+	}
+	ui.measuringSelection = true
+	start, end := ui.selectionRange()
+	ui.measuringSelection = false
+	text := []rune(ui.document)
+	return string(text[ui.documentOffset(start):ui.documentOffset(end)])
+}
+
+// textEdited carries an edit made in the editor over to the text and records it so it can be undone.
+// A folded region the edit reaches into is unfolded, see handling.AdjustFolds.
+func (ui *UI) textEdited(content string) {
+	if ui.loading {
+		// A newly opened file, restoreFolds folds it.
+		ui.loading = false
+		ui.document, ui.shown = content, content
+		ui.folded, ui.foldRows = nil, nil
+		ui.history.Clear()
+		return
+	}
+	c, ok := handling.DiffText(ui.shown, content)
+	if !ok {
+		return
+	}
+	c = alignChange(c, ui.shown, content, ui.cursorOffset())
+	start, end := c.Offset, c.Offset+len([]rune(c.Removed))
+	if start == 0 && content == c.Inserted {
+		// All of it was replaced, what's folded too.
+		end = len([]rune(ui.document))
+	} else {
+		start, end = ui.documentOffset(start), ui.documentOffset(end)
+	}
+	c = handling.TextChange{Offset: start, Removed: string([]rune(ui.document)[start:end]), Inserted: c.Inserted}
+	ui.shown = content
+	ui.history.Add(c, ui.typed)
+	ui.changeDocument(c)
+}
+
+// alignChange moves a change that inserts or deletes text found repeated next to it, such as a line break
+// between empty lines, to end at the cursor, which is where the editor made it.
+func alignChange(c handling.TextChange, before, after string, cursor int) handling.TextChange {
+	if c.Removed != "" && c.Inserted != "" || c.End() == cursor {
+		return c
+	}
+	old, updated := []rune(before), []rune(after)
+	if inserted := len([]rune(c.Inserted)); inserted > 0 {
+		offset := cursor - inserted
+		if offset >= 0 && cursor <= len(updated) && string(updated[:offset])+string(updated[cursor:]) == before {
+			return handling.TextChange{Offset: offset, Inserted: string(updated[offset:cursor])}
+		}
+		return c
+	}
+	removed := len([]rune(c.Removed))
+	if cursor+removed <= len(old) && string(old[:cursor])+string(old[cursor+removed:]) == after {
+		return handling.TextChange{Offset: cursor, Removed: string(old[cursor : cursor+removed])}
+	}
+	return c
+}
+
+// changeDocument makes a change to the text and moves the folded regions along with it.
+// When that unfolds any the editor shows what they hid, with the cursor after the change.
+func (ui *UI) changeDocument(c handling.TextChange) {
+	if len(ui.folded) == 0 {
+		ui.document = c.Apply(ui.document)
+		return
+	}
+	ui.folded = handling.AdjustFolds(ui.folded, ui.document, c)
+	ui.document = c.Apply(ui.document)
+	shown, rows := handling.FoldText(ui.document, ui.folded)
+	if shown != ui.shown {
+		ui.present(c.End())
+		return
+	}
+	ui.foldRows = rows
+	ui.foldsChanged()
+}
+
+// present shows the text in the editor with the folded regions left out and puts the cursor at a position of the text.
+func (ui *UI) present(cursor int) {
+	shown, rows := handling.FoldText(ui.document, ui.folded)
+	ui.foldRows = nil
+	if len(ui.folded) > 0 {
+		ui.foldRows = rows
+	}
+	ui.shown = shown
+	if shown != ui.Editor.Text {
+		ui.presenting = true
+		ui.Editor.SetText(shown)
+		ui.presenting = false
+	}
+	offset := ui.editorOffset(cursor)
+	ui.selectText(offset, offset)
+	ui.foldsChanged()
+	ui.cursorsTextChanged()
+	ui.checkSpelling()
+	ui.bracketLayer.Refresh()
+}
+
+// cursorDocumentOffset returns the position of the cursor in the text.
+func (ui *UI) cursorDocumentOffset() int {
+	return ui.documentOffset(ui.cursorOffset())
+}
+
+// setFolded changes the folded regions and shows the result. The cursor stays on the same text,
+// or goes to the end of the first line of the region it's folded in.
+func (ui *UI) setFolded(folded []handling.FoldRange) {
+	cursor := ui.cursorDocumentOffset()
+	ui.endSnippet()
+	ui.completion.hide()
+	ui.folded = folded
+	ui.present(cursor)
+}
+
+// isFolded reports whether a line of the editor is the first line of a folded region.
+func (ui *UI) isFolded(row int) bool {
+	if len(ui.folded) == 0 || row < 0 || row >= len(ui.foldRows) {
+		return false
+	}
+	start := ui.foldRows[row]
+	return slices.ContainsFunc(ui.folded, func(f handling.FoldRange) bool { return f.Start == start })
+}
+
+// foldRegions folds regions of the text, given by its lines. Regions inside others can be folded too,
+// unfolding the outer one shows them folded.
+func (ui *UI) foldRegions(ranges []handling.FoldRange) {
+	folded := slices.Clone(ui.folded)
+	for _, r := range ranges {
+		if !slices.ContainsFunc(folded, func(f handling.FoldRange) bool { return f.Start == r.Start }) {
+			folded = append(folded, r)
+		}
+	}
+	if len(folded) > len(ui.folded) {
+		ui.setFolded(folded)
+	}
+}
+
+// unfold shows the region folded under a line of the editor, reporting whether there was one.
+// Regions folded inside it stay folded.
+func (ui *UI) unfold(row int) bool {
+	if !ui.isFolded(row) {
+		return false
+	}
+	start := ui.foldRows[row]
+	ui.setFolded(slices.DeleteFunc(slices.Clone(ui.folded), func(f handling.FoldRange) bool { return f.Start == start }))
+	return true
+}
+
+// revealRow unfolds the regions hiding a line of the text and returns the line of the editor showing it.
+func (ui *UI) revealRow(row int) int {
+	if _, hidden := ui.displayRow(row); hidden {
+		ui.setFolded(slices.DeleteFunc(slices.Clone(ui.folded), func(f handling.FoldRange) bool {
+			return f.Start < row && row <= f.End
+		}))
+	}
+	display, _ := ui.displayRow(row)
+	return display
+}
+
+// unfoldForEdit unfolds the region folded under the cursor's line before Delete joins the next line to it,
+// or for Backspace at the start of a line, the one folded above it, so the lines it hides aren't taken along.
+func (ui *UI) unfoldForEdit(backspace bool) {
+	if len(ui.folded) == 0 || ui.Editor.SelectedText() != "" {
+		return
+	}
+	row, column := ui.Editor.CursorRow, ui.Editor.CursorColumn
+	if backspace {
+		if column == 0 {
+			ui.unfold(row - 1)
+		}
+	} else if column >= len([]rune(lineText(ui.Editor.Text, row))) {
+		ui.unfold(row)
+	}
+}
+
+// foldRanges returns the regions of the text that can be folded.
+func (ui *UI) foldRanges() []handling.FoldRange {
+	path, text := ui.currentLocation().Path, ui.document
+	if ui.foldRangesPath != path || ui.foldRangesText != text {
+		ui.foldRangeList = handling.FoldRanges(path, text)
+		ui.foldRangesPath, ui.foldRangesText = path, text
+	}
+	return ui.foldRangeList
+}
+
+// Fold the innermost region containing the cursor (Ctrl + Shift + [).
+func (ui *UI) foldAtCursor() {
+	row := ui.realRow(ui.Editor.CursorRow)
+	var inner *handling.FoldRange
+	for _, r := range slices.Clone(ui.foldRanges()) {
+		if r.Start <= row && row <= r.End && !slices.ContainsFunc(ui.folded, func(f handling.FoldRange) bool { return f.Start == r.Start }) {
+			inner = &r
+		}
+	}
+	if inner != nil {
+		ui.foldRegions([]handling.FoldRange{*inner})
+	}
+}
+
+// Unfold the region folded under the cursor's line (Ctrl + Shift + ]).
+func (ui *UI) unfoldAtCursor() {
+	ui.unfold(ui.Editor.CursorRow)
+}
+
+// toggleFold folds or unfolds the region starting on a line of the editor, from the gutter.
+func (ui *UI) toggleFold(row int) {
+	if ui.unfold(row) {
+		return
+	}
+	for _, r := range ui.foldRanges() {
+		if r.Start == ui.realRow(row) {
+			ui.foldRegions([]handling.FoldRange{r})
+			return
+		}
+	}
+}
+
+// Fold every region, those inside others too.
+func (ui *UI) foldAll() {
+	ui.foldRegions(ui.foldRanges())
+}
+
+// Unfold every region.
+func (ui *UI) unfoldAll() {
+	if len(ui.folded) > 0 {
+		ui.setFolded(nil)
+	}
+}
+
+// Fold the regions nested level deep and deeper, unfolding the rest, to see the outline of the file.
+func (ui *UI) foldLevel(level int) {
+	var folded []handling.FoldRange
+	for _, r := range ui.foldRanges() {
+		if r.Level >= level {
+			folded = append(folded, r)
+		}
+	}
+	ui.setFolded(folded)
+}
+
+// foldedRows returns the first lines of the folded regions in order.
+func (ui *UI) foldedRows() []int {
+	rows := make([]int, 0, len(ui.folded))
+	for _, f := range ui.folded {
+		rows = append(rows, f.Start)
+	}
+	sort.Ints(rows)
+	return rows
+}
+
+// foldsChanged remembers the folded regions of the current file and updates the gutter.
+func (ui *UI) foldsChanged() {
+	ui.Folds.Set(ui.currentLocation().Path, ui.foldedRows())
+	ui.Gutter.SetLines(strings.Count(ui.Editor.Text, "\n") + 1)
+	ui.Gutter.Refresh()
+	ui.foldLayer.Refresh()
+}
+
+// foldHeaders returns the first line of each folded region, trimmed, by its line.
+func (ui *UI) foldHeaders() map[int]string {
+	lines := strings.Split(ui.document, "\n")
+	headers := map[int]string{}
+	for _, f := range ui.folded {
+		if f.Start < len(lines) {
+			headers[f.Start] = strings.TrimSpace(lines[f.Start])
+		}
+	}
+	return headers
+}
+
+// refold folds again the regions that start on the same lines, with the same text, as headers,
+// for edits that unfold what they change.
+func (ui *UI) refold(headers map[int]string) {
+	lines := strings.Split(ui.document, "\n")
+	var ranges []handling.FoldRange
+	for _, r := range ui.foldRanges() {
+		if header, ok := headers[r.Start]; ok && strings.TrimSpace(lines[r.Start]) == header {
+			ranges = append(ranges, r)
+		}
+	}
+	ui.foldRegions(ranges)
+}
+
+// keepFolds runs edit, which may unfold the whole text, then folds again the regions that were folded before it
+// and still start on the same line, with the same text.
+func (ui *UI) keepFolds(edit func()) {
+	headers := ui.foldHeaders()
+	edit()
+	if len(headers) > 0 && len(ui.folded) == 0 {
+		ui.refold(headers)
+	}
+}
+
+// restoreFolds starts afresh with a newly opened file: its text as it was read, nothing to undo,
+// and the regions that were folded when it was last open folded again.
+func (ui *UI) restoreFolds() {
+	ui.loading = false
+	ui.document, ui.shown = ui.Editor.Text, ui.Editor.Text
+	ui.folded, ui.foldRows = nil, nil
+	ui.history.Clear()
+	saved := ui.Folds.Lines(ui.currentLocation().Path)
+	var folded []handling.FoldRange
+	for _, r := range ui.foldRanges() {
+		if slices.Contains(saved, r.Start) {
+			folded = append(folded, r)
+		}
+	}
+	if len(folded) > 0 {
+		ui.folded = folded
+		ui.present(ui.cursorOffset())
+	}
+}
+
+// foldLayer draws an ellipsis after the first line of each folded region, on top of the editor.
+type foldLayer struct {
+	widget.BaseWidget
+	ui *UI
+}
+
+// newFoldLayer creates the ellipses for ui's editor.
+func newFoldLayer(ui *UI) *foldLayer {
+	l := &foldLayer{ui: ui}
+	l.ExtendBaseWidget(l)
+	return l
+}
+
+func (l *foldLayer) CreateRenderer() fyne.WidgetRenderer {
+	return &foldRenderer{layer: l}
+}
+
+// foldRenderer draws the ellipses of the folded lines in view.
+type foldRenderer struct {
+	layer   *foldLayer
+	objects []fyne.CanvasObject
+}
+
+func (r *foldRenderer) MinSize() fyne.Size {
+	return fyne.Size{}
+}
+
+func (r *foldRenderer) Layout(fyne.Size) {
+	r.layoutEllipses()
+}
+
+func (r *foldRenderer) Refresh() {
+	r.layoutEllipses()
+	canvas.Refresh(r.layer)
+}
+
+func (r *foldRenderer) layoutEllipses() {
+	ui := r.layer.ui
+	r.objects = nil
+	if len(ui.folded) == 0 {
+		return
+	}
+	th := r.layer.Theme()
+	v := fyne.CurrentApp().Settings().ThemeVariant()
+	pad := th.Size(theme.SizeNameInnerPadding)
+	textSize := th.Size(theme.SizeNameText)
+	lineHeight := ui.lineHeight()
+	for row, line := range strings.Split(ui.Editor.Text, "\n") {
+		if !ui.isFolded(row) {
+			continue
+		}
+		ellipsis := canvas.NewText(" ⋯", th.Color(theme.ColorNamePrimary, v))
+		ellipsis.TextSize = textSize
+		ellipsis.TextStyle = ui.Editor.TextStyle
+		x := pad + fyne.MeasureText(line, textSize, ui.Editor.TextStyle).Width
+		ellipsis.Move(fyne.NewPos(x, pad+float32(row)*lineHeight))
+		r.objects = append(r.objects, ellipsis)
+	}
+}
+
+func (r *foldRenderer) Objects() []fyne.CanvasObject {
+	return r.objects
+}
+
+func (r *foldRenderer) Destroy() {}
@@ -0,0 +1,124 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	handling "github.com/Leda-Editor/Leda-Text-Editor/pkg/handling"
+)
+
+// format_on_save is the preference for formatting files when they're saved.
+const format_on_save = "editor_format_on_save"
+
+// formatters returns the formatter commands from config.json.
+func formatters() map[string][]string {
+	config, err := handling.LoadConfig("config.json")
+	if err != nil {
+		return nil
+	}
+	return config.Formatters
+}
+
+// formatPath returns the path whose extension picks the formatter, explaining why there isn't one if not.
+func (ui *UI) formatPath(title string) (string, bool) {
+	path := ui.currentLocation().Path
+	if path == "" {
+		dialog.ShowInformation(title, "Save the file so Leda knows how to format it.", ui.Window)
+		return "", false
+	}
+	if !handling.CanFormat(path, formatters()) {
+		dialog.ShowInformation(title, "There is no formatter for this kind of file, add one to config.json.", ui.Window)
+		return "", false
+	}
+	return path, true
+}
+
+// Format the whole file (Alt + Shift + F).
+func (ui *UI) formatDocument() {
+	path, ok := ui.formatPath("Format Document")
+	if !ok {
+		return
+	}
+	if err := ui.format(path); err != nil {
+		dialog.ShowError(err, ui.Window)
+	}
+}
+
+// format replaces the text with its formatted form, as one edit that can be undone.
+func (ui *UI) format(path string) error {
+	formatted, err := handling.Format(path, ui.text(), formatters())
+	if err != nil {
+		return fmt.Errorf("couldn't format %s: %w", path, err)
+	}
+	ui.replaceText(formatted)
+	return nil
+}
+
+// Format the selected text, keeping it selected.
+func (ui *UI) formatSelection() {
+	selection := ui.selectedText()
+	if selection == "" {
+		ui.formatDocument()
+		return
+	}
+	path, ok := ui.formatPath("Format Selection")
+	if !ok {
+		return
+	}
+	// Folded regions in the selection are formatted too, and come out unfolded.
+	formatted, err := handling.Format(path, selection, formatters())
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("couldn't format the selection: %w", err), ui.Window)
+		return
+	}
+	// Formatters end files with a newline, which the selection may not have.
+	if !strings.HasSuffix(selection, "\n") {
+		formatted = strings.TrimRight(formatted, "\n")
+	}
+	start, end := ui.selectionRange()
+	ui.replaceRange(start, end, formatted)
+	ui.selectText(start, start+len([]rune(formatted)))
+}
+
+// formatsOnSave reports whether saving the file at path formats it.
+func (ui *UI) formatsOnSave(path string) bool {
+	return ui.App.Preferences().Bool(format_on_save) && handling.CanFormat(path, formatters())
+}
+
+// formatBeforeSave formats the file being saved when format on save is on and there's a formatter for it.
+// A file that can't be formatted isn't saved, so mistakes aren't written over the last good version.
+func (ui *UI) formatBeforeSave(path string) error {
+	if !ui.formatsOnSave(path) {
+		return nil
+	}
+	err := ui.format(path)
+	if errors.Is(err, handling.ErrNoFormatter) {
+		return nil
+	}
+	return err
+}
+
+// checkBeforeAutoSave fails when format on save is on and the file can't be formatted, leaving the text alone,
+// so auto-save doesn't write what saving would refuse to.
+func (ui *UI) checkBeforeAutoSave(path string) error {
+	if !ui.formatsOnSave(path) {
+		return nil
+	}
+	_, err := handling.Format(path, ui.text(), formatters())
+	if errors.Is(err, handling.ErrNoFormatter) {
+		return nil
+	}
+	return err
+}
+
+// Turn formatting files when they're saved on or off.
+func (ui *UI) toggleFormatOnSave(item *fyne.MenuItem) {
+	prefs := ui.App.Preferences()
+	enabled := !prefs.Bool(format_on_save)
+	prefs.SetBool(format_on_save, enabled)
+	item.Checked = enabled
+	ui.mainMenu.Refresh()
+}
@@ -0,0 +1,125 @@
+package ui
+
+import (
+	"strings"
+
+	handling "github.com/Leda-Editor/Leda-Text-Editor/pkg/handling"
+)
+
+// updateIndentation picks up how the current file is indented, from config.json or the language's usual style.
+func (ui *UI) updateIndentation() {
+	var overrides map[string]handling.Indentation
+	if config, err := handling.LoadConfig("config.json"); err == nil {
+		overrides = config.Indentation
+	}
+	ui.indentation = handling.IndentationFor(ui.currentLocation().Path, overrides)
+}
+
+// cursorLine returns the cursor's line and the cursor's column within it.
+func (ui *UI) cursorLine() ([]rune, int) {
+	line := []rune(lineText(ui.Editor.Text, ui.Editor.CursorRow))
+	return line, min(ui.Editor.CursorColumn, len(line))
+}
+
+// cursorOffset returns the cursor's position in the text, in runes.
+func (ui *UI) cursorOffset() int {
+	return textOffset(ui.Editor.Text, ui.Editor.CursorRow, ui.Editor.CursorColumn)
+}
+
+// leadingSpace returns the whitespace a line starts with.
+func leadingSpace(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
+// newline starts a new line with the indentation of the current one, a level deeper after a line opening a block.
+// Between a pair of brackets the closing one moves to a line of its own below the cursor.
+func (ui *UI) newline() bool {
+	if ui.Editor.SelectedText() != "" {
+		return false
+	}
+	line, column := ui.cursorLine()
+	before := string(line[:column])
+	indent := leadingSpace(before)
+	text := "\n" + indent
+	closing := false
+	if handling.IndentsAfter(ui.currentLocation().Path, before) {
+		text += ui.indentation.Unit()
+		trimmed := []rune(strings.TrimRight(before, " \t"))
+		after := []rune(strings.TrimLeft(string(line[column:]), " \t"))
+		closing = len(after) > 0 && closingPairs[trimmed[len(trimmed)-1]] == after[0]
+	}
+	cursor := ui.cursorOffset()
+	if closing {
+		ui.replaceRange(cursor, cursor, text+"\n"+indent)
+		ui.selectText(cursor+len([]rune(text)), cursor+len([]rune(text)))
+	} else {
+		ui.replaceRange(cursor, cursor, text)
+	}
+	return true
+}
+
+// indentTab types spaces up to the next indentation level for files indented with spaces.
+func (ui *UI) indentTab() bool {
+	if ui.indentation.Tabs || ui.Editor.SelectedText() != "" {
+		return false
+	}
+	_, column := ui.cursorLine()
+	cursor := ui.cursorOffset()
+	ui.replaceRange(cursor, cursor, strings.Repeat(" ", ui.indentation.Width-column%ui.indentation.Width))
+	return true
+}
+
+// backspace deletes an empty pair of brackets or quotes together, and a level of indentation made of spaces at once.
+func (ui *UI) backspace() bool {
+	if ui.Editor.SelectedText() != "" {
+		return false
+	}
+	line, column := ui.cursorLine()
+	if column == 0 {
+		return false
+	}
+	cursor := ui.cursorOffset()
+	if ui.App.Preferences().BoolWithFallback(auto_close, true) && column < len(line) {
+		if close, ok := closingPairs[line[column-1]]; ok && line[column] == close {
+			ui.replaceRange(cursor-1, cursor+1, "")
+			return true
+		}
+	}
+	before := string(line[:column])
+	if ui.indentation.Tabs || strings.Trim(before, " ") != "" {
+		return false
+	}
+	count := (column-1)%ui.indentation.Width + 1
+	if count == 1 {
+		return false
+	}
+	ui.replaceRange(cursor-count, cursor, "")
+	return true
+}
+
+// dedentClosing lines a closing bracket typed at the start of a line up with the line holding its opening bracket.
+func (ui *UI) dedentClosing(r rune) bool {
+	if !handling.IsCloseBracket(r) || ui.Editor.SelectedText() != "" {
+		return false
+	}
+	line, column := ui.cursorLine()
+	before := string(line[:column])
+	if strings.TrimLeft(before, " \t") != "" {
+		return false
+	}
+	cursor := ui.cursorOffset()
+	text := []rune(ui.Editor.Text)
+	typed := append(append(append([]rune{}, text[:cursor]...), r), text[cursor:]...)
+	indent := ""
+	if open, ok := handling.MatchingBracket(typed, cursor); ok {
+		row, _ := rowColumnAt(ui.Editor.Text, open)
+		indent = leadingSpace(lineText(ui.Editor.Text, row))
+	} else {
+		indent = strings.TrimSuffix(before, ui.indentation.Unit())
+	}
+	if indent == before {
+		return false
+	}
+	ui.replaceRange(cursor-column, cursor, indent+string(r))
+	return true
+}
@@ -16,9 +16,13 @@ func (ui *UI) Layout() fyne.CanvasObject {
 		container.NewHBox(
 			ui.CharacterLabel,
 			widget.NewLabel(" | "),
+			ui.WordLabel,
+			widget.NewLabel(" | "),
 			ui.LineLabel,
 			widget.NewLabel(" | "),
 			ui.CurrentFileLabel,
+			ui.GoalProgress,
+			ui.MacroLabel,
 			layout.NewSpacer(),
 			ui.ZoomLabel,
 		),
@@ -48,11 +52,37 @@ func (ui *UI) Layout() fyne.CanvasObject {
 		sidebar.(*fyne.Container).Add(widget.NewButton("❌ Close", func() { ui.toggleSidebar() }))
 	}
 
-	var content fyne.CanvasObject
+	var editor fyne.CanvasObject = container.NewBorder(nil, nil, ui.Gutter, nil, ui.EditorScroll)
+	if ui.Outline.Visible {
+		outlineSplit := container.NewHSplit(
+			editor,
+			container.NewBorder(widget.NewLabel("📑 Outline"), nil, nil, nil, ui.Outline.Tree),
+		)
+		outlineSplit.SetOffset(0.75)
+		editor = outlineSplit
+	}
+
+	// The workspace tree, the bookmarks, the statistics and the search sidebar share the left pane.
+	var panels []fyne.CanvasObject
+	if ui.FileTree.Visible {
+		panels = append(panels, ui.FileTree.content())
+	}
+	if ui.BookmarkPanel.Visible {
+		panels = append(panels, ui.BookmarkPanel.content())
+	}
+	if ui.StatsPanel.Visible {
+		panels = append(panels, ui.StatsPanel.content())
+	}
 	if ui.SidebarVisible {
+		panels = append(panels, sidebar)
+	}
+	left := stackPanels(panels)
+
+	var content fyne.CanvasObject
+	if left != nil {
 		split := container.NewHSplit(
-			sidebar,
-			container.NewScroll(ui.Editor),
+			left,
+			editor,
 		)
 		split.SetOffset(0.2)
 
@@ -67,22 +97,49 @@ func (ui *UI) Layout() fyne.CanvasObject {
 	} else {
 		if ui.ShowMarkdown {
 			content = container.NewHSplit(
-				container.NewScroll(ui.Editor),
+				editor,
 				container.NewScroll(ui.Markdown),
 			)
 		} else {
-			content = container.NewScroll(ui.Editor)
+			content = editor
 		}
 	}
 
+	// The terminals and the task output share the bottom, hiding both gives the whole height to the editor.
+	var bottom fyne.CanvasObject
+	switch {
+	case ui.Terminals.Visible && ui.Tasks.Visible:
+		split := container.NewHSplit(ui.Terminals.content(), ui.Tasks.content())
+		split.SetOffset(0.5)
+		bottom = split
+	case ui.Terminals.Visible:
+		bottom = ui.Terminals.content()
+	case ui.Tasks.Visible:
+		bottom = ui.Tasks.content()
+	default:
+		return container.NewBorder(nil, statusBar, nil, nil, content)
+	}
 	mainSplit := container.NewVSplit(
-		content,     // top
-		ui.Terminal, // bottom
+		content, // top
+		bottom,  // bottom
 	)
 	mainSplit.SetOffset(0.8)
 	return container.NewBorder(nil, statusBar, nil, nil, mainSplit)
 }
 
+// stackPanels puts panels above each other with a draggable divider between each, nil if there are none.
+func stackPanels(panels []fyne.CanvasObject) fyne.CanvasObject {
+	switch len(panels) {
+	case 0:
+		return nil
+	case 1:
+		return panels[0]
+	}
+	split := container.NewVSplit(panels[0], stackPanels(panels[1:]))
+	split.SetOffset(1 / float64(len(panels)))
+	return split
+}
+
 func (ui *UI) UpdateLayout() {
 	ui.Window.SetContent(ui.Layout())
 	ui.Window.Content().Refresh()
@@ -0,0 +1,291 @@
+package ui
+
+import (
+	"slices"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	handling "github.com/Leda-Editor/Leda-Text-Editor/pkg/handling"
+)
+
+// Preferences for how Sort Lines orders lines.
+const (
+	sort_ignore_case = "sort_lines_ignore_case"
+	sort_numeric     = "sort_lines_numeric"
+	sort_unique      = "sort_lines_unique"
+)
+
+// lineSelection unfolds the selection and returns the editor's lines, with the first and last line the selection
+// covers or the cursor's line. A selection ending at the start of a line doesn't cover that line.
+func (ui *UI) lineSelection() (lines []string, first, last int) {
+	first, last = ui.unfoldSelection()
+	return strings.Split(ui.Editor.Text, "\n"), first, last
+}
+
+// unfoldSelection unfolds the regions folded on the lines the selection covers, or on the cursor's line,
+// keeping the selection on the same text. A folded line brings the whole region under it into the selection.
+// It returns the first and last line of the editor the selection then covers.
+func (ui *UI) unfoldSelection() (first, last int) {
+	text := ui.Editor.Text
+	c := ui.primaryCursor()
+	first, _ = rowColumnAt(text, c.Start())
+	last, column := rowColumnAt(text, c.End())
+	if last > first && column == 0 {
+		last--
+	}
+	folded := false
+	for row := first; row <= last; row++ {
+		folded = folded || ui.isFolded(row)
+	}
+	if !folded {
+		return first, last
+	}
+	// The lines of the text up to the next line shown, regions folded inside the folded ones are unfolded too.
+	start, end := ui.realRow(first), strings.Count(ui.document, "\n")
+	if last+1 < len(ui.foldRows) {
+		end = ui.foldRows[last+1] - 1
+	}
+	anchor, position := ui.documentOffset(c.Anchor), ui.documentOffset(c.Position)
+	ui.setFolded(slices.DeleteFunc(slices.Clone(ui.folded), func(f handling.FoldRange) bool {
+		return start <= f.Start && f.Start <= end
+	}))
+	ui.selectText(ui.editorOffset(anchor), ui.editorOffset(position))
+	first, _ = ui.displayRow(start)
+	last, _ = ui.displayRow(end)
+	return first, last
+}
+
+// documentLines returns the lines a command works on when it works on the whole text without a selection,
+// which unfolds the whole text. Commands using it are run by keepFolds to fold it again after.
+func (ui *UI) documentLines() (lines []string, first, last int) {
+	text := ui.Editor.Text
+	c := ui.primaryCursor()
+	start, _ := rowColumnAt(text, c.Start())
+	end, column := rowColumnAt(text, c.End())
+	if end > start && column == 0 {
+		end--
+	}
+	if start == end {
+		ui.unfoldAll()
+	}
+	lines, first, last = ui.lineSelection()
+	if first == last {
+		return lines, 0, len(lines) - 1
+	}
+	return lines, first, last
+}
+
+// replaceLines replaces lines from to to of the editor with lines as one edit that can be undone.
+// The selection, or the cursor, moves down by shift lines. When lines are changed in place
+// it stays on the same text within its lines, such as when they're indented.
+func (ui *UI) replaceLines(from, to int, lines []string, shift int) {
+	text := ui.Editor.Text
+	old := strings.Split(text, "\n")
+	c := ui.primaryCursor()
+	anchorRow, anchorColumn := rowColumnAt(text, c.Anchor)
+	row, column := rowColumnAt(text, c.Position)
+
+	if replacement := strings.Join(lines, "\n"); replacement != strings.Join(old[from:to+1], "\n") {
+		start := textOffset(text, from, 0)
+		end := textOffset(text, to, len([]rune(old[to])))
+		ui.replaceRange(start, end, replacement)
+	}
+
+	inPlace := shift == 0 && len(lines) == to-from+1
+	position := func(row, column int) int {
+		if inPlace && row >= from && row <= to {
+			// Only changes before the column move it, such as indentation.
+			before, after := []rune(old[row]), []rune(lines[row-from])
+			same := 0
+			for same < min(len(before), len(after)) && before[same] == after[same] {
+				same++
+			}
+			if column > same {
+				column = max(column+len(after)-len(before), same)
+			}
+		}
+		return textOffset(ui.Editor.Text, row+shift, column)
+	}
+	ui.selectText(position(anchorRow, anchorColumn), position(row, column))
+}
+
+// Duplicate the selected lines below them (Alt + Shift + Down).
+func (ui *UI) duplicateLines() {
+	lines, first, last := ui.lineSelection()
+	selected := lines[first : last+1]
+	ui.replaceLines(first, last, append(append([]string{}, selected...), selected...), len(selected))
+}
+
+// Delete the selected lines (Ctrl + Shift + K).
+func (ui *UI) deleteLines() {
+	lines, first, last := ui.lineSelection()
+	text := ui.Editor.Text
+	column := ui.Editor.CursorColumn
+	switch {
+	case last < len(lines)-1:
+		ui.replaceRange(textOffset(text, first, 0), textOffset(text, last+1, 0), "")
+	case first > 0:
+		ui.replaceRange(textOffset(text, first-1, len([]rune(lines[first-1]))), len([]rune(text)), "")
+		first--
+	default:
+		ui.replaceRange(0, len([]rune(text)), "")
+	}
+	ui.placeCursor(first, column)
+}
+
+// Move the selected lines up past the line above them (Alt + Up).
+func (ui *UI) moveLinesUp() {
+	lines, first, last := ui.lineSelection()
+	if first == 0 {
+		return
+	}
+	moved := append(append([]string{}, lines[first:last+1]...), lines[first-1])
+	ui.replaceLines(first-1, last, moved, -1)
+}
+
+// Move the selected lines down past the line below them (Alt + Down).
+func (ui *UI) moveLinesDown() {
+	lines, first, last := ui.lineSelection()
+	if last == len(lines)-1 {
+		return
+	}
+	moved := append([]string{lines[last+1]}, lines[first:last+1]...)
+	ui.replaceLines(first, last+1, moved, 1)
+}
+
+// Join the selected lines, or the cursor's line and the next, into one (Ctrl + Shift + J).
+func (ui *UI) joinLines() {
+	lines, first, last := ui.lineSelection()
+	if first == last {
+		if last == len(lines)-1 {
+			return
+		}
+		last++
+	}
+	ui.replaceLines(first, last, []string{handling.JoinLines(lines[first : last+1])}, 0)
+	// The cursor goes where the first two lines were joined.
+	join := len([]rune(strings.TrimRight(lines[first], " \t")))
+	offset := textOffset(ui.Editor.Text, first, join)
+	ui.selectText(offset, offset)
+}
+
+// Sort the selected lines, or every line, with the options chosen in Edit > Lines > Sort Lines.
+func (ui *UI) sortLines() {
+	prefs := ui.App.Preferences()
+	options := handling.SortOptions{
+		IgnoreCase: prefs.Bool(sort_ignore_case),
+		Numeric:    prefs.Bool(sort_numeric),
+		Unique:     prefs.Bool(sort_unique),
+	}
+	ui.keepFolds(func() {
+		lines, first, last := ui.documentLines()
+		// The line break at the end of the text isn't a line to sort.
+		if last == len(lines)-1 && last > first && lines[last] == "" {
+			last--
+		}
+		ui.replaceLines(first, last, handling.SortLines(lines[first:last+1], options), 0)
+	})
+}
+
+// Turn a Sort Lines option on or off.
+func (ui *UI) toggleSortOption(item *fyne.MenuItem, key string) {
+	prefs := ui.App.Preferences()
+	enabled := !prefs.Bool(key)
+	prefs.SetBool(key, enabled)
+	item.Checked = enabled
+	ui.mainMenu.Refresh()
+}
+
+// Reverse the order of the selected lines, or every line.
+func (ui *UI) reverseLines() {
+	ui.keepFolds(func() {
+		lines, first, last := ui.documentLines()
+		if last == len(lines)-1 && last > first && lines[last] == "" {
+			last--
+		}
+		ui.replaceLines(first, last, handling.ReverseLines(lines[first:last+1]), 0)
+	})
+}
+
+// Remove the whitespace at the end of the selected lines, or every line.
+func (ui *UI) trimTrailingWhitespace() {
+	ui.keepFolds(func() {
+		lines, first, last := ui.documentLines()
+		ui.replaceLines(first, last, handling.TrimTrailingWhitespace(lines[first:last+1]), 0)
+	})
+}
+
+// Indent the selected lines a level (Ctrl + ]).
+func (ui *UI) indentLines() {
+	lines, first, last := ui.lineSelection()
+	ui.replaceLines(first, last, handling.IndentLines(lines[first:last+1], ui.indentation.Unit()), 0)
+}
+
+// Outdent the selected lines a level (Ctrl + [).
+func (ui *UI) outdentLines() {
+	lines, first, last := ui.lineSelection()
+	ui.replaceLines(first, last, handling.OutdentLines(lines[first:last+1], ui.indentation.Width), 0)
+}
+
+// indentSelection indents a selection over several lines with Tab, or outdents it with Shift + Tab,
+// reporting whether there was one.
+func (ui *UI) indentSelection() bool {
+	if !strings.Contains(ui.Editor.SelectedText(), "\n") {
+		return false
+	}
+	if shiftPressed() {
+		ui.outdentLines()
+	} else {
+		ui.indentLines()
+	}
+	return true
+}
+
+// Comment out the selected lines, or uncomment them if they all are (Ctrl + /).
+func (ui *UI) toggleLineComment() {
+	lines, first, last := ui.lineSelection()
+	syntax := handling.CommentSyntaxFor(ui.currentLocation().Path)
+	ui.replaceLines(first, last, handling.ToggleLineComment(lines[first:last+1], syntax), 0)
+}
+
+// Put the selection, or the cursor's line, in a block comment, or take it out of one (Alt + Shift + A).
+func (ui *UI) toggleBlockComment() {
+	lines, first, _ := ui.lineSelection()
+	start, end := ui.selectionRange()
+	if start == end {
+		line := lines[first]
+		start = textOffset(ui.Editor.Text, first, len([]rune(leadingSpace(line))))
+		end = textOffset(ui.Editor.Text, first, len([]rune(line)))
+		if start == end {
+			return
+		}
+	}
+	text := []rune(ui.Editor.Text)
+	toggled := handling.ToggleBlockComment(string(text[start:end]), handling.CommentSyntaxFor(ui.currentLocation().Path))
+	ui.replaceRange(start, end, toggled)
+	ui.selectText(start, start+len([]rune(toggled)))
+}
+
+// Convert the selection, or the word at the cursor, to another case.
+func (ui *UI) convertCase(c handling.Case) {
+	ui.unfoldSelection()
+	text := []rune(ui.Editor.Text)
+	start, end := ui.selectionRange()
+	if start == end {
+		for start > 0 && handling.IsWordRune(text[start-1]) {
+			start--
+		}
+		for end < len(text) && handling.IsWordRune(text[end]) {
+			end++
+		}
+		if start == end {
+			return
+		}
+	}
+	converted := handling.ConvertCase(string(text[start:end]), c)
+	if converted == string(text[start:end]) {
+		return
+	}
+	ui.replaceRange(start, end, converted)
+	ui.selectText(start, start+len([]rune(converted)))
+}
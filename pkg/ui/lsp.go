@@ -0,0 +1,406 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	handling "github.com/Leda-Editor/Leda-Text-Editor/pkg/handling"
+	"github.com/Leda-Editor/Leda-Text-Editor/pkg/lsp"
+)
+
+const (
+	// lspSyncDelay is how long typing pauses before the language server gets the new text.
+	lspSyncDelay = 300 * time.Millisecond
+	// lspTimeout is how long a request to a language server may take.
+	lspTimeout = 5 * time.Second
+)
+
+// startLSP creates the language server manager and the goroutine that keeps the servers in step with the editor.
+func (ui *UI) startLSP(config *handling.Config) {
+	ui.LSP = lsp.NewManager(handling.WorkspaceRoot, config.LanguageServers)
+	ui.LSP.OnDiagnostics = func(path string, diagnostics []lsp.Diagnostic) {
+		fyne.Do(func() { ui.setDiagnostics(path, diagnostics) })
+	}
+	ui.LSP.OnError = func(err error) {
+		fyne.LogError("Language server not started", err)
+	}
+	ui.Diagnostics = map[string][]lsp.Diagnostic{}
+
+	// Document changes are sent in order by one goroutine, starting a server can take a while.
+	ui.lspQueue = lsp.NewQueue()
+}
+
+// lspFileChanged closes the previous file with its server and opens the new one.
+func (ui *UI) lspFileChanged() {
+	previous := ui.lspPath
+	path, text := ui.currentLocation().Path, ui.text()
+	ui.lspPath = path
+	if ui.lspTimer != nil {
+		ui.lspTimer.Stop()
+	}
+	ui.lspQueue.Add(func() {
+		if previous != "" {
+			if client := ui.LSP.Running(previous); client != nil {
+				client.Close(previous)
+			}
+		}
+		if path != "" {
+			if client := ui.LSP.ClientFor(path); client != nil {
+				client.Open(path, ui.LSP.LanguageID(path), text)
+			}
+		}
+	})
+	ui.diagnosticLayer.Refresh()
+}
+
+// lspTextChanged sends the editor's text to the server once typing pauses.
+func (ui *UI) lspTextChanged() {
+	// The text of a newly opened file is sent by lspFileChanged.
+	if ui.lspPath == "" || ui.lspPath != ui.currentLocation().Path {
+		return
+	}
+	if ui.lspTimer != nil {
+		ui.lspTimer.Stop()
+	}
+	ui.lspTimer = time.AfterFunc(lspSyncDelay, func() {
+		fyne.Do(ui.lspSync)
+	})
+}
+
+// lspSync sends the editor's text to the server now.
+func (ui *UI) lspSync() {
+	path, text := ui.lspPath, ui.text()
+	if path == "" || path != ui.currentLocation().Path {
+		return
+	}
+	if ui.lspTimer != nil {
+		ui.lspTimer.Stop()
+	}
+	ui.lspQueue.Change(path, func() {
+		// A server that has exited is started again.
+		if client := ui.LSP.ClientFor(path); client != nil {
+			client.Change(path, ui.LSP.LanguageID(path), text)
+		}
+	})
+}
+
+// lspSaved tells the server the current file was saved.
+func (ui *UI) lspSaved() {
+	path, text := ui.lspPath, ui.text()
+	if path == "" {
+		return
+	}
+	ui.lspQueue.Add(func() {
+		if client := ui.LSP.Running(path); client != nil {
+			client.Save(path, text)
+		}
+	})
+}
+
+// saveFile saves the current file and tells its language server.
+func (ui *UI) saveFile() {
+	handling.SaveFile(ui.Window, ui.Editor)
+	ui.lspSaved()
+	path := ui.currentLocation().Path
+	if path == handling.MacrosPath() {
+		ui.bindMacros()
+	}
+	if path == handling.UserWordsPath() || path != "" && path == handling.ProjectWordsPath() {
+		ui.loadWordLists()
+	}
+}
+
+// setDiagnostics shows the diagnostics a server published for a file.
+func (ui *UI) setDiagnostics(path string, diagnostics []lsp.Diagnostic) {
+	if len(diagnostics) == 0 {
+		delete(ui.Diagnostics, path)
+	} else {
+		ui.Diagnostics[path] = diagnostics
+	}
+
+	text := ui.text()
+	if path != ui.currentLocation().Path {
+		data, _ := os.ReadFile(path)
+		text = string(data)
+	}
+	problems := make([]handling.Problem, len(diagnostics))
+	for i, d := range diagnostics {
+		row, column := lsp.ColumnFor(text, d.Range.Start)
+		message := d.Message
+		if d.Source != "" {
+			message = d.Source + ": " + message
+		}
+		problems[i] = handling.Problem{
+			Location: handling.Location{Path: path, Row: row, Column: column},
+			Message:  message,
+			Warning:  d.Severity > lsp.SeverityError,
+		}
+	}
+	ui.Tasks.setDiagnostics(path, problems)
+	ui.diagnosticLayer.Refresh()
+	ui.Gutter.Refresh()
+}
+
+// lspRequest runs a request against the current file's server in the background and passes its result to done on the UI goroutine.
+func lspRequest[T any](ui *UI, title string, request func(ctx context.Context, client *lsp.Client, path string, pos lsp.Position) (T, error), done func(T)) {
+	if handling.CurrentFile == nil {
+		dialog.ShowInformation(title, "Save the file to use its language server.", ui.Window)
+		return
+	}
+	path := handling.CurrentFile.Path()
+	client := ui.LSP.Running(path)
+	if client == nil {
+		if !ui.LSP.Handles(path) {
+			dialog.ShowInformation(title, "There is no language server for this kind of file.", ui.Window)
+		} else {
+			dialog.ShowInformation(title, "The language server for this file isn't running.", ui.Window)
+		}
+		return
+	}
+	// The server should see what's on screen before answering.
+	ui.lspSync()
+	pos := lsp.PositionFor(ui.text(), ui.currentLocation().Row, ui.Editor.CursorColumn)
+	synced := ui.lspQueue.Synced()
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), lspTimeout)
+		defer cancel()
+		// Wait for queued changes to reach the server.
+		var result T
+		var err error
+		select {
+		case <-synced:
+			result, err = request(ctx, client, path, pos)
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+		fyne.Do(func() {
+			if err != nil {
+				if errors.Is(err, context.DeadlineExceeded) {
+					err = errors.New("the language server took too long to answer")
+				}
+				dialog.ShowError(err, ui.Window)
+				return
+			}
+			done(result)
+		})
+	}()
+}
+
+// Show the documentation of the symbol under the cursor, and any problems on its line (Ctrl + K).
+func (ui *UI) showHover() {
+	var messages []string
+	location := ui.currentLocation()
+	for _, d := range ui.Diagnostics[location.Path] {
+		if d.Range.Start.Line <= location.Row && location.Row <= d.Range.End.Line {
+			messages = append(messages, "**"+severityName(d.Severity)+":** "+d.Message)
+		}
+	}
+	show := func(hover string) {
+		if hover != "" {
+			messages = append(messages, hover)
+		}
+		if len(messages) == 0 {
+			return
+		}
+		ui.showInfoPopup(strings.Join(messages, "\n\n---\n\n"))
+	}
+	if handling.CurrentFile == nil || ui.LSP.Running(handling.CurrentFile.Path()) == nil {
+		show("")
+		return
+	}
+	lspRequest(ui, "Hover", func(ctx context.Context, client *lsp.Client, path string, pos lsp.Position) (string, error) {
+		return client.Hover(ctx, path, pos)
+	}, show)
+}
+
+// severityName describes a diagnostic severity.
+func severityName(severity int) string {
+	switch severity {
+	case lsp.SeverityWarning:
+		return "Warning"
+	case lsp.SeverityInformation:
+		return "Info"
+	case lsp.SeverityHint:
+		return "Hint"
+	}
+	return "Error"
+}
+
+// showInfoPopup shows markdown below the cursor until the next click.
+func (ui *UI) showInfoPopup(markdown string) {
+	text := widget.NewRichTextFromMarkdown(markdown)
+	text.Wrapping = fyne.TextWrapWord
+	scroll := container.NewVScroll(text)
+	popup := widget.NewPopUp(scroll, ui.Window.Canvas())
+	width := min(ui.Window.Canvas().Size().Width*0.6, 600)
+	text.Resize(fyne.NewSize(width, 0))
+	popup.Resize(fyne.NewSize(width, min(text.MinSize().Height, 300)))
+	popup.ShowAtPosition(ui.cursorPosition())
+}
+
+// Go to where the symbol under the cursor is defined (F12).
+func (ui *UI) goToDefinition() {
+	lspRequest(ui, "Go to Definition", func(ctx context.Context, client *lsp.Client, path string, pos lsp.Position) ([]lsp.Location, error) {
+		return client.Definition(ctx, path, pos)
+	}, func(locations []lsp.Location) {
+		switch len(locations) {
+		case 0:
+			dialog.ShowInformation("Go to Definition", "No definition found.", ui.Window)
+		case 1:
+			ui.jumpTo(ui.lspLocation(locations[0]))
+		default:
+			ui.showLocations("Definitions", locations)
+		}
+	})
+}
+
+// List every use of the symbol under the cursor (Shift + F12).
+func (ui *UI) findReferences() {
+	lspRequest(ui, "Find References", func(ctx context.Context, client *lsp.Client, path string, pos lsp.Position) ([]lsp.Location, error) {
+		return client.References(ctx, path, pos)
+	}, func(locations []lsp.Location) {
+		if len(locations) == 0 {
+			dialog.ShowInformation("Find References", "No references found.", ui.Window)
+			return
+		}
+		ui.showLocations(fmt.Sprintf("References (%d)", len(locations)), locations)
+	})
+}
+
+// lspLocation converts a server location to an editor location.
+func (ui *UI) lspLocation(loc lsp.Location) handling.Location {
+	path := lsp.URIToPath(loc.URI)
+	text := ui.text()
+	if path != ui.currentLocation().Path {
+		data, _ := os.ReadFile(path)
+		text = string(data)
+	}
+	row, column := lsp.ColumnFor(text, loc.Range.Start)
+	return handling.Location{Path: path, Row: row, Column: column}
+}
+
+// showLocations lists locations with the text of their lines, picking one jumps to it.
+func (ui *UI) showLocations(title string, locations []lsp.Location) {
+	type entry struct {
+		loc     handling.Location
+		snippet string
+	}
+	entries := make([]entry, len(locations))
+	texts := map[string]string{ui.currentLocation().Path: ui.text()}
+	for i, l := range locations {
+		loc := ui.lspLocation(l)
+		text, ok := texts[loc.Path]
+		if !ok {
+			data, _ := os.ReadFile(loc.Path)
+			text = string(data)
+			texts[loc.Path] = text
+		}
+		entries[i] = entry{loc, strings.TrimSpace(lineText(text, loc.Row))}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].loc.Path != entries[j].loc.Path {
+			return entries[i].loc.Path < entries[j].loc.Path
+		}
+		return entries[i].loc.Row < entries[j].loc.Row
+	})
+
+	var d dialog.Dialog
+	list := widget.NewList(
+		func() int { return len(entries) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			e := entries[id]
+			o.(*widget.Label).SetText(fmt.Sprintf("%s:%d  %s", ui.relativePath(e.loc.Path), e.loc.Row+1, e.snippet))
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) {
+		d.Hide()
+		ui.jumpTo(entries[id].loc)
+	}
+	d = dialog.NewCustom(title, "Close", list, ui.Window)
+	size := ui.Window.Canvas().Size()
+	d.Resize(fyne.NewSize(size.Width*0.6, size.Height*0.6))
+	d.Show()
+}
+
+// relativePath shows a path relative to the workspace when it's inside it.
+func (ui *UI) relativePath(path string) string {
+	if handling.WorkspaceRoot != "" {
+		if rel, err := filepath.Rel(handling.WorkspaceRoot, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return rel
+		}
+	}
+	return path
+}
+
+// Rename the symbol under the cursor everywhere it's used (F2).
+func (ui *UI) renameSymbol() {
+	word := wordAt(lineText(ui.Editor.Text, ui.Editor.CursorRow), ui.Editor.CursorColumn)
+	ui.askName("Rename Symbol", "New name", word, "Rename", func(name string) {
+		lspRequest(ui, "Rename Symbol", func(ctx context.Context, client *lsp.Client, path string, pos lsp.Position) (*lsp.WorkspaceEdit, error) {
+			return client.Rename(ctx, path, pos, name)
+		}, func(edit *lsp.WorkspaceEdit) {
+			ui.applyEdits(edit.Edits())
+		})
+	})
+}
+
+// applyEdits changes the current file in the editor and any other files on disk.
+func (ui *UI) applyEdits(edits map[string][]lsp.TextEdit) {
+	current := ui.currentLocation()
+	for path, changes := range edits {
+		if path == current.Path {
+			// One edit that can be undone.
+			ui.replaceText(lsp.ApplyEdits(ui.text(), changes))
+			ui.moveCursor(current.Row, current.Column)
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			dialog.ShowError(err, ui.Window)
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err == nil {
+			err = os.WriteFile(path, []byte(lsp.ApplyEdits(string(data), changes)), info.Mode())
+		}
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("failed to change %s: %w", filepath.Base(path), err), ui.Window)
+		}
+	}
+}
+
+// Restart the language servers, for example after installing one.
+func (ui *UI) restartLanguageServers() {
+	ui.LSP.Shutdown()
+	ui.Diagnostics = map[string][]lsp.Diagnostic{}
+	ui.Tasks.clearDiagnostics()
+	ui.lspPath = ""
+	ui.lspFileChanged()
+}
+
+// wordAt returns the identifier the column is in or just after.
+func wordAt(line string, column int) string {
+	runes := []rune(line)
+	column = min(column, len(runes))
+	isWord := func(r rune) bool { return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) }
+	start, end := column, column
+	for start > 0 && isWord(runes[start-1]) {
+		start--
+	}
+	for end < len(runes) && isWord(runes[end]) {
+		end++
+	}
+	return string(runes[start:end])
+}
@@ -0,0 +1,349 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/widget"
+	handling "github.com/Leda-Editor/Leda-Text-Editor/pkg/handling"
+)
+
+const (
+	// maxMacroRuns limits how many times Play to End of File plays a macro.
+	maxMacroRuns = 10000
+	// maxMacroDepth limits how deeply macros can play each other through their keys.
+	maxMacroDepth = 8
+)
+
+// macroShift is set while a macro plays a key that was pressed with shift.
+var macroShift bool
+
+// recordStep adds a step to the macro being recorded, if one is. What a macro does as it plays isn't recorded again.
+func (ui *UI) recordStep(step handling.MacroStep) {
+	if ui.recording && ui.playingMacro == 0 {
+		ui.macroSteps = handling.AddMacroStep(ui.macroSteps, step)
+	}
+}
+
+// recordRune records typing r.
+func (ui *UI) recordRune(r rune) {
+	ui.recordStep(handling.MacroStep{Text: string(r)})
+}
+
+// recordKey records pressing a key.
+func (ui *UI) recordKey(key *fyne.KeyEvent) {
+	ui.recordStep(handling.MacroStep{Key: key.Name, Shift: shiftPressed()})
+}
+
+// recordShortcut records the clipboard and undo shortcuts and the keys of commands,
+// leaving out the ones that record and play macros.
+func (ui *UI) recordShortcut(shortcut fyne.Shortcut) {
+	switch s := shortcut.(type) {
+	case *desktop.CustomShortcut:
+		if s.Modifier == desktop.ControlModifier|desktop.ShiftModifier && (s.KeyName == fyne.KeyR || s.KeyName == fyne.KeyP) {
+			return
+		}
+		ui.recordStep(handling.MacroStep{Shortcut: handling.FormatKeyBinding(s.KeyName, s.Modifier)})
+	case *fyne.ShortcutCopy, *fyne.ShortcutCut, *fyne.ShortcutPaste, *fyne.ShortcutSelectAll, *fyne.ShortcutUndo, *fyne.ShortcutRedo:
+		ui.recordStep(handling.MacroStep{Shortcut: shortcut.ShortcutName()})
+	}
+}
+
+// Start recording a macro, or stop and keep it to play back (Ctrl + Shift + R).
+func (ui *UI) toggleMacroRecording() {
+	if ui.recording {
+		ui.stopMacroRecording()
+		return
+	}
+	ui.recording = true
+	ui.macroSteps = nil
+	ui.completion.hide()
+	ui.MacroLabel.Show()
+}
+
+// stopMacroRecording stops recording, keeping the macro if anything was done.
+func (ui *UI) stopMacroRecording() {
+	ui.recording = false
+	ui.MacroLabel.Hide()
+	if len(ui.macroSteps) > 0 {
+		ui.lastMacro = ui.macroSteps
+	}
+}
+
+// recordedMacro returns the last recorded macro, stopping a recording first.
+// Without one it explains how to record one.
+func (ui *UI) recordedMacro(title string) []handling.MacroStep {
+	if ui.recording {
+		ui.stopMacroRecording()
+	}
+	if len(ui.lastMacro) == 0 {
+		dialog.ShowInformation(title, "Record a macro first with Edit > Macros > Start/Stop Recording (Ctrl + Shift + R).", ui.Window)
+	}
+	return ui.lastMacro
+}
+
+// macroShortcut returns the shortcut a step recorded by its name.
+func (ui *UI) macroShortcut(name string) fyne.Shortcut {
+	clipboard := ui.App.Clipboard()
+	switch name {
+	case "Copy":
+		return &fyne.ShortcutCopy{Clipboard: clipboard}
+	case "Cut":
+		return &fyne.ShortcutCut{Clipboard: clipboard}
+	case "Paste":
+		return &fyne.ShortcutPaste{Clipboard: clipboard}
+	case "SelectAll":
+		return &fyne.ShortcutSelectAll{}
+	case "Undo":
+		return &fyne.ShortcutUndo{}
+	case "Redo":
+		return &fyne.ShortcutRedo{}
+	}
+	key, modifier, err := handling.ParseKeyBinding(name)
+	if err != nil {
+		fyne.LogError("Failed to play a macro's shortcut", err)
+		return nil
+	}
+	return &desktop.CustomShortcut{KeyName: key, Modifier: modifier}
+}
+
+// playStep does what a step of a macro recorded, as if it was typed in the editor.
+func (ui *UI) playStep(step handling.MacroStep) {
+	e := ui.code
+	switch {
+	case step.Text != "":
+		for _, r := range step.Text {
+			e.TypedRune(r)
+		}
+	case step.Key != "":
+		shift := &fyne.KeyEvent{Name: desktop.KeyShiftLeft}
+		if step.Shift {
+			macroShift = true
+			e.KeyDown(shift)
+		}
+		e.TypedKey(&fyne.KeyEvent{Name: step.Key})
+		if step.Shift {
+			e.KeyUp(shift)
+			macroShift = false
+		}
+	case step.Shortcut != "":
+		if shortcut := ui.macroShortcut(step.Shortcut); shortcut != nil {
+			e.TypedShortcut(shortcut)
+		}
+	}
+}
+
+// playSteps plays a macro once.
+func (ui *UI) playSteps(steps []handling.MacroStep) {
+	if ui.playingMacro >= maxMacroDepth {
+		return
+	}
+	ui.playingMacro++
+	defer func() { ui.playingMacro-- }()
+	ui.Window.Canvas().Focus(ui.code)
+	for _, step := range steps {
+		ui.playStep(step)
+	}
+}
+
+// Play the last recorded macro (Ctrl + Shift + P).
+func (ui *UI) playMacro() {
+	ui.playMacroTimes(1)
+}
+
+// playMacroTimes plays the last recorded macro a number of times.
+func (ui *UI) playMacroTimes(times int) {
+	steps := ui.recordedMacro("Play Macro")
+	for i := 0; i < times && len(steps) > 0; i++ {
+		ui.playSteps(steps)
+	}
+	ui.completion.hide()
+}
+
+// Ask how many times to play the last recorded macro and play it.
+func (ui *UI) askMacroTimes() {
+	if ui.recordedMacro("Play Macro") == nil {
+		return
+	}
+	ui.askName("Play Macro", "Times", "2", "Play", func(value string) {
+		times, err := strconv.Atoi(value)
+		if err != nil || times < 1 {
+			dialog.ShowError(fmt.Errorf("%q isn't a number of times", value), ui.Window)
+			return
+		}
+		ui.playMacroTimes(times)
+	})
+}
+
+// Play the last recorded macro again and again until the end of the file,
+// stopping early once playing it doesn't move the cursor on.
+func (ui *UI) playMacroToEnd() {
+	steps := ui.recordedMacro("Play Macro")
+	if len(steps) == 0 {
+		return
+	}
+	for i := 0; i < maxMacroRuns; i++ {
+		row, column := ui.Editor.CursorRow, ui.Editor.CursorColumn
+		ui.playSteps(steps)
+		moved := ui.Editor.CursorRow > row || ui.Editor.CursorRow == row && ui.Editor.CursorColumn > column
+		if !moved || ui.cursorOffset() >= len([]rune(ui.Editor.Text)) {
+			break
+		}
+	}
+	ui.completion.hide()
+}
+
+// Save the last recorded macro under a name, optionally with a key to play it.
+func (ui *UI) saveMacro() {
+	steps := ui.recordedMacro("Save Macro")
+	if len(steps) == 0 {
+		return
+	}
+	name := widget.NewEntry()
+	key := widget.NewEntry()
+	key.SetPlaceHolder("Ctrl+Alt+1 (optional)")
+	items := []*widget.FormItem{widget.NewFormItem("Name", name), widget.NewFormItem("Key", key)}
+	dialog.ShowForm("Save Macro", "Save", "Cancel", items, func(ok bool) {
+		title := strings.TrimSpace(name.Text)
+		if !ok || title == "" {
+			return
+		}
+		binding := strings.TrimSpace(key.Text)
+		if binding != "" {
+			keyName, modifier, err := handling.ParseKeyBinding(binding)
+			if err == nil {
+				err = ui.checkMacroKey(keyName, modifier)
+			}
+			if err != nil {
+				dialog.ShowError(err, ui.Window)
+				return
+			}
+			binding = handling.FormatKeyBinding(keyName, modifier)
+		}
+		if err := handling.SaveMacro(title, handling.Macro{Key: binding, Steps: steps}); err != nil {
+			dialog.ShowError(fmt.Errorf("failed to save the macro: %w", err), ui.Window)
+			return
+		}
+		ui.bindMacros()
+	}, ui.Window)
+	ui.Window.Canvas().Focus(name)
+}
+
+// standardShortcuts are the key bindings the window turns into the clipboard, undo and select all shortcuts.
+var standardShortcuts = []fyne.KeyName{fyne.KeyZ, fyne.KeyY, fyne.KeyV, fyne.KeyC, fyne.KeyInsert, fyne.KeyX, fyne.KeyA}
+
+// addShortcut adds one of the app's shortcuts to the window, keeping its key binding from macros.
+func (ui *UI) addShortcut(shortcut *desktop.CustomShortcut, handler func(fyne.Shortcut)) {
+	if ui.appShortcuts == nil {
+		ui.appShortcuts = map[string]bool{}
+	}
+	ui.appShortcuts[handling.FormatKeyBinding(shortcut.KeyName, shortcut.Modifier)] = true
+	ui.Window.Canvas().AddShortcut(shortcut, handler)
+}
+
+// checkMacroKey returns an error if a macro can't be given a key binding because the app uses it.
+func (ui *UI) checkMacroKey(key fyne.KeyName, modifier fyne.KeyModifier) error {
+	binding := handling.FormatKeyBinding(key, modifier)
+	if ui.appShortcuts[binding] || modifier == fyne.KeyModifierShortcutDefault && slices.Contains(standardShortcuts, key) {
+		return fmt.Errorf("%s is already used by Leda", binding)
+	}
+	return nil
+}
+
+// bindMacros lets the saved macros be played with the keys given to them, replacing the keys bound before.
+// Keys the app uses are left to it.
+func (ui *UI) bindMacros() {
+	for _, shortcut := range ui.macroShortcuts {
+		ui.Window.Canvas().RemoveShortcut(shortcut)
+	}
+	ui.macroShortcuts = nil
+	macros, err := handling.LoadMacros()
+	if err != nil {
+		fyne.LogError("Failed to read macros", err)
+		return
+	}
+	for name, macro := range macros {
+		if macro.Key == "" {
+			continue
+		}
+		key, modifier, err := handling.ParseKeyBinding(macro.Key)
+		if err == nil {
+			err = ui.checkMacroKey(key, modifier)
+		}
+		if err != nil {
+			fyne.LogError("Failed to bind macro "+name, err)
+			continue
+		}
+		shortcut := &desktop.CustomShortcut{KeyName: key, Modifier: modifier}
+		ui.Window.Canvas().AddShortcut(shortcut, func(fyne.Shortcut) {
+			ui.playSavedMacro(name)
+		})
+		ui.macroShortcuts = append(ui.macroShortcuts, shortcut)
+	}
+}
+
+// playSavedMacro plays a saved macro, read again so changes to the macros file are picked up.
+func (ui *UI) playSavedMacro(name string) {
+	macros, err := handling.LoadMacros()
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("failed to read the macros: %w", err), ui.Window)
+		return
+	}
+	if macro, ok := macros[name]; ok {
+		ui.playSteps(macro.Steps)
+		ui.completion.hide()
+	}
+}
+
+// ShowRunMacro lists the saved macros and plays the one picked.
+func ShowRunMacro(ui *UI) {
+	macros, err := handling.LoadMacros()
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("failed to read the macros: %w", err), ui.Window)
+		return
+	}
+	if len(macros) == 0 {
+		dialog.ShowInformation("Run Macro", "There are no saved macros yet. Record one and save it with Edit > Macros > Save Last Macro.", ui.Window)
+		return
+	}
+	names := make([]string, 0, len(macros))
+	for name := range macros {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var d dialog.Dialog
+	list := widget.NewList(
+		func() int { return len(names) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			o.(*widget.Label).SetText(fmt.Sprintf("%s    %s", names[id], macros[names[id]].Key))
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) {
+		d.Hide()
+		ui.playSteps(macros[names[id]].Steps)
+		ui.completion.hide()
+	}
+	d = dialog.NewCustom("Run Macro", "Cancel", list, ui.Window)
+	size := ui.Window.Canvas().Size()
+	d.Resize(fyne.NewSize(size.Width*0.5, size.Height*0.5))
+	d.Show()
+}
+
+// Open the macros file to rename, rebind or delete saved macros. Saving it binds their keys again.
+func (ui *UI) editMacros() {
+	path := handling.MacrosPath()
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		dialog.ShowInformation("Edit Macros", "There are no saved macros yet. Record one and save it with Edit > Macros > Save Last Macro.", ui.Window)
+		return
+	}
+	ui.jumpTo(handling.Location{Path: path})
+}
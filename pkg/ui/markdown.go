@@ -0,0 +1,37 @@
+package ui
+
+import (
+	"unicode/utf8"
+
+	"fyne.io/fyne/v2/widget"
+	handling "github.com/Leda-Editor/Leda-Text-Editor/pkg/handling"
+)
+
+// parseMarkdown builds the preview segments for the given source, with its images and clickable task checkboxes.
+func (ui *UI) parseMarkdown(input string) []widget.RichTextSegment {
+	r := &handling.MarkdownRenderer{
+		Image: ui.previewImage,
+		ToggleTask: func(offset int, checked bool) {
+			ui.toggleTask(input, offset, checked)
+		},
+	}
+	return r.Segments(input)
+}
+
+// toggleTask rewrites a `[ ]`/`[x]` marker in the text as one edit that can be undone, leaving the cursor
+// and the folded regions where they are, as long as the text hasn't changed since rendering.
+func (ui *UI) toggleTask(source string, offset int, checked bool) {
+	content := ui.text()
+	if content != source || offset < 0 || offset+3 > len(content) || content[offset] != '[' || content[offset+2] != ']' {
+		return
+	}
+	mark := " "
+	if checked {
+		mark = "x"
+	}
+	ui.editText(handling.TextChange{
+		Offset:   utf8.RuneCountInString(content[:offset+1]),
+		Removed:  content[offset+1 : offset+2],
+		Inserted: mark,
+	})
+}
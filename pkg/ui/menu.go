@@ -1,6 +1,8 @@
 package ui
 
 import (
+	"fmt"
+
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
@@ -9,10 +11,27 @@ import (
 
 // creates menu bar.
 func (ui *UI) CreateMenuBar() *fyne.Container {
+	exportItem := fyne.NewMenuItem("Export", nil)
+	exportItem.ChildMenu = fyne.NewMenu("",
+		fyne.NewMenuItem("HTML", func() { handling.ExportHTML(ui.Window, ui.Editor, ui.exportStyle(), false) }),
+		fyne.NewMenuItem("HTML with Table of Contents", func() { handling.ExportHTML(ui.Window, ui.Editor, ui.exportStyle(), true) }),
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("PDF (Plain Text)", func() { handling.ExportPDF(ui.Window, ui.Editor, false, false) }),
+		fyne.NewMenuItem("PDF (Plain Text with Line Numbers)", func() { handling.ExportPDF(ui.Window, ui.Editor, false, true) }),
+		fyne.NewMenuItem("PDF (Rendered Markdown)", func() { handling.ExportPDF(ui.Window, ui.Editor, true, false) }),
+	)
+
+	ui.recentItem = fyne.NewMenuItem("Open Recent", nil)
+	ui.recentItem.ChildMenu = ui.recentMenu()
+
 	fileMenu := fyne.NewMenu("File",
 		fyne.NewMenuItem("Open", func() { handling.OpenFile(ui.Window, ui.Editor) }),
-		fyne.NewMenuItem("Save", func() { handling.SaveFile(ui.Window, ui.Editor) }),
+		fyne.NewMenuItem("Open Folder", func() { handling.OpenFolder(ui.Window) }),
+		ui.recentItem,
+		fyne.NewMenuItem("Go to File…", func() { ShowQuickOpen(ui) }),
+		fyne.NewMenuItem("Save", func() { ui.saveFile() }),
 		fyne.NewMenuItem("Save As", func() { handling.SaveFileAs(ui.Window, ui.Editor) }),
+		exportItem,
 		fyne.NewMenuItem("Exit", func() { handling.ClearEditor(ui.Editor) }),
 		fyne.NewMenuItemSeparator(),
 		fyne.NewMenuItem("Auto-save", func() { handling.ToggleAutoSave(ui.Window, ui.Editor) }),
@@ -22,21 +41,206 @@ func (ui *UI) CreateMenuBar() *fyne.Container {
 		}),
 	)
 
+	foldLevel := fyne.NewMenuItem("Fold Level", nil)
+	foldLevel.ChildMenu = fyne.NewMenu("")
+	for level := 1; level <= 5; level++ {
+		foldLevel.ChildMenu.Items = append(foldLevel.ChildMenu.Items,
+			fyne.NewMenuItem(fmt.Sprintf("Level %d", level), func() { ui.foldLevel(level) }))
+	}
+
 	viewMenu := fyne.NewMenu("View",
 		fyne.NewMenuItem("Zoom Out", func() { ui.ZoomOut() }),
 		fyne.NewMenuItem("Zoom In", func() { ui.ZoomIn() }),
 		fyne.NewMenuItem("Reset Zoom", func() { ui.ResetZoom() }),
 		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Fold", func() { ui.foldAtCursor() }),
+		fyne.NewMenuItem("Unfold", func() { ui.unfoldAtCursor() }),
+		fyne.NewMenuItem("Fold All", func() { ui.foldAll() }),
+		fyne.NewMenuItem("Unfold All", func() { ui.unfoldAll() }),
+		foldLevel,
+		fyne.NewMenuItemSeparator(),
 		fyne.NewMenuItem("Show/Hide Markdown Preview", func() { ui.toggleMarkdownPreview() }),
+		fyne.NewMenuItem("Show/Hide Outline", func() { ui.toggleOutline() }),
+		fyne.NewMenuItem("Show/Hide Workspace Sidebar", func() { ui.toggleFileTree() }),
+		fyne.NewMenuItem("Show/Hide Bookmarks", func() { ui.toggleBookmarkPanel() }),
+		fyne.NewMenuItem("Show/Hide Statistics", func() { ui.toggleStatsPanel() }),
+		fyne.NewMenuItem("Set Word Goal…", func() { ui.setWordGoal() }),
+		fyne.NewMenuItem("Remove Word Goal", func() { ui.removeWordGoal() }),
 		fyne.NewMenuItem("Dark Mode On/Off", func() { ToggleDarkMode(ui.App, ui) }),
 		fyne.NewMenuItem("Set Custom Theme", func() {
 			OpenThemePickerModal(ui.App, ui.Window, ui)
 		}),
 	)
 
+	autoCompletion := fyne.NewMenuItem("Suggest Completions While Typing", nil)
+	autoCompletion.Checked = ui.App.Preferences().BoolWithFallback(auto_completion, true)
+	autoCompletion.Action = func() { ui.toggleAutoCompletion(autoCompletion) }
+
+	formatOnSave := fyne.NewMenuItem("Format on Save", nil)
+	formatOnSave.Checked = ui.App.Preferences().Bool(format_on_save)
+	formatOnSave.Action = func() { ui.toggleFormatOnSave(formatOnSave) }
+
+	autoClose := fyne.NewMenuItem("Auto-close Brackets and Quotes", nil)
+	autoClose.Checked = ui.App.Preferences().BoolWithFallback(auto_close, true)
+	autoClose.Action = func() { ui.toggleAutoClose(autoClose) }
+
+	clipboardPersist := fyne.NewMenuItem("Remember Clipboard History Between Sessions", nil)
+	clipboardPersist.Checked = ui.App.Preferences().Bool(clipboard_persist)
+	clipboardPersist.Action = func() { ui.toggleClipboardPersist(clipboardPersist) }
+
+	clipboardItem := fyne.NewMenuItem("Clipboard History", nil)
+	clipboardItem.ChildMenu = fyne.NewMenu("",
+		fyne.NewMenuItem("Paste from History…", func() { ShowClipboardHistory(ui) }),
+		fyne.NewMenuItem("Clear Unpinned Entries", func() { ui.clearClipboardHistory() }),
+		fyne.NewMenuItemSeparator(),
+		clipboardPersist,
+	)
+
+	spellCheck := fyne.NewMenuItem("Check Spelling", nil)
+	spellCheck.Checked = ui.App.Preferences().BoolWithFallback(spell_check, true)
+	spellCheck.Action = func() { ui.toggleSpellCheck(spellCheck) }
+
+	var languageItems []*fyne.MenuItem
+	for _, language := range handling.DictionaryLanguages(ui.dictionariesDir) {
+		item := fyne.NewMenuItem(language, func() { ui.chooseSpellLanguage(language, languageItems) })
+		item.Checked = language == ui.spellLanguage()
+		languageItems = append(languageItems, item)
+	}
+	if len(languageItems) == 0 {
+		languageItems = append(languageItems, fyne.NewMenuItem("No Dictionaries…", func() { ui.showDictionariesHelp() }))
+	}
+	languageItem := fyne.NewMenuItem("Dictionary", nil)
+	languageItem.ChildMenu = fyne.NewMenu("", languageItems...)
+
+	spellingItem := fyne.NewMenuItem("Spelling", nil)
+	spellingItem.ChildMenu = fyne.NewMenu("",
+		spellCheck,
+		languageItem,
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Edit User Word List", func() { ui.editWordList(handling.UserWordsPath()) }),
+		fyne.NewMenuItem("Edit Project Word List", func() { ui.editWordList(handling.ProjectWordsPath()) }),
+	)
+
+	sortOption := func(label, key string) *fyne.MenuItem {
+		item := fyne.NewMenuItem(label, nil)
+		item.Checked = ui.App.Preferences().Bool(key)
+		item.Action = func() { ui.toggleSortOption(item, key) }
+		return item
+	}
+	sortItem := fyne.NewMenuItem("Sort Lines", nil)
+	sortItem.ChildMenu = fyne.NewMenu("",
+		fyne.NewMenuItem("Sort", func() { ui.sortLines() }),
+		fyne.NewMenuItemSeparator(),
+		sortOption("Ignore Case", sort_ignore_case),
+		sortOption("Numeric", sort_numeric),
+		sortOption("Remove Duplicates", sort_unique),
+	)
+
+	linesItem := fyne.NewMenuItem("Lines", nil)
+	linesItem.ChildMenu = fyne.NewMenu("",
+		fyne.NewMenuItem("Duplicate Line", func() { ui.duplicateLines() }),
+		fyne.NewMenuItem("Delete Line", func() { ui.deleteLines() }),
+		fyne.NewMenuItem("Move Line Up", func() { ui.moveLinesUp() }),
+		fyne.NewMenuItem("Move Line Down", func() { ui.moveLinesDown() }),
+		fyne.NewMenuItem("Join Lines", func() { ui.joinLines() }),
+		fyne.NewMenuItemSeparator(),
+		sortItem,
+		fyne.NewMenuItem("Reverse Lines", func() { ui.reverseLines() }),
+		fyne.NewMenuItem("Trim Trailing Whitespace", func() { ui.trimTrailingWhitespace() }),
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Indent", func() { ui.indentLines() }),
+		fyne.NewMenuItem("Outdent", func() { ui.outdentLines() }),
+	)
+
+	caseItem := fyne.NewMenuItem("Convert Case", nil)
+	caseItem.ChildMenu = fyne.NewMenu("",
+		fyne.NewMenuItem("UPPER CASE", func() { ui.convertCase(handling.UpperCase) }),
+		fyne.NewMenuItem("lower case", func() { ui.convertCase(handling.LowerCase) }),
+		fyne.NewMenuItem("Title Case", func() { ui.convertCase(handling.TitleCase) }),
+		fyne.NewMenuItem("snake_case", func() { ui.convertCase(handling.SnakeCase) }),
+		fyne.NewMenuItem("camelCase", func() { ui.convertCase(handling.CamelCase) }),
+	)
+
+	macrosItem := fyne.NewMenuItem("Macros", nil)
+	macrosItem.ChildMenu = fyne.NewMenu("",
+		fyne.NewMenuItem("Start/Stop Recording", func() { ui.toggleMacroRecording() }),
+		fyne.NewMenuItem("Play Last Macro", func() { ui.playMacro() }),
+		fyne.NewMenuItem("Play Last Macro N Times…", func() { ui.askMacroTimes() }),
+		fyne.NewMenuItem("Play Last Macro to End of File", func() { ui.playMacroToEnd() }),
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Save Last Macro…", func() { ui.saveMacro() }),
+		fyne.NewMenuItem("Run Saved Macro…", func() { ShowRunMacro(ui) }),
+		fyne.NewMenuItem("Edit Saved Macros", func() { ui.editMacros() }),
+	)
+
 	editMenu := fyne.NewMenu("Edit",
 		fyne.NewMenuItem("Search", func() { ShowSearchUI(false, ui) }),
 		fyne.NewMenuItem("Search & Replace", func() { ShowSearchUI(true, ui) }),
+		fyne.NewMenuItemSeparator(),
+		clipboardItem,
+		fyne.NewMenuItemSeparator(),
+		linesItem,
+		fyne.NewMenuItem("Toggle Line Comment", func() { ui.toggleLineComment() }),
+		fyne.NewMenuItem("Toggle Block Comment", func() { ui.toggleBlockComment() }),
+		caseItem,
+		spellingItem,
+		macrosItem,
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Format Document", func() { ui.formatDocument() }),
+		fyne.NewMenuItem("Format Selection", func() { ui.formatSelection() }),
+		formatOnSave,
+		autoClose,
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Add Next Occurrence", func() { ui.addNextOccurrence() }),
+		fyne.NewMenuItem("Remove Extra Cursors", func() { ui.clearCursors() }),
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Trigger Completion", func() { ui.showCompletion() }),
+		autoCompletion,
+		fyne.NewMenuItem("Insert Snippet…", func() { ShowInsertSnippet(ui) }),
+		fyne.NewMenuItem("Configure Snippets", func() { ui.configureSnippets() }),
+		fyne.NewMenuItem("Show Hover", func() { ui.showHover() }),
+		fyne.NewMenuItem("Rename Symbol…", func() { ui.renameSymbol() }),
+		fyne.NewMenuItem("Restart Language Servers", func() { ui.restartLanguageServers() }),
+	)
+
+	goMenu := fyne.NewMenu("Go",
+		fyne.NewMenuItem("Go to File…", func() { ShowQuickOpen(ui) }),
+		fyne.NewMenuItem("Go to Line…", func() { ShowGoToLine(ui) }),
+		fyne.NewMenuItem("Go to Symbol…", func() { ShowGoToSymbol(ui) }),
+		fyne.NewMenuItem("Go to Definition", func() { ui.goToDefinition() }),
+		fyne.NewMenuItem("Find References", func() { ui.findReferences() }),
+		fyne.NewMenuItem("Go to Matching Bracket", func() { ui.goToMatchingBracket() }),
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Back", func() { ui.navigateBack() }),
+		fyne.NewMenuItem("Forward", func() { ui.navigateForward() }),
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Toggle Bookmark", func() { ui.toggleBookmark(ui.currentLocation().Row) }),
+		fyne.NewMenuItem("Next Bookmark", func() { ui.nextBookmark() }),
+		fyne.NewMenuItem("Previous Bookmark", func() { ui.previousBookmark() }),
+		fyne.NewMenuItem("Clear Bookmarks in File", func() { ui.clearBookmarks() }),
+	)
+
+	bracketedPaste := fyne.NewMenuItem("Bracketed Paste for Multi-line Input", nil)
+	bracketedPaste.Checked = ui.App.Preferences().BoolWithFallback(bracketed_paste, true)
+	bracketedPaste.Action = func() { ui.toggleBracketedPaste(bracketedPaste) }
+
+	terminalMenu := fyne.NewMenu("Terminal",
+		fyne.NewMenuItem("New Terminal", func() { ui.newTerminal() }),
+		fyne.NewMenuItem("Rename Terminal…", func() { ui.renameTerminal() }),
+		fyne.NewMenuItem("Kill Terminal", func() { ui.killTerminal() }),
+		fyne.NewMenuItem("Close Terminal", func() { ui.closeTerminal() }),
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Run Selection in Terminal", func() { ui.runSelection() }),
+		fyne.NewMenuItem("Run File", func() { ui.runFile() }),
+		bracketedPaste,
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Run Task…", func() { ShowRunTask(ui) }),
+		fyne.NewMenuItem("Rerun Last Task", func() { ui.rerunTask() }),
+		fyne.NewMenuItem("Stop Task", func() { ui.stopTask() }),
+		fyne.NewMenuItem("Configure Tasks", func() { ui.configureTasks() }),
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Show/Hide Terminal Panel", func() { ui.toggleTerminalPanel() }),
+		fyne.NewMenuItem("Show/Hide Task Output", func() { ui.toggleTaskPanel() }),
 	)
 
 	helpMenu := fyne.NewMenu("Help",
@@ -45,8 +249,8 @@ func (ui *UI) CreateMenuBar() *fyne.Container {
 		}),
 	)
 
-	mainMenu := fyne.NewMainMenu(fileMenu, viewMenu, editMenu, helpMenu)
-	ui.Window.SetMainMenu(mainMenu)
+	ui.mainMenu = fyne.NewMainMenu(fileMenu, viewMenu, editMenu, goMenu, terminalMenu, helpMenu)
+	ui.Window.SetMainMenu(ui.mainMenu)
 
 	return container.NewVBox()
 }
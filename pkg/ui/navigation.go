@@ -0,0 +1,264 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+	handling "github.com/Leda-Editor/Leda-Text-Editor/pkg/handling"
+)
+
+// currentLocation returns the file and cursor position being edited.
+func (ui *UI) currentLocation() handling.Location {
+	path := ""
+	if handling.CurrentFile != nil {
+		path = handling.CurrentFile.Path()
+	}
+	return handling.Location{Path: path, Row: ui.realRow(ui.Editor.CursorRow), Column: ui.Editor.CursorColumn}
+}
+
+// jumpTo moves to a location, opening its file if needed, and remembers where the cursor was for Alt+Left.
+func (ui *UI) jumpTo(loc handling.Location) {
+	from := ui.currentLocation()
+	ui.showLocation(loc, func(shown bool) {
+		// Moving within the same line isn't worth going back to.
+		if shown && (from.Path != loc.Path || from.Row != loc.Row) {
+			ui.History.Push(from)
+		}
+	})
+}
+
+// jumpToLine moves within the current file.
+func (ui *UI) jumpToLine(row, col int) {
+	ui.jumpTo(handling.Location{Path: ui.currentLocation().Path, Row: row, Column: col})
+}
+
+// showLocation opens the location's file if it isn't the current one and moves the cursor there,
+// then tells done whether it did.
+func (ui *UI) showLocation(loc handling.Location, done func(shown bool)) {
+	if loc.Path == ui.currentLocation().Path {
+		ui.moveCursor(loc.Row, loc.Column)
+		done(true)
+		return
+	}
+	if loc.Path == "" {
+		done(false)
+		return
+	}
+	ui.openPath(loc.Path, func(opened bool) {
+		if opened {
+			ui.moveCursor(loc.Row, loc.Column)
+		}
+		done(opened)
+	})
+}
+
+// openPath opens a file in place of the current one and tells done whether it did.
+// If the current file has changes that aren't saved, the user is asked to save them or let them go first.
+func (ui *UI) openPath(path string, done func(opened bool)) {
+	open := func() {
+		handling.OpenPath(ui.Window, ui.Editor, path)
+		done(ui.currentLocation().Path == path)
+	}
+	if !ui.modified() {
+		open()
+		return
+	}
+
+	current := ui.currentLocation().Path
+	message := "Save the changes to " + filepath.Base(current) + " before opening " + filepath.Base(path) + "?"
+	if current == "" {
+		message = "The new file hasn't been saved. Discard it and open " + filepath.Base(path) + "?"
+	}
+	var d *dialog.CustomDialog
+	choose := func(action func()) func() {
+		return func() {
+			d.Hide()
+			action()
+		}
+	}
+	buttons := []fyne.CanvasObject{
+		widget.NewButton("Cancel", choose(func() { done(false) })),
+		widget.NewButton("Discard", choose(open)),
+	}
+	if current != "" {
+		save := widget.NewButton("Save", choose(func() {
+			ui.saveFile()
+			// Saving stops if the file can't be formatted or written, then it stays open.
+			if ui.modified() {
+				done(false)
+				return
+			}
+			open()
+		}))
+		save.Importance = widget.HighImportance
+		buttons = append(buttons, save)
+	}
+	d = dialog.NewCustomWithoutButtons("Unsaved Changes", widget.NewLabel(message), ui.Window)
+	d.SetButtons(buttons)
+	d.Show()
+}
+
+// modified reports whether the editor has changes that aren't in the current file, or any text for a new file.
+func (ui *UI) modified() bool {
+	path := ui.currentLocation().Path
+	if path == "" {
+		return ui.text() != ""
+	}
+	data, err := os.ReadFile(path)
+	return err != nil || string(data) != ui.text()
+}
+
+// canReturnTo reports whether a location from the history can still be shown.
+// An unsaved buffer is lost once another file has been opened.
+func (ui *UI) canReturnTo(loc handling.Location) bool {
+	if loc.Path == "" {
+		return ui.currentLocation().Path == ""
+	}
+	_, err := os.Stat(loc.Path)
+	return err == nil
+}
+
+// Go back to the previous cursor location (Alt + Left).
+func (ui *UI) navigateBack() {
+	if loc, ok := ui.History.Back(ui.currentLocation(), ui.canReturnTo); ok {
+		ui.showLocation(loc, func(shown bool) {
+			if !shown {
+				// Staying put, so the history goes back to how it was.
+				ui.History.Forward(loc, func(handling.Location) bool { return true })
+			}
+		})
+	}
+}
+
+// Go forward again after going back (Alt + Right).
+func (ui *UI) navigateForward() {
+	if loc, ok := ui.History.Forward(ui.currentLocation(), ui.canReturnTo); ok {
+		ui.showLocation(loc, func(shown bool) {
+			if !shown {
+				ui.History.Back(loc, func(handling.Location) bool { return true })
+			}
+		})
+	}
+}
+
+// ShowGoToLine asks for a line, optionally followed by a column, and moves the cursor there.
+func ShowGoToLine(ui *UI) {
+	lines := strings.Count(ui.text(), "\n") + 1
+	entry := widget.NewEntry()
+	entry.SetPlaceHolder("line[:column]")
+
+	item := widget.NewFormItem("Line", entry)
+	item.HintText = fmt.Sprintf("Current line %d of %d", ui.currentLocation().Row+1, lines)
+	form := dialog.NewForm("Go to Line", "Go", "Cancel", []*widget.FormItem{item}, func(ok bool) {
+		if !ok {
+			return
+		}
+		line, column, err := handling.ParseLineColumn(entry.Text)
+		if err != nil {
+			dialog.ShowError(err, ui.Window)
+			return
+		}
+		ui.jumpToLine(line-1, column-1)
+	}, ui.Window)
+	entry.OnSubmitted = func(string) { form.Submit() }
+	form.Show()
+	ui.Window.Canvas().Focus(entry)
+}
+
+// ShowGoToSymbol lists the outline of the current file for fuzzy searching.
+func ShowGoToSymbol(ui *UI) {
+	location := ui.currentLocation()
+	symbols := handling.FlattenOutline(handling.BuildOutline(location.Path, ui.text()))
+	if len(symbols) == 0 {
+		dialog.ShowInformation("Go to Symbol", "No headings or symbols found in this file.", ui.Window)
+		return
+	}
+
+	results := symbols
+	selected := 0
+
+	entry := newPickerEntry()
+	entry.SetPlaceHolder("Search headings and symbols")
+	list := widget.NewList(
+		func() int { return len(results) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			o.(*widget.Label).SetText(fmt.Sprintf("%s    :%d", outlineLabel(results[id]), results[id].Line+1))
+		},
+	)
+
+	update := func() {
+		query := strings.TrimSpace(entry.Text)
+		if query == "" {
+			results = symbols
+		} else {
+			type scored struct {
+				item  *handling.OutlineItem
+				score int
+			}
+			var matches []scored
+			for _, symbol := range symbols {
+				if score, ok := handling.FuzzyScore(query, symbol.Name); ok {
+					matches = append(matches, scored{symbol, score})
+				}
+			}
+			sort.SliceStable(matches, func(i, j int) bool {
+				return matches[i].score > matches[j].score
+			})
+			results = make([]*handling.OutlineItem, len(matches))
+			for i, m := range matches {
+				results[i] = m.item
+			}
+		}
+		selected = 0
+		list.Refresh()
+		if len(results) > 0 {
+			list.Select(0)
+		}
+	}
+
+	var popup *widget.PopUp
+	open := func() {
+		if selected < 0 || selected >= len(results) {
+			return
+		}
+		popup.Hide()
+		ui.jumpToLine(results[selected].Line, 0)
+	}
+
+	list.OnSelected = func(id widget.ListItemID) { selected = id }
+	entry.OnChanged = func(string) { update() }
+	entry.OnSubmitted = func(string) { open() }
+	entry.onUp = func() {
+		if selected > 0 {
+			list.Select(selected - 1)
+		}
+	}
+	entry.onDown = func() {
+		if selected < len(results)-1 {
+			list.Select(selected + 1)
+		}
+	}
+	entry.onEscape = func() { popup.Hide() }
+
+	content := container.NewBorder(
+		container.NewVBox(widget.NewLabel("🧭 Go to Symbol"), entry),
+		container.NewHBox(layout.NewSpacer(), widget.NewButton("Go", open), widget.NewButton("Close", func() { popup.Hide() })),
+		nil, nil,
+		list,
+	)
+	popup = widget.NewModalPopUp(content, ui.Window.Canvas())
+	size := ui.Window.Canvas().Size()
+	popup.Resize(fyne.NewSize(size.Width*0.5, size.Height*0.6))
+	popup.Show()
+	ui.Window.Canvas().Focus(entry)
+	list.Select(0)
+}
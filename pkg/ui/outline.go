@@ -0,0 +1,174 @@
+package ui
+
+import (
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+	handling "github.com/Leda-Editor/Leda-Text-Editor/pkg/handling"
+)
+
+// OutlinePanel lists the headings or symbols of the current document.
+type OutlinePanel struct {
+	// Tree shows the outline, node IDs are index paths such as "0/2".
+	Tree *widget.Tree
+	// Visible indicates whether the panel is shown next to the editor.
+	Visible bool
+
+	items    map[widget.TreeNodeID]*handling.OutlineItem
+	children map[widget.TreeNodeID][]widget.TreeNodeID
+	// order holds the node IDs in document order, used to find the section containing the cursor.
+	order []widget.TreeNodeID
+	// known remembers which branches have been seen so only new ones are opened automatically.
+	known map[widget.TreeNodeID]bool
+	// syncing is set while the selection follows the cursor, so it doesn't jump back.
+	syncing bool
+}
+
+// newOutlinePanel creates the outline tree for the given UI.
+func newOutlinePanel(ui *UI) *OutlinePanel {
+	p := &OutlinePanel{
+		items:    map[widget.TreeNodeID]*handling.OutlineItem{},
+		children: map[widget.TreeNodeID][]widget.TreeNodeID{},
+		known:    map[widget.TreeNodeID]bool{},
+	}
+
+	p.Tree = widget.NewTree(
+		func(id widget.TreeNodeID) []widget.TreeNodeID {
+			return p.children[id]
+		},
+		func(id widget.TreeNodeID) bool {
+			return len(p.children[id]) > 0
+		},
+		func(branch bool) fyne.CanvasObject {
+			return newOutlineRow()
+		},
+		func(id widget.TreeNodeID, branch bool, o fyne.CanvasObject) {
+			item, ok := p.items[id]
+			if !ok {
+				return
+			}
+			row := o.(*outlineRow)
+			row.SetText(outlineLabel(item))
+			row.onTapped = func() { ui.jumpToLine(item.Line, 0) }
+		},
+	)
+
+	// Rows handle their own taps, so the entry already highlighted for the cursor jumps too.
+	// Selecting with the keyboard goes through here.
+	p.Tree.OnSelected = func(id widget.TreeNodeID) {
+		if p.syncing {
+			return
+		}
+		if item, ok := p.items[id]; ok {
+			ui.jumpToLine(item.Line, 0)
+		}
+	}
+	return p
+}
+
+// outlineRow is an outline entry that jumps to its line whenever it's tapped,
+// the tree only reports taps that change the selection.
+type outlineRow struct {
+	widget.Label
+	onTapped func()
+}
+
+func newOutlineRow() *outlineRow {
+	r := &outlineRow{}
+	r.ExtendBaseWidget(r)
+	return r
+}
+
+// Tapped jumps to the entry's line.
+func (r *outlineRow) Tapped(*fyne.PointEvent) {
+	if r.onTapped != nil {
+		r.onTapped()
+	}
+}
+
+// outlineLabel prefixes code symbols with a marker for their kind.
+func outlineLabel(item *handling.OutlineItem) string {
+	switch item.Kind {
+	case "heading":
+		return item.Name
+	case "func", "method":
+		return "ƒ " + item.Name
+	default:
+		return "◆ " + item.Name
+	}
+}
+
+// UpdateOutline rebuilds the outline from the editor content.
+func (ui *UI) UpdateOutline(content string) {
+	p := ui.Outline
+	if p == nil || !p.Visible {
+		return
+	}
+
+	name := ""
+	if handling.CurrentFile != nil {
+		name = handling.CurrentFile.Name()
+	}
+
+	p.items = map[widget.TreeNodeID]*handling.OutlineItem{}
+	p.children = map[widget.TreeNodeID][]widget.TreeNodeID{}
+	p.order = nil
+
+	var add func(parent widget.TreeNodeID, items []*handling.OutlineItem)
+	add = func(parent widget.TreeNodeID, items []*handling.OutlineItem) {
+		for i, item := range items {
+			id := strconv.Itoa(i)
+			if parent != "" {
+				id = parent + "/" + id
+			}
+			p.items[id] = item
+			p.order = append(p.order, id)
+			p.children[parent] = append(p.children[parent], id)
+			add(id, item.Children)
+		}
+	}
+	add("", handling.BuildOutline(name, content))
+
+	p.Tree.Refresh()
+	for id := range p.children {
+		if id != "" && !p.known[id] {
+			p.known[id] = true
+			p.Tree.OpenBranch(id)
+		}
+	}
+	ui.highlightOutline()
+}
+
+// highlightOutline selects the outline entry whose section contains the cursor.
+func (ui *UI) highlightOutline() {
+	p := ui.Outline
+	if p == nil || !p.Visible {
+		return
+	}
+
+	current := ""
+	row := ui.currentLocation().Row
+	for _, id := range p.order {
+		if p.items[id].Line > row {
+			break
+		}
+		current = id
+	}
+
+	p.syncing = true
+	defer func() { p.syncing = false }()
+	if current == "" {
+		p.Tree.UnselectAll()
+		return
+	}
+	p.Tree.Select(current)
+	p.Tree.ScrollTo(current)
+}
+
+// Toggle visibility of the outline panel.
+func (ui *UI) toggleOutline() {
+	ui.Outline.Visible = !ui.Outline.Visible
+	ui.UpdateOutline(ui.text())
+	ui.UpdateLayout()
+}
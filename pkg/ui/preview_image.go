@@ -0,0 +1,116 @@
+package ui
+
+import (
+	"fmt"
+	"image"
+	"path/filepath"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	handling "github.com/Leda-Editor/Leda-Text-Editor/pkg/handling"
+	"github.com/yuin/goldmark/ast"
+)
+
+// previewImage resolves an image reference for the preview. Local files are resolved
+// against the directory of the current file, remote ones are left to fyne.
+func (ui *UI) previewImage(img *ast.Image) widget.RichTextSegment {
+	dest := string(img.Destination)
+
+	baseDir := ""
+	if handling.CurrentFile != nil {
+		baseDir = filepath.Dir(handling.CurrentFile.Path())
+	}
+	path, err := handling.ResolveImagePath(dest, baseDir)
+	if err != nil {
+		uri, err := storage.ParseURI(dest)
+		if err != nil {
+			uri = storage.NewFileURI(dest)
+		}
+		return &widget.ImageSegment{Source: uri, Title: string(img.Title), Alignment: fyne.TextAlignCenter}
+	}
+
+	decoded, err := handling.LoadImage(path)
+	if err != nil {
+		style := widget.RichTextStyleParagraph
+		style.ColorName = theme.ColorNameError
+		return &widget.TextSegment{Style: style, Text: fmt.Sprintf("⚠️ Image %s: %v", dest, err)}
+	}
+	return &imageSegment{Image: decoded, Title: string(img.Title), Pane: ui.Markdown}
+}
+
+// imageSegment shows a decoded local image scaled down to the width of the preview pane.
+type imageSegment struct {
+	Image image.Image
+	Title string
+	// Pane is the widget whose width limits the image size.
+	Pane fyne.CanvasObject
+}
+
+// Inline returns false as images are blocks.
+func (i *imageSegment) Inline() bool {
+	return false
+}
+
+// Textual returns the content of this segment rendered to plain text.
+func (i *imageSegment) Textual() string {
+	return "Image " + i.Title
+}
+
+// Visual returns a new image widget for this segment.
+func (i *imageSegment) Visual() fyne.CanvasObject {
+	img := canvas.NewImageFromImage(i.Image)
+	img.FillMode = canvas.ImageFillContain
+	bounds := i.Image.Bounds()
+	p := &previewImage{
+		image:   img,
+		natural: fyne.NewSize(float32(bounds.Dx()), float32(bounds.Dy())),
+		pane:    i.Pane,
+	}
+	p.ExtendBaseWidget(p)
+	return p
+}
+
+// Update applies a new image to an existing visual.
+func (i *imageSegment) Update(o fyne.CanvasObject) {
+	p := o.(*previewImage)
+	bounds := i.Image.Bounds()
+	p.image.Image = i.Image
+	p.natural = fyne.NewSize(float32(bounds.Dx()), float32(bounds.Dy()))
+	p.Refresh()
+}
+
+func (i *imageSegment) Select(_, _ fyne.Position) {}
+
+func (i *imageSegment) SelectedText() string {
+	return ""
+}
+
+func (i *imageSegment) Unselect() {}
+
+// previewImage is an image that keeps its aspect ratio and never grows wider than its pane.
+type previewImage struct {
+	widget.BaseWidget
+	image   *canvas.Image
+	natural fyne.Size
+	pane    fyne.CanvasObject
+}
+
+func (p *previewImage) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(p.image)
+}
+
+// MinSize returns the natural image size, scaled down to fit the pane.
+func (p *previewImage) MinSize() fyne.Size {
+	size := p.natural
+	if p.pane == nil {
+		return size
+	}
+	maxWidth := p.pane.Size().Width - theme.Padding()*4
+	if maxWidth > 0 && size.Width > maxWidth {
+		size = fyne.NewSize(maxWidth, size.Height*maxWidth/size.Width)
+	}
+	return size
+}
@@ -0,0 +1,184 @@
+package ui
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+	handling "github.com/Leda-Editor/Leda-Text-Editor/pkg/handling"
+)
+
+const (
+	quickOpenResults      = 50
+	quickOpenPreviewLines = 40
+)
+
+var (
+	// workspaceIndex caches the file list of the workspace it was built for.
+	workspaceIndex     []string
+	workspaceIndexRoot string
+)
+
+// pickerEntry is a single line entry that forwards navigation keys to its picker.
+type pickerEntry struct {
+	widget.Entry
+	onUp, onDown, onEscape func()
+}
+
+func newPickerEntry() *pickerEntry {
+	e := &pickerEntry{}
+	e.ExtendBaseWidget(e)
+	return e
+}
+
+// TypedKey handles Up, Down and Escape, anything else is normal typing.
+func (e *pickerEntry) TypedKey(key *fyne.KeyEvent) {
+	switch key.Name {
+	case fyne.KeyUp:
+		e.onUp()
+	case fyne.KeyDown:
+		e.onDown()
+	case fyne.KeyEscape:
+		e.onEscape()
+	default:
+		e.Entry.TypedKey(key)
+	}
+}
+
+// ShowQuickOpen shows the "Go to File" overlay for the workspace.
+func ShowQuickOpen(ui *UI) {
+	root := handling.WorkspaceRoot
+	if root == "" {
+		dialog.ShowInformation("Go to File", "Open a folder first (File > Open Folder).", ui.Window)
+		return
+	}
+
+	var results []string
+	selected := 0
+
+	entry := newPickerEntry()
+	entry.SetPlaceHolder("Search files by name (append :line to jump)")
+	status := widget.NewLabel("Indexing…")
+	preview := widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+
+	list := widget.NewList(
+		func() int { return len(results) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			o.(*widget.Label).SetText(results[id])
+		},
+	)
+
+	showPreview := func() {
+		if selected < 0 || selected >= len(results) {
+			preview.SetText("")
+			return
+		}
+		_, line, _ := handling.ParseLineSuffix(entry.Text)
+		preview.SetText(previewFile(filepath.Join(root, results[selected]), line))
+	}
+
+	update := func() {
+		query, _, _ := handling.ParseLineSuffix(entry.Text)
+		results = handling.FuzzyMatch(strings.TrimSpace(query), root, workspaceIndex, quickOpenResults)
+		selected = 0
+		list.Refresh()
+		if len(results) > 0 {
+			list.Select(0)
+		}
+		showPreview()
+	}
+
+	var popup *widget.PopUp
+	open := func() {
+		if selected < 0 || selected >= len(results) {
+			return
+		}
+		popup.Hide()
+		_, line, column := handling.ParseLineSuffix(entry.Text)
+		ui.jumpTo(handling.Location{Path: filepath.Join(root, results[selected]), Row: line - 1, Column: column - 1})
+	}
+
+	list.OnSelected = func(id widget.ListItemID) {
+		selected = id
+		showPreview()
+	}
+	entry.OnChanged = func(string) { update() }
+	entry.OnSubmitted = func(string) { open() }
+	entry.onUp = func() {
+		if selected > 0 {
+			list.Select(selected - 1)
+		}
+	}
+	entry.onDown = func() {
+		if selected < len(results)-1 {
+			list.Select(selected + 1)
+		}
+	}
+	entry.onEscape = func() { popup.Hide() }
+
+	openButton := widget.NewButton("Open", open)
+	closeButton := widget.NewButton("Close", func() { popup.Hide() })
+	split := container.NewHSplit(list, container.NewScroll(preview))
+	split.SetOffset(0.45)
+	content := container.NewBorder(
+		container.NewVBox(widget.NewLabel("🔎 Go to File"), entry),
+		container.NewHBox(status, layout.NewSpacer(), openButton, closeButton),
+		nil, nil,
+		split,
+	)
+
+	popup = widget.NewModalPopUp(content, ui.Window.Canvas())
+	size := ui.Window.Canvas().Size()
+	popup.Resize(fyne.NewSize(size.Width*0.7, size.Height*0.6))
+	popup.Show()
+	ui.Window.Canvas().Focus(entry)
+
+	// Show the previous index straight away and refresh it in the background.
+	if workspaceIndexRoot == root {
+		update()
+	}
+	ignores := handling.WorkspaceIgnores()
+	go func() {
+		files := handling.IndexWorkspace(root, ignores)
+		fyne.Do(func() {
+			workspaceIndex, workspaceIndexRoot = files, root
+			status.SetText(fmt.Sprintf("%s: %d files", filepath.Base(root), len(files)))
+			update()
+		})
+	}()
+}
+
+// previewFile reads a few lines of a file, starting shortly before line when it is given.
+func previewFile(path string, line int) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return err.Error()
+	}
+	defer file.Close()
+
+	start := max(line-5, 1)
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for n := 1; scanner.Scan() && len(lines) < quickOpenPreviewLines; n++ {
+		if n < start {
+			continue
+		}
+		text := scanner.Text()
+		if strings.ContainsRune(text, 0) {
+			return "(binary file)"
+		}
+		if runes := []rune(text); len(runes) > 200 {
+			text = string(runes[:200]) + "…"
+		}
+		lines = append(lines, text)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,187 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	handling "github.com/Leda-Editor/Leda-Text-Editor/pkg/handling"
+)
+
+// recentMenuEntries is how many unpinned files and folders the Open Recent menu shows.
+const recentMenuEntries = 10
+
+// recentLabel shows the name first, followed by where it is.
+func recentLabel(path string) string {
+	dir := filepath.Dir(path)
+	if home, err := os.UserHomeDir(); err == nil && strings.HasPrefix(dir, home) {
+		dir = "~" + strings.TrimPrefix(dir, home)
+	}
+	return fmt.Sprintf("%s  —  %s", filepath.Base(path), dir)
+}
+
+// recentMenu builds the File > Open Recent submenu.
+func (ui *UI) recentMenu() *fyne.Menu {
+	var items []*fyne.MenuItem
+	add := func(list *handling.RecentList, open func(string)) {
+		for _, path := range list.Pinned() {
+			path := path
+			items = append(items, fyne.NewMenuItem("📌 "+recentLabel(path), func() { open(path) }))
+		}
+		for i, path := range list.Paths() {
+			if i == recentMenuEntries {
+				break
+			}
+			path := path
+			items = append(items, fyne.NewMenuItem(recentLabel(path), func() { open(path) }))
+		}
+	}
+
+	add(handling.RecentFiles, ui.openRecentFile)
+	if len(items) > 0 {
+		items = append(items, fyne.NewMenuItemSeparator())
+	}
+	folders := len(items)
+	add(handling.RecentFolders, ui.openRecentFolder)
+	if len(items) > folders {
+		items = append(items, fyne.NewMenuItemSeparator())
+	}
+	if len(items) == 0 {
+		empty := fyne.NewMenuItem("No Recently Opened Files", nil)
+		empty.Disabled = true
+		items = append(items, empty, fyne.NewMenuItemSeparator())
+	}
+
+	pin := fyne.NewMenuItem("Pin Current File", func() { ui.togglePinCurrentFile() })
+	if handling.CurrentFile == nil {
+		pin.Disabled = true
+	} else if handling.RecentFiles.IsPinned(handling.CurrentFile.Path()) {
+		pin.Label = "Unpin Current File"
+	}
+	items = append(items,
+		pin,
+		fyne.NewMenuItem("Manage Recently Opened…", func() { ShowManageRecent(ui) }),
+		fyne.NewMenuItem("Clear Recently Opened", func() {
+			handling.RecentFiles.Clear()
+			handling.RecentFolders.Clear()
+		}),
+	)
+	return fyne.NewMenu("", items...)
+}
+
+// refreshRecentMenu rebuilds Open Recent after the lists or the current file changed.
+func (ui *UI) refreshRecentMenu() {
+	if ui.recentItem == nil || ui.mainMenu == nil {
+		return
+	}
+	ui.recentItem.ChildMenu = ui.recentMenu()
+	ui.mainMenu.Refresh()
+}
+
+// openRecentFile opens a file from the recent list, forgetting it if it's gone.
+func (ui *UI) openRecentFile(path string) {
+	if _, err := os.Stat(path); err != nil {
+		handling.RecentFiles.Remove(path)
+		dialog.ShowError(fmt.Errorf("%s no longer exists and was removed from the recent files", filepath.Base(path)), ui.Window)
+		return
+	}
+	ui.jumpTo(handling.Location{Path: path})
+}
+
+// openRecentFolder makes a recent folder the workspace, forgetting it if it's gone.
+func (ui *UI) openRecentFolder(path string) {
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		handling.RecentFolders.Remove(path)
+		dialog.ShowError(fmt.Errorf("%s no longer exists and was removed from the recent folders", filepath.Base(path)), ui.Window)
+		return
+	}
+	handling.SetWorkspace(path)
+}
+
+// togglePinCurrentFile pins the open file to the top of Open Recent, or unpins it.
+func (ui *UI) togglePinCurrentFile() {
+	if handling.CurrentFile == nil {
+		return
+	}
+	path := handling.CurrentFile.Path()
+	handling.RecentFiles.SetPinned(path, !handling.RecentFiles.IsPinned(path))
+}
+
+// ShowManageRecent lists every recent file and folder with buttons to pin or remove them.
+func ShowManageRecent(ui *UI) {
+	type entry struct {
+		list   *handling.RecentList
+		path   string
+		folder bool
+	}
+	var entries []entry
+	load := func() {
+		entries = nil
+		for _, list := range []*handling.RecentList{handling.RecentFiles, handling.RecentFolders} {
+			folder := list == handling.RecentFolders
+			for _, path := range append(append([]string{}, list.Pinned()...), list.Paths()...) {
+				entries = append(entries, entry{list, path, folder})
+			}
+		}
+	}
+	load()
+
+	var list *widget.List
+	list = widget.NewList(
+		func() int { return len(entries) },
+		func() fyne.CanvasObject {
+			return container.NewBorder(nil, nil,
+				widget.NewIcon(nil),
+				container.NewHBox(widget.NewButton("Unpin", nil), widget.NewButtonWithIcon("", theme.DeleteIcon(), nil)),
+				widget.NewLabel(""),
+			)
+		},
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			e := entries[id]
+			row := o.(*fyne.Container)
+			label := row.Objects[0].(*widget.Label)
+			icon := row.Objects[1].(*widget.Icon)
+			buttons := row.Objects[2].(*fyne.Container)
+			pin := buttons.Objects[0].(*widget.Button)
+			remove := buttons.Objects[1].(*widget.Button)
+
+			label.SetText(recentLabel(e.path))
+			icon.SetResource(fileIcon(e.path, e.folder))
+			pinned := e.list.IsPinned(e.path)
+			if pinned {
+				pin.SetText("Unpin")
+			} else {
+				pin.SetText("Pin")
+			}
+			pin.OnTapped = func() {
+				e.list.SetPinned(e.path, !pinned)
+				load()
+				list.Refresh()
+			}
+			remove.OnTapped = func() {
+				e.list.Remove(e.path)
+				load()
+				list.Refresh()
+			}
+		},
+	)
+
+	removeMissing := widget.NewButton("Remove Missing", func() {
+		handling.RecentFiles.Prune()
+		handling.RecentFolders.Prune()
+		load()
+		list.Refresh()
+	})
+	content := container.NewBorder(nil, container.NewHBox(layout.NewSpacer(), removeMissing), nil, nil, list)
+	d := dialog.NewCustom("Recently Opened", "Close", content, ui.Window)
+	size := ui.Window.Canvas().Size()
+	d.Resize(fyne.NewSize(size.Width*0.6, size.Height*0.6))
+	d.Show()
+}
@@ -209,6 +209,8 @@ func ShowSearchUI(enableReplace bool, ui *UI) {
 		ui.SearchAreaContainer = container.NewVBox()
 	}
 
+	// Matches are found in the text as shown, so nothing can be folded away.
+	ui.unfoldAll()
 	ui.ReplaceTermEntry.Hide()
 	if enableReplace {
 		ui.ReplaceTermEntry.Show()
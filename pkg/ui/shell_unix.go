@@ -0,0 +1,119 @@
+//go:build !windows
+
+package ui
+
+import (
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+
+	"fyne.io/fyne/v2"
+	"github.com/creack/pty"
+	"github.com/fyne-io/terminal"
+)
+
+// shellProcess is the shell running in a terminal tab.
+type shellProcess struct {
+	lock sync.Mutex
+	cmd  *exec.Cmd
+	tty  *os.File
+	// pending holds input sent before the shell started.
+	pending [][]byte
+	// killed is set once the shell is killed, so one that hasn't started yet never does.
+	killed bool
+	// stop is closed when the shell is killed, so waiting for the terminal's size ends.
+	stop chan struct{}
+}
+
+// startShell runs the user's shell in dir once term has been laid out, calling onExit with its exit code when it ends.
+// The shell is started here rather than by the terminal so it gets its own directory
+// without changing Leda's, and so it can be killed.
+func startShell(term *terminal.Terminal, dir string, onExit func(code int)) *shellProcess {
+	p := &shellProcess{stop: make(chan struct{})}
+	sizes := make(chan terminal.Config, 1)
+	term.AddListener(sizes)
+
+	go func() {
+		// The terminal reports its size once it's on screen, the shell needs it to start with.
+		var size terminal.Config
+		select {
+		case size = <-sizes:
+		case <-p.stop:
+			term.RemoveListener(sizes)
+			return
+		}
+
+		shell := os.Getenv("SHELL")
+		if shell == "" {
+			shell = "bash"
+		}
+		cmd := exec.Command(shell)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "TERM=xterm-256color")
+		// The shell is started with the lock held, so it can't be killed halfway through starting.
+		p.lock.Lock()
+		if p.killed {
+			p.lock.Unlock()
+			term.RemoveListener(sizes)
+			return
+		}
+		tty, err := pty.StartWithSize(cmd, &pty.Winsize{Rows: uint16(size.Rows), Cols: uint16(size.Columns)})
+		if err != nil {
+			p.lock.Unlock()
+			term.RemoveListener(sizes)
+			fyne.LogError("Failed to start shell", err)
+			onExit(-1)
+			return
+		}
+		p.cmd = cmd
+		p.tty = tty
+		for _, input := range p.pending {
+			tty.Write(input)
+		}
+		p.pending = nil
+		p.lock.Unlock()
+
+		go func() {
+			for size := range sizes {
+				pty.Setsize(tty, &pty.Winsize{Rows: uint16(size.Rows), Cols: uint16(size.Columns)})
+			}
+		}()
+
+		term.RunWithConnection(tty, tty)
+		cmd.Wait()
+		term.RemoveListener(sizes)
+		onExit(cmd.ProcessState.ExitCode())
+	}()
+	return p
+}
+
+// kill stops the shell and anything it started in the foreground, or keeps it from starting if it hasn't yet.
+func (p *shellProcess) kill() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if !p.killed {
+		p.killed = true
+		close(p.stop)
+	}
+	if p.cmd == nil || p.cmd.Process == nil {
+		return
+	}
+	// The shell leads its own session, so its process group includes its jobs.
+	if err := syscall.Kill(-p.cmd.Process.Pid, syscall.SIGKILL); err != nil {
+		p.cmd.Process.Kill()
+	}
+}
+
+// send types input into the shell, holding on to it until the shell has started.
+func (p *shellProcess) send(input []byte) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.tty == nil {
+		p.pending = append(p.pending, input)
+		return
+	}
+	if _, err := p.tty.Write(input); err != nil {
+		fyne.LogError("Failed to write to shell", err)
+	}
+}
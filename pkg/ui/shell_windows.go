@@ -0,0 +1,37 @@
+//go:build windows
+
+package ui
+
+import (
+	"fyne.io/fyne/v2"
+	"github.com/fyne-io/terminal"
+)
+
+// shellProcess is the shell running in a terminal tab.
+type shellProcess struct {
+	term *terminal.Terminal
+}
+
+// startShell runs the terminal's shell in dir, calling onExit with its exit code when it ends.
+func startShell(term *terminal.Terminal, dir string, onExit func(code int)) *shellProcess {
+	term.SetStartDir(dir)
+	go func() {
+		if err := term.RunLocalShell(); err != nil {
+			fyne.LogError("Failed to start shell", err)
+		}
+		onExit(term.ExitCode())
+	}()
+	return &shellProcess{term: term}
+}
+
+// kill asks the shell to exit, the terminal doesn't expose its process on Windows.
+func (p *shellProcess) kill() {
+	p.term.Exit()
+}
+
+// send types input into the shell.
+func (p *shellProcess) send(input []byte) {
+	if _, err := p.term.Write(input); err != nil {
+		fyne.LogError("Failed to write to shell", err)
+	}
+}
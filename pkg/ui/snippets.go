@@ -0,0 +1,308 @@
+package ui
+
+import (
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+	handling "github.com/Leda-Editor/Leda-Text-Editor/pkg/handling"
+)
+
+// snippetSession follows the tab stops of an inserted snippet while Tab moves through them.
+type snippetSession struct {
+	// stops are where the tab stops are in the editor's text, in runes, ending with $0.
+	stops   []handling.TabStop
+	current int
+	// text is the editor's text as of the last change, to work out what the next one did.
+	text string
+	// mirror is the span of the current stop being rewritten to match the first, -1 while the user types.
+	mirror int
+}
+
+// expandSnippetPrefix inserts the snippet whose prefix is the word before the cursor, reporting whether there was one.
+func (ui *UI) expandSnippetPrefix() bool {
+	word := handling.WordBefore(lineText(ui.Editor.Text, ui.Editor.CursorRow), ui.Editor.CursorColumn)
+	if word == "" || ui.Editor.SelectedText() != "" {
+		return false
+	}
+	snippets, _ := handling.LoadSnippets(handling.SnippetLanguage(ui.currentLocation().Path))
+	for _, snippet := range snippets {
+		for _, prefix := range snippet.Prefixes {
+			if prefix == word {
+				ui.insertSnippet(snippet, len([]rune(word)))
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// insertSnippet replaces the count characters before the cursor, or the selection, with a snippet
+// and selects its first tab stop.
+func (ui *UI) insertSnippet(snippet handling.Snippet, count int) {
+	ui.endSnippet()
+	line := lineText(ui.Editor.Text, ui.Editor.CursorRow)
+	indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+	vars := handling.SnippetVariables(ui.currentLocation().Path, ui.Editor.SelectedText(),
+		ui.Window.Clipboard().Content(), line, ui.currentLocation().Row, time.Now())
+	expansion := handling.ExpandSnippet(snippet.Body, vars, indent)
+
+	ui.replaceBeforeCursor(count, expansion.Text)
+	start := textOffset(ui.Editor.Text, ui.Editor.CursorRow, ui.Editor.CursorColumn) - len([]rune(expansion.Text))
+	for i := range expansion.Stops {
+		for j := range expansion.Stops[i].Spans {
+			expansion.Stops[i].Spans[j].Start += start
+			expansion.Stops[i].Spans[j].End += start
+		}
+	}
+
+	ui.snippet = &snippetSession{stops: expansion.Stops, text: ui.Editor.Text, mirror: -1}
+	ui.selectStop()
+}
+
+// nextStop moves to the next tab stop, or the previous one when delta is -1.
+func (ui *UI) nextStop(delta int) {
+	s := ui.snippet
+	s.current = min(max(s.current+delta, 0), len(s.stops)-1)
+	ui.selectStop()
+}
+
+// selectStop selects the current tab stop's text, offering its choices if it has any.
+// Reaching $0 puts the cursor there and ends the snippet.
+func (ui *UI) selectStop() {
+	s := ui.snippet
+	stop := s.stops[s.current]
+	span := stop.Spans[0]
+	if s.current == len(s.stops)-1 {
+		ui.endSnippet()
+		ui.selectText(span.End, span.End)
+		return
+	}
+	if len(stop.Choices) == 0 {
+		ui.selectText(span.Start, span.End)
+		return
+	}
+	// The cursor goes at the start so every choice matches, picking one types over the selection.
+	ui.selectText(span.End, span.Start)
+	items := make([]handling.CompletionItem, len(stop.Choices))
+	for i, choice := range stop.Choices {
+		items[i] = handling.CompletionItem{Label: choice, Insert: choice, Start: ui.Editor.CursorColumn, Kind: handling.CompletionChoice, Rank: i}
+	}
+	ui.completion.show(items, ui.Editor.CursorRow)
+}
+
+// endSnippet stops Tab moving between the stops of the last snippet.
+func (ui *UI) endSnippet() {
+	ui.snippet = nil
+}
+
+// snippetTextChanged moves the tab stops along with an edit, copying the current stop's text to its mirrors.
+// An edit outside the current stop ends the snippet.
+func (ui *UI) snippetTextChanged(content string) {
+	s := ui.snippet
+	if s == nil {
+		return
+	}
+	previous, text := []rune(s.text), []rune(content)
+	s.text = content
+
+	// Edits end at the cursor, so what's after it hasn't changed.
+	cursor := textOffset(content, ui.Editor.CursorRow, ui.Editor.CursorColumn)
+	suffix := len(text) - cursor
+	if suffix < 0 || suffix > len(previous) {
+		ui.endSnippet()
+		return
+	}
+	at := 0
+	for at < min(cursor, len(previous)-suffix) && previous[at] == text[at] {
+		at++
+	}
+	removed, inserted := len(previous)-suffix-at, cursor-at
+
+	stop := &s.stops[s.current]
+	target := &stop.Spans[0]
+	if s.mirror >= 0 {
+		target = &stop.Spans[s.mirror]
+	}
+	if at < target.Start || at+removed > target.End {
+		ui.endSnippet()
+		return
+	}
+	before := *target
+	for i := range s.stops {
+		for j := range s.stops[i].Spans {
+			moveSpan(&s.stops[i].Spans[j], target, before, at, removed, inserted)
+		}
+	}
+
+	if s.mirror < 0 && len(stop.Spans) > 1 {
+		ui.updateMirrors()
+	}
+}
+
+// moveSpan moves a tab stop's span along with an edit of the target span, which was before when the edit was made.
+func moveSpan(span, target *handling.Span, before handling.Span, at, removed, inserted int) {
+	delta := inserted - removed
+	switch {
+	case span == target:
+		span.End += delta
+	case span.Start <= before.Start && before.End <= span.End && span.End-span.Start > before.End-before.Start:
+		// A placeholder holding the one edited grows and shrinks with it.
+		span.End += delta
+	case span.Start >= at+removed:
+		span.Start += delta
+		span.End += delta
+	case span.End <= at:
+	default:
+		// The span was inside the text that was replaced.
+		span.Start, span.End = at+inserted, at+inserted
+	}
+}
+
+// updateMirrors copies the current stop's text to the other places it's repeated, leaving the cursor where it was.
+func (ui *UI) updateMirrors() {
+	s := ui.snippet
+	stop := &s.stops[s.current]
+	primary := stop.Spans[0]
+	value := string([]rune(ui.Editor.Text)[primary.Start:primary.End])
+	offset := textOffset(ui.Editor.Text, ui.Editor.CursorRow, ui.Editor.CursorColumn) - primary.Start
+
+	for i := 1; i < len(stop.Spans); i++ {
+		span := stop.Spans[i]
+		if string([]rune(ui.Editor.Text)[span.Start:span.End]) == value {
+			continue
+		}
+		s.mirror = i
+		ui.selectText(span.End, span.End)
+		ui.replaceBeforeCursor(span.End-span.Start, value)
+		if ui.snippet == nil {
+			return
+		}
+	}
+	s.mirror = -1
+	ui.selectText(stop.Spans[0].Start+offset, stop.Spans[0].Start+offset)
+}
+
+// snippetKey handles Tab, Shift + Tab and Escape while moving through a snippet's stops,
+// and Tab after a snippet's prefix. It reports whether it used the key.
+func (ui *UI) snippetKey(key *fyne.KeyEvent) bool {
+	switch {
+	case key.Name == fyne.KeyTab && ui.snippet != nil:
+		if shiftPressed() {
+			ui.nextStop(-1)
+		} else {
+			ui.nextStop(1)
+		}
+	case key.Name == fyne.KeyEscape && ui.snippet != nil:
+		ui.endSnippet()
+	case key.Name == fyne.KeyTab && !shiftPressed():
+		return ui.expandSnippetPrefix()
+	default:
+		return false
+	}
+	return true
+}
+
+// ShowInsertSnippet lists the snippets for the current file, the one picked replaces the selection (Ctrl + J).
+func ShowInsertSnippet(ui *UI) {
+	language := handling.SnippetLanguage(ui.currentLocation().Path)
+	snippets, err := handling.LoadSnippets(language)
+	if err != nil {
+		dialog.ShowError(err, ui.Window)
+	}
+	if len(snippets) == 0 {
+		dialog.ShowConfirm("Insert Snippet", "There are no "+language+" snippets yet. Create some?", func(ok bool) {
+			if ok {
+				ui.configureSnippets()
+			}
+		}, ui.Window)
+		return
+	}
+
+	results := snippets
+	selected := 0
+
+	entry := newPickerEntry()
+	entry.SetPlaceHolder("Search snippets")
+	list := widget.NewList(
+		func() int { return len(results) },
+		func() fyne.CanvasObject {
+			detail := widget.NewLabel("")
+			detail.Importance = widget.LowImportance
+			return container.NewBorder(nil, nil, nil, detail, widget.NewLabel(""))
+		},
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			row := o.(*fyne.Container)
+			snippet := results[id]
+			row.Objects[0].(*widget.Label).SetText(snippet.Name + "    " + strings.Join(snippet.Prefixes, ", "))
+			row.Objects[1].(*widget.Label).SetText(snippet.Description)
+		},
+	)
+
+	update := func() {
+		query := strings.TrimSpace(entry.Text)
+		results = nil
+		for _, snippet := range snippets {
+			if _, ok := handling.FuzzyScore(query, snippet.Name+" "+strings.Join(snippet.Prefixes, " ")); ok {
+				results = append(results, snippet)
+			}
+		}
+		selected = 0
+		list.Refresh()
+		if len(results) > 0 {
+			list.Select(0)
+		}
+	}
+
+	var popup *widget.PopUp
+	insert := func() {
+		if selected < 0 || selected >= len(results) {
+			return
+		}
+		popup.Hide()
+		ui.Window.Canvas().Focus(ui.code)
+		ui.insertSnippet(results[selected], 0)
+	}
+
+	list.OnSelected = func(id widget.ListItemID) { selected = id }
+	entry.OnChanged = func(string) { update() }
+	entry.OnSubmitted = func(string) { insert() }
+	entry.onUp = func() {
+		if selected > 0 {
+			list.Select(selected - 1)
+		}
+	}
+	entry.onDown = func() {
+		if selected < len(results)-1 {
+			list.Select(selected + 1)
+		}
+	}
+	entry.onEscape = func() { popup.Hide() }
+
+	content := container.NewBorder(
+		container.NewVBox(widget.NewLabel("✂️ Insert Snippet"), entry),
+		container.NewHBox(layout.NewSpacer(), widget.NewButton("Insert", insert), widget.NewButton("Close", func() { popup.Hide() })),
+		nil, nil,
+		list,
+	)
+	popup = widget.NewModalPopUp(content, ui.Window.Canvas())
+	size := ui.Window.Canvas().Size()
+	popup.Resize(fyne.NewSize(size.Width*0.5, size.Height*0.6))
+	popup.Show()
+	ui.Window.Canvas().Focus(entry)
+	list.Select(0)
+}
+
+// Open the snippets file for the current file's language, creating it with an example if needed.
+func (ui *UI) configureSnippets() {
+	path, err := handling.CreateSnippetsFile(handling.SnippetLanguage(ui.currentLocation().Path))
+	if err != nil {
+		dialog.ShowError(err, ui.Window)
+		return
+	}
+	ui.jumpTo(handling.Location{Path: path})
+}
@@ -0,0 +1,313 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	handling "github.com/Leda-Editor/Leda-Text-Editor/pkg/handling"
+)
+
+const (
+	// spell_check is the preference for underlining misspelled words, on by default.
+	spell_check = "editor_spell_check"
+	// spell_language is the preference for the dictionary spelling is checked with, such as "en_US".
+	spell_language = "spell_language"
+	// spellCheckDelay is how long typing pauses before the text is checked again.
+	spellCheckDelay = 300 * time.Millisecond
+)
+
+// spellLanguage returns the dictionary to check spelling with: the one chosen, else en_US, else the first there is.
+// It's "" when there are no dictionaries.
+func (ui *UI) spellLanguage() string {
+	languages := handling.DictionaryLanguages(ui.dictionariesDir)
+	for _, language := range []string{ui.App.Preferences().String(spell_language), "en_US"} {
+		if slices.Contains(languages, language) {
+			return language
+		}
+	}
+	if len(languages) > 0 {
+		return languages[0]
+	}
+	return ""
+}
+
+// loadSpelling reads the dictionary in the background, then checks the text with it and the word lists.
+// Spelling isn't checked while it's turned off or there's no dictionary.
+func (ui *UI) loadSpelling() {
+	ui.dictionary = nil
+	ui.spelling = nil
+	ui.misspellings = nil
+	ui.spellingLayer.Refresh()
+	language := ui.spellLanguage()
+	if !ui.App.Preferences().BoolWithFallback(spell_check, true) || language == "" {
+		return
+	}
+	dir := ui.dictionariesDir
+	go func() {
+		dictionary, err := handling.LoadDictionary(dir, language)
+		fyne.Do(func() {
+			if err != nil {
+				fyne.LogError("Failed to load the "+language+" dictionary", err)
+				return
+			}
+			// Spelling may have been turned off or another dictionary chosen meanwhile.
+			if !ui.App.Preferences().BoolWithFallback(spell_check, true) || ui.spellLanguage() != language {
+				return
+			}
+			ui.dictionary = dictionary
+			ui.loadWordLists()
+		})
+	}()
+}
+
+// loadWordLists reads the user's and the open folder's word lists again and checks the text.
+func (ui *UI) loadWordLists() {
+	if ui.dictionary == nil {
+		return
+	}
+	var lists [][]string
+	for _, path := range []string{handling.UserWordsPath(), handling.ProjectWordsPath()} {
+		words, err := handling.LoadWordList(path)
+		if err != nil {
+			fyne.LogError("Failed to read word list "+path, err)
+		}
+		lists = append(lists, words)
+	}
+	ui.spelling = handling.NewSpellChecker(ui.dictionary, lists...)
+	ui.checkSpelling()
+}
+
+// spellTextChanged checks the text again once typing pauses.
+func (ui *UI) spellTextChanged() {
+	if ui.spelling == nil {
+		return
+	}
+	if ui.spellTimer != nil {
+		ui.spellTimer.Stop()
+	}
+	ui.spellTimer = time.AfterFunc(spellCheckDelay, func() {
+		fyne.Do(ui.checkSpelling)
+	})
+}
+
+// checkSpelling finds the misspelled words of the editor's text now and underlines them.
+func (ui *UI) checkSpelling() {
+	if ui.spellTimer != nil {
+		ui.spellTimer.Stop()
+	}
+	ui.misspellings = nil
+	if ui.spelling != nil {
+		ui.misspellings = ui.spelling.Misspellings(ui.Editor.Text, ui.currentLocation().Path)
+	}
+	ui.spellingLayer.Refresh()
+}
+
+// misspellingAt returns the misspelled word at a position within the editor.
+func (ui *UI) misspellingAt(pos fyne.Position) (handling.Misspelling, bool) {
+	row := ui.rowAt(pos.Y)
+	if row < 0 || row > strings.Count(ui.Editor.Text, "\n") {
+		return handling.Misspelling{}, false
+	}
+	offset := textOffset(ui.Editor.Text, row, ui.columnAt(row, pos.X))
+	for _, m := range ui.misspellings {
+		if m.Start <= offset && offset <= m.End {
+			return m, true
+		}
+	}
+	return handling.Misspelling{}, false
+}
+
+// showSpellingMenu offers the suggestions for a misspelled word that's right-clicked and to add it to a word list,
+// with the editor's editing commands after them. It reports whether there was a misspelled word there.
+func (ui *UI) showSpellingMenu(e *fyne.PointEvent) bool {
+	m, ok := ui.misspellingAt(e.Position)
+	if !ok {
+		return false
+	}
+	var items []*fyne.MenuItem
+	for _, suggestion := range ui.spelling.Suggest(m.Word) {
+		items = append(items, fyne.NewMenuItem(suggestion, func() { ui.correctSpelling(m, suggestion) }))
+	}
+	if len(items) == 0 {
+		none := fyne.NewMenuItem("No Suggestions", nil)
+		none.Disabled = true
+		items = append(items, none)
+	}
+	project := fyne.NewMenuItem("Add to Project Dictionary", func() { ui.addToWordList(handling.ProjectWordsPath(), m.Word) })
+	project.Disabled = handling.ProjectWordsPath() == ""
+	items = append(items,
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Add to User Dictionary", func() { ui.addToWordList(handling.UserWordsPath(), m.Word) }),
+		project,
+		fyne.NewMenuItem("Ignore", func() {
+			ui.spelling.AddWord(m.Word)
+			ui.checkSpelling()
+		}),
+		fyne.NewMenuItemSeparator(),
+	)
+	items = append(items, ui.editMenuItems()...)
+	ui.Window.Canvas().Focus(ui.code)
+	widget.ShowPopUpMenuAtPosition(fyne.NewMenu("", items...), ui.Window.Canvas(), e.AbsolutePosition)
+	return true
+}
+
+// correctSpelling replaces a misspelled word with a suggestion, if the word is still there.
+func (ui *UI) correctSpelling(m handling.Misspelling, suggestion string) {
+	text := []rune(ui.Editor.Text)
+	if m.End > len(text) || string(text[m.Start:m.End]) != m.Word {
+		return
+	}
+	ui.replaceRange(m.Start, m.End, suggestion)
+	ui.checkSpelling()
+}
+
+// addToWordList adds a word to a word list so it's no longer underlined.
+func (ui *UI) addToWordList(path, word string) {
+	if err := handling.AddToWordList(path, word); err != nil {
+		dialog.ShowError(fmt.Errorf("failed to add %q to the word list: %w", word, err), ui.Window)
+		return
+	}
+	ui.loadWordLists()
+}
+
+// Turn underlining misspelled words on or off.
+func (ui *UI) toggleSpellCheck(item *fyne.MenuItem) {
+	prefs := ui.App.Preferences()
+	enabled := !prefs.BoolWithFallback(spell_check, true)
+	prefs.SetBool(spell_check, enabled)
+	item.Checked = enabled
+	ui.mainMenu.Refresh()
+	ui.loadSpelling()
+}
+
+// Check spelling with another of the dictionaries.
+func (ui *UI) chooseSpellLanguage(language string, items []*fyne.MenuItem) {
+	ui.App.Preferences().SetString(spell_language, language)
+	for _, item := range items {
+		item.Checked = item.Label == language
+	}
+	ui.mainMenu.Refresh()
+	ui.loadSpelling()
+}
+
+// Open a word list to change the words added to it, creating it if needed. Saving it checks the text again.
+func (ui *UI) editWordList(path string) {
+	if path == "" {
+		dialog.ShowInformation("Edit Word List", "Open a folder to give it a word list of its own.", ui.Window)
+		return
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		err = os.MkdirAll(filepath.Dir(path), 0755)
+		if err == nil {
+			err = os.WriteFile(path, nil, 0644)
+		}
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("failed to create the word list: %w", err), ui.Window)
+			return
+		}
+	}
+	ui.jumpTo(handling.Location{Path: path})
+}
+
+// Explain where dictionaries go when there are none to check spelling with.
+func (ui *UI) showDictionariesHelp() {
+	dialog.ShowInformation("Spelling", "There are no dictionaries in "+ui.dictionariesDir+".\n"+
+		"Put a Hunspell dictionary's .dic and .aff files there, such as en_US.dic and en_US.aff,\n"+
+		"or set \"spelling\": {\"dictionaries\": \"/usr/share/hunspell\"} in config.json and restart.", ui.Window)
+}
+
+// spellingLayer underlines the misspelled words of the editor's text, on top of the editor.
+type spellingLayer struct {
+	widget.BaseWidget
+	ui *UI
+}
+
+// newSpellingLayer creates the underlines for ui's editor.
+func newSpellingLayer(ui *UI) *spellingLayer {
+	l := &spellingLayer{ui: ui}
+	l.ExtendBaseWidget(l)
+	return l
+}
+
+func (l *spellingLayer) CreateRenderer() fyne.WidgetRenderer {
+	return &spellingRenderer{layer: l}
+}
+
+// spellingRenderer draws a dotted line under each misspelled word that's in view.
+type spellingRenderer struct {
+	layer   *spellingLayer
+	objects []fyne.CanvasObject
+}
+
+func (r *spellingRenderer) MinSize() fyne.Size {
+	return fyne.Size{}
+}
+
+func (r *spellingRenderer) Layout(fyne.Size) {
+	r.layoutLines()
+}
+
+func (r *spellingRenderer) Refresh() {
+	r.layoutLines()
+	canvas.Refresh(r.layer)
+}
+
+// layoutLines creates the underlines of the lines in view, as dots so they're told apart from the language server's.
+func (r *spellingRenderer) layoutLines() {
+	ui := r.layer.ui
+	r.objects = nil
+	if len(ui.misspellings) == 0 {
+		return
+	}
+	th := r.layer.Theme()
+	colour := th.Color(theme.ColorNameError, fyne.CurrentApp().Settings().ThemeVariant())
+	pad := th.Size(theme.SizeNameInnerPadding)
+	textSize := th.Size(theme.SizeNameText)
+	lineHeight := ui.lineHeight()
+	firstRow := ui.rowAt(ui.EditorScroll.Offset.Y)
+	lastRow := ui.rowAt(ui.EditorScroll.Offset.Y+ui.EditorScroll.Size().Height) + 1
+
+	row, lineStart, scanned := 0, 0, 0
+	text := []rune(ui.Editor.Text)
+	for _, m := range ui.misspellings {
+		if m.End > len(text) {
+			break
+		}
+		for ; scanned < m.Start; scanned++ {
+			if text[scanned] == '\n' {
+				row++
+				lineStart = scanned + 1
+			}
+		}
+		if row < firstRow {
+			continue
+		}
+		if row > lastRow {
+			break
+		}
+		x1 := pad + fyne.MeasureText(string(text[lineStart:m.Start]), textSize, ui.Editor.TextStyle).Width
+		x2 := x1 + fyne.MeasureText(string(text[m.Start:m.End]), textSize, ui.Editor.TextStyle).Width
+		y := pad + float32(row+1)*lineHeight - 1
+		for x := x1; x < x2; x += 4 {
+			dot := canvas.NewRectangle(colour)
+			dot.Move(fyne.NewPos(x, y-1))
+			dot.Resize(fyne.NewSize(2, 2))
+			r.objects = append(r.objects, dot)
+		}
+	}
+}
+
+func (r *spellingRenderer) Objects() []fyne.CanvasObject {
+	return r.objects
+}
+
+func (r *spellingRenderer) Destroy() {}
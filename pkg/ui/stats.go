@@ -0,0 +1,195 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	handling "github.com/Leda-Editor/Leda-Text-Editor/pkg/handling"
+)
+
+// statRows are the counts the statistics panel shows, in order.
+var statRows = []string{"Words", "Characters", "Characters (no spaces)", "Sentences", "Paragraphs", "Reading time"}
+
+// StatsPanel shows the counts of the text and of the selection, the word goal and the words written each day.
+type StatsPanel struct {
+	// Visible indicates whether the panel is shown.
+	Visible bool
+
+	ui *UI
+	// document and selection hold a label for each of statRows.
+	document, selection []*widget.Label
+	goal                *widget.ProgressBar
+	history             *widget.List
+	days                []handling.DailyWords
+}
+
+// newStatsPanel creates the statistics panel for the given UI.
+func newStatsPanel(ui *UI) *StatsPanel {
+	p := &StatsPanel{ui: ui, goal: widget.NewProgressBar()}
+	for range statRows {
+		p.document = append(p.document, widget.NewLabel("0"))
+		p.selection = append(p.selection, widget.NewLabel("0"))
+	}
+	p.history = widget.NewList(
+		func() int { return len(p.days) },
+		func() fyne.CanvasObject {
+			return container.NewHBox(widget.NewLabel(""), layout.NewSpacer(), widget.NewLabel(""))
+		},
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			row := o.(*fyne.Container)
+			row.Objects[0].(*widget.Label).SetText(p.days[id].Day)
+			row.Objects[2].(*widget.Label).SetText(fmt.Sprintf("%d words", p.days[id].Words))
+		},
+	)
+	return p
+}
+
+// statValues returns the counts of statRows for stats.
+func statValues(stats handling.TextStats) []string {
+	return []string{
+		strconv.Itoa(stats.Words),
+		strconv.Itoa(stats.Characters),
+		strconv.Itoa(stats.CharactersNoSpaces),
+		strconv.Itoa(stats.Sentences),
+		strconv.Itoa(stats.Paragraphs),
+		formatReadingTime(stats.ReadingTime()),
+	}
+}
+
+// formatReadingTime writes a reading time in whole minutes, such as "4 min" or "1 h 12 min".
+func formatReadingTime(d time.Duration) string {
+	minutes := int(d.Round(time.Minute) / time.Minute)
+	switch {
+	case d == 0:
+		return "0 min"
+	case minutes == 0:
+		return "< 1 min"
+	case minutes < 60:
+		return fmt.Sprintf("%d min", minutes)
+	}
+	return fmt.Sprintf("%d h %d min", minutes/60, minutes%60)
+}
+
+// refresh shows the counts of the text, the word goal and the history.
+func (p *StatsPanel) refresh(stats handling.TextStats) {
+	if !p.Visible {
+		return
+	}
+	updateGoal(p.ui, p.goal)
+	for i, value := range statValues(stats) {
+		p.document[i].SetText(value)
+	}
+	p.days = p.ui.Goals.History()
+	p.history.Refresh()
+	p.refreshSelection()
+}
+
+// refreshSelection shows the counts of the selection.
+func (p *StatsPanel) refreshSelection() {
+	if !p.Visible {
+		return
+	}
+	stats := handling.CountText(p.ui.selectedText())
+	for i, value := range statValues(stats) {
+		p.selection[i].SetText(value)
+	}
+}
+
+// content returns the panel with its toolbar.
+func (p *StatsPanel) content() fyne.CanvasObject {
+	toolbar := container.NewHBox(
+		widget.NewLabelWithStyle("📊 Statistics", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		layout.NewSpacer(),
+		widget.NewButtonWithIcon("", theme.CancelIcon(), func() { p.ui.toggleStatsPanel() }),
+	)
+	counts := container.NewGridWithColumns(3,
+		widget.NewLabel(""),
+		widget.NewLabelWithStyle("Document", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle("Selection", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+	)
+	for i, name := range statRows {
+		counts.Add(widget.NewLabel(name))
+		counts.Add(p.document[i])
+		counts.Add(p.selection[i])
+	}
+	goal := container.NewBorder(nil, nil, widget.NewLabel("Word goal"),
+		widget.NewButton("Set…", func() { p.ui.setWordGoal() }), p.goal)
+	top := container.NewVBox(
+		toolbar,
+		counts,
+		widget.NewSeparator(),
+		goal,
+		widget.NewSeparator(),
+		widget.NewLabelWithStyle("Words written", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+	)
+	return container.NewBorder(top, nil, nil, nil, p.history)
+}
+
+// updateGoal shows how far the current file's words are towards its goal on a bar, hiding it without a goal.
+// In the panel the bar stays to say there's no goal.
+func updateGoal(ui *UI, bar *widget.ProgressBar) {
+	goal := ui.Goals.Goal(ui.currentLocation().Path)
+	if goal == 0 {
+		bar.Max, bar.Value = 1, 0
+		if bar == ui.GoalProgress {
+			bar.Hide()
+		}
+		bar.TextFormatter = func() string { return "No goal" }
+		bar.Refresh()
+		return
+	}
+	bar.TextFormatter = func() string { return fmt.Sprintf("%d / %d words", ui.wordCount, goal) }
+	bar.Max = float64(goal)
+	bar.Value = float64(min(ui.wordCount, goal))
+	bar.Show()
+	bar.Refresh()
+}
+
+// countWritten adds the words the current file gained by typing since it was last counted to today's words written.
+// Words that come from pasting, undo and redo or reloading the file aren't written, and switching files starts counting the new one afresh.
+func (ui *UI) countWritten(words int) {
+	path := ui.currentLocation().Path
+	if path == ui.wordCountPath && ui.typing && words > ui.wordCount {
+		ui.Goals.AddWritten(time.Now(), words-ui.wordCount)
+	}
+	ui.wordCountPath = path
+	ui.wordCount = words
+}
+
+// Set how many words the current file is meant to reach, shown in the status bar. 0 removes the goal.
+func (ui *UI) setWordGoal() {
+	initial := ""
+	if goal := ui.Goals.Goal(ui.currentLocation().Path); goal > 0 {
+		initial = strconv.Itoa(goal)
+	}
+	ui.askName("Word Goal", "Words", initial, "Set", func(value string) {
+		goal, err := strconv.Atoi(strings.ReplaceAll(value, ",", ""))
+		if err != nil || goal < 0 {
+			dialog.ShowError(fmt.Errorf("%q isn't a number of words", value), ui.Window)
+			return
+		}
+		ui.Goals.SetGoal(ui.currentLocation().Path, goal)
+		ui.UpdateCounts(ui.text())
+	})
+}
+
+// Remove the current file's word goal.
+func (ui *UI) removeWordGoal() {
+	ui.Goals.SetGoal(ui.currentLocation().Path, 0)
+	ui.UpdateCounts(ui.text())
+}
+
+// Toggle visibility of the statistics panel.
+func (ui *UI) toggleStatsPanel() {
+	ui.StatsPanel.Visible = !ui.StatsPanel.Visible
+	ui.UpdateCounts(ui.text())
+	ui.UpdateLayout()
+}
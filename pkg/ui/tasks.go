@@ -0,0 +1,308 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	handling "github.com/Leda-Editor/Leda-Text-Editor/pkg/handling"
+)
+
+// TaskPanel shows the output of the last task, and the problems found in it and by the language servers.
+type TaskPanel struct {
+	// Tabs switches between the output and the problems.
+	Tabs *container.AppTabs
+	// Output holds everything the task printed.
+	Output *widget.TextGrid
+	// Problems lists the errors found in the output and the diagnostics of the language servers.
+	Problems *widget.List
+	// Status describes the task that is running or last ran.
+	Status *widget.Label
+	// Visible indicates whether the panel is shown.
+	Visible bool
+
+	ui           *UI
+	outputScroll *container.Scroll
+	problemsTab  *container.TabItem
+	// problems is what the list shows: the task's problems followed by the diagnostics.
+	problems     []handling.Problem
+	taskProblems []handling.Problem
+	// diagnostics holds the language servers' problems, keyed by path.
+	diagnostics map[string][]handling.Problem
+	run         *handling.TaskRun
+	// runs counts the tasks started, so output of a replaced task is ignored.
+	runs int
+	// last is the task that ran last, for Rerun Last Task.
+	last *handling.Task
+}
+
+// newTaskPanel creates the task output for the given UI.
+func newTaskPanel(ui *UI) *TaskPanel {
+	p := &TaskPanel{ui: ui, Status: widget.NewLabel("No task has run yet."), diagnostics: map[string][]handling.Problem{}}
+	p.Output = widget.NewTextGrid()
+	p.Output.Scroll = container.ScrollNone
+	p.outputScroll = container.NewScroll(p.Output)
+
+	p.Problems = widget.NewList(
+		func() int { return len(p.problems) },
+		func() fyne.CanvasObject {
+			return container.NewBorder(nil, nil, widget.NewIcon(theme.ErrorIcon()), nil, widget.NewLabel(""))
+		},
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			row := o.(*fyne.Container)
+			row.Objects[0].(*widget.Label).SetText(p.label(id))
+			if p.problems[id].Warning {
+				row.Objects[1].(*widget.Icon).SetResource(theme.WarningIcon())
+			} else {
+				row.Objects[1].(*widget.Icon).SetResource(theme.ErrorIcon())
+			}
+		},
+	)
+	p.Problems.OnSelected = func(id widget.ListItemID) {
+		p.Problems.Unselect(id)
+		if id < len(p.problems) {
+			ui.jumpTo(p.problems[id].Location)
+		}
+	}
+
+	p.problemsTab = container.NewTabItem("Problems", p.Problems)
+	p.Tabs = container.NewAppTabs(
+		container.NewTabItem("Output", p.outputScroll),
+		p.problemsTab,
+	)
+	return p
+}
+
+// label describes a problem as "file:line:column  message", relative to the workspace where possible.
+func (p *TaskPanel) label(id int) string {
+	problem := p.problems[id]
+	name := problem.Path
+	if handling.WorkspaceRoot != "" {
+		if rel, err := filepath.Rel(handling.WorkspaceRoot, name); err == nil {
+			name = rel
+		}
+	}
+	return fmt.Sprintf("%s:%d:%d  %s", name, problem.Row+1, problem.Column+1, problem.Message)
+}
+
+// start runs a task, replacing the output of the previous one.
+func (p *TaskPanel) start(task handling.Task) {
+	if p.run != nil {
+		p.run.Stop()
+	}
+	matcher, err := task.Matcher()
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("problem matcher of %s: %w", task.Label, err), p.ui.Window)
+		return
+	}
+	p.last = &task
+	p.taskProblems = nil
+	p.updateProblems()
+	p.Output.Rows = nil
+	p.Output.Refresh()
+	p.Tabs.Select(p.Tabs.Items[0])
+	if !p.Visible {
+		p.ui.toggleTaskPanel()
+	}
+
+	root := handling.WorkspaceRoot
+	dir := task.Dir(root)
+	p.appendOutput("> " + task.Command)
+	p.Status.SetText("Running " + task.Label + "…")
+
+	p.runs++
+	id := p.runs
+	run, err := handling.StartTask(task, root, func(line string) {
+		fyne.Do(func() {
+			if p.runs == id {
+				p.appendOutput(line)
+				p.addProblem(line, dir, matcher)
+			}
+		})
+	}, func(err error) {
+		fyne.Do(func() {
+			if p.runs == id {
+				p.finished(task, err)
+			}
+		})
+	})
+	if err != nil {
+		p.appendOutput(err.Error())
+		p.Status.SetText(task.Label + " failed to start.")
+		return
+	}
+	p.run = run
+}
+
+// appendOutput adds a line to the output and keeps the end in view.
+func (p *TaskPanel) appendOutput(line string) {
+	p.Output.Append(line)
+	p.outputScroll.ScrollToBottom()
+}
+
+// addProblem adds the problem a line of output reports, if it reports one.
+func (p *TaskPanel) addProblem(line, dir string, matcher *regexp.Regexp) {
+	problem, ok := handling.ParseProblem(line, dir, matcher)
+	if !ok {
+		return
+	}
+	p.taskProblems = append(p.taskProblems, problem)
+	p.updateProblems()
+}
+
+// setDiagnostics replaces the language server problems of a file.
+func (p *TaskPanel) setDiagnostics(path string, problems []handling.Problem) {
+	if len(problems) == 0 {
+		delete(p.diagnostics, path)
+	} else {
+		p.diagnostics[path] = problems
+	}
+	p.updateProblems()
+}
+
+// clearDiagnostics forgets the problems of every language server.
+func (p *TaskPanel) clearDiagnostics() {
+	p.diagnostics = map[string][]handling.Problem{}
+	p.updateProblems()
+}
+
+// updateProblems lists the task's problems followed by the diagnostics sorted by file,
+// and shows how many there are in the tab title.
+func (p *TaskPanel) updateProblems() {
+	paths := make([]string, 0, len(p.diagnostics))
+	for path := range p.diagnostics {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	p.problems = append([]handling.Problem{}, p.taskProblems...)
+	for _, path := range paths {
+		p.problems = append(p.problems, p.diagnostics[path]...)
+	}
+	p.Problems.Refresh()
+
+	p.problemsTab.Text = "Problems"
+	if len(p.problems) > 0 {
+		p.problemsTab.Text = fmt.Sprintf("Problems (%d)", len(p.problems))
+	}
+	p.Tabs.Refresh()
+}
+
+// finished reports how a task ended, switching to the problems if it found any.
+func (p *TaskPanel) finished(task handling.Task, err error) {
+	p.run = nil
+
+	var exit *exec.ExitError
+	switch {
+	case err == nil:
+		p.Status.SetText(task.Label + " finished.")
+	case errors.As(err, &exit) && exit.ExitCode() >= 0:
+		p.Status.SetText(fmt.Sprintf("%s failed with exit code %d.", task.Label, exit.ExitCode()))
+	default:
+		p.Status.SetText(task.Label + " was stopped.")
+	}
+	if len(p.taskProblems) > 0 {
+		p.Tabs.Select(p.problemsTab)
+	}
+}
+
+// content returns the tabs with the task toolbar.
+func (p *TaskPanel) content() fyne.CanvasObject {
+	toolbar := container.NewVBox(
+		widget.NewButtonWithIcon("", theme.MediaPlayIcon(), func() { ShowRunTask(p.ui) }),
+		widget.NewButtonWithIcon("", theme.MediaReplayIcon(), func() { p.ui.rerunTask() }),
+		widget.NewButtonWithIcon("", theme.MediaStopIcon(), func() { p.ui.stopTask() }),
+		widget.NewButtonWithIcon("", theme.CancelIcon(), func() { p.ui.toggleTaskPanel() }),
+	)
+	return container.NewBorder(p.Status, nil, nil, toolbar, p.Tabs)
+}
+
+// loadTasks reads the workspace's tasks, offering to create the tasks file when there is none.
+func (ui *UI) loadTasks() ([]handling.Task, bool) {
+	if handling.WorkspaceRoot == "" {
+		dialog.ShowInformation("Run Task", "Open a folder to run the tasks defined in its .leda/tasks.json.", ui.Window)
+		return nil, false
+	}
+	tasks, err := handling.LoadTasks(handling.WorkspaceRoot)
+	if errors.Is(err, os.ErrNotExist) || err == nil && len(tasks) == 0 {
+		dialog.ShowConfirm("Run Task", "This folder has no tasks yet. Create .leda/tasks.json?", func(ok bool) {
+			if ok {
+				ui.configureTasks()
+			}
+		}, ui.Window)
+		return nil, false
+	}
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("failed to read the tasks: %w", err), ui.Window)
+		return nil, false
+	}
+	return tasks, true
+}
+
+// ShowRunTask lists the workspace's tasks and runs the one picked (Ctrl + Shift + B).
+func ShowRunTask(ui *UI) {
+	tasks, ok := ui.loadTasks()
+	if !ok {
+		return
+	}
+	var d dialog.Dialog
+	list := widget.NewList(
+		func() int { return len(tasks) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			o.(*widget.Label).SetText(fmt.Sprintf("%s    %s", tasks[id].Label, tasks[id].Command))
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) {
+		d.Hide()
+		ui.Tasks.start(tasks[id])
+	}
+	d = dialog.NewCustom("Run Task", "Cancel", list, ui.Window)
+	size := ui.Window.Canvas().Size()
+	d.Resize(fyne.NewSize(size.Width*0.5, size.Height*0.5))
+	d.Show()
+}
+
+// Run the last task again.
+func (ui *UI) rerunTask() {
+	if ui.Tasks.last == nil {
+		ShowRunTask(ui)
+		return
+	}
+	ui.Tasks.start(*ui.Tasks.last)
+}
+
+// Stop the running task.
+func (ui *UI) stopTask() {
+	if ui.Tasks.run != nil {
+		ui.Tasks.run.Stop()
+	}
+}
+
+// Open the workspace's tasks file, creating an example one if there is none.
+func (ui *UI) configureTasks() {
+	if handling.WorkspaceRoot == "" {
+		dialog.ShowInformation("Configure Tasks", "Open a folder to define its tasks.", ui.Window)
+		return
+	}
+	path, err := handling.CreateTasksFile(handling.WorkspaceRoot)
+	if err != nil {
+		dialog.ShowError(err, ui.Window)
+		return
+	}
+	ui.jumpTo(handling.Location{Path: path})
+}
+
+// Toggle visibility of the task output (Ctrl + Shift + U).
+func (ui *UI) toggleTaskPanel() {
+	ui.Tasks.Visible = !ui.Tasks.Visible
+	ui.UpdateLayout()
+}
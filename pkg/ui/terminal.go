@@ -0,0 +1,288 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	handling "github.com/Leda-Editor/Leda-Text-Editor/pkg/handling"
+	"github.com/fyne-io/terminal"
+)
+
+// bracketed_paste is the preference for wrapping multi-line input sent to a terminal in bracketed paste markers.
+const bracketed_paste = "terminal_bracketed_paste"
+
+// TerminalPanel holds the tabbed shells below the editor.
+type TerminalPanel struct {
+	// Tabs has one tab per shell.
+	Tabs *container.DocTabs
+	// Visible indicates whether the panel is shown.
+	Visible bool
+
+	ui       *UI
+	sessions map[*container.TabItem]*terminalSession
+	// count numbers new tabs.
+	count int
+}
+
+// terminalSession is a shell and the terminal showing it.
+type terminalSession struct {
+	term  *terminal.Terminal
+	shell *shellProcess
+	dir   string
+	// closed is set once the tab is closed, so its exit isn't reported.
+	closed bool
+}
+
+// newTerminalPanel creates the terminal tabs for the given UI.
+func newTerminalPanel(ui *UI) *TerminalPanel {
+	p := &TerminalPanel{
+		ui:       ui,
+		sessions: map[*container.TabItem]*terminalSession{},
+		Visible:  true,
+	}
+	p.Tabs = container.NewDocTabs()
+	p.Tabs.CreateTab = func() *container.TabItem {
+		return p.newTab(terminalDir())
+	}
+	p.Tabs.OnClosed = func(tab *container.TabItem) {
+		p.closed(tab)
+	}
+	p.Tabs.OnSelected = func(tab *container.TabItem) {
+		p.focus()
+	}
+	return p
+}
+
+// terminalDir picks the folder of the open file, the workspace or the home folder, in that order.
+func terminalDir() string {
+	if handling.CurrentFile != nil {
+		return filepath.Dir(handling.CurrentFile.Path())
+	}
+	if handling.WorkspaceRoot != "" {
+		return handling.WorkspaceRoot
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return home
+	}
+	return ""
+}
+
+// newTab starts a shell in dir and returns its tab without adding it.
+func (p *TerminalPanel) newTab(dir string) *container.TabItem {
+	p.count++
+	tab := container.NewTabItemWithIcon(fmt.Sprintf("Terminal %d", p.count), theme.ComputerIcon(), nil)
+	p.start(tab, dir)
+	return tab
+}
+
+// start runs a new shell in a tab, replacing whatever the tab showed.
+func (p *TerminalPanel) start(tab *container.TabItem, dir string) {
+	session := &terminalSession{term: terminal.New(), dir: dir}
+	session.shell = startShell(session.term, dir, func(code int) {
+		fyne.Do(func() { p.exited(tab, session, code) })
+	})
+	p.sessions[tab] = session
+	tab.Content = session.term
+
+	// The terminal takes every key while it has focus, so it needs the panel's shortcuts itself.
+	session.term.AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyBackTick, Modifier: desktop.ControlModifier}, func(fyne.Shortcut) {
+		p.ui.toggleTerminalPanel()
+	})
+	session.term.AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyBackTick, Modifier: desktop.ControlModifier | desktop.ShiftModifier}, func(fyne.Shortcut) {
+		p.ui.newTerminal()
+	})
+}
+
+// exited keeps the output of a finished shell and offers to restart it.
+func (p *TerminalPanel) exited(tab *container.TabItem, session *terminalSession, code int) {
+	if session.closed || p.sessions[tab] != session {
+		return
+	}
+	message := fmt.Sprintf("Shell exited with code %d.", code)
+	if code < 0 {
+		message = "Shell was stopped."
+	}
+	banner := container.NewHBox(
+		widget.NewLabel(message),
+		layout.NewSpacer(),
+		widget.NewButtonWithIcon("Restart", theme.ViewRefreshIcon(), func() {
+			p.start(tab, session.dir)
+			p.Tabs.Refresh()
+			p.focus()
+		}),
+		widget.NewButtonWithIcon("Close", theme.CancelIcon(), func() {
+			p.Tabs.Remove(tab)
+			p.closed(tab)
+		}),
+	)
+	tab.Content = container.NewBorder(banner, nil, nil, nil, session.term)
+	p.Tabs.Refresh()
+}
+
+// closed stops the shell of a tab that has been closed.
+func (p *TerminalPanel) closed(tab *container.TabItem) {
+	if session, ok := p.sessions[tab]; ok {
+		session.closed = true
+		session.shell.kill()
+		delete(p.sessions, tab)
+	}
+	// Closing the last terminal hides the panel.
+	if len(p.Tabs.Items) == 0 && p.Visible {
+		p.ui.toggleTerminalPanel()
+	}
+}
+
+// Active returns the selected terminal, nil if there is none.
+func (p *TerminalPanel) Active() *terminal.Terminal {
+	if session := p.active(); session != nil {
+		return session.term
+	}
+	return nil
+}
+
+// active returns the session of the selected tab, nil if there is none.
+func (p *TerminalPanel) active() *terminalSession {
+	return p.sessions[p.Tabs.Selected()]
+}
+
+// focus gives the keyboard to the selected terminal.
+func (p *TerminalPanel) focus() {
+	if term := p.Active(); term != nil {
+		p.ui.Window.Canvas().Focus(term)
+	}
+}
+
+// content returns the tabs with the terminal toolbar.
+func (p *TerminalPanel) content() fyne.CanvasObject {
+	toolbar := container.NewVBox(
+		widget.NewButtonWithIcon("", theme.ContentAddIcon(), func() { p.ui.newTerminal() }),
+		widget.NewButtonWithIcon("", theme.DocumentCreateIcon(), func() { p.ui.renameTerminal() }),
+		widget.NewButtonWithIcon("", theme.MediaStopIcon(), func() { p.ui.killTerminal() }),
+		widget.NewButtonWithIcon("", theme.CancelIcon(), func() { p.ui.toggleTerminalPanel() }),
+	)
+	return container.NewBorder(nil, nil, nil, toolbar, p.Tabs)
+}
+
+// Open a new terminal tab (Ctrl + Shift + `).
+func (ui *UI) newTerminal() {
+	p := ui.Terminals
+	tab := p.newTab(terminalDir())
+	p.Tabs.Append(tab)
+	p.Tabs.Select(tab)
+	if !p.Visible {
+		ui.toggleTerminalPanel()
+	}
+	p.focus()
+}
+
+// Rename the selected terminal tab.
+func (ui *UI) renameTerminal() {
+	tab := ui.Terminals.Tabs.Selected()
+	if tab == nil {
+		return
+	}
+	ui.askName("Rename Terminal", "Name", tab.Text, "Rename", func(name string) {
+		tab.Text = name
+		ui.Terminals.Tabs.Refresh()
+	})
+}
+
+// Kill the selected terminal's shell, leaving its tab to restart it.
+func (ui *UI) killTerminal() {
+	if session := ui.Terminals.active(); session != nil {
+		session.shell.kill()
+	}
+}
+
+// Close the selected terminal tab.
+func (ui *UI) closeTerminal() {
+	tab := ui.Terminals.Tabs.Selected()
+	if tab == nil {
+		return
+	}
+	ui.Terminals.Tabs.Remove(tab)
+	ui.Terminals.closed(tab)
+}
+
+// Toggle visibility of the terminal panel (Ctrl + `).
+func (ui *UI) toggleTerminalPanel() {
+	p := ui.Terminals
+	p.Visible = !p.Visible
+	if p.Visible && len(p.Tabs.Items) == 0 {
+		tab := p.newTab(terminalDir())
+		p.Tabs.Append(tab)
+	}
+	ui.UpdateLayout()
+	if p.Visible {
+		p.focus()
+	}
+}
+
+// sendToTerminal types text into the selected terminal and runs it, opening a terminal if needed.
+// The editor keeps the keyboard so more can be sent.
+func (ui *UI) sendToTerminal(text string) {
+	p := ui.Terminals
+	if !p.Visible {
+		ui.toggleTerminalPanel()
+	}
+	if p.active() == nil {
+		tab := p.newTab(terminalDir())
+		p.Tabs.Append(tab)
+		p.Tabs.Select(tab)
+	}
+	bracketed := ui.App.Preferences().BoolWithFallback(bracketed_paste, true)
+	p.active().shell.send([]byte(handling.TerminalInput(text, bracketed)))
+	ui.Window.Canvas().Focus(ui.code)
+}
+
+// Run the selected text, or the current line, in the terminal (Ctrl + Enter).
+func (ui *UI) runSelection() {
+	text := ui.selectedText()
+	if text == "" {
+		text = lineText(ui.text(), ui.currentLocation().Row)
+	}
+	if strings.TrimSpace(text) == "" {
+		return
+	}
+	ui.sendToTerminal(text)
+}
+
+// Save the current file and run it in the terminal (Ctrl + F5).
+func (ui *UI) runFile() {
+	if handling.CurrentFile == nil {
+		dialog.ShowError(errors.New("save the file before running it"), ui.Window)
+		return
+	}
+	ui.saveFile()
+
+	config, err := handling.LoadConfig("config.json")
+	if err != nil {
+		config = &handling.Config{}
+	}
+	command, err := handling.RunCommand(handling.CurrentFile.Path(), config.Interpreters)
+	if err != nil {
+		dialog.ShowError(err, ui.Window)
+		return
+	}
+	ui.sendToTerminal(command)
+}
+
+// Toggle wrapping multi-line input sent to the terminal in bracketed paste markers.
+// Shells that understand them take the text as one paste instead of running each line as it arrives.
+func (ui *UI) toggleBracketedPaste(item *fyne.MenuItem) {
+	prefs := ui.App.Preferences()
+	enabled := !prefs.BoolWithFallback(bracketed_paste, true)
+	prefs.SetBool(bracketed_paste, enabled)
+	item.Checked = enabled
+	ui.mainMenu.Refresh()
+}
@@ -362,6 +362,12 @@ func parseHexColor(s string) color.Color {
 	return color.RGBA{uint8(r), uint8(g), uint8(b), 255}
 }
 
+// Converts color.Color to a HEX color code
+func formatHexColor(c color.Color) string {
+	rgba := colorToRGBA(c)
+	return fmt.Sprintf("#%02X%02X%02X", rgba.R, rgba.G, rgba.B)
+}
+
 // LoadFont tries to load a font file, otherwise returns default.
 func LoadFont(path string, fallback fyne.Resource) fyne.Resource {
 	if path == "" {
@@ -3,14 +3,16 @@ package ui
 import (
 	"fmt"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/driver/desktop"
 	"fyne.io/fyne/v2/widget"
 	handling "github.com/Leda-Editor/Leda-Text-Editor/pkg/handling"
-	"github.com/fyne-io/terminal"
+	"github.com/Leda-Editor/Leda-Text-Editor/pkg/lsp"
 )
 
 // UI specifies the user interface.
@@ -24,17 +26,28 @@ type UI struct {
 	// Core state.
 	// Editor retains raw text in an edit buffer.
 	Editor *widget.Entry
+	// EditorScroll scrolls the editor, the gutter follows its offset.
+	EditorScroll *container.Scroll
+	// Gutter shows line numbers and bookmarks next to the editor.
+	Gutter *Gutter
 	// Markdown retains rich text interactions: clicks, hovers and longpresses.
 	Markdown *widget.RichText
 	// MenuBar adds a menu to the window.
 	MenuBar *fyne.Container
-	// Terminal window
-	Terminal *terminal.Terminal
+	// Terminals holds the tabbed shells below the editor.
+	Terminals *TerminalPanel
+	// Tasks shows the output and problems of the workspace's tasks.
+	Tasks *TaskPanel
 	// Theme allows to customize theme, such as font size.
 	Theme *Theme
-	// CharacterLabel & LineLabel creates labels for the respective counters.
+	// CharacterLabel, WordLabel & LineLabel creates labels for the respective counters.
 	CharacterLabel *widget.Label
+	WordLabel      *widget.Label
 	LineLabel      *widget.Label
+	// GoalProgress shows how far the current file is towards its word goal, hidden if it has none.
+	GoalProgress *widget.ProgressBar
+	// MacroLabel shows while a macro is being recorded.
+	MacroLabel *widget.Label
 
 	// current file
 	CurrentFileLabel *widget.Label
@@ -60,6 +73,107 @@ type UI struct {
 	// Markdown visibility toggle
 	ShowMarkdown bool
 
+	// Outline lists headings or symbols and follows the cursor.
+	Outline *OutlinePanel
+	// FileTree shows the workspace folder opened with File > Open Folder.
+	FileTree *FileTreePanel
+	// History holds the cursor locations to go back and forward to.
+	History *handling.NavigationHistory
+	// Bookmarks holds the bookmarked lines of every file, saved in the app preferences.
+	Bookmarks *handling.Bookmarks
+	// Folds holds the folded regions of every file, saved in the app preferences.
+	Folds *handling.Folds
+	// Clipboard holds what was copied and cut in the editor, saved in the app preferences only if asked to.
+	Clipboard *handling.ClipboardHistory
+	// BookmarkPanel lists the bookmarks.
+	BookmarkPanel *BookmarkPanel
+	// StatsPanel shows the counts of the text and the selection and the words written each day.
+	StatsPanel *StatsPanel
+	// Goals holds the word goal of each file and the words written each day, saved in the app preferences.
+	Goals *handling.WritingGoals
+	// LSP starts the language servers of the files being edited.
+	LSP *lsp.Manager
+	// Diagnostics holds the problems the language servers found in each file, keyed by path.
+	Diagnostics map[string][]lsp.Diagnostic
+
+	// mainMenu and recentItem are kept to rebuild File > Open Recent.
+	mainMenu   *fyne.MainMenu
+	recentItem *fyne.MenuItem
+
+	// code is the widget behind Editor, it's what goes into the layout and gets focus.
+	code *codeEditor
+	// lastText and lastTextPath remember the text before an edit so bookmarks can follow it.
+	lastText     string
+	lastTextPath string
+	// diagnosticLayer underlines the diagnostics of the current file.
+	diagnosticLayer *diagnosticLayer
+	// wordCount is how many words the file at wordCountPath had when last counted, see countWritten.
+	wordCount     int
+	wordCountPath string
+	// typing is set while the editor handles a typed character, only the words typed count towards those written.
+	typing bool
+	// spellingLayer underlines the misspellings of the editor's text, found by spelling with dictionary.
+	spellingLayer   *spellingLayer
+	dictionariesDir string
+	dictionary      *handling.Dictionary
+	spelling        *handling.SpellChecker
+	misspellings    []handling.Misspelling
+	// spellTimer delays checking the text again until typing pauses.
+	spellTimer *time.Timer
+	// bracketLayer highlights the pair of brackets at the cursor.
+	bracketLayer *bracketLayer
+	// cursors are the cursors added besides the editor's own, drawn by cursorLayer.
+	cursors     []handling.Cursor
+	cursorLayer *cursorLayer
+	// editingCursors is set while an edit is made at every cursor, so they aren't removed by it.
+	editingCursors bool
+	// block is the block selection being dragged out, nil when there's none.
+	block *blockSelection
+	// document is the whole text, the editor shows it as shown with the lines of the folded regions left out
+	// and foldRows holds the line of document each line of the editor is, see folding.go.
+	document string
+	shown    string
+	folded   []handling.FoldRange
+	foldRows []int
+	// presenting is set while the editor is given the text as it's shown, which isn't an edit,
+	// and loading while it's given the text of a file being opened.
+	presenting bool
+	loading    bool
+	// history holds the edits made to document so they can be undone, typed is set while a key is typed
+	// so the edits of a word typed or deleted are undone together.
+	history handling.EditHistory
+	typed   bool
+	// measuringSelection is set while selectedText moves the selection about to find where it is.
+	measuringSelection bool
+	// foldLayer marks the folded lines.
+	foldLayer *foldLayer
+	// foldRangeList caches the regions of foldRangesText that can be folded.
+	foldRangeList  []handling.FoldRange
+	foldRangesPath string
+	foldRangesText string
+	// recording is set while a macro is recorded into macroSteps, lastMacro is the one recorded last.
+	recording  bool
+	macroSteps []handling.MacroStep
+	lastMacro  []handling.MacroStep
+	// playingMacro counts the macros being played, they can play each other through their keys.
+	playingMacro int
+	// appShortcuts holds the key bindings the app uses, which macros can't take, macroShortcuts the ones macros have.
+	appShortcuts   map[string]bool
+	macroShortcuts []fyne.Shortcut
+	// indentation is how the current file is indented.
+	indentation handling.Indentation
+	// completion suggests words to finish the one being typed.
+	completion *completionPopup
+	// completionProviders make the suggestions, earlier ones are listed first when they match as well.
+	completionProviders []handling.CompletionProvider
+	// snippet is the inserted snippet whose tab stops Tab moves through, nil if there's none.
+	snippet *snippetSession
+	// lspQueue sends document changes to the language servers in order.
+	lspQueue *lsp.Queue
+	// lspPath is the file that is open with its language server, lspTimer delays sending its changes.
+	lspPath  string
+	lspTimer *time.Timer
+
 	ZoomLabel *widget.Label
 }
 
@@ -70,11 +184,13 @@ func NewUI(app fyne.App, win fyne.Window) *UI {
 	ui := &UI{
 		App:                 app,
 		Window:              win,
-		Editor:              widget.NewMultiLineEntry(),
 		Markdown:            widget.NewRichTextFromMarkdown(""),
 		Theme:               theme,
 		CharacterLabel:      widget.NewLabelWithStyle("Characters: 0", fyne.TextAlignLeading, fyne.TextStyle{Bold: false}),
+		WordLabel:           widget.NewLabelWithStyle("Words: 0", fyne.TextAlignLeading, fyne.TextStyle{Bold: false}),
 		LineLabel:           widget.NewLabelWithStyle("Lines: 0", fyne.TextAlignLeading, fyne.TextStyle{Bold: false}),
+		GoalProgress:        widget.NewProgressBar(),
+		MacroLabel:          widget.NewLabelWithStyle("● Recording macro", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
 		CurrentFileLabel:    widget.NewLabelWithStyle("Current File: None", fyne.TextAlignLeading, fyne.TextStyle{Bold: false}),
 		SearchAreaContainer: container.NewVBox(),
 		SearchTermEntry:     widget.NewEntry(),
@@ -85,9 +201,17 @@ func NewUI(app fyne.App, win fyne.Window) *UI {
 		CurrentMatchIdx:     -1,
 		OriginalText:        "",
 		ShowMarkdown:        true,
+		History:             &handling.NavigationHistory{},
+		Bookmarks:           handling.LoadBookmarks(app.Preferences()),
+		Folds:               handling.LoadFolds(app.Preferences()),
+		Goals:               handling.LoadWritingGoals(app.Preferences()),
+		Clipboard:           handling.LoadClipboardHistory(app.Preferences(), app.Preferences().Bool(clipboard_persist)),
 		ZoomLabel:           widget.NewLabelWithStyle("ZoomL 100%", fyne.TextAlignCenter, fyne.TextStyle{Bold: false}),
 	}
 
+	ui.MacroLabel.Hide()
+	ui.GoalProgress.Hide()
+
 	config, err := handling.LoadConfig("config.json")
 	if err != nil {
 		fmt.Println("Error loading config:", err)
@@ -95,17 +219,47 @@ func NewUI(app fyne.App, win fyne.Window) *UI {
 	}
 
 	ui.Theme.SetThemeFromConfig(config)
+	ui.dictionariesDir = handling.DictionariesDir(config)
 
-	ui.MenuBar = ui.CreateMenuBar()
-	ui.Terminal = terminal.New()
+	ui.code = newCodeEditor(ui)
+	ui.Editor = &ui.code.Entry
+	ui.Gutter = newGutter(ui)
+	ui.diagnosticLayer = newDiagnosticLayer(ui)
+	ui.spellingLayer = newSpellingLayer(ui)
+	ui.bracketLayer = newBracketLayer(ui)
+	ui.cursorLayer = newCursorLayer(ui)
+	ui.foldLayer = newFoldLayer(ui)
+	ui.updateIndentation()
+	ui.completion = newCompletionPopup(ui)
+	ui.completionProviders = []handling.CompletionProvider{
+		ui.lspCompletions,
+		handling.SnippetCompletions,
+		handling.MarkdownLinkCompletions,
+		handling.PathCompletions,
+		handling.BufferWordCompletions,
+	}
+	ui.EditorScroll = container.NewScroll(container.NewStack(ui.code, ui.foldLayer, ui.diagnosticLayer, ui.spellingLayer, ui.bracketLayer, ui.cursorLayer))
+	ui.EditorScroll.OnScrolled = func(fyne.Position) {
+		ui.Gutter.Refresh()
+		ui.spellingLayer.Refresh()
+	}
 
-	go func() {
-		// Wait before starting the shell to prevent resizing errors
-		time.Sleep(time.Millisecond * 500)
-		if err := ui.Terminal.RunLocalShell(); err != nil {
-			fmt.Println("Error running local shell:", err)
-		}
-	}()
+	// Recently opened files and folders, forgetting any that have been deleted since.
+	handling.LoadRecent(app.Preferences())
+	handling.RecentFiles.Prune()
+	handling.RecentFolders.Prune()
+
+	ui.Outline = newOutlinePanel(ui)
+	ui.FileTree = newFileTreePanel(ui)
+	ui.BookmarkPanel = newBookmarkPanel(ui)
+	ui.StatsPanel = newStatsPanel(ui)
+	ui.MenuBar = ui.CreateMenuBar()
+	// Start with one terminal, its shell starts once the terminal has a size.
+	ui.Terminals = newTerminalPanel(ui)
+	ui.Terminals.Tabs.Append(ui.Terminals.newTab(terminalDir()))
+	ui.Tasks = newTaskPanel(ui)
+	ui.startLSP(config)
+	ui.loadSpelling()
 
 	ui.Theme.ApplyTheme()
 	ApplyUserTheme(ui)
@@ -115,80 +269,310 @@ func NewUI(app fyne.App, win fyne.Window) *UI {
 
 	// Update Markdown Preview whenever text changes.
 	ui.Editor.OnChanged = func(content string) {
-		ui.RenderMarkdown(content)
-		ui.UpdateCounts(content)
+		if ui.presenting {
+			return
+		}
+		ui.textEdited(content)
+		ui.textChanged()
+	}
+
+	// Keep the cursor in view and the outline selection on the section containing it.
+	ui.Editor.OnCursorChanged = func() {
+		ui.ensureCursorVisible()
+		ui.highlightOutline()
+		ui.bracketLayer.Refresh()
+		ui.StatsPanel.refreshSelection()
+	}
+
+	// a file's text isn't an edit that can be undone
+	handling.OnFileLoading = func(fyne.URI) {
+		ui.loading = true
 	}
 
 	// update markdown preview when file changes
 	handling.OnFileChanged = func(uri fyne.URI) {
+		// Opening a file clears the undo history.
+		ui.restoreFolds()
 		ui.UpdateFileLabel(uri)
+		// Relative image paths and the outline's language depend on the file.
+		ui.RenderMarkdown(ui.text())
+		ui.UpdateOutline(ui.text())
+		// The unsaved buffer is gone once a file is opened in its place, and its bookmarks with it.
+		ui.Bookmarks.Clear("")
+		ui.bookmarksChanged()
+		ui.refreshRecentMenu()
+		ui.lspFileChanged()
+		ui.checkSpelling()
+		ui.completion.hide()
+		ui.endSnippet()
+		ui.updateIndentation()
 	}
 
+	// bookmarks follow the text to the file it's saved as
+	handling.OnSavedAs = func(previous, uri fyne.URI) {
+		from := ""
+		if previous != nil {
+			from = previous.Path()
+		}
+		ui.Bookmarks.SavedAs(from, uri.Path())
+		ui.bookmarksChanged()
+	}
+
+	// keep File > Open Recent up to date
+	handling.OnRecentChanged = func() {
+		ui.refreshRecentMenu()
+	}
+
+	// show the workspace tree when a folder is opened
+	handling.OnWorkspaceChanged = func(root string) {
+		ui.FileTree.SetRoot(root)
+		ui.LSP.SetRoot(root)
+		ui.lspPath = ""
+		ui.lspFileChanged()
+		ui.loadWordLists()
+		ui.UpdateLayout()
+	}
+
+	// save and export the text with its folded regions
+	handling.FullText = ui.fullText
+
+	// format files as they're saved, if that's turned on
+	handling.BeforeSave = ui.formatBeforeSave
+	handling.BeforeAutoSave = ui.checkBeforeAutoSave
+
 	return ui
 }
 
 func registerShortcuts(win fyne.Window, ui *UI) {
 	// Toggle Find Sidebar (Ctrl + F).
-	win.Canvas().AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyF, Modifier: desktop.ControlModifier}, func(shortcut fyne.Shortcut) {
+	ui.addShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyF, Modifier: desktop.ControlModifier}, func(shortcut fyne.Shortcut) {
 		ShowSearchUI(false, ui)
 	})
 	// Toggle Find & Replace Sidebar (Ctrl + H).
-	win.Canvas().AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyH, Modifier: desktop.ControlModifier}, func(shortcut fyne.Shortcut) {
+	ui.addShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyH, Modifier: desktop.ControlModifier}, func(shortcut fyne.Shortcut) {
 		ShowSearchUI(true, ui)
 	})
 	// Find Next (Ctrl + G).
-	win.Canvas().AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyG, Modifier: desktop.ControlModifier}, func(shortcut fyne.Shortcut) {
+	ui.addShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyG, Modifier: desktop.ControlModifier}, func(shortcut fyne.Shortcut) {
 		if ui.SidebarVisible {
 			ui.nextMatch()
 		}
 	})
 	// Find Previous (Ctrl + Shift + G).
-	win.Canvas().AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyG, Modifier: desktop.ControlModifier | desktop.ShiftModifier}, func(shortcut fyne.Shortcut) {
+	ui.addShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyG, Modifier: desktop.ControlModifier | desktop.ShiftModifier}, func(shortcut fyne.Shortcut) {
 		if ui.SidebarVisible {
 			ui.previousMatch()
 		}
 	})
 	// Open File (Ctrl + O)
-	win.Canvas().AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyO, Modifier: desktop.ControlModifier}, func(shortcut fyne.Shortcut) {
+	ui.addShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyO, Modifier: desktop.ControlModifier}, func(shortcut fyne.Shortcut) {
 		handling.OpenFile(ui.Window, ui.Editor)
 	})
+	// Go to File (Ctrl + P).
+	ui.addShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyP, Modifier: desktop.ControlModifier}, func(shortcut fyne.Shortcut) {
+		ShowQuickOpen(ui)
+	})
+	// Go to Line (Ctrl + L).
+	ui.addShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyL, Modifier: desktop.ControlModifier}, func(shortcut fyne.Shortcut) {
+		ShowGoToLine(ui)
+	})
+	// Go to Symbol (Ctrl + Shift + O).
+	ui.addShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyO, Modifier: desktop.ControlModifier | desktop.ShiftModifier}, func(shortcut fyne.Shortcut) {
+		ShowGoToSymbol(ui)
+	})
+	// Go Back (Alt + Left).
+	ui.addShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyLeft, Modifier: desktop.AltModifier}, func(shortcut fyne.Shortcut) {
+		ui.navigateBack()
+	})
+	// Go Forward (Alt + Right).
+	ui.addShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyRight, Modifier: desktop.AltModifier}, func(shortcut fyne.Shortcut) {
+		ui.navigateForward()
+	})
+	// Toggle Bookmark (Ctrl + Alt + K).
+	ui.addShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyK, Modifier: desktop.ControlModifier | desktop.AltModifier}, func(shortcut fyne.Shortcut) {
+		ui.toggleBookmark(ui.currentLocation().Row)
+	})
+	// Next Bookmark (Ctrl + Alt + L).
+	ui.addShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyL, Modifier: desktop.ControlModifier | desktop.AltModifier}, func(shortcut fyne.Shortcut) {
+		ui.nextBookmark()
+	})
+	// Previous Bookmark (Ctrl + Alt + J).
+	ui.addShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyJ, Modifier: desktop.ControlModifier | desktop.AltModifier}, func(shortcut fyne.Shortcut) {
+		ui.previousBookmark()
+	})
+	// Toggle Terminal Panel (Ctrl + `).
+	ui.addShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyBackTick, Modifier: desktop.ControlModifier}, func(shortcut fyne.Shortcut) {
+		ui.toggleTerminalPanel()
+	})
+	// New Terminal (Ctrl + Shift + `).
+	ui.addShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyBackTick, Modifier: desktop.ControlModifier | desktop.ShiftModifier}, func(shortcut fyne.Shortcut) {
+		ui.newTerminal()
+	})
+	// Run Selection in Terminal (Ctrl + Enter).
+	ui.addShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyReturn, Modifier: desktop.ControlModifier}, func(shortcut fyne.Shortcut) {
+		ui.runSelection()
+	})
+	// Run File (Ctrl + F5).
+	ui.addShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyF5, Modifier: desktop.ControlModifier}, func(shortcut fyne.Shortcut) {
+		ui.runFile()
+	})
+	// Show Hover (Ctrl + K).
+	ui.addShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyK, Modifier: desktop.ControlModifier}, func(shortcut fyne.Shortcut) {
+		ui.showHover()
+	})
+	// Trigger Completion (Ctrl + Space).
+	ui.addShortcut(&desktop.CustomShortcut{KeyName: fyne.KeySpace, Modifier: desktop.ControlModifier}, func(shortcut fyne.Shortcut) {
+		ui.showCompletion()
+	})
+	// Format Document (Alt + Shift + F).
+	ui.addShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyF, Modifier: desktop.AltModifier | desktop.ShiftModifier}, func(shortcut fyne.Shortcut) {
+		ui.formatDocument()
+	})
+	// Go to Matching Bracket (Ctrl + Shift + \).
+	ui.addShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyBackslash, Modifier: desktop.ControlModifier | desktop.ShiftModifier}, func(shortcut fyne.Shortcut) {
+		ui.goToMatchingBracket()
+	})
+	// Fold (Ctrl + Shift + [).
+	ui.addShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyLeftBracket, Modifier: desktop.ControlModifier | desktop.ShiftModifier}, func(shortcut fyne.Shortcut) {
+		ui.foldAtCursor()
+	})
+	// Unfold (Ctrl + Shift + ]).
+	ui.addShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyRightBracket, Modifier: desktop.ControlModifier | desktop.ShiftModifier}, func(shortcut fyne.Shortcut) {
+		ui.unfoldAtCursor()
+	})
+	// Fold All (Ctrl + Alt + [).
+	ui.addShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyLeftBracket, Modifier: desktop.ControlModifier | desktop.AltModifier}, func(shortcut fyne.Shortcut) {
+		ui.foldAll()
+	})
+	// Unfold All (Ctrl + Alt + ]).
+	ui.addShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyRightBracket, Modifier: desktop.ControlModifier | desktop.AltModifier}, func(shortcut fyne.Shortcut) {
+		ui.unfoldAll()
+	})
+	// Add Next Occurrence (Ctrl + D).
+	ui.addShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyD, Modifier: desktop.ControlModifier}, func(shortcut fyne.Shortcut) {
+		ui.addNextOccurrence()
+	})
+	// Duplicate Line (Alt + Shift + Down).
+	ui.addShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyDown, Modifier: desktop.AltModifier | desktop.ShiftModifier}, func(shortcut fyne.Shortcut) {
+		ui.duplicateLines()
+	})
+	// Delete Line (Ctrl + Shift + K).
+	ui.addShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyK, Modifier: desktop.ControlModifier | desktop.ShiftModifier}, func(shortcut fyne.Shortcut) {
+		ui.deleteLines()
+	})
+	// Move Line Up (Alt + Up).
+	ui.addShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyUp, Modifier: desktop.AltModifier}, func(shortcut fyne.Shortcut) {
+		ui.moveLinesUp()
+	})
+	// Move Line Down (Alt + Down).
+	ui.addShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyDown, Modifier: desktop.AltModifier}, func(shortcut fyne.Shortcut) {
+		ui.moveLinesDown()
+	})
+	// Join Lines (Ctrl + Shift + J).
+	ui.addShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyJ, Modifier: desktop.ControlModifier | desktop.ShiftModifier}, func(shortcut fyne.Shortcut) {
+		ui.joinLines()
+	})
+	// Indent (Ctrl + ]).
+	ui.addShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyRightBracket, Modifier: desktop.ControlModifier}, func(shortcut fyne.Shortcut) {
+		ui.indentLines()
+	})
+	// Outdent (Ctrl + [).
+	ui.addShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyLeftBracket, Modifier: desktop.ControlModifier}, func(shortcut fyne.Shortcut) {
+		ui.outdentLines()
+	})
+	// Toggle Line Comment (Ctrl + /).
+	ui.addShortcut(&desktop.CustomShortcut{KeyName: fyne.KeySlash, Modifier: desktop.ControlModifier}, func(shortcut fyne.Shortcut) {
+		ui.toggleLineComment()
+	})
+	// Toggle Block Comment (Alt + Shift + A).
+	ui.addShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyA, Modifier: desktop.AltModifier | desktop.ShiftModifier}, func(shortcut fyne.Shortcut) {
+		ui.toggleBlockComment()
+	})
+	// Start/Stop Recording Macro (Ctrl + Shift + R).
+	ui.addShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyR, Modifier: desktop.ControlModifier | desktop.ShiftModifier}, func(shortcut fyne.Shortcut) {
+		ui.toggleMacroRecording()
+	})
+	// Play Macro (Ctrl + Shift + P).
+	ui.addShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyP, Modifier: desktop.ControlModifier | desktop.ShiftModifier}, func(shortcut fyne.Shortcut) {
+		ui.playMacro()
+	})
+	// Paste from Clipboard History (Ctrl + Shift + V).
+	ui.addShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyV, Modifier: desktop.ControlModifier | desktop.ShiftModifier}, func(shortcut fyne.Shortcut) {
+		ShowClipboardHistory(ui)
+	})
+	// Insert Snippet (Ctrl + J).
+	ui.addShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyJ, Modifier: desktop.ControlModifier}, func(shortcut fyne.Shortcut) {
+		ShowInsertSnippet(ui)
+	})
+	// Run Task (Ctrl + Shift + B).
+	ui.addShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyB, Modifier: desktop.ControlModifier | desktop.ShiftModifier}, func(shortcut fyne.Shortcut) {
+		ShowRunTask(ui)
+	})
+	// Toggle Task Output (Ctrl + Shift + U).
+	ui.addShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyU, Modifier: desktop.ControlModifier | desktop.ShiftModifier}, func(shortcut fyne.Shortcut) {
+		ui.toggleTaskPanel()
+	})
+	// Toggle Workspace Sidebar (Ctrl + B).
+	ui.addShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyB, Modifier: desktop.ControlModifier}, func(shortcut fyne.Shortcut) {
+		ui.toggleFileTree()
+	})
 	// Save File (Ctrl + S).
-	win.Canvas().AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyS, Modifier: desktop.ControlModifier}, func(shortcut fyne.Shortcut) {
-		handling.SaveFile(ui.Window, ui.Editor)
+	ui.addShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyS, Modifier: desktop.ControlModifier}, func(shortcut fyne.Shortcut) {
+		ui.saveFile()
 	})
 	// Save File As (Ctrl + Shift + S).
-	win.Canvas().AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyS, Modifier: desktop.ControlModifier | desktop.ShiftModifier}, func(shortcut fyne.Shortcut) {
+	ui.addShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyS, Modifier: desktop.ControlModifier | desktop.ShiftModifier}, func(shortcut fyne.Shortcut) {
 		handling.SaveFileAs(ui.Window, ui.Editor)
 	})
 	// Zoom In (Ctrl + =).
-	win.Canvas().AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyEqual, Modifier: desktop.ControlModifier}, func(shortcut fyne.Shortcut) {
+	ui.addShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyEqual, Modifier: desktop.ControlModifier}, func(shortcut fyne.Shortcut) {
 		ui.ZoomIn()
 	})
 	// Zoom Out (Ctrl + -).
-	win.Canvas().AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyMinus, Modifier: desktop.ControlModifier}, func(shortcut fyne.Shortcut) {
+	ui.addShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyMinus, Modifier: desktop.ControlModifier}, func(shortcut fyne.Shortcut) {
 		ui.ZoomOut()
 	})
 	// Reset Zoom (Ctrl + 0).
-	win.Canvas().AddShortcut(&desktop.CustomShortcut{KeyName: fyne.Key0, Modifier: desktop.ControlModifier}, func(shortcut fyne.Shortcut) {
+	ui.addShortcut(&desktop.CustomShortcut{KeyName: fyne.Key0, Modifier: desktop.ControlModifier}, func(shortcut fyne.Shortcut) {
 		ui.ResetZoom()
 	})
 	// Toggle Markdown Preview (Ctrl + M).
-	win.Canvas().AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyM, Modifier: desktop.ControlModifier}, func(shortcut fyne.Shortcut) {
+	ui.addShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyM, Modifier: desktop.ControlModifier}, func(shortcut fyne.Shortcut) {
 		ui.toggleMarkdownPreview()
 	})
-	// Toggle Dark Mode (Ctrl + D).
-	win.Canvas().AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyD, Modifier: desktop.ControlModifier}, func(shortcut fyne.Shortcut) {
+	// Toggle Outline (Ctrl + Shift + L).
+	ui.addShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyL, Modifier: desktop.ControlModifier | desktop.ShiftModifier}, func(shortcut fyne.Shortcut) {
+		ui.toggleOutline()
+	})
+	// Toggle Dark Mode (Ctrl + Shift + D).
+	ui.addShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyD, Modifier: desktop.ControlModifier | desktop.ShiftModifier}, func(shortcut fyne.Shortcut) {
 		ToggleDarkMode(ui.App, ui)
 	})
 	// Open Custom Theme Settings (Ctrl + Shift + T).
-	win.Canvas().AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyT, Modifier: desktop.ControlModifier}, func(shortcut fyne.Shortcut) {
+	ui.addShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyT, Modifier: desktop.ControlModifier}, func(shortcut fyne.Shortcut) {
 		OpenThemePickerModal(ui.App, ui.Window, ui)
 	})
+	// Saved macros, with the keys given to them when they were saved or in macros.json.
+	ui.bindMacros()
+}
+
+// askName shows a form with a single text field and calls onSubmit with the entered value.
+func (ui *UI) askName(title, label, initial, confirm string, onSubmit func(string)) {
+	entry := widget.NewEntry()
+	entry.SetText(initial)
+	dialog.ShowForm(title, confirm, "Cancel", []*widget.FormItem{widget.NewFormItem(label, entry)}, func(ok bool) {
+		value := strings.TrimSpace(entry.Text)
+		if !ok || value == "" {
+			return
+		}
+		onSubmit(value)
+	}, ui.Window)
+	ui.Window.Canvas().Focus(entry)
 }
 
 // Updates Markdown Preview.
 func (ui *UI) RenderMarkdown(input string) {
-	ui.Markdown.ParseMarkdown(input)
+	ui.Markdown.Segments = ui.parseMarkdown(input)
 	ui.Markdown.Refresh()
 }
 
@@ -217,14 +601,61 @@ func (ui *UI) toggleMarkdownPreview() {
 	ui.UpdateLayout()
 }
 
+// Move the cursor to a zero based row and column of the text and scroll it into view,
+// unfolding the regions the row is folded in. Positions past the end of a line or the text are clamped.
+func (ui *UI) moveCursor(row, col int) {
+	ui.placeCursor(ui.revealRow(row), col)
+}
+
+// placeCursor moves the cursor to a zero based row and column of the editor, as it's shown.
+func (ui *UI) placeCursor(row, col int) {
+	lines := strings.Split(ui.Editor.Text, "\n")
+	row = min(max(row, 0), len(lines)-1)
+	ui.Editor.CursorRow = row
+	ui.Editor.CursorColumn = min(max(col, 0), len([]rune(lines[row])))
+	ui.Editor.Refresh()
+	ui.ensureCursorVisible()
+	ui.Window.Canvas().Focus(ui.code)
+}
+
+// textChanged updates everything that follows the text after it's been changed, in the editor or by undo.
+func (ui *UI) textChanged() {
+	text := ui.text()
+	ui.RenderMarkdown(text)
+	ui.UpdateCounts(text)
+	ui.UpdateOutline(text)
+	ui.followEdit(text)
+	ui.lspTextChanged()
+	ui.spellTextChanged()
+	ui.snippetTextChanged(ui.Editor.Text)
+	ui.cursorsTextChanged()
+	ui.bracketLayer.Refresh()
+	ui.foldLayer.Refresh()
+}
+
+// followEdit moves the bookmarks of the current file along with an edit and updates the gutter.
+func (ui *UI) followEdit(content string) {
+	path := ui.currentLocation().Path
+	if path == ui.lastTextPath && len(ui.Bookmarks.Lines(path)) > 0 {
+		ui.Bookmarks.Adjust(path, ui.lastText, content)
+		ui.bookmarksChanged()
+	}
+	ui.lastText, ui.lastTextPath = content, path
+	ui.Gutter.SetLines(strings.Count(ui.Editor.Text, "\n") + 1)
+}
+
 // Update character & line counts.
 func (ui *UI) UpdateCounts(content string) {
-	charCount := len(content)
+	stats := handling.CountText(content)
 	lineCount := len(widget.NewTextGridFromString(content).Rows)
+	ui.countWritten(stats.Words)
 
 	// Update the labels.
-	ui.CharacterLabel.SetText(fmt.Sprintf("Characters: %d", charCount))
+	ui.CharacterLabel.SetText(fmt.Sprintf("Characters: %d", stats.Characters))
+	ui.WordLabel.SetText(fmt.Sprintf("Words: %d", stats.Words))
 	ui.LineLabel.SetText(fmt.Sprintf("Lines: %d", lineCount))
+	updateGoal(ui, ui.GoalProgress)
+	ui.StatsPanel.refresh(stats)
 }
 
 func (ui *UI) UpdateZoomLabel() {